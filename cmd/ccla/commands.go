@@ -0,0 +1,560 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/logger"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// syncSummary is the JSON output of the sync command
+type syncSummary struct {
+	ProjectsProcessed int      `json:"projectsProcessed"`
+	SessionsFound     int      `json:"sessionsFound"`
+	SessionsSynced    int      `json:"sessionsSynced"`
+	SessionsSkipped   int      `json:"sessionsSkipped"`
+	ErrorCount        int      `json:"errorCount"`
+	Errors            []string `json:"errors"`
+}
+
+// sessionItem is the JSON output of a session in the sessions list command
+type sessionItem struct {
+	ID                  string    `json:"id"`
+	ProjectName         string    `json:"projectName"`
+	GitBranch           string    `json:"gitBranch"`
+	StartTime           time.Time `json:"startTime"`
+	EndTime             time.Time `json:"endTime"`
+	DurationSeconds     int       `json:"durationSeconds"`
+	InputTokens         int       `json:"inputTokens"`
+	OutputTokens        int       `json:"outputTokens"`
+	CacheCreationTokens int       `json:"cacheCreationTokens"`
+	CacheReadTokens     int       `json:"cacheReadTokens"`
+	EstimatedCostUSD    float64   `json:"estimatedCostUsd"`
+	ErrorCount          int       `json:"errorCount"`
+	SubagentCount       int       `json:"subagentCount"`
+	SubagentTokens      int       `json:"subagentTokens"`
+	FirstUserMessage    string    `json:"firstUserMessage"`
+}
+
+// sessionDetail is the JSON output of the session show command
+type sessionDetail struct {
+	ID                  string             `json:"id"`
+	ProjectPath         string             `json:"projectPath"`
+	GitBranch           string             `json:"gitBranch"`
+	ParentSessionID     string             `json:"parentSessionId,omitempty"`
+	StartTime           time.Time          `json:"startTime"`
+	EndTime             time.Time          `json:"endTime"`
+	DurationSeconds     int                `json:"durationSeconds"`
+	InputTokens         int                `json:"inputTokens"`
+	OutputTokens        int                `json:"outputTokens"`
+	CacheCreationTokens int                `json:"cacheCreationTokens"`
+	CacheReadTokens     int                `json:"cacheReadTokens"`
+	EstimatedCostUSD    float64            `json:"estimatedCostUsd"`
+	ErrorCount          int                `json:"errorCount"`
+	EntryCount          int                `json:"entryCount"`
+	ToolCallCount       int                `json:"toolCallCount"`
+	Models              []sessionModelItem `json:"models"`
+}
+
+// sessionModelItem is the token usage of a model in a session
+type sessionModelItem struct {
+	Model            string  `json:"model"`
+	InputTokens      int     `json:"inputTokens"`
+	OutputTokens     int     `json:"outputTokens"`
+	EstimatedCostUSD float64 `json:"estimatedCostUsd"`
+}
+
+// syncCommand syncs Claude Code logs into the database
+func syncCommand(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("sync", stderr)
+	claudeDir := fs.String("claude-dir", "", "Claude projects directory (default: $CLAUDE_PROJECTS_DIR or ~/.claude/projects)")
+	full := fs.Bool("full", false, "sync all sessions instead of only changed files")
+	if _, err := parseArgs(fs, args); err != nil {
+		return exitUsage
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	dir, err := resolveClaudeDir(*claudeDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to get Claude directory: %v\n", err)
+		return exitError
+	}
+
+	database, err := opts.openDB()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	defer database.Close()
+
+	// ログは標準エラー出力に出し、標準出力は結果のみにする
+	log := logger.New()
+	log.SetOutput(stderr)
+
+	p := parser.NewParser(dir)
+	var result *db.SyncResult
+	if *full {
+		result, err = db.SyncAllWithLogger(database, p, log)
+	} else {
+		result, err = db.SyncIncrementalWithLogger(database, p, log)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Sync failed: %v\n", err)
+		return exitError
+	}
+
+	summary := syncSummary{
+		ProjectsProcessed: result.ProjectsProcessed,
+		SessionsFound:     result.SessionsFound,
+		SessionsSynced:    result.SessionsSynced,
+		SessionsSkipped:   result.SessionsSkipped,
+		ErrorCount:        result.ErrorCount,
+		Errors:            result.Errors,
+	}
+	if summary.Errors == nil {
+		summary.Errors = []string{}
+	}
+
+	r := fieldReport([]field{
+		{"projects_processed", formatInt(summary.ProjectsProcessed)},
+		{"sessions_found", formatInt(summary.SessionsFound)},
+		{"sessions_synced", formatInt(summary.SessionsSynced)},
+		{"sessions_skipped", formatInt(summary.SessionsSkipped)},
+		{"errors", formatInt(summary.ErrorCount)},
+	}, summary)
+	if err := writeReport(stdout, opts.format, r); err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %v\n", err)
+		return exitError
+	}
+
+	if summary.ErrorCount > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// sessionsListCommand lists sessions ordered by start time (newest first)
+func sessionsListCommand(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("sessions list", stderr)
+	projectName := fs.String("project", "", "only list sessions of the project (name)")
+	limit := fs.Int("limit", 20, "maximum number of sessions")
+	offset := fs.Int("offset", 0, "number of sessions to skip")
+	if _, err := parseArgs(fs, args); err != nil {
+		return exitUsage
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if *limit <= 0 || *offset < 0 {
+		fmt.Fprintln(stderr, "limit must be a positive integer and offset must not be negative")
+		return exitUsage
+	}
+
+	database, err := opts.openDB()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	defer database.Close()
+
+	// プロジェクト名の表示用
+	projects, err := database.ListProjects()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	projectNames := make(map[int64]string, len(projects))
+	var projectID *int64
+	for _, project := range projects {
+		projectNames[project.ID] = project.Name
+		if project.Name == *projectName {
+			id := project.ID
+			projectID = &id
+		}
+	}
+	if *projectName != "" && projectID == nil {
+		fmt.Fprintf(stderr, "project not found: %s\n", *projectName)
+		return exitError
+	}
+
+	sessions, err := database.ListSessions(projectID, *limit, *offset)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	items := make([]sessionItem, 0, len(sessions))
+	rows := make([][]string, 0, len(sessions))
+	for _, s := range sessions {
+		item := sessionItem{
+			ID:                  s.ID,
+			ProjectName:         projectNames[s.ProjectID],
+			GitBranch:           s.GitBranch,
+			StartTime:           s.StartTime,
+			EndTime:             s.EndTime,
+			DurationSeconds:     s.DurationSeconds,
+			InputTokens:         s.TotalInputTokens,
+			OutputTokens:        s.TotalOutputTokens,
+			CacheCreationTokens: s.TotalCacheCreationTokens,
+			CacheReadTokens:     s.TotalCacheReadTokens,
+			EstimatedCostUSD:    s.TotalCostUSD,
+			ErrorCount:          s.ErrorCount,
+			SubagentCount:       s.SubagentCount,
+			SubagentTokens:      s.SubagentTokens,
+			FirstUserMessage:    s.FirstUserMessage,
+		}
+		items = append(items, item)
+		rows = append(rows, []string{
+			item.ID,
+			item.ProjectName,
+			item.GitBranch,
+			formatTime(item.StartTime),
+			formatDuration(item.DurationSeconds),
+			formatInt(item.InputTokens),
+			formatInt(item.OutputTokens),
+			formatCost(item.EstimatedCostUSD),
+			formatInt(item.ErrorCount),
+			formatInt(item.SubagentCount),
+			truncateText(opts.format, item.FirstUserMessage),
+		})
+	}
+
+	r := report{
+		headers: []string{"ID", "PROJECT", "BRANCH", "START", "DURATION", "INPUT", "OUTPUT", "COST_USD", "ERRORS", "SUBAGENTS", "FIRST_MESSAGE"},
+		rows:    rows,
+		value:   items,
+	}
+	if err := writeReport(stdout, opts.format, r); err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// sessionShowCommand shows the summary of a session
+func sessionShowCommand(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("session show", stderr)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(stderr, "Usage: ccla session show <id> [options]")
+		return exitUsage
+	}
+
+	database, err := opts.openDB()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	defer database.Close()
+
+	session, err := database.GetSession(positional[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	detail := sessionDetail{
+		ID:                  session.ID,
+		ProjectPath:         session.ProjectPath,
+		GitBranch:           session.GitBranch,
+		ParentSessionID:     session.ParentSessionID,
+		StartTime:           session.StartTime,
+		EndTime:             session.EndTime,
+		DurationSeconds:     int(session.EndTime.Sub(session.StartTime).Seconds()),
+		InputTokens:         session.TotalTokens.InputTokens,
+		OutputTokens:        session.TotalTokens.OutputTokens,
+		CacheCreationTokens: session.TotalTokens.CacheCreationInputTokens,
+		CacheReadTokens:     session.TotalTokens.CacheReadInputTokens,
+		ErrorCount:          session.ErrorCount,
+		EntryCount:          len(session.Entries),
+		ToolCallCount:       len(session.ToolCalls),
+		Models:              make([]sessionModelItem, 0, len(session.ModelUsage)),
+	}
+
+	// モデル別の推定コスト（セッション開始時点の料金で算出）
+	for model, usage := range session.ModelUsage {
+		cost := database.EstimateCost(model, session.StartTime, usage)
+		detail.EstimatedCostUSD += cost
+		detail.Models = append(detail.Models, sessionModelItem{
+			Model:            model,
+			InputTokens:      usage.InputTokens,
+			OutputTokens:     usage.OutputTokens,
+			EstimatedCostUSD: cost,
+		})
+	}
+	sort.Slice(detail.Models, func(i, j int) bool {
+		return detail.Models[i].Model < detail.Models[j].Model
+	})
+
+	fields := []field{
+		{"id", detail.ID},
+		{"project_path", detail.ProjectPath},
+		{"git_branch", detail.GitBranch},
+	}
+	if detail.ParentSessionID != "" {
+		fields = append(fields, field{"parent_session_id", detail.ParentSessionID})
+	}
+	fields = append(fields,
+		field{"start_time", formatTime(detail.StartTime)},
+		field{"end_time", formatTime(detail.EndTime)},
+		field{"duration", formatDuration(detail.DurationSeconds)},
+		field{"input_tokens", formatInt(detail.InputTokens)},
+		field{"output_tokens", formatInt(detail.OutputTokens)},
+		field{"cache_creation_tokens", formatInt(detail.CacheCreationTokens)},
+		field{"cache_read_tokens", formatInt(detail.CacheReadTokens)},
+		field{"estimated_cost_usd", formatCost(detail.EstimatedCostUSD)},
+		field{"errors", formatInt(detail.ErrorCount)},
+		field{"entries", formatInt(detail.EntryCount)},
+		field{"tool_calls", formatInt(detail.ToolCallCount)},
+	)
+	for _, model := range detail.Models {
+		fields = append(fields, field{
+			"model:" + model.Model,
+			fmt.Sprintf("input=%d output=%d cost_usd=%s", model.InputTokens, model.OutputTokens, formatCost(model.EstimatedCostUSD)),
+		})
+	}
+
+	if err := writeReport(stdout, opts.format, fieldReport(fields, detail)); err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// statsCommand shows total, project or group statistics
+func statsCommand(args []string, stdout, stderr io.Writer) int {
+	const usage = "Usage: ccla stats total|project <name>|group <name|id> [options]"
+
+	fs, opts := newFlagSet("stats", stderr)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if len(positional) == 0 {
+		fmt.Fprintln(stderr, usage)
+		return exitUsage
+	}
+
+	scope := positional[0]
+	switch {
+	case scope == "total" && len(positional) == 1:
+	case (scope == "project" || scope == "group") && len(positional) == 2:
+	default:
+		fmt.Fprintln(stderr, usage)
+		return exitUsage
+	}
+
+	database, err := opts.openDB()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	defer database.Close()
+
+	var r report
+	switch scope {
+	case "total":
+		stats, err := database.GetTotalStats("")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		r = fieldReport(append([]field{
+			{"groups", formatInt(stats.TotalGroups)},
+			{"projects", formatInt(stats.TotalProjects)},
+		}, statsFields(stats.TotalSessions, stats.TotalInputTokens, stats.TotalOutputTokens,
+			stats.TotalCacheCreationTokens, stats.TotalCacheReadTokens, stats.EstimatedCostUSD,
+			stats.AvgTokens, stats.FirstSession, stats.LastSession, stats.ErrorRate,
+			stats.ActiveSeconds, stats.IdleSeconds)...), stats)
+	case "project":
+		project, err := database.GetProjectByName(positional[1])
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		stats, err := database.GetProjectStats(project.ID, "")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		r = fieldReport(statsFields(stats.TotalSessions, stats.TotalInputTokens, stats.TotalOutputTokens,
+			stats.TotalCacheCreationTokens, stats.TotalCacheReadTokens, stats.EstimatedCostUSD,
+			stats.AvgTokens, stats.FirstSession, stats.LastSession, stats.ErrorRate,
+			stats.ActiveSeconds, stats.IdleSeconds), stats)
+	case "group":
+		groupID, err := resolveGroupID(database, positional[1])
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		stats, err := database.GetGroupStats(groupID, "")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		r = fieldReport(append([]field{
+			{"projects", formatInt(stats.TotalProjects)},
+		}, statsFields(stats.TotalSessions, stats.TotalInputTokens, stats.TotalOutputTokens,
+			stats.TotalCacheCreationTokens, stats.TotalCacheReadTokens, stats.EstimatedCostUSD,
+			stats.AvgTokens, stats.FirstSession, stats.LastSession, stats.ErrorRate,
+			stats.ActiveSeconds, stats.IdleSeconds)...), stats)
+	}
+
+	if err := writeReport(stdout, opts.format, r); err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// statsFields builds the rows shared by total, project and group statistics
+func statsFields(sessions, inputTokens, outputTokens, cacheCreationTokens, cacheReadTokens int,
+	cost, avgTokens float64, firstSession, lastSession time.Time, errorRate float64,
+	activeSeconds, idleSeconds int) []field {
+	return []field{
+		{"sessions", formatInt(sessions)},
+		{"input_tokens", formatInt(inputTokens)},
+		{"output_tokens", formatInt(outputTokens)},
+		{"cache_creation_tokens", formatInt(cacheCreationTokens)},
+		{"cache_read_tokens", formatInt(cacheReadTokens)},
+		{"estimated_cost_usd", formatCost(cost)},
+		{"avg_tokens", strconv.FormatFloat(avgTokens, 'f', 1, 64)},
+		{"first_session", formatTime(firstSession)},
+		{"last_session", formatTime(lastSession)},
+		{"error_rate", formatRate(errorRate)},
+		{"active_time", formatDuration(activeSeconds)},
+		{"idle_time", formatDuration(idleSeconds)},
+	}
+}
+
+// resolveGroupID resolves a project group given by ID or name
+func resolveGroupID(database *db.DB, nameOrID string) (int64, error) {
+	if id, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		group, err := database.GetProjectGroupByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return group.ID, nil
+	}
+
+	group, err := database.GetProjectGroupByName(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	return group.ID, nil
+}
+
+// timelineCommand shows time-series statistics across all projects, a project or a group
+func timelineCommand(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("timeline", stderr)
+	period := fs.String("period", "day", "aggregation period: day, week or month")
+	limit := fs.Int("limit", 30, "maximum number of periods")
+	projectName := fs.String("project", "", "only aggregate the project (name)")
+	groupName := fs.String("group", "", "only aggregate the project group (name or ID)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return exitUsage
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if *period != "day" && *period != "week" && *period != "month" {
+		fmt.Fprintln(stderr, "period must be 'day', 'week', or 'month'")
+		return exitUsage
+	}
+	if *limit <= 0 {
+		fmt.Fprintln(stderr, "limit must be a positive integer")
+		return exitUsage
+	}
+	if *projectName != "" && *groupName != "" {
+		fmt.Fprintln(stderr, "project and group cannot be specified together")
+		return exitUsage
+	}
+
+	database, err := opts.openDB()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	defer database.Close()
+
+	var timeline []db.TimeSeriesStats
+	switch {
+	case *projectName != "":
+		project, err := database.GetProjectByName(*projectName)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		timeline, err = database.GetTimeSeriesStats(project.ID, *period, *limit, "")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	case *groupName != "":
+		groupID, err := resolveGroupID(database, *groupName)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		timeline, err = database.GetGroupTimeSeriesStats(groupID, *period, *limit, "")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	default:
+		timeline, err = database.GetTotalTimeSeriesStats(*period, *limit, "")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	}
+	if timeline == nil {
+		timeline = []db.TimeSeriesStats{}
+	}
+
+	rows := make([][]string, 0, len(timeline))
+	for _, ts := range timeline {
+		rows = append(rows, []string{
+			formatDate(ts.PeriodStart),
+			formatDate(ts.PeriodEnd),
+			formatInt(ts.SessionCount),
+			formatInt(ts.TotalInputTokens),
+			formatInt(ts.TotalOutputTokens),
+			formatInt(ts.TotalCacheCreationTokens),
+			formatInt(ts.TotalCacheReadTokens),
+			formatCost(ts.EstimatedCostUSD),
+		})
+	}
+
+	r := report{
+		headers: []string{"PERIOD_START", "PERIOD_END", "SESSIONS", "INPUT", "OUTPUT", "CACHE_CREATION", "CACHE_READ", "COST_USD"},
+		rows:    rows,
+		value:   timeline,
+	}
+	if err := writeReport(stdout, opts.format, r); err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/pricing"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // 実行時のエラー
+	exitUsage = 2 // 引数の誤り
+)
+
+const usageText = `Usage: ccla <command> [options]
+
+Commands:
+  sync                           Sync Claude Code logs into the database
+  sessions list                  List sessions
+  session show <id>              Show a session
+  stats total                    Show statistics across all projects
+  stats project <name>           Show statistics of a project
+  stats group <name|id>          Show statistics of a project group
+  timeline                       Show time-series statistics
+
+Common options:
+  -db <path>          Database file (default: $DB_PATH or ccloganalysis.db next to the executable)
+  -format <format>    Output format: table, json or csv (default: table)
+
+Run "ccla <command> -h" for the options of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the CLI and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usageText)
+		return exitUsage
+	}
+
+	var cmd command
+	rest := args[1:]
+	switch args[0] {
+	case "sync":
+		cmd = syncCommand
+	case "sessions":
+		if len(rest) == 0 || rest[0] != "list" {
+			fmt.Fprintln(stderr, "Usage: ccla sessions list [options]")
+			return exitUsage
+		}
+		cmd, rest = sessionsListCommand, rest[1:]
+	case "session":
+		if len(rest) == 0 || rest[0] != "show" {
+			fmt.Fprintln(stderr, "Usage: ccla session show <id> [options]")
+			return exitUsage
+		}
+		cmd, rest = sessionShowCommand, rest[1:]
+	case "stats":
+		cmd = statsCommand
+	case "timeline":
+		cmd = timelineCommand
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usageText)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "Unknown command: %s\n\n", args[0])
+		fmt.Fprint(stderr, usageText)
+		return exitUsage
+	}
+
+	return cmd(rest, stdout, stderr)
+}
+
+// command is a subcommand handler
+type command func(args []string, stdout, stderr io.Writer) int
+
+// commonOptions holds the options shared by all subcommands
+type commonOptions struct {
+	dbPath string
+	format string
+}
+
+// newFlagSet creates a flag set with the common options registered
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *commonOptions) {
+	fs := flag.NewFlagSet("ccla "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	opts := &commonOptions{}
+	fs.StringVar(&opts.dbPath, "db", "", "database file (default: $DB_PATH or ccloganalysis.db next to the executable)")
+	fs.StringVar(&opts.format, "format", formatTable, "output format: table, json or csv")
+
+	return fs, opts
+}
+
+// parseArgs parses flags that may appear before or after positional arguments
+// flagパッケージは最初の位置引数で解析を止めるため、`session show <id> -format json` のような指定に対応する
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// validate checks the common options
+func (o *commonOptions) validate() error {
+	switch o.format {
+	case formatTable, formatJSON, formatCSV:
+		return nil
+	default:
+		return fmt.Errorf("format must be 'table', 'json', or 'csv'")
+	}
+}
+
+// openDB opens the database in the same location as the server
+func (o *commonOptions) openDB() (*db.DB, error) {
+	dbPath := o.dbPath
+	if dbPath == "" {
+		dbPath = os.Getenv("DB_PATH")
+	}
+	if dbPath == "" {
+		// デフォルトはサーバーと同じく実行ファイルと同じディレクトリ
+		exePath, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get executable path: %w", err)
+		}
+		dbPath = filepath.Join(filepath.Dir(exePath), "ccloganalysis.db")
+	}
+
+	database, err := db.NewDB(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// カスタム料金表（サーバーと同じPRICING_FILE）
+	if pricingFile := os.Getenv("PRICING_FILE"); pricingFile != "" {
+		table, err := pricing.LoadTable(pricingFile)
+		if err != nil {
+			database.Close()
+			return nil, fmt.Errorf("failed to load pricing table: %w", err)
+		}
+		database.SetPricingTable(table)
+		// 保存済みのコストを料金表に合わせて再計算する
+		if err := database.RecalculateCosts(); err != nil {
+			database.Close()
+			return nil, fmt.Errorf("failed to recalculate costs: %w", err)
+		}
+	}
+
+	// 作業時間の上限（サーバーと同じIDLE_CUTOFF）
+	if idleCutoff := os.Getenv("IDLE_CUTOFF"); idleCutoff != "" {
+		cutoff, err := db.ParseIdleCutoff(idleCutoff)
+		if err != nil {
+			database.Close()
+			return nil, err
+		}
+		database.SetIdleCutoff(cutoff)
+	}
+
+	return database, nil
+}
+
+// resolveClaudeDir returns the Claude projects directory (flag, $CLAUDE_PROJECTS_DIR or ~/.claude/projects)
+func resolveClaudeDir(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if claudeDir := os.Getenv("CLAUDE_PROJECTS_DIR"); claudeDir != "" {
+		return claudeDir, nil
+	}
+	return parser.GetDefaultClaudeDir()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTestEnv creates a Claude projects directory with one session and returns it with a database path
+func setupTestEnv(t *testing.T) (string, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, "projects")
+	projectDir := filepath.Join(claudeDir, "cli-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+
+	content := `{"type":"user","timestamp":"2025-01-06T10:00:00Z","sessionId":"cli-session","uuid":"uuid-1","cwd":"/path/to/cli","gitBranch":"main","message":{"role":"user","content":[{"type":"text","text":"Write a report"}]}}
+{"type":"assistant","timestamp":"2025-01-06T10:00:30Z","sessionId":"cli-session","uuid":"uuid-2","parentUuid":"uuid-1","cwd":"/path/to/cli","gitBranch":"main","message":{"model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":100,"output_tokens":40}}}
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "cli-session.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+
+	return claudeDir, filepath.Join(tmpDir, "test.db")
+}
+
+// runCLI runs the CLI and returns the exit code and output
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	claudeDir, dbPath := setupTestEnv(t)
+
+	t.Run("syncでログが同期される", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "sync", "-db", dbPath, "-claude-dir", claudeDir, "-format", "json")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+
+		var summary syncSummary
+		if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+			t.Fatalf("Failed to decode output: %v\n%s", err, stdout)
+		}
+		if summary.SessionsSynced != 1 {
+			t.Errorf("Expected 1 session synced, got %d", summary.SessionsSynced)
+		}
+		if strings.Contains(stdout, "INFO") {
+			t.Error("Expected logs not to be written to stdout")
+		}
+	})
+
+	t.Run("sessions listをJSONで出力できる", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "sessions", "list", "-db", dbPath, "-format", "json")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+
+		var items []sessionItem
+		if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+			t.Fatalf("Failed to decode output: %v\n%s", err, stdout)
+		}
+		if len(items) != 1 || items[0].ID != "cli-session" || items[0].ProjectName != "cli-project" {
+			t.Errorf("Unexpected sessions: %+v", items)
+		}
+	})
+
+	t.Run("位置引数の後のオプションも解釈される", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "session", "show", "cli-session", "-db", dbPath, "-format", "json")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+
+		var detail sessionDetail
+		if err := json.Unmarshal([]byte(stdout), &detail); err != nil {
+			t.Fatalf("Failed to decode output: %v\n%s", err, stdout)
+		}
+		if detail.InputTokens != 100 || detail.DurationSeconds != 30 || len(detail.Models) != 1 {
+			t.Errorf("Unexpected session detail: %+v", detail)
+		}
+	})
+
+	t.Run("timelineをCSVで出力できる", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "timeline", "--period", "week", "-db", dbPath, "-format", "csv")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+
+		records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v\n%s", err, stdout)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Expected header and 1 row, got %v", records)
+		}
+		if records[1][0] != "2025-01-06" || records[1][2] != "1" || records[1][3] != "100" {
+			t.Errorf("Unexpected timeline row: %v", records[1])
+		}
+	})
+
+	t.Run("stats projectをテーブルで出力できる", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "stats", "project", "cli-project", "-db", dbPath)
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		if !strings.Contains(stdout, "sessions") || !strings.Contains(stdout, "input_tokens") {
+			t.Errorf("Unexpected table output:\n%s", stdout)
+		}
+	})
+
+	t.Run("存在しないセッションはエラー終了する", func(t *testing.T) {
+		code, _, _ := runCLI(t, "session", "show", "missing-session", "-db", dbPath)
+		if code != exitError {
+			t.Errorf("Expected exit code %d, got %d", exitError, code)
+		}
+	})
+}
+
+func TestRun_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"コマンドなし", nil},
+		{"不明なコマンド", []string{"unknown"}},
+		{"sessionsのサブコマンドなし", []string{"sessions"}},
+		{"不正な出力形式", []string{"stats", "total", "-format", "xml"}},
+		{"不正な期間", []string{"timeline", "-period", "year"}},
+		{"statsの対象なし", []string{"stats", "project"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := runCLI(t, tt.args...)
+			if code != exitUsage {
+				t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+			}
+		})
+	}
+}
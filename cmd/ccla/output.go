@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// maxTableTextLength is the maximum number of characters of free text shown in table output
+const maxTableTextLength = 60
+
+// report is the result of a command rendered as table, JSON or CSV
+type report struct {
+	headers []string
+	rows    [][]string
+	value   interface{} // JSON出力する値（テーブル・CSVではheaders/rowsを使う）
+}
+
+// writeReport renders the report in the given format
+func writeReport(w io.Writer, format string, r report) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.value)
+	case formatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(r.headers); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(r.rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(r.headers, "\t"))
+		for _, row := range r.rows {
+			// テーブルでは改行・タブを含む値で列が崩れないようにする
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.Join(strings.Fields(cell), " ")
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// field is a single row of a key/value report
+type field struct {
+	name  string
+	value string
+}
+
+// fieldReport builds a two-column report from key/value pairs
+func fieldReport(fields []field, value interface{}) report {
+	rows := make([][]string, 0, len(fields))
+	for _, f := range fields {
+		rows = append(rows, []string{f.name, f.value})
+	}
+	return report{
+		headers: []string{"FIELD", "VALUE"},
+		rows:    rows,
+		value:   value,
+	}
+}
+
+// formatInt formats an integer value
+func formatInt(v int) string {
+	return strconv.Itoa(v)
+}
+
+// formatCost formats a USD cost
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// formatRate formats a ratio as a percentage
+func formatRate(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+}
+
+// formatTime formats a timestamp in local time (empty for zero time)
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// formatDate formats the date part of a timestamp
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// formatDuration formats a number of seconds as a duration
+func formatDuration(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// truncateText shortens free text for table output
+func truncateText(format, s string) string {
+	if format != formatTable {
+		return s
+	}
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= maxTableTextLength {
+		return string(runes)
+	}
+	return string(runes[:maxTableTextLength]) + "..."
+}
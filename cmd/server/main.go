@@ -13,7 +13,9 @@ import (
 
 	"github.com/a-tak/ccloganalysis/internal/api"
 	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/events"
 	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/pricing"
 	"github.com/a-tak/ccloganalysis/internal/scanner"
 	"github.com/a-tak/ccloganalysis/internal/watcher"
 )
@@ -51,12 +53,40 @@ func main() {
 	}
 	defer database.Close()
 
+	// Load custom pricing table if specified (default: built-in table)
+	if pricingFile := os.Getenv("PRICING_FILE"); pricingFile != "" {
+		table, err := pricing.LoadTable(pricingFile)
+		if err != nil {
+			log.Fatalf("Failed to load pricing table: %v", err)
+		}
+		database.SetPricingTable(table)
+		if err := database.RecalculateCosts(); err != nil {
+			log.Printf("Warning: Failed to recalculate costs: %v", err)
+		}
+	}
+
+	// Apply custom idle cutoff for active/idle time if specified (default: 10 minutes)
+	if idleCutoff := os.Getenv("IDLE_CUTOFF"); idleCutoff != "" {
+		cutoff, err := db.ParseIdleCutoff(idleCutoff)
+		if err != nil {
+			log.Fatalf("Failed to parse IDLE_CUTOFF: %v", err)
+		}
+		database.SetIdleCutoff(cutoff)
+		if err := database.RecalculateActivityTimes(); err != nil {
+			log.Printf("Warning: Failed to recalculate activity times: %v", err)
+		}
+	}
+
 	// Create parser for sync functionality
 	p := parser.NewParser(claudeDir)
 	service := api.NewDatabaseSessionService(database, p)
 
+	// Create event bus for live updates (GET /api/events)
+	eventBus := events.NewBus()
+
 	// Create scan manager
 	scanManager := scanner.NewScanManager(database, p)
+	scanManager.SetEventBus(eventBus)
 
 	// Sync project groups from existing data before starting scan
 	// This allows groups to be displayed immediately on server startup
@@ -66,36 +96,6 @@ func main() {
 		fmt.Println("Project groups synchronized from existing data")
 	}
 
-	// Start initial sync asynchronously (unless SKIP_INITIAL_SYNC is set)
-	skipInitialSync := os.Getenv("SKIP_INITIAL_SYNC") != ""
-	if !skipInitialSync {
-		if err := scanManager.StartInitialScan(context.Background()); err != nil {
-			log.Printf("Warning: Failed to start initial scan: %v", err)
-		}
-		fmt.Println("Initial sync started in background...")
-
-		// Wait for initial sync to complete before starting file watcher
-		// This prevents concurrent sync operations that could cause excessive logging
-		fmt.Println("Waiting for initial sync to complete...")
-		scanManager.WaitForInitialScan()
-		fmt.Println("Initial sync completed")
-	} else {
-		fmt.Println("Skipping initial sync (SKIP_INITIAL_SYNC is set)")
-	}
-
-	// Initialize file watcher (after initial sync completes)
-	watcherConfig := watcher.LoadWatcherConfig()
-	var fileWatcher *watcher.FileWatcher
-
-	if watcherConfig.Enabled && p != nil {
-		fileWatcher = watcher.NewFileWatcher(database, p, watcherConfig)
-		if fileWatcher != nil {
-			fileWatcher.Start()
-			fmt.Printf("File watcher enabled (interval: %s, debounce: %s)\n",
-				watcherConfig.Interval, watcherConfig.Debounce)
-		}
-	}
-
 	fmt.Printf("Claude Code Log Analysis Server\n")
 	fmt.Printf("================================\n")
 	fmt.Printf("Claude projects directory: %s\n", claudeDir)
@@ -104,6 +104,7 @@ func main() {
 
 	// Create handler and routes
 	handler := api.NewHandler(service, scanManager)
+	handler.SetEventBus(eventBus)
 	router := handler.Routes()
 
 	// Setup HTTP server with graceful shutdown support
@@ -112,11 +113,16 @@ func main() {
 		Handler: router,
 	}
 
+	// Close event streams on shutdown so that Shutdown does not wait for them
+	server.RegisterOnShutdown(eventBus.Close)
+
 	// Setup graceful shutdown
+	// Register before the initial sync so that a signal during the sync is handled
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 
 	// Start HTTP server in goroutine
+	// Listen before the initial sync so that clients receive its scan.progress events
 	serverErrors := make(chan error, 1)
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -124,35 +130,86 @@ func main() {
 		}
 	}()
 
+	// Start initial sync asynchronously (unless SKIP_INITIAL_SYNC is set)
+	skipInitialSync := os.Getenv("SKIP_INITIAL_SYNC") != ""
+	if !skipInitialSync {
+		if err := scanManager.StartInitialScan(context.Background()); err != nil {
+			log.Printf("Warning: Failed to start initial scan: %v", err)
+		}
+		fmt.Println("Initial sync started in background...")
+
+		// Wait for initial sync to complete before starting file watcher
+		// This prevents concurrent sync operations that could cause excessive logging
+		// A server error (e.g. port already in use) or a shutdown signal ends the wait
+		fmt.Println("Waiting for initial sync to complete...")
+		scanDone := make(chan struct{})
+		go func() {
+			scanManager.WaitForInitialScan()
+			close(scanDone)
+		}()
+
+		select {
+		case <-scanDone:
+			fmt.Println("Initial sync completed")
+		case err := <-serverErrors:
+			log.Fatalf("Server error: %v", err)
+		case <-sigCh:
+			shutdown(server, scanManager, nil, database)
+			return
+		}
+	} else {
+		fmt.Println("Skipping initial sync (SKIP_INITIAL_SYNC is set)")
+	}
+
+	// Initialize file watcher (after initial sync completes)
+	watcherConfig := watcher.LoadWatcherConfig()
+	var fileWatcher *watcher.FileWatcher
+
+	if watcherConfig.Enabled && p != nil {
+		fileWatcher = watcher.NewFileWatcher(database, p, watcherConfig)
+		if fileWatcher != nil {
+			fileWatcher.SetEventBus(eventBus)
+			fileWatcher.Start()
+			fmt.Printf("File watcher enabled (backend: %s, interval: %s, debounce: %s)\n",
+				fileWatcher.Backend(), watcherConfig.Interval, watcherConfig.Debounce)
+		}
+	}
+
 	// Wait for shutdown signal or server error
 	select {
 	case err := <-serverErrors:
 		log.Fatalf("Server error: %v", err)
 	case <-sigCh:
-		fmt.Println("\nShutting down gracefully...")
-
-		// Create shutdown context with timeout (shorter for faster shutdown)
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		defer cancel()
+		shutdown(server, scanManager, fileWatcher, database)
+	}
+}
 
-		// Shutdown HTTP server gracefully
-		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("Server shutdown error: %v", err)
-		}
+// shutdown stops the HTTP server, the background sync and the database
+// fileWatcher is nil if the watcher has not been started
+func shutdown(server *http.Server, scanManager *scanner.ScanManager, fileWatcher *watcher.FileWatcher, database *db.DB) {
+	fmt.Println("\nShutting down gracefully...")
 
-		// Stop scan manager (max 5 seconds)
-		if scanManager != nil {
-			scanManager.Stop()
-		}
+	// Create shutdown context with timeout (shorter for faster shutdown)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
-		// Stop file watcher
-		if fileWatcher != nil {
-			fileWatcher.Stop()
-		}
+	// Shutdown HTTP server gracefully
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
 
-		// Close database
-		database.Close()
+	// Stop scan manager (max 5 seconds)
+	if scanManager != nil {
+		scanManager.Stop()
+	}
 
-		fmt.Println("Shutdown complete")
+	// Stop file watcher
+	if fileWatcher != nil {
+		fileWatcher.Stop()
 	}
+
+	// Close database
+	database.Close()
+
+	fmt.Println("Shutdown complete")
 }
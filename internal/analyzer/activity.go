@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultIdleCutoff is the longest gap between entries counted as AI work time
+const DefaultIdleCutoff = 10 * time.Minute
+
+// ActivityTime splits the elapsed time of a session into AI work time and user wait time
+type ActivityTime struct {
+	Active time.Duration // AIが応答を生成している、またはツールが実行されている時間
+	Idle   time.Duration // ユーザーの入力待ちなどのアイドル時間
+}
+
+// ActiveSeconds returns the AI work time in whole seconds
+func (a ActivityTime) ActiveSeconds() int {
+	return int(a.Active.Seconds())
+}
+
+// IdleSeconds returns the user wait time in whole seconds
+func (a ActivityTime) IdleSeconds() int {
+	return int(a.Idle.Seconds())
+}
+
+// ComputeActivityTime segments the timeline of a session into processing and idle intervals
+//
+// The gap before an assistant entry, a tool result or any other entry written without user input
+// is processing time. The gap before a user prompt is the user reading and thinking, and counts as
+// idle. Processing gaps longer than idleCutoff (e.g. a permission prompt left unanswered overnight)
+// count as idle beyond the cutoff.
+func ComputeActivityTime(entries []TreeEntry, idleCutoff time.Duration) ActivityTime {
+	return ActivityTime{}.Append(time.Time{}, entries, idleCutoff)
+}
+
+// Append adds the gaps of entries written after the entry at last to the activity time
+// last is the latest entry already counted (the zero time if none). When no entry is earlier than last,
+// appending entries in pieces gives the same result as ComputeActivityTime over all of them.
+func (a ActivityTime) Append(last time.Time, entries []TreeEntry, idleCutoff time.Duration) ActivityTime {
+	sorted := make([]TreeEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Timestamp.IsZero() {
+			sorted = append(sorted, entry)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	previous := last
+	for _, entry := range sorted {
+		if previous.IsZero() {
+			previous = entry.Timestamp
+			continue
+		}
+		gap := entry.Timestamp.Sub(previous)
+		if gap <= 0 {
+			continue
+		}
+		previous = entry.Timestamp
+		if entry.IsPrompt {
+			a.Idle += gap
+			continue
+		}
+		if idleCutoff > 0 && gap > idleCutoff {
+			a.Active += idleCutoff
+			a.Idle += gap - idleCutoff
+			continue
+		}
+		a.Active += gap
+	}
+
+	return a
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeActivityTime(t *testing.T) {
+	at := func(uuid string, seconds int, isPrompt bool) TreeEntry {
+		entry := treeEntry(uuid, "", 0, isPrompt, 0)
+		entry.Timestamp = time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC).Add(time.Duration(seconds) * time.Second)
+		return entry
+	}
+
+	t.Run("応答とツール実行は作業時間、ユーザー入力までは待機時間", func(t *testing.T) {
+		activity := ComputeActivityTime([]TreeEntry{
+			at("u1", 0, true),
+			at("a1", 20, false),  // 応答生成 20秒
+			at("r1", 50, false),  // ツール実行 30秒
+			at("a2", 60, false),  // 応答生成 10秒
+			at("u2", 180, true),  // 入力待ち 120秒
+			at("a3", 185, false), // 応答生成 5秒
+		}, DefaultIdleCutoff)
+
+		if activity.ActiveSeconds() != 65 || activity.IdleSeconds() != 120 {
+			t.Errorf("Expected 65s active / 120s idle, got %+v", activity)
+		}
+	})
+
+	t.Run("上限を超える処理時間は待機時間として扱う", func(t *testing.T) {
+		// ツールの許可待ちで一晩放置
+		activity := ComputeActivityTime([]TreeEntry{
+			at("u1", 0, true),
+			at("a1", 10, false),
+			at("r1", 10+14*3600, false),
+		}, 10*time.Minute)
+
+		if activity.ActiveSeconds() != 610 || activity.IdleSeconds() != 14*3600-600 {
+			t.Errorf("Unexpected activity: %+v", activity)
+		}
+	})
+
+	t.Run("時刻順に並べ替え、タイムスタンプのないエントリは無視する", func(t *testing.T) {
+		activity := ComputeActivityTime([]TreeEntry{
+			at("a1", 30, false),
+			{UUID: "no-time"},
+			at("u1", 0, true),
+		}, DefaultIdleCutoff)
+
+		if activity.ActiveSeconds() != 30 || activity.IdleSeconds() != 0 {
+			t.Errorf("Unexpected activity: %+v", activity)
+		}
+	})
+
+	t.Run("追記分を加算すると全体から計算した結果と一致する", func(t *testing.T) {
+		entries := []TreeEntry{
+			at("u1", 0, true),
+			at("a1", 20, false),
+			at("u2", 140, true),
+			at("a2", 150, false),
+			at("r2", 150+11*60, false),
+		}
+		want := ComputeActivityTime(entries, DefaultIdleCutoff)
+
+		activity := ComputeActivityTime(entries[:2], DefaultIdleCutoff)
+		activity = activity.Append(entries[1].Timestamp, entries[2:], DefaultIdleCutoff)
+		if activity != want {
+			t.Errorf("Expected %+v, got %+v", want, activity)
+		}
+	})
+}
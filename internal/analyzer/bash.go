@@ -0,0 +1,225 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// Bash command families
+const (
+	BashFamilyGit     = "git"
+	BashFamilyTest    = "test"
+	BashFamilyBuild   = "build"
+	BashFamilyPackage = "package"
+	BashFamilyLint    = "lint"
+	BashFamilyFile    = "file"
+	BashFamilyCustom  = "custom"
+)
+
+// BashFamilies lists the command families in display order
+var BashFamilies = []string{
+	BashFamilyGit, BashFamilyTest, BashFamilyBuild, BashFamilyPackage,
+	BashFamilyLint, BashFamilyFile, BashFamilyCustom,
+}
+
+// bashProgramFamilies maps programs that always belong to one family
+var bashProgramFamilies = map[string]string{
+	"git": BashFamilyGit, "gh": BashFamilyGit,
+
+	"pytest": BashFamilyTest, "jest": BashFamilyTest, "vitest": BashFamilyTest, "mocha": BashFamilyTest,
+	"rspec": BashFamilyTest, "phpunit": BashFamilyTest, "playwright": BashFamilyTest, "tox": BashFamilyTest,
+
+	"make": BashFamilyBuild, "cmake": BashFamilyBuild, "tsc": BashFamilyBuild, "webpack": BashFamilyBuild,
+	"vite": BashFamilyBuild, "gradle": BashFamilyBuild, "gradlew": BashFamilyBuild, "mvn": BashFamilyBuild,
+	"docker": BashFamilyBuild, "bazel": BashFamilyBuild, "javac": BashFamilyBuild, "gcc": BashFamilyBuild,
+
+	"pip": BashFamilyPackage, "pip3": BashFamilyPackage, "brew": BashFamilyPackage, "apt": BashFamilyPackage,
+	"apt-get": BashFamilyPackage, "poetry": BashFamilyPackage, "uv": BashFamilyPackage, "bundle": BashFamilyPackage,
+	"composer": BashFamilyPackage,
+
+	"eslint": BashFamilyLint, "prettier": BashFamilyLint, "golangci-lint": BashFamilyLint, "gofmt": BashFamilyLint,
+	"ruff": BashFamilyLint, "flake8": BashFamilyLint, "black": BashFamilyLint, "mypy": BashFamilyLint,
+	"stylelint": BashFamilyLint, "biome": BashFamilyLint, "shellcheck": BashFamilyLint, "rubocop": BashFamilyLint,
+
+	"ls": BashFamilyFile, "cat": BashFamilyFile, "head": BashFamilyFile, "tail": BashFamilyFile,
+	"find": BashFamilyFile, "grep": BashFamilyFile, "rg": BashFamilyFile, "mkdir": BashFamilyFile,
+	"rm": BashFamilyFile, "mv": BashFamilyFile, "cp": BashFamilyFile, "touch": BashFamilyFile,
+	"chmod": BashFamilyFile, "wc": BashFamilyFile, "tree": BashFamilyFile, "sed": BashFamilyFile,
+	"awk": BashFamilyFile, "pwd": BashFamilyFile, "du": BashFamilyFile, "ln": BashFamilyFile,
+	"stat": BashFamilyFile, "diff": BashFamilyFile, "file": BashFamilyFile,
+}
+
+// bashSubcommandFamilies maps subcommands of multi-purpose tools (go, cargo, npm, ...) to families
+var bashSubcommandFamilies = map[string]map[string]string{
+	"go": {
+		"test": BashFamilyTest, "build": BashFamilyBuild, "install": BashFamilyBuild, "run": BashFamilyBuild,
+		"generate": BashFamilyBuild, "get": BashFamilyPackage, "mod": BashFamilyPackage,
+		"vet": BashFamilyLint, "fmt": BashFamilyLint,
+	},
+	"cargo": {
+		"test": BashFamilyTest, "build": BashFamilyBuild, "run": BashFamilyBuild, "check": BashFamilyBuild,
+		"add": BashFamilyPackage, "install": BashFamilyPackage, "update": BashFamilyPackage,
+		"clippy": BashFamilyLint, "fmt": BashFamilyLint,
+	},
+	"dotnet": {
+		"test": BashFamilyTest, "build": BashFamilyBuild, "run": BashFamilyBuild, "add": BashFamilyPackage,
+		"restore": BashFamilyPackage,
+	},
+}
+
+// nodePackageManagers are the package managers whose scripts are classified by their name
+var nodePackageManagers = map[string]bool{"npm": true, "yarn": true, "pnpm": true, "bun": true}
+
+// nodePackageRunners run the command of a package given as their first argument
+var nodePackageRunners = map[string]bool{"npx": true, "bunx": true}
+
+// nodeInstallCommands are the package manager subcommands that install or remove dependencies
+var nodeInstallCommands = map[string]bool{
+	"install": true, "i": true, "ci": true, "add": true, "remove": true, "uninstall": true,
+	"update": true, "upgrade": true,
+}
+
+// BashCommand is a Bash tool call classified by the program it runs
+type BashCommand struct {
+	Command string // 実行したコマンド全体
+	Program string // 分類に使ったプログラム名（例: git, go, npm）
+	Family  string // BashFamily*
+}
+
+// ParseBashCommand extracts and classifies the command of a Bash tool input (JSON)
+// Returns false for inputs without a command.
+func ParseBashCommand(input string) (BashCommand, bool) {
+	var params struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(input), &params); err != nil || strings.TrimSpace(params.Command) == "" {
+		return BashCommand{}, false
+	}
+
+	program, family := ClassifyBashCommand(params.Command)
+	return BashCommand{Command: params.Command, Program: program, Family: family}, true
+}
+
+// ClassifyBashCommand returns the program and family of a shell command
+//
+// The first segment of a command chain that is not a cd is classified, skipping environment variable
+// assignments and wrappers such as sudo or timeout.
+func ClassifyBashCommand(command string) (program, family string) {
+	for _, segment := range splitCommandChain(command) {
+		words := commandWords(segment)
+		if len(words) == 0 || words[0] == "cd" {
+			continue
+		}
+		return words[0], classifyWords(words)
+	}
+	return "", BashFamilyCustom
+}
+
+// splitCommandChain splits a command at &&, || and ; (pipes stay within a segment)
+func splitCommandChain(command string) []string {
+	replacer := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n")
+	return strings.Split(replacer.Replace(command), "\n")
+}
+
+// commandWords splits a segment into words, dropping environment variable assignments and wrappers
+func commandWords(segment string) []string {
+	words := strings.Fields(segment)
+	for len(words) > 0 {
+		word := words[0]
+		switch {
+		case strings.Contains(word, "=") && !strings.HasPrefix(word, "="):
+			words = words[1:]
+		case word == "sudo" || word == "time" || word == "env" || word == "nohup":
+			words = words[1:]
+		case word == "timeout" && len(words) > 1:
+			words = words[2:]
+		default:
+			words[0] = filepath.Base(word)
+			return words
+		}
+	}
+	return nil
+}
+
+// classifyWords classifies the words of a command whose first word is the program
+func classifyWords(words []string) string {
+	program := words[0]
+	subcommand := ""
+	if len(words) > 1 {
+		subcommand = words[1]
+	}
+
+	if family, ok := bashProgramFamilies[program]; ok {
+		// make test などはテストとみなす
+		if family == BashFamilyBuild && strings.Contains(subcommand, "test") {
+			return BashFamilyTest
+		}
+		return family
+	}
+
+	if families, ok := bashSubcommandFamilies[program]; ok {
+		if family, ok := families[subcommand]; ok {
+			return family
+		}
+		return BashFamilyCustom
+	}
+
+	if nodePackageManagers[program] {
+		if nodeInstallCommands[subcommand] {
+			return BashFamilyPackage
+		}
+		// npm run <script> はスクリプト名で分類する
+		script := subcommand
+		if (subcommand == "run" || subcommand == "run-script") && len(words) > 2 {
+			script = words[2]
+		}
+		return classifyScriptName(script)
+	}
+
+	// npx <package> は実行するパッケージで分類する
+	if nodePackageRunners[program] {
+		args := words[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return BashFamilyCustom
+		}
+		pkg := packageCommandName(args[0])
+		if family := classifyWords(append([]string{pkg}, args[1:]...)); family != BashFamilyCustom {
+			return family
+		}
+		return classifyScriptName(pkg)
+	}
+
+	// python -m pytest など
+	if (program == "python" || program == "python3") && len(words) > 2 && words[1] == "-m" {
+		return classifyWords(words[2:])
+	}
+
+	return BashFamilyCustom
+}
+
+// packageCommandName returns the command name of a package specifier
+// Example: "@biomejs/biome@1.9.0" -> "biome", "prettier@3" -> "prettier"
+func packageCommandName(pkg string) string {
+	if i := strings.LastIndex(pkg, "@"); i > 0 {
+		pkg = pkg[:i]
+	}
+	return pkg[strings.LastIndex(pkg, "/")+1:]
+}
+
+// classifyScriptName classifies a package.json script by its name
+func classifyScriptName(script string) string {
+	switch {
+	case strings.Contains(script, "test"):
+		return BashFamilyTest
+	case strings.Contains(script, "lint") || strings.Contains(script, "format") || strings.Contains(script, "typecheck"):
+		return BashFamilyLint
+	case strings.Contains(script, "build"):
+		return BashFamilyBuild
+	default:
+		return BashFamilyCustom
+	}
+}
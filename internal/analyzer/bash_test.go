@@ -0,0 +1,57 @@
+package analyzer
+
+import "testing"
+
+func TestClassifyBashCommand(t *testing.T) {
+	tests := []struct {
+		command     string
+		wantProgram string
+		wantFamily  string
+	}{
+		{"git status", "git", BashFamilyGit},
+		{"gh pr create --title x", "gh", BashFamilyGit},
+		{"go test ./...", "go", BashFamilyTest},
+		{"go build ./... && go vet ./...", "go", BashFamilyBuild},
+		{"go vet ./...", "go", BashFamilyLint},
+		{"cd web && npm run test:unit", "npm", BashFamilyTest},
+		{"npm install", "npm", BashFamilyPackage},
+		{"pnpm lint", "pnpm", BashFamilyLint},
+		{"yarn build", "yarn", BashFamilyBuild},
+		{"npx vitest run", "npx", BashFamilyTest},
+		{"npx --yes prettier@3 --check .", "npx", BashFamilyLint},
+		{"bunx @biomejs/biome check", "bunx", BashFamilyLint},
+		{"npx tsc --noEmit", "npx", BashFamilyBuild},
+		{"npx create-next-app my-app", "npx", BashFamilyCustom},
+		{"python3 -m pytest tests/", "python3", BashFamilyTest},
+		{"CGO_ENABLED=0 go build -o bin/app", "go", BashFamilyBuild},
+		{"timeout 60 make test", "make", BashFamilyTest},
+		{"make", "make", BashFamilyBuild},
+		{"cargo clippy -- -D warnings", "cargo", BashFamilyLint},
+		{"pip install -r requirements.txt", "pip", BashFamilyPackage},
+		{"ls -la | grep foo", "ls", BashFamilyFile},
+		{"/usr/bin/find . -name '*.go'", "find", BashFamilyFile},
+		{"./scripts/deploy.sh", "deploy.sh", BashFamilyCustom},
+		{"go run ./cmd/server", "go", BashFamilyBuild},
+		{"cd /tmp", "", BashFamilyCustom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			program, family := ClassifyBashCommand(tt.command)
+			if program != tt.wantProgram || family != tt.wantFamily {
+				t.Errorf("ClassifyBashCommand(%q) = %q, %q; want %q, %q", tt.command, program, family, tt.wantProgram, tt.wantFamily)
+			}
+		})
+	}
+}
+
+func TestParseBashCommand(t *testing.T) {
+	command, ok := ParseBashCommand(`{"command":"go test ./...","description":"Run tests"}`)
+	if !ok || command.Command != "go test ./..." || command.Program != "go" || command.Family != BashFamilyTest {
+		t.Errorf("Unexpected command: %+v, %v", command, ok)
+	}
+
+	if _, ok := ParseBashCommand(`{"description":"no command"}`); ok {
+		t.Error("Expected false for input without a command")
+	}
+}
@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Claude Codeがスラッシュコマンドの実行時に書き込むタグ
+var (
+	commandNamePattern = regexp.MustCompile(`(?s)<command-name>\s*(.*?)\s*</command-name>`)
+	commandArgsPattern = regexp.MustCompile(`(?s)<command-args>\s*(.*?)\s*</command-args>`)
+)
+
+// builtinCommands are the slash commands shipped with Claude Code
+// これ以外のコマンドは .claude/commands などに定義されたカスタムコマンドとみなす
+var builtinCommands = map[string]bool{
+	"/add-dir": true, "/agents": true, "/bug": true, "/clear": true, "/compact": true,
+	"/config": true, "/context": true, "/cost": true, "/doctor": true, "/exit": true,
+	"/export": true, "/help": true, "/hooks": true, "/ide": true, "/init": true,
+	"/install-github-app": true, "/login": true, "/logout": true, "/mcp": true, "/memory": true,
+	"/model": true, "/output-style": true, "/permissions": true, "/pr-comments": true, "/release-notes": true,
+	"/resume": true, "/review": true, "/rewind": true, "/security-review": true, "/status": true,
+	"/statusline": true, "/terminal-setup": true, "/todos": true, "/upgrade": true, "/usage": true,
+	"/vim": true,
+}
+
+// Command is a slash command invocation parsed from a user message
+type Command struct {
+	Name   string // スラッシュを含むコマンド名（例: /compact, /frontend:review）
+	Args   string
+	Custom bool // 組み込みコマンドではない
+}
+
+// ParseCommand extracts the slash command from the text of a user message
+// Claude Code records a command as <command-name>/review</command-name> with its arguments in <command-args>.
+func ParseCommand(text string) (Command, bool) {
+	match := commandNamePattern.FindStringSubmatch(text)
+	if match == nil || match[1] == "" {
+		return Command{}, false
+	}
+
+	name := match[1]
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+
+	command := Command{Name: name, Custom: !builtinCommands[name]}
+	if args := commandArgsPattern.FindStringSubmatch(text); args != nil {
+		command.Args = args[1]
+	}
+	return command, true
+}
+
+// CommandInvocation is a slash command with the usage of the turns that follow it
+type CommandInvocation struct {
+	Command
+	UUID      string
+	Timestamp time.Time
+
+	// コマンドから次のユーザー入力までのエントリ（コマンド自身を含む）
+	EntryCount          int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+}
+
+// ExtractCommands finds the slash commands in the time-ordered entries of a session
+//
+// The usage of each command covers the entries from the command up to the next real user prompt,
+// i.e. the work Claude did in response to the command.
+func ExtractCommands(entries []TaskEntry) []CommandInvocation {
+	return ContinueCommands(nil, entries)
+}
+
+// ContinueCommands finds the slash commands in entries written after an earlier part of the session
+// open is the last command of the earlier part when its turn has not ended yet (nil otherwise); the usage
+// of the entries up to the next real user prompt is added to it. Returns the commands found in entries.
+func ContinueCommands(open *CommandInvocation, entries []TaskEntry) []CommandInvocation {
+	var invocations []CommandInvocation
+	current := open
+	for _, entry := range entries {
+		if IsTaskPrompt(entry) {
+			current = nil
+			if command, ok := ParseCommand(entry.Text); ok {
+				invocations = append(invocations, CommandInvocation{
+					Command:   command,
+					UUID:      entry.UUID,
+					Timestamp: entry.Timestamp,
+				})
+				current = &invocations[len(invocations)-1]
+			}
+		}
+		if current == nil {
+			continue
+		}
+
+		current.EntryCount++
+		current.InputTokens += entry.InputTokens
+		current.OutputTokens += entry.OutputTokens
+		current.CacheCreationTokens += entry.CacheCreationTokens
+		current.CacheReadTokens += entry.CacheReadTokens
+	}
+	return invocations
+}
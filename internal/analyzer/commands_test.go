@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   Command
+		wantOK bool
+	}{
+		{
+			name:   "組み込みコマンド",
+			text:   "<command-name>/compact</command-name>\n<command-message>compact</command-message>\n<command-args></command-args>",
+			want:   Command{Name: "/compact"},
+			wantOK: true,
+		},
+		{
+			name:   "引数付きのカスタムコマンド",
+			text:   "<command-message>frontend:review is running…</command-message>\n<command-name>/frontend:review</command-name>\n<command-args>src/App.tsx</command-args>",
+			want:   Command{Name: "/frontend:review", Args: "src/App.tsx", Custom: true},
+			wantOK: true,
+		},
+		{
+			name:   "スラッシュのないコマンド名",
+			text:   "<command-name>model</command-name>",
+			want:   Command{Name: "/model"},
+			wantOK: true,
+		},
+		{
+			name: "通常のテキスト",
+			text: "Please run /clear later",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseCommand(tt.text)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseCommand() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExtractCommands(t *testing.T) {
+	start := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	entry := func(uuid string, seconds int, prompt bool, text string, output int) TaskEntry {
+		return TaskEntry{UUID: uuid, Timestamp: start.Add(time.Duration(seconds) * time.Second), IsPrompt: prompt, Text: text, OutputTokens: output}
+	}
+
+	commands := ExtractCommands([]TaskEntry{
+		entry("u1", 0, true, "Fix the bug", 0),
+		entry("a1", 5, false, "", 100),
+		entry("c1", 10, true, "<command-name>/review</command-name><command-args>42</command-args>", 0),
+		entry("a2", 20, false, "", 300),
+		entry("a3", 30, false, "", 200),
+		entry("c2", 40, true, "<command-name>/clear</command-name>", 0),
+		entry("o1", 41, true, "<local-command-stdout></local-command-stdout>", 0),
+		entry("u2", 50, true, "Add a README", 0),
+		entry("a4", 60, false, "", 50),
+	})
+
+	if len(commands) != 2 {
+		t.Fatalf("Expected 2 commands, got %+v", commands)
+	}
+	review := commands[0]
+	if review.Name != "/review" || review.Args != "42" || review.UUID != "c1" || review.EntryCount != 3 || review.OutputTokens != 500 {
+		t.Errorf("Unexpected /review invocation: %+v", review)
+	}
+	// コマンド出力はコマンドのターンに含め、次のユーザー入力で終了する
+	clear := commands[1]
+	if clear.Name != "/clear" || clear.EntryCount != 2 || clear.OutputTokens != 0 {
+		t.Errorf("Unexpected /clear invocation: %+v", clear)
+	}
+
+	// 途中までの抽出に続けると、終わっていないターンに追記分の使用量を加算する
+	open := ExtractCommands([]TaskEntry{
+		entry("c1", 10, true, "<command-name>/review</command-name>", 0),
+		entry("a2", 20, false, "", 300),
+	})[0]
+	continued := ContinueCommands(&open, []TaskEntry{
+		entry("a3", 30, false, "", 200),
+		entry("c2", 40, true, "<command-name>/clear</command-name>", 0),
+		entry("a4", 50, false, "", 50),
+	})
+	if open.EntryCount != 3 || open.OutputTokens != 500 {
+		t.Errorf("Unexpected continued /review invocation: %+v", open)
+	}
+	if len(continued) != 1 || continued[0].Name != "/clear" || continued[0].OutputTokens != 50 {
+		t.Errorf("Unexpected continued commands: %+v", continued)
+	}
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import "time"
+
+// CommitAttributionWindow is how long after a session ends a commit is still attributed to it
+const CommitAttributionWindow = 30 * time.Minute
+
+// CommitSession is a session reduced to the fields needed to attribute commits to it
+type CommitSession struct {
+	GitBranch string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// AttributeCommit returns the index of the session a commit is attributed to, or -1 if none.
+// A commit belongs to a session when it was made between the session start and
+// CommitAttributionWindow after its end. When several sessions match, a session that was
+// running at the commit time is preferred, then one on the commit's branch, then the latest started.
+func AttributeCommit(sessions []CommitSession, branch string, at time.Time) int {
+	best := -1
+	bestDuring, bestSameBranch := false, false
+	for i, session := range sessions {
+		if at.Before(session.StartTime) || at.After(session.EndTime.Add(CommitAttributionWindow)) {
+			continue
+		}
+
+		during := !at.After(session.EndTime)
+		sameBranch := branch != "" && session.GitBranch == branch
+		if best >= 0 {
+			if during != bestDuring {
+				if !during {
+					continue
+				}
+			} else if sameBranch != bestSameBranch {
+				if !sameBranch {
+					continue
+				}
+			} else if !session.StartTime.After(sessions[best].StartTime) {
+				continue
+			}
+		}
+
+		best, bestDuring, bestSameBranch = i, during, sameBranch
+	}
+	return best
+}
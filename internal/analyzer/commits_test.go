@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttributeCommit(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	sessions := []CommitSession{
+		{GitBranch: "main", StartTime: base, EndTime: base.Add(time.Hour)},
+		{GitBranch: "feature", StartTime: base.Add(30 * time.Minute), EndTime: base.Add(2 * time.Hour)},
+		{GitBranch: "main", StartTime: base.Add(3 * time.Hour), EndTime: base.Add(4 * time.Hour)},
+	}
+
+	tests := []struct {
+		name   string
+		branch string
+		at     time.Time
+		want   int
+	}{
+		{"セッション中のコミット", "main", base.Add(10 * time.Minute), 0},
+		{"重なる場合は同じブランチのセッション", "main", base.Add(45 * time.Minute), 0},
+		{"重なる場合は同じブランチのセッション（別ブランチ）", "feature", base.Add(45 * time.Minute), 1},
+		{"ブランチが一致しない場合は後に始まったセッション", "other", base.Add(45 * time.Minute), 1},
+		{"終了直後のコミットより実行中のセッションを優先", "main", base.Add(70 * time.Minute), 1},
+		{"終了後の猶予期間内", "feature", base.Add(2*time.Hour + 20*time.Minute), 1},
+		{"猶予期間を過ぎたコミット", "main", base.Add(2*time.Hour + 40*time.Minute), -1},
+		{"最初のセッションより前のコミット", "main", base.Add(-time.Minute), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AttributeCommit(sessions, tt.branch, tt.at); got != tt.want {
+				t.Errorf("AttributeCommit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
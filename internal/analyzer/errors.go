@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// MaxErrorPatternLength is the maximum number of characters kept from a normalized error message
+const MaxErrorPatternLength = 500
+
+// エラーメッセージ正規化用の置換ルール（適用順に意味がある）
+var (
+	toolUseErrorTagPattern = regexp.MustCompile(`</?tool_use_error>`)
+	uuidPattern            = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	windowsPathPattern     = regexp.MustCompile(`[A-Za-z]:\\[^\s'"` + "`" + `:,()\[\]]*`)
+	unixPathPattern        = regexp.MustCompile(`(^|[\s'"` + "`" + `(=])(?:~|\.{1,2})?/[^\s'"` + "`" + `:,()\[\]]+`)
+	hexPattern             = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{7,}\b`)
+	numberPattern          = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	whitespacePattern      = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeErrorMessage strips variable parts (paths, UUIDs, hex IDs, numbers) from an error message
+// so that the same kind of failure produces the same text
+func NormalizeErrorMessage(message string) string {
+	normalized := toolUseErrorTagPattern.ReplaceAllString(message, "")
+	normalized = uuidPattern.ReplaceAllString(normalized, "<UUID>")
+	normalized = windowsPathPattern.ReplaceAllString(normalized, "<PATH>")
+	normalized = unixPathPattern.ReplaceAllString(normalized, "${1}<PATH>")
+	normalized = replaceLongHex(normalized)
+	normalized = numberPattern.ReplaceAllString(normalized, "<N>")
+	normalized = whitespacePattern.ReplaceAllString(normalized, " ")
+	normalized = strings.TrimSpace(normalized)
+
+	runes := []rune(normalized)
+	if len(runes) > MaxErrorPatternLength {
+		normalized = string(runes[:MaxErrorPatternLength])
+	}
+	return normalized
+}
+
+// replaceLongHex replaces hex strings of 7 or more characters (commit hashes, addresses, etc.)
+// 数字と英字の両方を含むものだけを対象にし、通常の英単語は置換しない
+func replaceLongHex(s string) string {
+	return hexPattern.ReplaceAllStringFunc(s, func(match string) string {
+		digits := strings.TrimPrefix(match, "0x")
+		if !strings.ContainsAny(digits, "0123456789") || !strings.ContainsAny(digits, "abcdefABCDEF") {
+			return match
+		}
+		return "<HEX>"
+	})
+}
+
+// ErrorPatternHash returns a stable hash identifying a normalized error message for a tool
+func ErrorPatternHash(toolName, normalizedMessage string) string {
+	sum := sha256.Sum256([]byte(toolName + "\x00" + normalizedMessage))
+	return hex.EncodeToString(sum[:])
+}
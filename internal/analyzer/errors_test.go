@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Unixパスを置換する",
+			input:    "rm: cannot remove '/tmp/build-123/out.txt': No such file or directory",
+			expected: "rm: cannot remove '<PATH>': No such file or directory",
+		},
+		{
+			name:     "Windowsパスを置換する",
+			input:    `open C:\Users\me\project\main.go: The system cannot find the file specified.`,
+			expected: "open <PATH>: The system cannot find the file specified.",
+		},
+		{
+			name:     "UUIDと数値を置換する",
+			input:    "session 3f2b8c1e-4d5a-4b6c-9e8f-0a1b2c3d4e5f failed after 30 retries (exit code 127)",
+			expected: "session <UUID> failed after <N> retries (exit code <N>)",
+		},
+		{
+			name:     "コミットハッシュを置換し英単語は残す",
+			input:    "fatal: bad object a1b2c3d4e5 in deadbeef",
+			expected: "fatal: bad object <HEX> in deadbeef",
+		},
+		{
+			name:     "tool_use_errorタグと余分な空白を除去する",
+			input:    "<tool_use_error>File has not been read yet.\n  Read it first</tool_use_error>",
+			expected: "File has not been read yet. Read it first",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeErrorMessage(tt.input)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("長いメッセージは切り詰める", func(t *testing.T) {
+		got := NormalizeErrorMessage(strings.Repeat("x ", MaxErrorPatternLength))
+		if len([]rune(got)) != MaxErrorPatternLength {
+			t.Errorf("Expected %d runes, got %d", MaxErrorPatternLength, len([]rune(got)))
+		}
+	})
+}
+
+func TestErrorPatternHash(t *testing.T) {
+	a := NormalizeErrorMessage("cannot open /a/b.txt at line 10")
+	b := NormalizeErrorMessage("cannot open /c/d.txt at line 42")
+
+	if ErrorPatternHash("Bash", a) != ErrorPatternHash("Bash", b) {
+		t.Error("Expected same hash for messages differing only in variable parts")
+	}
+	if ErrorPatternHash("Bash", a) == ErrorPatternHash("Read", a) {
+		t.Error("Expected different hash for different tools")
+	}
+}
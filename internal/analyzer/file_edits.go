@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// FileEdit is the change a file-editing tool call made to a file
+type FileEdit struct {
+	FilePath     string
+	LinesAdded   int
+	LinesRemoved int
+}
+
+// editInput holds the fields of the Edit, MultiEdit and Write tool inputs
+type editInput struct {
+	FilePath  string `json:"file_path"`
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+	Content   string `json:"content"`
+	Edits     []struct {
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	} `json:"edits"`
+}
+
+// ParseFileEdit computes the file and line changes of an Edit, MultiEdit or Write tool input (JSON)
+//
+// Edit and MultiEdit count the lines that differ between old_string and new_string.
+// Write counts every line of content as added, since the previous content of the file is not logged.
+// Returns false for other tools and inputs without a file_path.
+func ParseFileEdit(toolName, input string) (FileEdit, bool) {
+	if toolName != "Edit" && toolName != "MultiEdit" && toolName != "Write" {
+		return FileEdit{}, false
+	}
+
+	var params editInput
+	if err := json.Unmarshal([]byte(input), &params); err != nil || params.FilePath == "" {
+		return FileEdit{}, false
+	}
+
+	edit := FileEdit{FilePath: params.FilePath}
+	switch toolName {
+	case "Edit":
+		edit.LinesAdded, edit.LinesRemoved = countLineChanges(params.OldString, params.NewString)
+	case "MultiEdit":
+		for _, e := range params.Edits {
+			added, removed := countLineChanges(e.OldString, e.NewString)
+			edit.LinesAdded += added
+			edit.LinesRemoved += removed
+		}
+	case "Write":
+		edit.LinesAdded = len(splitLines(params.Content))
+	}
+	return edit, true
+}
+
+// countLineChanges returns the number of lines added and removed when replacing oldText with newText
+// 先頭と末尾の共通行を除いた残りを変更行とみなす
+func countLineChanges(oldText, newText string) (added, removed int) {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	return len(newLines) - prefix - suffix, len(oldLines) - prefix - suffix
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import "testing"
+
+func TestParseFileEdit(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolName string
+		input    string
+		want     FileEdit
+		wantOK   bool
+	}{
+		{
+			name:     "Editは変更された行だけを数える",
+			toolName: "Edit",
+			input:    `{"file_path":"/src/a.go","old_string":"func a() {\n\treturn 1\n}","new_string":"func a() {\n\tx := 1\n\treturn x\n}"}`,
+			want:     FileEdit{FilePath: "/src/a.go", LinesAdded: 2, LinesRemoved: 1},
+			wantOK:   true,
+		},
+		{
+			name:     "行の削除",
+			toolName: "Edit",
+			input:    `{"file_path":"/src/a.go","old_string":"a\nb\nc","new_string":"a\nc"}`,
+			want:     FileEdit{FilePath: "/src/a.go", LinesRemoved: 1},
+			wantOK:   true,
+		},
+		{
+			name:     "MultiEditは各編集を合算する",
+			toolName: "MultiEdit",
+			input:    `{"file_path":"/src/b.go","edits":[{"old_string":"x","new_string":"y"},{"old_string":"","new_string":"z1\nz2\n"}]}`,
+			want:     FileEdit{FilePath: "/src/b.go", LinesAdded: 3, LinesRemoved: 1},
+			wantOK:   true,
+		},
+		{
+			name:     "Writeは全行を追加とみなす",
+			toolName: "Write",
+			input:    `{"file_path":"/src/c.go","content":"package c\n\nfunc C() {}\n"}`,
+			want:     FileEdit{FilePath: "/src/c.go", LinesAdded: 3},
+			wantOK:   true,
+		},
+		{
+			name:     "編集ツール以外",
+			toolName: "Read",
+			input:    `{"file_path":"/src/a.go"}`,
+		},
+		{
+			name:     "file_pathがない",
+			toolName: "Edit",
+			input:    `{"old_string":"a","new_string":"b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseFileEdit(tt.toolName, tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseFileEdit() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package analyzer
+
+import "strings"
+
+// mcpToolPrefix is the prefix Claude Code gives to tools provided by MCP servers
+const mcpToolPrefix = "mcp__"
+
+// ParseMCPToolName splits an MCP tool name (mcp__<server>__<tool>) into its server and tool
+// Returns false for built-in tools such as Bash or Edit.
+func ParseMCPToolName(name string) (server, tool string, ok bool) {
+	rest, found := strings.CutPrefix(name, mcpToolPrefix)
+	if !found {
+		return "", "", false
+	}
+	// サーバー名に単一のアンダースコアが含まれる場合があるため、最初の "__" で区切る
+	server, tool, found = strings.Cut(rest, "__")
+	if !found || server == "" || tool == "" {
+		return "", "", false
+	}
+	return server, tool, true
+}
@@ -0,0 +1,30 @@
+package analyzer
+
+import "testing"
+
+func TestParseMCPToolName(t *testing.T) {
+	tests := []struct {
+		name       string
+		toolName   string
+		wantServer string
+		wantTool   string
+		wantOK     bool
+	}{
+		{name: "MCPツール", toolName: "mcp__github__create_issue", wantServer: "github", wantTool: "create_issue", wantOK: true},
+		{name: "アンダースコアを含むサーバー名", toolName: "mcp__plugin_playwright__browser_click", wantServer: "plugin_playwright", wantTool: "browser_click", wantOK: true},
+		{name: "ツール名に区切りを含む", toolName: "mcp__db__query__raw", wantServer: "db", wantTool: "query__raw", wantOK: true},
+		{name: "組み込みツール", toolName: "Bash"},
+		{name: "ツール名がない", toolName: "mcp__github"},
+		{name: "サーバー名がない", toolName: "mcp____create_issue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, tool, ok := ParseMCPToolName(tt.toolName)
+			if server != tt.wantServer || tool != tt.wantTool || ok != tt.wantOK {
+				t.Errorf("ParseMCPToolName(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.toolName, server, tool, ok, tt.wantServer, tt.wantTool, tt.wantOK)
+			}
+		})
+	}
+}
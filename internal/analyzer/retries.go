@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// RetrySimilarityThreshold is the minimum input similarity for a call to count as a retry of a failed call
+const RetrySimilarityThreshold = 0.9
+
+// AttemptCall is a tool call considered for retry detection
+type AttemptCall struct {
+	Timestamp time.Time
+	Name      string
+	Input     string // tool_useのinput（JSON）
+	Model     string
+	IsError   bool
+	Result    string
+}
+
+// AttemptChain is a tool call followed by the retries of its failures
+type AttemptChain struct {
+	ToolName  string
+	Model     string // 最初の試行を行ったモデル
+	StartedAt time.Time
+	Attempts  int
+
+	FirstAttemptSucceeded bool
+	Succeeded             bool  // 最後の試行が成功したか
+	Calls                 []int // 試行にあたる呼び出しのインデックス（呼び出し順）
+}
+
+// Retries returns the number of attempts after the first one
+func (c AttemptChain) Retries() int {
+	return c.Attempts - 1
+}
+
+// DetectRetries groups the tool calls of a session (in call order) into attempt chains
+//
+// A call is a retry of an earlier failed call when it uses the same tool with a near-identical input,
+// or when it is another Edit of the same file after a "string not found" error. Each chain ends
+// with its first successful attempt.
+func DetectRetries(calls []AttemptCall) []AttemptChain {
+	var chains []AttemptChain
+	// 最後の試行が失敗しているチェーン（chainsのインデックスと最後の試行）
+	type openChain struct {
+		index int
+		last  AttemptCall
+	}
+	var open []openChain
+
+	for i, call := range calls {
+		retried := -1
+		// 直近の失敗から順に、再試行とみなせるものを探す
+		for j := len(open) - 1; j >= 0; j-- {
+			if isRetryOf(call, open[j].last) {
+				retried = j
+				break
+			}
+		}
+
+		if retried < 0 {
+			chains = append(chains, AttemptChain{
+				ToolName:              call.Name,
+				Model:                 call.Model,
+				StartedAt:             call.Timestamp,
+				Attempts:              1,
+				FirstAttemptSucceeded: !call.IsError,
+				Succeeded:             !call.IsError,
+				Calls:                 []int{i},
+			})
+			if call.IsError {
+				open = append(open, openChain{index: len(chains) - 1, last: call})
+			}
+			continue
+		}
+
+		chain := &chains[open[retried].index]
+		chain.Attempts++
+		chain.Succeeded = !call.IsError
+		chain.Calls = append(chain.Calls, i)
+		if call.IsError {
+			open[retried].last = call
+		} else {
+			open = append(open[:retried], open[retried+1:]...)
+		}
+	}
+
+	return chains
+}
+
+// isRetryOf reports whether call retries the failed call previous
+func isRetryOf(call, previous AttemptCall) bool {
+	if isEditTool(call.Name) && isEditTool(previous.Name) && isStringNotFoundError(previous.Result) {
+		path := editFilePath(call.Input)
+		if path != "" && path == editFilePath(previous.Input) {
+			return true
+		}
+	}
+	return call.Name == previous.Name && inputSimilarity(call.Input, previous.Input) >= RetrySimilarityThreshold
+}
+
+// isEditTool reports whether a tool replaces strings in an existing file
+func isEditTool(name string) bool {
+	return name == "Edit" || name == "MultiEdit"
+}
+
+// isStringNotFoundError reports whether an Edit failed because old_string was not in the file
+func isStringNotFoundError(result string) bool {
+	lower := strings.ToLower(result)
+	return strings.Contains(lower, "string to replace not found") || strings.Contains(lower, "string not found")
+}
+
+// editFilePath returns the file_path of an Edit input
+func editFilePath(input string) string {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		return ""
+	}
+	return params.FilePath
+}
+
+// inputSimilarity returns the share of two inputs covered by their common prefix and suffix (0.0 - 1.0)
+// 一部だけを書き換えた再試行を高く評価し、長い入力でも線形時間で比較できる
+func inputSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	shorter := min(len(a), len(b))
+	longer := max(len(a), len(b))
+
+	prefix := 0
+	for prefix < shorter && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < shorter-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return float64(prefix+suffix) / float64(longer)
+}
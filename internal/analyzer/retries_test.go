@@ -0,0 +1,81 @@
+package analyzer
+
+import "testing"
+
+func TestDetectRetries(t *testing.T) {
+	call := func(name, input string, isError bool, result string) AttemptCall {
+		return AttemptCall{Name: name, Input: input, Model: "claude-haiku-4-5", IsError: isError, Result: result}
+	}
+
+	t.Run("失敗後にほぼ同じ入力で呼び出すと再試行", func(t *testing.T) {
+		chains := DetectRetries([]AttemptCall{
+			call("Bash", `{"command":"go test ./..."}`, true, "exit status 1"),
+			call("Read", `{"file_path":"/src/main.go"}`, false, ""),
+			call("Bash", `{"command":"go test ./... -v"}`, true, "exit status 1"),
+			call("Bash", `{"command":"go test ./..."}`, false, "ok"),
+		})
+
+		if len(chains) != 2 {
+			t.Fatalf("Expected 2 chains, got %+v", chains)
+		}
+		bash := chains[0]
+		if bash.ToolName != "Bash" || bash.Attempts != 3 || bash.Retries() != 2 || bash.FirstAttemptSucceeded || !bash.Succeeded {
+			t.Errorf("Unexpected Bash chain: %+v", bash)
+		}
+		if chains[1].Attempts != 1 || !chains[1].FirstAttemptSucceeded {
+			t.Errorf("Unexpected Read chain: %+v", chains[1])
+		}
+		if len(bash.Calls) != 3 || bash.Calls[0] != 0 || bash.Calls[2] != 3 || len(chains[1].Calls) != 1 || chains[1].Calls[0] != 1 {
+			t.Errorf("Unexpected chain calls: %v / %v", bash.Calls, chains[1].Calls)
+		}
+	})
+
+	t.Run("文字列が見つからないEditの後に同じファイルを編集すると再試行", func(t *testing.T) {
+		chains := DetectRetries([]AttemptCall{
+			call("Edit", `{"file_path":"/src/a.go","new_string":"x","old_string":"func main() {"}`, true,
+				"<tool_use_error>String to replace not found in file.</tool_use_error>"),
+			call("Edit", `{"file_path":"/src/a.go","new_string":"completely different replacement","old_string":"package main"}`, false, ""),
+		})
+
+		if len(chains) != 1 || chains[0].Attempts != 2 || !chains[0].Succeeded {
+			t.Errorf("Expected a single recovered chain, got %+v", chains)
+		}
+	})
+
+	t.Run("成功後や別の入力での呼び出しは再試行ではない", func(t *testing.T) {
+		chains := DetectRetries([]AttemptCall{
+			call("Bash", `{"command":"go build ./..."}`, false, ""),
+			call("Bash", `{"command":"go build ./..."}`, false, ""),
+			call("Bash", `{"command":"npm install"}`, true, "ENOENT"),
+			call("Bash", `{"command":"ls -la /tmp/project"}`, false, ""),
+			// 別の理由で失敗したEditの後の同じファイルの編集
+			call("Edit", `{"file_path":"/src/a.go","old_string":"a"}`, true, "File has not been read yet"),
+			call("Edit", `{"file_path":"/src/a.go","old_string":"something else entirely"}`, false, ""),
+		})
+
+		if len(chains) != 6 {
+			t.Fatalf("Expected 6 chains, got %+v", chains)
+		}
+		for _, chain := range chains {
+			if chain.Attempts != 1 {
+				t.Errorf("Unexpected retry: %+v", chain)
+			}
+		}
+		if chains[2].FirstAttemptSucceeded || chains[2].Succeeded {
+			t.Errorf("Expected failed npm chain, got %+v", chains[2])
+		}
+	})
+}
+
+func TestInputSimilarity(t *testing.T) {
+	if got := inputSimilarity("abc", "abc"); got != 1 {
+		t.Errorf("Expected 1 for identical inputs, got %v", got)
+	}
+	if got := inputSimilarity("", "abc"); got != 0 {
+		t.Errorf("Expected 0, got %v", got)
+	}
+	// 接頭辞と接尾辞が重なる場合に二重に数えない
+	if got := inputSimilarity("aa", "aaa"); got != 2.0/3.0 {
+		t.Errorf("Expected 2/3, got %v", got)
+	}
+}
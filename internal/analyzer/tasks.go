@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"strings"
+	"time"
+)
+
+// タスクの区切りとみなさないユーザーメッセージ（Claude Codeが自動で書き込むもの）
+var nonPromptPrefixes = []string{
+	"<local-command-stdout>",
+	"<local-command-stderr>",
+	"Caveat: The messages below were generated by the user while running local commands",
+	"[Request interrupted by user",
+}
+
+// TaskEntry is a log entry reduced to the fields needed to split a session into tasks
+type TaskEntry struct {
+	UUID                string
+	Timestamp           time.Time
+	IsPrompt            bool   // ツール結果ではないユーザー入力
+	Text                string // メッセージのテキスト部分
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+}
+
+// TaskToolCall is a tool call reduced to the fields needed to attribute it to a task
+type TaskToolCall struct {
+	Timestamp time.Time
+	IsError   bool
+}
+
+// Task is a part of a session started by a user prompt
+type Task struct {
+	Index     int    // セッション内の通し番号（1始まり）
+	StartUUID string // タスクを開始したユーザー入力
+	Prompt    string
+	StartTime time.Time
+	EndTime   time.Time
+
+	EntryCount          int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+	ToolCallCount       int
+	ErrorCount          int // エラー結果を返したツール呼び出しの数
+}
+
+// IsTaskPrompt reports whether an entry is a real user prompt that starts a new task
+// Warmup, local command output and interruption notices are written by Claude Code, not typed by the user.
+func IsTaskPrompt(entry TaskEntry) bool {
+	if !entry.IsPrompt {
+		return false
+	}
+	text := strings.TrimSpace(entry.Text)
+	if text == "" || text == "Warmup" {
+		return false
+	}
+	for _, prefix := range nonPromptPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// SegmentTasks splits the time-ordered entries of a session into tasks at each real user prompt
+//
+// Entries before the first prompt (e.g. Warmup) belong to the first task, so the tasks of a session
+// add up to the session totals. Tool calls are attributed to the task running when they were made.
+func SegmentTasks(entries []TaskEntry, toolCalls []TaskToolCall) []Task {
+	return AppendTasks(nil, entries, toolCalls)
+}
+
+// AppendTasks continues the segmentation of a session with the entries and tool calls written after the given tasks
+// Only the last of the given tasks can still grow. Tool calls made before the last task started are attributed
+// to it, so pass only the calls made after its start.
+func AppendTasks(tasks []Task, entries []TaskEntry, toolCalls []TaskToolCall) []Task {
+	for _, entry := range entries {
+		prompt := IsTaskPrompt(entry)
+		// Warmupなどの前置きで始まったタスクは、最初のユーザー入力をそのタスクの開始とする
+		if len(tasks) == 0 || (prompt && tasks[len(tasks)-1].StartUUID != "") {
+			index := 1
+			if len(tasks) > 0 {
+				index = tasks[len(tasks)-1].Index + 1
+			}
+			tasks = append(tasks, Task{Index: index, StartTime: entry.Timestamp})
+		}
+
+		task := &tasks[len(tasks)-1]
+		if prompt && task.StartUUID == "" {
+			task.StartUUID = entry.UUID
+			task.Prompt = entry.Text
+		}
+		if entry.Timestamp.After(task.EndTime) {
+			task.EndTime = entry.Timestamp
+		}
+		task.EntryCount++
+		task.InputTokens += entry.InputTokens
+		task.OutputTokens += entry.OutputTokens
+		task.CacheCreationTokens += entry.CacheCreationTokens
+		task.CacheReadTokens += entry.CacheReadTokens
+	}
+
+	for _, call := range toolCalls {
+		if len(tasks) == 0 {
+			break
+		}
+		// ツール呼び出し時点で開始済みの最後のタスク
+		i := len(tasks) - 1
+		for i > 0 && tasks[i].StartTime.After(call.Timestamp) {
+			i--
+		}
+		tasks[i].ToolCallCount++
+		if call.IsError {
+			tasks[i].ErrorCount++
+		}
+	}
+
+	return tasks
+}
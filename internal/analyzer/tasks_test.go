@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSegmentTasks(t *testing.T) {
+	start := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	prompt := func(uuid string, seconds int, text string) TaskEntry {
+		return TaskEntry{UUID: uuid, Timestamp: at(seconds), IsPrompt: true, Text: text}
+	}
+	reply := func(uuid string, seconds, input, output int) TaskEntry {
+		return TaskEntry{UUID: uuid, Timestamp: at(seconds), InputTokens: input, OutputTokens: output}
+	}
+	toolResult := func(uuid string, seconds int) TaskEntry {
+		return TaskEntry{UUID: uuid, Timestamp: at(seconds)}
+	}
+
+	t.Run("ユーザー入力ごとにタスクを分割する", func(t *testing.T) {
+		tasks := SegmentTasks([]TaskEntry{
+			prompt("u1", 0, "Fix the login bug"),
+			reply("a1", 10, 100, 20),
+			toolResult("r1", 20),
+			reply("a2", 30, 150, 40),
+			prompt("u2", 100, "<command-name>/clear</command-name>"),
+			prompt("u3", 110, "Add a README"),
+			reply("a3", 130, 50, 10),
+		}, []TaskToolCall{
+			{Timestamp: at(10), IsError: true},
+			{Timestamp: at(130)},
+		})
+
+		if len(tasks) != 3 {
+			t.Fatalf("Expected 3 tasks, got %+v", tasks)
+		}
+		first := tasks[0]
+		if first.Index != 1 || first.StartUUID != "u1" || first.Prompt != "Fix the login bug" || first.EntryCount != 4 {
+			t.Errorf("Unexpected first task: %+v", first)
+		}
+		if first.InputTokens != 250 || first.OutputTokens != 60 || first.ToolCallCount != 1 || first.ErrorCount != 1 {
+			t.Errorf("Unexpected first task totals: %+v", first)
+		}
+		if first.EndTime.Sub(first.StartTime) != 30*time.Second {
+			t.Errorf("Expected 30s task, got %v", first.EndTime.Sub(first.StartTime))
+		}
+		if tasks[2].StartUUID != "u3" || tasks[2].ToolCallCount != 1 || tasks[2].ErrorCount != 0 {
+			t.Errorf("Unexpected last task: %+v", tasks[2])
+		}
+	})
+
+	t.Run("Warmupやコマンド出力、中断通知ではタスクを分割しない", func(t *testing.T) {
+		tasks := SegmentTasks([]TaskEntry{
+			prompt("w1", 0, "Warmup"),
+			reply("w2", 1, 10, 5),
+			prompt("u1", 10, "Run the tests"),
+			reply("a1", 20, 100, 10),
+			prompt("i1", 25, "[Request interrupted by user for tool use]"),
+			prompt("o1", 26, "<local-command-stdout></local-command-stdout>"),
+		}, nil)
+
+		if len(tasks) != 1 {
+			t.Fatalf("Expected 1 task, got %+v", tasks)
+		}
+		// Warmupは最初のタスクに含める
+		if tasks[0].StartUUID != "u1" || tasks[0].Prompt != "Run the tests" || tasks[0].EntryCount != 6 || tasks[0].InputTokens != 110 {
+			t.Errorf("Unexpected task: %+v", tasks[0])
+		}
+		if !tasks[0].StartTime.Equal(at(0)) {
+			t.Errorf("Expected task to start with the Warmup, got %v", tasks[0].StartTime)
+		}
+	})
+
+	t.Run("最後のタスクから続けて分割すると全体から分割した結果と一致する", func(t *testing.T) {
+		entries := []TaskEntry{
+			prompt("u1", 0, "Fix the login bug"),
+			reply("a1", 10, 100, 20),
+			prompt("u2", 40, "Add a test"),
+			reply("a2", 50, 80, 10),
+			toolResult("r2", 60),
+			prompt("u3", 90, "Commit"),
+			reply("a3", 95, 30, 5),
+		}
+		toolCalls := []TaskToolCall{{Timestamp: at(10)}, {Timestamp: at(50), IsError: true}, {Timestamp: at(95)}}
+		want := SegmentTasks(entries, toolCalls)
+
+		tasks := SegmentTasks(entries[:4], toolCalls[:2])
+		tasks = append(tasks[:len(tasks)-1], AppendTasks(tasks[len(tasks)-1:], entries[4:], toolCalls[2:])...)
+		if !reflect.DeepEqual(tasks, want) {
+			t.Errorf("Expected %+v, got %+v", want, tasks)
+		}
+	})
+
+	t.Run("エントリがなければタスクもない", func(t *testing.T) {
+		if tasks := SegmentTasks(nil, []TaskToolCall{{Timestamp: start}}); len(tasks) != 0 {
+			t.Errorf("Expected no tasks, got %+v", tasks)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+)
+
+// TreeEntry is a log entry reduced to the fields needed to build the conversation tree
+type TreeEntry struct {
+	UUID         string
+	ParentUUID   string
+	Type         string
+	Timestamp    time.Time
+	IsPrompt     bool // ツール結果ではないユーザー入力
+	InputTokens  int
+	OutputTokens int
+}
+
+// AbandonedBranch is a part of the conversation left behind by a rewind or an edited prompt
+type AbandonedBranch struct {
+	ForkUUID     string // 分岐元のメインパス上のエントリ
+	FirstUUID    string // 分岐の最初のエントリ（破棄されたユーザー入力）
+	EntryCount   int
+	InputTokens  int
+	OutputTokens int
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// ConversationTree is the DAG of a session built from parentUuid
+type ConversationTree struct {
+	Entries  []TreeEntry       // 時刻順
+	Roots    []string          // 親を持たない（または親が記録されていない）エントリ
+	MainPath map[string]bool   // メインパス上のエントリ
+	BranchOf map[string]int    // 破棄された分岐上のエントリ -> Branchesのインデックス
+	Branches []AbandonedBranch // 分岐元の時刻順
+}
+
+// AbandonedTokens returns the input and output tokens spent on abandoned branches
+func (t *ConversationTree) AbandonedTokens() (int, int) {
+	input, output := 0, 0
+	for _, branch := range t.Branches {
+		input += branch.InputTokens
+		output += branch.OutputTokens
+	}
+	return input, output
+}
+
+// AbandonedEntries returns the number of entries on abandoned branches
+func (t *ConversationTree) AbandonedEntries() int {
+	return len(t.BranchOf)
+}
+
+// BuildConversationTree reconstructs the conversation tree and separates the main path from abandoned branches
+//
+// Each root (an entry without a recorded parent, e.g. the start of the session or of a resumed
+// conversation) starts a segment whose main path leads to its latest entry. A child of a main path
+// entry that is not on the main path starts an abandoned branch only when it is a user prompt:
+// rewinding or editing a prompt replaces the prompt, whereas sibling tool results of parallel tool
+// calls and streamed content blocks continue the same turn.
+func BuildConversationTree(entries []TreeEntry) *ConversationTree {
+	sorted := make([]TreeEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	tree := &ConversationTree{
+		Entries:  sorted,
+		MainPath: make(map[string]bool),
+		BranchOf: make(map[string]int),
+	}
+
+	index := make(map[string]int, len(sorted))
+	for i, entry := range sorted {
+		index[entry.UUID] = i
+	}
+
+	children := make(map[string][]int)
+	for i, entry := range sorted {
+		if _, ok := index[entry.ParentUUID]; ok && entry.ParentUUID != entry.UUID {
+			children[entry.ParentUUID] = append(children[entry.ParentUUID], i)
+		} else {
+			tree.Roots = append(tree.Roots, entry.UUID)
+		}
+	}
+
+	// 各セグメントの最新のエントリからルートまでをメインパスとする
+	for _, root := range tree.Roots {
+		latest := latestDescendant(sorted, index[root], children)
+		for i := latest; ; {
+			uuid := sorted[i].UUID
+			if tree.MainPath[uuid] {
+				break
+			}
+			tree.MainPath[uuid] = true
+			parent, ok := index[sorted[i].ParentUUID]
+			if !ok || uuid == root {
+				break
+			}
+			i = parent
+		}
+	}
+
+	// メインパスから外れた子のうち、ユーザー入力から始まるものを破棄された分岐とする
+	var continuations []int
+	for _, entry := range sorted {
+		if !tree.MainPath[entry.UUID] {
+			continue
+		}
+		for _, child := range children[entry.UUID] {
+			if tree.MainPath[sorted[child].UUID] {
+				continue
+			}
+			if !sorted[child].IsPrompt {
+				continuations = append(continuations, child)
+				continue
+			}
+			tree.addBranch(entry.UUID, child, children)
+		}
+	}
+
+	// 同じターンの続き（並列ツール呼び出しの結果など）はメインパスに含め、その先の分岐も探す
+	for len(continuations) > 0 {
+		i := continuations[0]
+		continuations = continuations[1:]
+		uuid := sorted[i].UUID
+		if tree.MainPath[uuid] {
+			continue
+		}
+		tree.MainPath[uuid] = true
+		for _, child := range children[uuid] {
+			if sorted[child].IsPrompt {
+				tree.addBranch(uuid, child, children)
+			} else {
+				continuations = append(continuations, child)
+			}
+		}
+	}
+
+	sort.SliceStable(tree.Branches, func(i, j int) bool {
+		return tree.Branches[i].StartTime.Before(tree.Branches[j].StartTime)
+	})
+	for i, branch := range tree.Branches {
+		tree.markBranch(branch.FirstUUID, i, index, children)
+	}
+
+	return tree
+}
+
+// latestDescendant returns the index of the latest entry in the subtree of the given entry
+// entriesは時刻順のため、インデックスが最大のエントリが最新（同時刻ならログ上で後のもの）
+// 長い会話でもスタックを消費しないよう反復で走査する
+func latestDescendant(entries []TreeEntry, start int, children map[string][]int) int {
+	latest := start
+	stack := []int{start}
+	visited := make(map[int]bool)
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		if i > latest {
+			latest = i
+		}
+		stack = append(stack, children[entries[i].UUID]...)
+	}
+	return latest
+}
+
+// addBranch records the subtree of an abandoned child as a branch
+func (t *ConversationTree) addBranch(forkUUID string, first int, children map[string][]int) {
+	branch := AbandonedBranch{
+		ForkUUID:  forkUUID,
+		FirstUUID: t.Entries[first].UUID,
+		StartTime: t.Entries[first].Timestamp,
+		EndTime:   t.Entries[first].Timestamp,
+	}
+
+	stack := []int{first}
+	visited := make(map[int]bool)
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+
+		entry := t.Entries[i]
+		branch.EntryCount++
+		branch.InputTokens += entry.InputTokens
+		branch.OutputTokens += entry.OutputTokens
+		if entry.Timestamp.Before(branch.StartTime) {
+			branch.StartTime = entry.Timestamp
+		}
+		if entry.Timestamp.After(branch.EndTime) {
+			branch.EndTime = entry.Timestamp
+		}
+		stack = append(stack, children[entry.UUID]...)
+	}
+
+	t.Branches = append(t.Branches, branch)
+}
+
+// markBranch maps the entries of a branch to its index
+func (t *ConversationTree) markBranch(firstUUID string, branchIndex int, index map[string]int, children map[string][]int) {
+	stack := []int{index[firstUUID]}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		uuid := t.Entries[i].UUID
+		if _, ok := t.BranchOf[uuid]; ok {
+			continue
+		}
+		t.BranchOf[uuid] = branchIndex
+		stack = append(stack, children[uuid]...)
+	}
+}
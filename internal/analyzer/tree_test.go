@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+// treeEntry returns a tree entry at the given second of the session
+func treeEntry(uuid, parent string, second int, isPrompt bool, tokens int) TreeEntry {
+	entryType := "assistant"
+	if isPrompt {
+		entryType = "user"
+	}
+	return TreeEntry{
+		UUID:         uuid,
+		ParentUUID:   parent,
+		Type:         entryType,
+		Timestamp:    time.Date(2025, 5, 1, 10, 0, second, 0, time.UTC),
+		IsPrompt:     isPrompt,
+		InputTokens:  tokens,
+		OutputTokens: tokens / 10,
+	}
+}
+
+func TestBuildConversationTree(t *testing.T) {
+	t.Run("一本道の会話は全てメインパス", func(t *testing.T) {
+		tree := BuildConversationTree([]TreeEntry{
+			treeEntry("u1", "", 0, true, 0),
+			treeEntry("a1", "u1", 1, false, 100),
+			treeEntry("u2", "a1", 2, true, 0),
+			treeEntry("a2", "u2", 3, false, 200),
+		})
+
+		if len(tree.Roots) != 1 || len(tree.MainPath) != 4 || len(tree.Branches) != 0 {
+			t.Errorf("Unexpected tree: roots=%v main=%d branches=%d", tree.Roots, len(tree.MainPath), len(tree.Branches))
+		}
+	})
+
+	t.Run("巻き戻しで破棄された分岐を検出する", func(t *testing.T) {
+		// u2 -> a2 -> u3 -> a3 を破棄し、a1 から u2' で再開
+		tree := BuildConversationTree([]TreeEntry{
+			treeEntry("u1", "", 0, true, 0),
+			treeEntry("a1", "u1", 1, false, 100),
+			treeEntry("u2", "a1", 2, true, 0),
+			treeEntry("a2", "u2", 3, false, 200),
+			treeEntry("u3", "a2", 4, true, 0),
+			treeEntry("a3", "u3", 5, false, 300),
+			treeEntry("u2b", "a1", 6, true, 0),
+			treeEntry("a2b", "u2b", 7, false, 400),
+		})
+
+		if len(tree.Branches) != 1 {
+			t.Fatalf("Expected 1 branch, got %+v", tree.Branches)
+		}
+		branch := tree.Branches[0]
+		if branch.ForkUUID != "a1" || branch.FirstUUID != "u2" || branch.EntryCount != 4 {
+			t.Errorf("Unexpected branch: %+v", branch)
+		}
+
+		input, output := tree.AbandonedTokens()
+		if input != 500 || output != 50 {
+			t.Errorf("Expected 500/50 abandoned tokens, got %d/%d", input, output)
+		}
+		if tree.AbandonedEntries() != 4 {
+			t.Errorf("Expected 4 abandoned entries, got %d", tree.AbandonedEntries())
+		}
+		for _, uuid := range []string{"u1", "a1", "u2b", "a2b"} {
+			if !tree.MainPath[uuid] {
+				t.Errorf("Expected %s on the main path", uuid)
+			}
+		}
+		if _, ok := tree.BranchOf["a3"]; !ok {
+			t.Error("Expected a3 to belong to the abandoned branch")
+		}
+	})
+
+	t.Run("並列ツール呼び出しの結果は分岐として扱わない", func(t *testing.T) {
+		// a1 の2つのtool_resultがどちらも a1 を親に持つ
+		tree := BuildConversationTree([]TreeEntry{
+			treeEntry("u1", "", 0, true, 0),
+			treeEntry("a1", "u1", 1, false, 100),
+			treeEntry("r1", "a1", 2, false, 0),
+			treeEntry("r2", "a1", 3, false, 0),
+			treeEntry("a2", "r2", 4, false, 200),
+		})
+
+		if len(tree.Branches) != 0 {
+			t.Errorf("Expected no branches, got %+v", tree.Branches)
+		}
+		if !tree.MainPath["r1"] {
+			t.Error("Expected the sibling tool result to stay on the main path")
+		}
+	})
+
+	t.Run("親が記録されていないエントリは別のセグメントとして扱う", func(t *testing.T) {
+		tree := BuildConversationTree([]TreeEntry{
+			treeEntry("u1", "", 0, true, 0),
+			treeEntry("a1", "u1", 1, false, 100),
+			treeEntry("c1", "missing", 2, true, 0),
+			treeEntry("a2", "c1", 3, false, 200),
+		})
+
+		if len(tree.Roots) != 2 || len(tree.MainPath) != 4 || len(tree.Branches) != 0 {
+			t.Errorf("Unexpected tree: roots=%v main=%d branches=%+v", tree.Roots, len(tree.MainPath), tree.Branches)
+		}
+	})
+}
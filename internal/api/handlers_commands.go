@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// getCommandStatsHandler handles GET /api/commands/stats
+// Optional query parameters: project (project name), groupId (project group ID)
+func (h *Handler) getCommandStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetCommandStats(projectName, groupID)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve command statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
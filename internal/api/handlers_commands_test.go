@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestGetCommandStatsHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("コマンド統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			CommandStats: &CommandStatsResponse{
+				Commands: []CommandStatsItem{
+					{Name: "/team:review", Custom: true, Invocations: 3, Sessions: 2, Projects: 1, AvgTokens: 1200},
+				},
+				Projects: []CommandStatsItem{
+					{Name: "/team:review", Custom: true, ProjectName: "test-project", Invocations: 3, Sessions: 2, Projects: 1, AvgTokens: 1200},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commands/stats?project=test-project", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommandStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response CommandStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Commands) != 1 || !response.Commands[0].Custom || response.Projects[0].ProjectName != "test-project" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("不正なgroupIdは400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commands/stats?groupId=abc", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommandStatsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commands/stats?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommandStatsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
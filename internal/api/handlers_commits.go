@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Default number of commits and sessions without commits returned by GET /api/commits
+const defaultCommitLimit = 50
+
+// getCommitStatsHandler handles GET /api/commits
+// Optional query parameters: project, groupId, from, to (session start date), limit
+func (h *Handler) getCommitStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	projectName := query.Get("project")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	from := query.Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "from must be in YYYY-MM-DD format")
+		return
+	}
+	to := query.Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "to must be in YYYY-MM-DD format")
+		return
+	}
+
+	limit, err := parseLimitParam(r, defaultCommitLimit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetCommitStats(CommitStatsParams{
+		ProjectName: projectName,
+		GroupID:     groupID,
+		From:        from,
+		To:          to,
+		Limit:       limit,
+	})
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve commit statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestGetCommitStatsHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("コミットの統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			CommitStats: &CommitStatsResponse{
+				GitAvailable: true,
+				Summary:      CommitSummary{Sessions: 2, SessionsWithCommits: 1, Commits: 2, TotalTokens: 1000, TokensPerCommit: 500},
+				Commits: []CommitItem{
+					{Hash: "abc123", Subject: "Add parser", SessionID: "session-1", TotalTokens: 300},
+					{Hash: "def456", Subject: "Fix parser", SessionID: "session-1", TotalTokens: 300},
+				},
+				SessionsWithoutCommits: []UncommittedSessionItem{{SessionID: "session-2", TotalTokens: 400}},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commits?project=test-project&from=2025-06-01&limit=10", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommitStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		params := mockService.LastCommitStatsParams
+		if params.ProjectName != "test-project" || params.From != "2025-06-01" || params.Limit != 10 {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+
+		var response CommitStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !response.GitAvailable || len(response.Commits) != 2 || response.SessionsWithoutCommits[0].SessionID != "session-2" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("limitを省略すると50件", func(t *testing.T) {
+		mockService := &MockSessionService{CommitStats: &CommitStatsResponse{}}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commits", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommitStatsHandler(w, req)
+
+		if w.Code != http.StatusOK || mockService.LastCommitStatsParams.Limit != 50 {
+			t.Errorf("Expected status 200 with limit 50, got %d / %d", w.Code, mockService.LastCommitStatsParams.Limit)
+		}
+	})
+
+	t.Run("不正な日付は400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commits?to=2025/06/01", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommitStatsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/commits?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.getCommitStatsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// listErrorPatternsHandler handles GET /api/errors/patterns
+// Optional query parameters: project, groupId, tool, limit, offset
+func (h *Handler) listErrorPatternsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	limit, err := parseLimitParam(r, 50)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	offset, err := parseOffsetParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	patterns, err := h.service.ListErrorPatterns(projectName, groupID, r.URL.Query().Get("tool"), limit, offset)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve error patterns")
+		return
+	}
+
+	json.NewEncoder(w).Encode(patterns)
+}
+
+// getErrorOccurrencesHandler handles GET /api/errors/patterns/{id}/occurrences
+// Optional query parameters: limit, offset
+func (h *Handler) getErrorOccurrencesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	patternID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "invalid pattern ID")
+		return
+	}
+
+	limit, err := parseLimitParam(r, 50)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	offset, err := parseOffsetParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	occurrences, err := h.service.GetErrorOccurrences(patternID, limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(occurrences)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestErrorPatternHandlers(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("エラーパターン一覧を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			ErrorPatterns: &ErrorPatternListResponse{
+				Patterns: []ErrorPatternResponse{
+					{ID: 1, ToolName: "Bash", ErrorMessage: "Exit code <N>", OccurrenceCount: 3, SessionCount: 2, ProjectCount: 1},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/errors/patterns?tool=Bash&limit=10", nil)
+		w := httptest.NewRecorder()
+
+		handler.listErrorPatternsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response ErrorPatternListResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Patterns) != 1 || response.Patterns[0].OccurrenceCount != 3 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/errors/patterns?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.listErrorPatternsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("発生箇所を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			ErrorOccurrences: &ErrorOccurrenceListResponse{
+				Pattern: ErrorPatternResponse{ID: 1, ToolName: "Bash"},
+				Occurrences: []ErrorOccurrenceResponse{
+					{ID: 10, SessionID: "session-1", ProjectName: "test-project", ToolName: "Bash", ErrorMessage: "Exit code 1"},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/errors/patterns/1/occurrences", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+
+		handler.getErrorOccurrencesHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response ErrorOccurrenceListResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Occurrences) != 1 || response.Occurrences[0].SessionID != "session-1" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("不正なパターンIDは400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/errors/patterns/abc/occurrences", nil)
+		req.SetPathValue("id", "abc")
+		w := httptest.NewRecorder()
+
+		handler.getErrorOccurrencesHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないパターンは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("error pattern not found: 999")})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/errors/patterns/999/occurrences", nil)
+		req.SetPathValue("id", "999")
+		w := httptest.NewRecorder()
+
+		handler.getErrorOccurrencesHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/events"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+// eventsKeepAliveInterval is the interval of comment lines sent to keep idle connections open
+const eventsKeepAliveInterval = 30 * time.Second
+
+// eventsHandler streams live update events as Server-Sent Events
+func (h *Handler) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSONError(w, http.StatusNotImplemented, "not_available", "Event stream not available")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Streaming is not supported")
+		return
+	}
+
+	eventCh, unsubscribe := h.events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// 接続直後に現在のスキャン状態を送る（ポーリングなしで初期表示できるように）
+	if h.scanManager != nil {
+		initial := events.Event{Type: events.TypeScanProgress, Time: time.Now(), Data: h.scanManager.GetProgress()}
+		if err := writeServerSentEvent(w, initial); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeServerSentEvent writes an event in the text/event-stream format
+func writeServerSentEvent(w io.Writer, event events.Event) error {
+	data := event.Data
+	if progress, ok := data.(scanner.ScanProgress); ok {
+		// スキャン進捗は GET /api/scan/status と同じ形式で送る
+		data = convertScanProgress(progress)
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	if event.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+	return err
+}
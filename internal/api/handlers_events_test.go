@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/events"
+)
+
+func TestEventsHandler(t *testing.T) {
+	t.Run("イベントバスが未設定の場合は501を返す", func(t *testing.T) {
+		handler := NewHandler(&MockSessionService{}, nil)
+		req := httptest.NewRequest("GET", "/api/events", nil)
+		w := httptest.NewRecorder()
+
+		handler.Routes().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+
+	t.Run("発行したイベントがSSEで配信される", func(t *testing.T) {
+		bus := events.NewBus()
+		handler := NewHandler(&MockSessionService{}, nil)
+		handler.SetEventBus(bus)
+
+		server := httptest.NewServer(handler.Routes())
+		defer server.Close()
+		defer bus.Close()
+
+		resp, err := http.Get(server.URL + "/api/events")
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("Expected text/event-stream, got %s", ct)
+		}
+
+		// 購読が登録されるまで待つ
+		deadline := time.Now().Add(2 * time.Second)
+		for bus.SubscriberCount() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		bus.Publish(events.TypeSessionUpdated, events.SessionEventData{ProjectName: "project", SessionID: "session-1"})
+
+		reader := bufio.NewReader(resp.Body)
+		var lines []string
+		for len(lines) < 3 {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Failed to read event: %v", err)
+			}
+			lines = append(lines, strings.TrimRight(line, "\n"))
+		}
+
+		if lines[0] != "id: 1" {
+			t.Errorf("Unexpected id line: %q", lines[0])
+		}
+		if lines[1] != "event: session.updated" {
+			t.Errorf("Unexpected event line: %q", lines[1])
+		}
+		if lines[2] != `data: {"projectName":"project","sessionId":"session-1"}` {
+			t.Errorf("Unexpected data line: %q", lines[2])
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Default number of hot files returned for a project
+const defaultHotFileLimit = 50
+
+// getSessionFilesHandler handles GET /api/sessions/{project}/{id}/files
+func (h *Handler) getSessionFilesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	files, err := h.service.GetSessionFiles(r.PathValue("project"), r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(files)
+}
+
+// getProjectFilesHandler handles GET /api/projects/{name}/files
+// Optional query parameters: period (day, week, month), from, to, limit (number of hot files)
+func (h *Handler) getProjectFilesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.PathValue("name")
+	if projectName == "" {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "project name is required")
+		return
+	}
+
+	period, err := parsePeriodParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "from must be in YYYY-MM-DD format")
+		return
+	}
+	to := r.URL.Query().Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "to must be in YYYY-MM-DD format")
+		return
+	}
+
+	limit, err := parseLimitParam(r, defaultHotFileLimit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	files, err := h.service.GetProjectFiles(projectName, ProjectFilesParams{
+		Period: period,
+		From:   from,
+		To:     to,
+		Limit:  limit,
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(files)
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestGetSessionFilesHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("セッションで編集したファイルを取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			SessionFiles: &SessionFilesResponse{
+				SessionID:    "session-1",
+				FilesTouched: 1,
+				Edits:        2,
+				LinesAdded:   5,
+				LinesRemoved: 1,
+				Files:        []FileEditItem{{FilePath: "/src/a.go", Edits: 2, Sessions: 1, LinesAdded: 5, LinesRemoved: 1}},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/test-project/session-1/files", nil)
+		req.SetPathValue("project", "test-project")
+		req.SetPathValue("id", "session-1")
+		w := httptest.NewRecorder()
+
+		handler.getSessionFilesHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response SessionFilesResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.FilesTouched != 1 || len(response.Files) != 1 || response.Files[0].FilePath != "/src/a.go" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("存在しないセッションは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("session not found")})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/test-project/missing/files", nil)
+		req.SetPathValue("project", "test-project")
+		req.SetPathValue("id", "missing")
+		w := httptest.NewRecorder()
+
+		handler.getSessionFilesHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestGetProjectFilesHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("よく編集されるファイルと期間ごとの変更量を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			ProjectFiles: &ProjectFilesResponse{
+				ProjectName: "test-project",
+				Period:      "week",
+				Files:       []FileEditItem{{FilePath: "/src/a.go", Edits: 3, Sessions: 2}},
+				Churn:       []FileChurnItem{{Period: "2025-W23", Edits: 3, FilesTouched: 1, LinesAdded: 10}},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/projects/test-project/files?period=week&from=2025-06-01&limit=10", nil)
+		req.SetPathValue("name", "test-project")
+		w := httptest.NewRecorder()
+
+		handler.getProjectFilesHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response ProjectFilesResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Files) != 1 || len(response.Churn) != 1 || response.Churn[0].Period != "2025-W23" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+		params := mockService.LastProjectFilesParams
+		if params.Period != "week" || params.From != "2025-06-01" || params.Limit != 10 {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+	})
+
+	t.Run("不正な期間は400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/projects/test-project/files?period=year", nil)
+		req.SetPathValue("name", "test-project")
+		w := httptest.NewRecorder()
+
+		handler.getProjectFilesHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found")})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/projects/missing/files", nil)
+		req.SetPathValue("name", "missing")
+		w := httptest.NewRecorder()
+
+		handler.getProjectFilesHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -40,6 +40,7 @@ func (h *Handler) getGroupHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // getGroupStatsHandler returns statistics for a project group
+// Optional query parameters: version (Claude Code version)
 func (h *Handler) getGroupStatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -49,7 +50,7 @@ func (h *Handler) getGroupStatsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := h.service.GetProjectGroupStats(groupID)
+	stats, err := h.service.GetProjectGroupStats(groupID, r.URL.Query().Get("version"))
 	if err != nil {
 		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
 		return
@@ -59,6 +60,7 @@ func (h *Handler) getGroupStatsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // getGroupTimelineHandler returns time-series statistics for a project group
+// Optional query parameters: period, limit, version (Claude Code version)
 func (h *Handler) getGroupTimelineHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -80,7 +82,7 @@ func (h *Handler) getGroupTimelineHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	timeline, err := h.service.GetProjectGroupTimeline(groupID, period, limit)
+	timeline, err := h.service.GetProjectGroupTimeline(groupID, period, limit, r.URL.Query().Get("version"))
 	if err != nil {
 		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
 		return
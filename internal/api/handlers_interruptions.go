@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// getInterruptionStatsHandler handles GET /api/interruptions/stats
+// Optional query parameters: project (project name), groupId (project group ID), version (Claude Code version)
+func (h *Handler) getInterruptionStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+	version := r.URL.Query().Get("version")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetInterruptionStats(projectName, groupID, version)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve interruption statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestGetInterruptionStatsHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("中断の統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			InterruptionStats: &InterruptionStatsResponse{
+				Total: InterruptionStatsItem{Interrupts: 2, Rejections: 1, Total: 3, BaseCount: 10, Rate: 0.3},
+				Projects: []InterruptionStatsItem{
+					{Name: "test-project", Interrupts: 2, Rejections: 1, Total: 3, BaseCount: 10, Rate: 0.3},
+				},
+				Models: []InterruptionStatsItem{
+					{Name: "claude-sonnet-4-5", Interrupts: 2, Rejections: 1, Total: 3, BaseCount: 40, Rate: 0.075},
+				},
+				Tools: []InterruptionStatsItem{
+					{Name: "Edit", Rejections: 1, Total: 1, BaseCount: 20, Rate: 0.05},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/interruptions/stats?project=test-project", nil)
+		w := httptest.NewRecorder()
+
+		handler.getInterruptionStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response InterruptionStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Total.Rate != 0.3 || len(response.Tools) != 1 || response.Tools[0].Name != "Edit" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("バージョンで絞り込める", func(t *testing.T) {
+		mockService := &MockSessionService{InterruptionStats: &InterruptionStatsResponse{}}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/interruptions/stats?version=1.0.0", nil)
+		w := httptest.NewRecorder()
+
+		handler.getInterruptionStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if mockService.LastStatsVersion != "1.0.0" {
+			t.Errorf("Expected version 1.0.0, got %q", mockService.LastStatsVersion)
+		}
+	})
+
+	t.Run("不正なgroupIdは400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/interruptions/stats?groupId=abc", nil)
+		w := httptest.NewRecorder()
+
+		handler.getInterruptionStatsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/interruptions/stats?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.getInterruptionStatsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// parseMCPStatsParams parses the project, groupId, from and to query parameters of the MCP endpoints
+func parseMCPStatsParams(r *http.Request) (MCPStatsParams, error) {
+	query := r.URL.Query()
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		return MCPStatsParams{}, err
+	}
+
+	from := query.Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		return MCPStatsParams{}, fmt.Errorf("from must be in YYYY-MM-DD format")
+	}
+	to := query.Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		return MCPStatsParams{}, fmt.Errorf("to must be in YYYY-MM-DD format")
+	}
+
+	return MCPStatsParams{
+		ProjectName: query.Get("project"),
+		GroupID:     groupID,
+		From:        from,
+		To:          to,
+	}, nil
+}
+
+// listMCPServersHandler handles GET /api/mcp/servers
+// Optional query parameters: project, groupId, from, to
+func (h *Handler) listMCPServersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := parseMCPStatsParams(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	servers, err := h.service.ListMCPServers(params)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve MCP server statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(servers)
+}
+
+// getMCPServerHandler handles GET /api/mcp/servers/{server}
+// Optional query parameters: project, groupId, from, to
+func (h *Handler) getMCPServerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := parseMCPStatsParams(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	detail, err := h.service.GetMCPServer(r.PathValue("server"), params)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(detail)
+}
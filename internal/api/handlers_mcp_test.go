@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestListMCPServersHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("サーバー別の統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			MCPServers: &MCPServerListResponse{
+				Servers: []MCPStatsItem{
+					{Name: "github", CallCount: 4, ErrorCount: 1, ErrorRate: 0.25, TotalResultBytes: 32000, Tools: 2, Sessions: 2},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/mcp/servers?project=test-project&from=2025-06-01&to=2025-06-30", nil)
+		w := httptest.NewRecorder()
+
+		handler.listMCPServersHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response MCPServerListResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Servers) != 1 || response.Servers[0].Name != "github" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+		params := mockService.LastMCPStatsParams
+		if params.ProjectName != "test-project" || params.From != "2025-06-01" || params.To != "2025-06-30" {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+	})
+
+	t.Run("不正な日付は400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/mcp/servers?from=2025/06/01", nil)
+		w := httptest.NewRecorder()
+
+		handler.listMCPServersHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/mcp/servers?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.listMCPServersHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestGetMCPServerHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("サーバーの詳細を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			MCPServerDetail: &MCPServerDetailResponse{
+				Server:   MCPStatsItem{Name: "github", CallCount: 4},
+				Tools:    []MCPStatsItem{{Name: "get_file", CallCount: 3}},
+				Projects: []MCPStatsItem{{Name: "test-project", CallCount: 4}},
+				Daily:    []MCPStatsItem{{Date: "2025-06-01", CallCount: 4}},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/mcp/servers/github", nil)
+		req.SetPathValue("server", "github")
+		w := httptest.NewRecorder()
+
+		handler.getMCPServerHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response MCPServerDetailResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Server.Name != "github" || len(response.Tools) != 1 || response.Daily[0].Date != "2025-06-01" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("存在しないサーバーは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("MCP server not found: missing")})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/mcp/servers/missing", nil)
+		req.SetPathValue("server", "missing")
+		w := httptest.NewRecorder()
+
+		handler.getMCPServerHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
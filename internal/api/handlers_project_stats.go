@@ -6,6 +6,7 @@ import (
 )
 
 // getProjectStatsHandler returns project-level statistics
+// Optional query parameters: version (Claude Code version)
 func (h *Handler) getProjectStatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -15,7 +16,7 @@ func (h *Handler) getProjectStatsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	stats, err := h.service.GetProjectStats(projectName)
+	stats, err := h.service.GetProjectStats(projectName, r.URL.Query().Get("version"))
 	if err != nil {
 		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
 		return
@@ -25,6 +26,7 @@ func (h *Handler) getProjectStatsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 // getProjectTimelineHandler returns time-series statistics for a project
+// Optional query parameters: period, limit, version (Claude Code version)
 func (h *Handler) getProjectTimelineHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -46,7 +48,7 @@ func (h *Handler) getProjectTimelineHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	timeline, err := h.service.GetProjectTimeline(projectName, period, limit)
+	timeline, err := h.service.GetProjectTimeline(projectName, period, limit, r.URL.Query().Get("version"))
 	if err != nil {
 		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
 		return
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/scanner"
 )
 
 // getScanStatusHandler returns the current scan status
@@ -17,15 +19,21 @@ func (h *Handler) getScanStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	progress := h.scanManager.GetProgress()
+	response := convertScanProgress(h.scanManager.GetProgress())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
+// convertScanProgress converts scanner progress to the API response
+func convertScanProgress(progress scanner.ScanProgress) ScanStatusResponse {
 	var completedAt *string
 	if progress.CompletedAt != nil {
 		t := progress.CompletedAt.Format(time.RFC3339)
 		completedAt = &t
 	}
 
-	response := ScanStatusResponse{
+	return ScanStatusResponse{
 		Status:            string(progress.Status),
 		ProjectsProcessed: progress.ProjectsProcessed,
 		SessionsFound:     progress.SessionsFound,
@@ -36,7 +44,4 @@ func (h *Handler) getScanStatusHandler(w http.ResponseWriter, r *http.Request) {
 		CompletedAt:       completedAt,
 		LastError:         progress.LastError,
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// maxSearchLimit is the maximum number of search results returned per page
+const maxSearchLimit = 100
+
+// searchHandler handles GET /api/search
+// Query parameters: q (required), project, groupId, branch, role, from, to, limit, offset
+func (h *Handler) searchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "q is required")
+		return
+	}
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	role := query.Get("role")
+	if role != "" && role != "user" && role != "assistant" && role != "tool" {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "role must be 'user', 'assistant', or 'tool'")
+		return
+	}
+
+	from := query.Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "from must be in YYYY-MM-DD format")
+		return
+	}
+	to := query.Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "to must be in YYYY-MM-DD format")
+		return
+	}
+
+	limit, err := parseLimitParam(r, 20)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	offset, err := parseOffsetParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	params := SearchParams{
+		Query:       q,
+		ProjectName: query.Get("project"),
+		GroupID:     groupID,
+		GitBranch:   query.Get("branch"),
+		Role:        role,
+		From:        from,
+		To:          to,
+		Limit:       limit,
+		Offset:      offset,
+	}
+
+	result, err := h.service.Search(params)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to search")
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestSearchHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("検索結果を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			SearchResponse: &SearchResponse{
+				Query: "バグ",
+				Total: 1,
+				Limit: 20,
+				Results: []SearchResultItem{
+					{SessionID: "session-1", ProjectName: "test-project", Role: "user", Snippet: "<mark>バグ</mark>を修正"},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=%E3%83%90%E3%82%B0&project=test-project&branch=main&role=user&from=2026-01-01&to=2026-01-31&limit=500&offset=20", nil)
+		w := httptest.NewRecorder()
+
+		handler.searchHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response SearchResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Total != 1 || len(response.Results) != 1 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		params := mockService.LastSearchParams
+		if params.Query != "バグ" || params.ProjectName != "test-project" || params.GitBranch != "main" || params.Role != "user" {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+		if params.Limit != maxSearchLimit {
+			t.Errorf("Expected limit to be capped at %d, got %d", maxSearchLimit, params.Limit)
+		}
+		if params.Offset != 20 {
+			t.Errorf("Expected offset 20, got %d", params.Offset)
+		}
+	})
+
+	t.Run("不正なパラメータは400", func(t *testing.T) {
+		cases := []string{
+			"/api/search",
+			"/api/search?q=%20",
+			"/api/search?q=test&role=system",
+			"/api/search?q=test&from=2026/01/01",
+			"/api/search?q=test&offset=-1",
+			"/api/search?q=test&groupId=abc",
+		}
+		for _, url := range cases {
+			handler := newHandler(&MockSessionService{})
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+
+			handler.searchHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", url, w.Code)
+			}
+		}
+	})
+}
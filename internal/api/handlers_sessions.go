@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+)
+
+// Default and maximum number of entries returned per page
+const (
+	defaultEntryLimit = 200
+	maxEntryLimit     = 1000
+)
+
+// getSessionSummaryHandler returns session details without the conversation and tool calls
+func (h *Handler) getSessionSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	summary, err := h.service.GetSessionSummary(r.PathValue("project"), r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(summary)
+}
+
+// listSessionEntriesHandler returns a page of the log entries of a session
+func (h *Handler) listSessionEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+
+	role := query.Get("role")
+	if role != "" && role != "user" && role != "assistant" {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "role must be 'user' or 'assistant'")
+		return
+	}
+
+	omitToolIO, err := parseOptionalBoolParam(r, "omitToolIO")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	limit, err := parseLimitParam(r, defaultEntryLimit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if limit > maxEntryLimit {
+		limit = maxEntryLimit
+	}
+
+	params := SessionEntryParams{
+		EntryType:  query.Get("type"),
+		Role:       role,
+		OmitToolIO: omitToolIO != nil && *omitToolIO,
+		Cursor:     query.Get("cursor"),
+		Limit:      limit,
+	}
+
+	result, err := h.service.ListSessionEntries(r.PathValue("project"), r.PathValue("id"), params)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidCursor) {
+			writeJSONError(w, http.StatusBadRequest, "bad_request", "cursor is invalid")
+			return
+		}
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// getConversationTreeHandler returns the conversation tree of a session
+func (h *Handler) getConversationTreeHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tree, err := h.service.GetConversationTree(r.PathValue("project"), r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(tree)
+}
+
+// getContextUsageHandler returns the context-size series and the compactions of a session
+func (h *Handler) getContextUsageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	usage, err := h.service.GetContextUsage(r.PathValue("project"), r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(usage)
+}
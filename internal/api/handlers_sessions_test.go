@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestSessionEntriesHandlers(t *testing.T) {
+	newRouter := func(service SessionService) http.Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser(t.TempDir())
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager).Routes()
+	}
+
+	t.Run("セッション概要を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			SessionSummary: &SessionDetailSummaryResponse{ID: "session-001", EntryCount: 1200},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/summary", nil)
+		w := httptest.NewRecorder()
+		newRouter(mockService).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response SessionDetailSummaryResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.ID != "session-001" || response.EntryCount != 1200 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("エントリのパラメータがサービスに渡される", func(t *testing.T) {
+		mockService := &MockSessionService{
+			SessionEntries: &SessionEntryListResponse{
+				Entries:    []SessionEntryResponse{{UUID: "uuid-1", Type: "user"}},
+				Total:      50,
+				NextCursor: "next",
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/entries?type=assistant&role=assistant&omitToolIO=true&cursor=abc&limit=5000", nil)
+		w := httptest.NewRecorder()
+		newRouter(mockService).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response SessionEntryListResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Total != 50 || response.NextCursor != "next" || len(response.Entries) != 1 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		params := mockService.LastSessionEntryParams
+		if params.EntryType != "assistant" || params.Role != "assistant" || !params.OmitToolIO || params.Cursor != "abc" {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+		if params.Limit != maxEntryLimit {
+			t.Errorf("Expected limit to be capped at %d, got %d", maxEntryLimit, params.Limit)
+		}
+	})
+
+	t.Run("省略時の件数", func(t *testing.T) {
+		mockService := &MockSessionService{SessionEntries: &SessionEntryListResponse{}}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/entries", nil)
+		w := httptest.NewRecorder()
+		newRouter(mockService).ServeHTTP(w, req)
+
+		if mockService.LastSessionEntryParams.Limit != defaultEntryLimit || mockService.LastSessionEntryParams.OmitToolIO {
+			t.Errorf("Unexpected default params: %+v", mockService.LastSessionEntryParams)
+		}
+	})
+
+	t.Run("不正なパラメータは400", func(t *testing.T) {
+		for _, query := range []string{"role=tool", "omitToolIO=1", "limit=-5"} {
+			t.Run(query, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/entries?"+query, nil)
+				w := httptest.NewRecorder()
+				newRouter(&MockSessionService{}).ServeHTTP(w, req)
+
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("Expected status 400, got %d", w.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("不正なカーソルは400", func(t *testing.T) {
+		mockService := &MockSessionService{err: fmt.Errorf("failed to list session entries: %w", db.ErrInvalidCursor)}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/entries?cursor=broken", nil)
+		w := httptest.NewRecorder()
+		newRouter(mockService).ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("会話ツリーを取得できる", func(t *testing.T) {
+		branch := 0
+		mockService := &MockSessionService{
+			ConversationTree: &ConversationTreeResponse{
+				SessionID: "session-001",
+				Roots:     []string{"u1"},
+				Nodes: []ConversationNodeResponse{
+					{UUID: "u1", Type: "user", MainPath: true},
+					{UUID: "u2", ParentUUID: "u1", Type: "user", Branch: &branch},
+				},
+				Abandoned: AbandonedBranchSummaryResponse{Branches: 1, InputTokens: 500, TotalTokens: 520},
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/tree", nil)
+		w := httptest.NewRecorder()
+		newRouter(mockService).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response ConversationTreeResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Nodes) != 2 || response.Nodes[1].Branch == nil || response.Abandoned.TotalTokens != 520 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("コンテキスト使用量を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			ContextUsage: &ContextUsageResponse{
+				SessionID:         "session-001",
+				PeakContextTokens: 155000,
+				CompactionCount:   1,
+				Points:            []ContextPointResponse{{UUID: "a1", ContextTokens: 155000}},
+				Compactions:       []CompactionResponse{{UUID: "c1", Trigger: "auto", PreTokens: 155060}},
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/session-001/context", nil)
+		w := httptest.NewRecorder()
+		newRouter(mockService).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response ContextUsageResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.PeakContextTokens != 155000 || len(response.Points) != 1 || response.Compactions[0].Trigger != "auto" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("存在しないセッションは404", func(t *testing.T) {
+		mockService := &MockSessionService{err: errors.New("session not found")}
+
+		for _, path := range []string{"/summary", "/entries", "/tree", "/context"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/sessions/project-1/missing"+path, nil)
+			w := httptest.NewRecorder()
+			newRouter(mockService).ServeHTTP(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path, w.Code)
+			}
+		}
+	})
+}
@@ -8,10 +8,11 @@ import (
 )
 
 // getTotalStatsHandler returns total statistics across all projects
+// Optional query parameters: version (Claude Code version)
 func (h *Handler) getTotalStatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	stats, err := h.service.GetTotalStats()
+	stats, err := h.service.GetTotalStats(r.URL.Query().Get("version"))
 	if err != nil {
 		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve statistics")
 		return
@@ -21,6 +22,7 @@ func (h *Handler) getTotalStatsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // getTotalTimelineHandler returns time-series statistics across all projects
+// Optional query parameters: period, limit, version (Claude Code version)
 func (h *Handler) getTotalTimelineHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -36,7 +38,7 @@ func (h *Handler) getTotalTimelineHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	timeline, err := h.service.GetTotalTimeline(period, limit)
+	timeline, err := h.service.GetTotalTimeline(period, limit, r.URL.Query().Get("version"))
 	if err != nil {
 		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve timeline data")
 		return
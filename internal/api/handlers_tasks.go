@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+)
+
+// Default and maximum number of tasks returned per page
+const (
+	defaultTaskLimit = 100
+	maxTaskLimit     = 1000
+)
+
+// listTasksHandler handles GET /api/tasks
+// Optional query parameters: project, groupId, branch, from, to, sort, order, limit, offset
+func (h *Handler) listTasksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	from := query.Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "from must be in YYYY-MM-DD format")
+		return
+	}
+	to := query.Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "to must be in YYYY-MM-DD format")
+		return
+	}
+
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = db.TaskSortStartTime
+	}
+	if !db.IsValidTaskSort(sortBy) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "sort must be 'startTime', 'tokens', 'duration', 'toolCalls', or 'errors'")
+		return
+	}
+	order := query.Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "order must be 'asc' or 'desc'")
+		return
+	}
+
+	limit, err := parseLimitParam(r, defaultTaskLimit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if limit > maxTaskLimit {
+		limit = maxTaskLimit
+	}
+
+	offset, err := parseOffsetParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	params := TaskListParams{
+		ProjectName: query.Get("project"),
+		GroupID:     groupID,
+		GitBranch:   query.Get("branch"),
+		From:        from,
+		To:          to,
+		SortBy:      sortBy,
+		Order:       order,
+		Limit:       limit,
+		Offset:      offset,
+	}
+
+	tasks, err := h.service.ListTasks(params)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve tasks")
+		return
+	}
+
+	json.NewEncoder(w).Encode(tasks)
+}
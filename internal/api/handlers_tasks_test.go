@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestListTasksHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("タスク一覧を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			Tasks: &TaskListResponse{
+				Tasks: []TaskResponse{
+					{SessionID: "session-1", ProjectName: "test-project", Index: 2, Prompt: "Add a README", ToolCallCount: 3},
+				},
+				Total: 1,
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks?project=test-project&sort=tokens&order=asc&limit=5000&offset=10", nil)
+		w := httptest.NewRecorder()
+
+		handler.listTasksHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response TaskListResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Total != 1 || len(response.Tasks) != 1 || response.Tasks[0].Index != 2 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		params := mockService.LastTaskListParams
+		if params.ProjectName != "test-project" || params.SortBy != "tokens" || params.Order != "asc" || params.Offset != 10 {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+		if params.Limit != maxTaskLimit {
+			t.Errorf("Expected limit to be capped at %d, got %d", maxTaskLimit, params.Limit)
+		}
+	})
+
+	t.Run("既定では開始時刻の新しい順", func(t *testing.T) {
+		mockService := &MockSessionService{Tasks: &TaskListResponse{Tasks: []TaskResponse{}}}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+		w := httptest.NewRecorder()
+
+		handler.listTasksHandler(w, req)
+
+		params := mockService.LastTaskListParams
+		if w.Code != http.StatusOK || params.SortBy != db.TaskSortStartTime || params.Order != "desc" || params.Limit != defaultTaskLimit {
+			t.Errorf("Unexpected defaults: status %d, params %+v", w.Code, params)
+		}
+	})
+
+	t.Run("不正なパラメータは400", func(t *testing.T) {
+		for _, query := range []string{"sort=cost", "order=up", "from=2025/01/01", "groupId=abc", "offset=-1"} {
+			handler := newHandler(&MockSessionService{})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/tasks?"+query, nil)
+			w := httptest.NewRecorder()
+
+			handler.listTasksHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+			}
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.listTasksHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// getToolStatsHandler handles GET /api/tools/stats
+// Optional query parameters: project (project name), groupId (project group ID), version (Claude Code version)
+func (h *Handler) getToolStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+	version := r.URL.Query().Get("version")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetToolStats(projectName, groupID, version)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve tool statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+// getRetryStatsHandler handles GET /api/tools/retries
+// Optional query parameters: project (project name), groupId (project group ID), version (Claude Code version)
+func (h *Handler) getRetryStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+	version := r.URL.Query().Get("version")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetRetryStats(projectName, groupID, version)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve retry statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Default number of failing commands returned by the Bash statistics
+const defaultFailingCommandLimit = 20
+
+// getBashStatsHandler handles GET /api/tools/bash
+// Optional query parameters: project, groupId, version, from, to, limit (number of failing commands)
+func (h *Handler) getBashStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	projectName := query.Get("project")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	from := query.Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "from must be in YYYY-MM-DD format")
+		return
+	}
+	to := query.Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "to must be in YYYY-MM-DD format")
+		return
+	}
+
+	limit, err := parseLimitParam(r, defaultFailingCommandLimit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetBashStats(BashStatsParams{
+		ProjectName: projectName,
+		GroupID:     groupID,
+		Version:     query.Get("version"),
+		From:        from,
+		To:          to,
+		Limit:       limit,
+	})
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve Bash statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
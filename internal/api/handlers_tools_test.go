@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func TestGetToolStatsHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("ツール統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			ToolStats: &ToolStatsResponse{
+				Tools: []ToolStatsItem{
+					{ToolName: "Bash", CallCount: 4, ErrorCount: 1, ErrorRate: 0.25, P50LatencyMs: 200, P95LatencyMs: 1000},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/stats?project=test-project", nil)
+		w := httptest.NewRecorder()
+
+		handler.getToolStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response ToolStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Tools) != 1 || response.Tools[0].P95LatencyMs != 1000 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("不正なgroupIdは400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/stats?groupId=abc", nil)
+		w := httptest.NewRecorder()
+
+		handler.getToolStatsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないグループは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("group not found: %w", db.ErrProjectGroupNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/stats?groupId=999", nil)
+		w := httptest.NewRecorder()
+
+		handler.getToolStatsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestGetRetryStatsHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("再試行統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			RetryStats: &RetryStatsResponse{
+				Total: RetryStatsItem{Operations: 4, FirstAttemptSuccesses: 3, FirstAttemptRate: 0.75, Retries: 2, Recovered: 1},
+				Tools: []RetryStatsItem{
+					{ToolName: "Edit", Operations: 4, FirstAttemptSuccesses: 3, FirstAttemptRate: 0.75, Retries: 2, Recovered: 1},
+				},
+				Models: []RetryStatsItem{
+					{Model: "claude-haiku-4-5", Operations: 4, FirstAttemptSuccesses: 3, FirstAttemptRate: 0.75, Retries: 2, Recovered: 1},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/retries?project=test-project", nil)
+		w := httptest.NewRecorder()
+
+		handler.getRetryStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response RetryStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Total.FirstAttemptRate != 0.75 || len(response.Models) != 1 || response.Models[0].Model != "claude-haiku-4-5" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("不正なgroupIdは400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/retries?groupId=abc", nil)
+		w := httptest.NewRecorder()
+
+		handler.getRetryStatsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/retries?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.getRetryStatsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("絞り込み時でも集計の失敗は500", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("database is locked")})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/retries?project=test-project", nil)
+		w := httptest.NewRecorder()
+
+		handler.getRetryStatsHandler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+	})
+}
+
+func TestGetBashStatsHandler(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser("/tmp")
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("Bashコマンドの統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			BashStats: &BashStatsResponse{
+				Families: []BashFamilyStatsItem{
+					{Family: "test", Count: 3, FailureCount: 2, FailureRate: 2.0 / 3, P50DurationMs: 2000, P95DurationMs: 4000},
+				},
+				Projects: []BashFamilyStatsItem{
+					{Family: "test", ProjectName: "test-project", Count: 3, FailureCount: 2},
+				},
+				FailingCommands: []FailingBashCommandItem{
+					{Command: "go test ./...", Program: "go", Family: "test", Count: 3, FailureCount: 2, LastError: "--- FAIL: TestA"},
+				},
+			},
+		}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/bash?project=test-project&from=2025-06-01&limit=5", nil)
+		w := httptest.NewRecorder()
+
+		handler.getBashStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response BashStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Families) != 1 || response.Families[0].Family != "test" || len(response.FailingCommands) != 1 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+		params := mockService.LastBashStatsParams
+		if params.ProjectName != "test-project" || params.From != "2025-06-01" || params.Limit != 5 {
+			t.Errorf("Unexpected params: %+v", params)
+		}
+	})
+
+	t.Run("limit未指定はデフォルト値", func(t *testing.T) {
+		mockService := &MockSessionService{BashStats: &BashStatsResponse{}}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/bash", nil)
+		w := httptest.NewRecorder()
+
+		handler.getBashStatsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if mockService.LastBashStatsParams.Limit != defaultFailingCommandLimit {
+			t.Errorf("Expected default limit %d, got %d", defaultFailingCommandLimit, mockService.LastBashStatsParams.Limit)
+		}
+	})
+
+	t.Run("不正なパラメータは400", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{})
+
+		for _, url := range []string{"/api/tools/bash?to=20250601", "/api/tools/bash?limit=0", "/api/tools/bash?groupId=abc"} {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+
+			handler.getBashStatsHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", url, w.Code)
+			}
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		handler := newHandler(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/bash?project=missing", nil)
+		w := httptest.NewRecorder()
+
+		handler.getBashStatsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
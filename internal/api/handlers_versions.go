@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Default number of days before and after a version first appeared compared by GET /api/versions/{version}/compare
+const defaultVersionCompareDays = 7
+
+// listVersionsHandler handles GET /api/versions
+// Optional query parameters: project (project name), groupId (project group ID)
+func (h *Handler) listVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	versions, err := h.service.ListVersions(projectName, groupID)
+	if err != nil {
+		// 絞り込み対象のプロジェクト・グループが存在しない場合は404
+		if isScopeNotFound(err) {
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve version statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(versions)
+}
+
+// compareVersionHandler handles GET /api/versions/{version}/compare
+// Optional query parameters: project, groupId, days (length of each period, default 7)
+func (h *Handler) compareVersionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectName := r.URL.Query().Get("project")
+
+	groupID, err := parseGroupIDParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	days, err := parseDaysParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	comparison, err := h.service.CompareVersion(r.PathValue("version"), days, projectName, groupID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// parseDaysParam parses the optional days query parameter
+func parseDaysParam(r *http.Request) (int, error) {
+	daysStr := r.URL.Query().Get("days")
+	if daysStr == "" {
+		return defaultVersionCompareDays, nil
+	}
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days <= 0 {
+		return 0, fmt.Errorf("days must be a positive integer")
+	}
+	return days, nil
+}
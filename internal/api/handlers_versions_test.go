@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/scanner"
+)
+
+func newVersionTestRouter(service SessionService) http.Handler {
+	mockDB := &db.DB{}
+	mockParser := parser.NewParser("/tmp")
+	mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+	return NewHandler(service, mockScanManager).Routes()
+}
+
+func TestListVersionsHandler(t *testing.T) {
+	t.Run("バージョンごとの統計を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			Versions: &VersionListResponse{
+				Versions: []VersionStatsItem{
+					{Version: "1.0.0", Sessions: 10, ErrorRate: 0.2},
+					{Version: "1.1.0", Sessions: 5, ErrorRate: 0.4},
+				},
+			},
+		}
+		router := newVersionTestRouter(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/versions", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response VersionListResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Versions) != 2 || response.Versions[1].Version != "1.1.0" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("存在しないプロジェクトは404", func(t *testing.T) {
+		router := newVersionTestRouter(&MockSessionService{err: fmt.Errorf("project not found: %w", db.ErrProjectNotFound)})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/versions?project=missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestCompareVersionHandler(t *testing.T) {
+	t.Run("バージョン登場前後の比較を取得できる", func(t *testing.T) {
+		mockService := &MockSessionService{
+			VersionComparison: &VersionComparisonResponse{
+				Version:         "1.1.0",
+				PreviousVersion: "1.0.0",
+				FirstSeen:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				Days:            14,
+				Before:          VersionStatsItem{Sessions: 10},
+				After:           VersionStatsItem{Sessions: 8},
+			},
+		}
+		router := newVersionTestRouter(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/versions/1.1.0/compare?days=14", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if mockService.LastCompareDays != 14 {
+			t.Errorf("Expected days 14, got %d", mockService.LastCompareDays)
+		}
+
+		var response VersionComparisonResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.PreviousVersion != "1.0.0" || response.Before.Sessions != 10 || response.After.Sessions != 8 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+
+	t.Run("daysを省略すると7日", func(t *testing.T) {
+		mockService := &MockSessionService{VersionComparison: &VersionComparisonResponse{Version: "1.1.0"}}
+		router := newVersionTestRouter(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/versions/1.1.0/compare", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || mockService.LastCompareDays != 7 {
+			t.Errorf("Expected status 200 with 7 days, got %d / %d", w.Code, mockService.LastCompareDays)
+		}
+	})
+
+	t.Run("不正なdaysは400", func(t *testing.T) {
+		router := newVersionTestRouter(&MockSessionService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/versions/1.1.0/compare?days=0", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないバージョンは404", func(t *testing.T) {
+		router := newVersionTestRouter(&MockSessionService{err: fmt.Errorf("version not found: 9.9.9")})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/versions/9.9.9/compare", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestStatsVersionFilter(t *testing.T) {
+	paths := []string{
+		"/api/stats/total",
+		"/api/stats/timeline",
+		"/api/projects/test-project/stats",
+		"/api/projects/test-project/timeline",
+		"/api/groups/1/stats",
+		"/api/groups/1/timeline",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			mockService := &MockSessionService{
+				TotalStats:           &TotalStatsResponse{},
+				TotalTimeline:        &TimeSeriesResponse{},
+				ProjectGroupStats:    &ProjectGroupStatsResponse{},
+				ProjectGroupTimeline: &TimeSeriesResponse{},
+			}
+			router := newVersionTestRouter(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, path+"?version=1.1.0", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if mockService.LastStatsVersion != "1.1.0" {
+				t.Errorf("Expected version 1.1.0, got %q", mockService.LastStatsVersion)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
 )
 
 // writeJSONError writes an error response with the specified status code
@@ -53,6 +56,66 @@ func parseLimitParam(r *http.Request, defaultLimit int) (int, error) {
 	return limit, nil
 }
 
+// parseGroupIDParam parses the optional groupId query parameter
+// Returns nil if the parameter is not specified.
+func parseGroupIDParam(r *http.Request) (*int64, error) {
+	groupIDStr := r.URL.Query().Get("groupId")
+	if groupIDStr == "" {
+		return nil, nil
+	}
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("groupId must be an integer")
+	}
+	return &groupID, nil
+}
+
+// isScopeNotFound reports whether err means the project or group used as a filter does not exist
+func isScopeNotFound(err error) bool {
+	return errors.Is(err, db.ErrProjectNotFound) || errors.Is(err, db.ErrProjectGroupNotFound)
+}
+
+// parseOffsetParam parses and validates offset query parameter
+func parseOffsetParam(r *http.Request) (int, error) {
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr == "" {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("offset must be a non-negative integer")
+	}
+	return offset, nil
+}
+
+// parseOptionalIntParam parses an optional non-negative integer query parameter
+// Returns nil if the parameter is not specified.
+func parseOptionalIntParam(r *http.Request, name string) (*int, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return &value, nil
+}
+
+// parseOptionalBoolParam parses an optional boolean query parameter ("true" or "false")
+// Returns nil if the parameter is not specified.
+func parseOptionalBoolParam(r *http.Request, name string) (*bool, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+	if valueStr != "true" && valueStr != "false" {
+		return nil, fmt.Errorf("%s must be 'true' or 'false'", name)
+	}
+	value := valueStr == "true"
+	return &value, nil
+}
+
 // extractDisplayName extracts the last folder name from a decoded path
 // Example: "C:/Users/username/projects/my-project" -> "my-project"
 func extractDisplayName(decodedPath string) string {
@@ -3,12 +3,15 @@ package api
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
 	"os"
 	"strings"
 
+	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/events"
 	"github.com/a-tak/ccloganalysis/internal/scanner"
 	"github.com/a-tak/ccloganalysis/internal/static"
 )
@@ -18,6 +21,7 @@ type Handler struct {
 	service     SessionService
 	dbService   *DatabaseSessionService
 	scanManager *scanner.ScanManager
+	events      *events.Bus
 }
 
 // NewHandler creates a new Handler with the given service and scan manager
@@ -35,6 +39,11 @@ func NewHandler(service SessionService, scanManager *scanner.ScanManager) *Handl
 	}
 }
 
+// SetEventBus sets the bus streamed by GET /api/events
+func (h *Handler) SetEventBus(bus *events.Bus) {
+	h.events = bus
+}
+
 // spaHandler serves static files and falls back to index.html for SPA routing
 type spaHandler struct {
 	staticFS   http.FileSystem
@@ -122,8 +131,15 @@ func (h *Handler) Routes() http.Handler {
 	mux.HandleFunc("GET /api/projects/{name}/stats", h.getProjectStatsHandler)
 	mux.HandleFunc("GET /api/projects/{name}/timeline", h.getProjectTimelineHandler)
 	mux.HandleFunc("GET /api/projects/{name}/daily/{date}", h.getProjectDailyStatsHandler)
+	mux.HandleFunc("GET /api/projects/{name}/files", h.getProjectFilesHandler)
 	mux.HandleFunc("GET /api/sessions", h.listSessionsHandler)
 	mux.HandleFunc("GET /api/sessions/{project}/{id}", h.getSessionHandler)
+	mux.HandleFunc("GET /api/sessions/{project}/{id}/summary", h.getSessionSummaryHandler)
+	mux.HandleFunc("GET /api/sessions/{project}/{id}/entries", h.listSessionEntriesHandler)
+	mux.HandleFunc("GET /api/sessions/{project}/{id}/tree", h.getConversationTreeHandler)
+	mux.HandleFunc("GET /api/sessions/{project}/{id}/context", h.getContextUsageHandler)
+	mux.HandleFunc("GET /api/sessions/{project}/{id}/files", h.getSessionFilesHandler)
+	mux.HandleFunc("GET /api/tasks", h.listTasksHandler)
 	mux.HandleFunc("POST /api/analyze", h.analyzeHandler)
 	mux.HandleFunc("GET /api/groups", h.listGroupsHandler)
 	mux.HandleFunc("GET /api/groups/{id}", h.getGroupHandler)
@@ -136,9 +152,35 @@ func (h *Handler) Routes() http.Handler {
 	mux.HandleFunc("GET /api/stats/timeline", h.getTotalTimelineHandler)
 	mux.HandleFunc("GET /api/stats/daily/{date}", h.getDailyStatsHandler)
 
+	// Tool analytics endpoints
+	mux.HandleFunc("GET /api/tools/stats", h.getToolStatsHandler)
+	mux.HandleFunc("GET /api/tools/retries", h.getRetryStatsHandler)
+	mux.HandleFunc("GET /api/tools/bash", h.getBashStatsHandler)
+	mux.HandleFunc("GET /api/commands/stats", h.getCommandStatsHandler)
+	mux.HandleFunc("GET /api/mcp/servers", h.listMCPServersHandler)
+	mux.HandleFunc("GET /api/mcp/servers/{server}", h.getMCPServerHandler)
+	mux.HandleFunc("GET /api/interruptions/stats", h.getInterruptionStatsHandler)
+
+	// Claude Code version endpoints
+	mux.HandleFunc("GET /api/versions", h.listVersionsHandler)
+	mux.HandleFunc("GET /api/versions/{version}/compare", h.compareVersionHandler)
+
+	// Git commit correlation endpoint
+	mux.HandleFunc("GET /api/commits", h.getCommitStatsHandler)
+
+	// Error pattern endpoints
+	mux.HandleFunc("GET /api/errors/patterns", h.listErrorPatternsHandler)
+	mux.HandleFunc("GET /api/errors/patterns/{id}/occurrences", h.getErrorOccurrencesHandler)
+
+	// Search endpoint
+	mux.HandleFunc("GET /api/search", h.searchHandler)
+
 	// Scan status endpoint
 	mux.HandleFunc("GET /api/scan/status", h.getScanStatusHandler)
 
+	// Live update events (Server-Sent Events)
+	mux.HandleFunc("GET /api/events", h.eventsHandler)
+
 	// Debug endpoint (only available when using DatabaseSessionService)
 	if h.dbService != nil && h.scanManager != nil {
 		mux.HandleFunc("GET /api/debug/status", DebugStatusHandler(h.dbService, h.scanManager))
@@ -183,25 +225,107 @@ func (h *Handler) listProjectsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// listSessionsHandler returns list of sessions
+// maxSessionListLimit is the default and maximum number of sessions returned per page
+const maxSessionListLimit = 1000
+
+// listSessionsHandler returns a page of sessions filtered and sorted by query parameters
 func (h *Handler) listSessionsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	projectName := r.URL.Query().Get("project")
+	query := r.URL.Query()
 
-	sessions, err := h.service.ListSessions(projectName)
+	groupID, err := parseGroupIDParam(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{
-			Error:   "internal_error",
-			Message: err.Error(),
-		})
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
 		return
 	}
 
-	json.NewEncoder(w).Encode(SessionListResponse{
-		Sessions: sessions,
-	})
+	from := query.Get("from")
+	if from != "" && !isValidDateFormat(from) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "from must be in YYYY-MM-DD format")
+		return
+	}
+	to := query.Get("to")
+	if to != "" && !isValidDateFormat(to) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "to must be in YYYY-MM-DD format")
+		return
+	}
+
+	minTokens, err := parseOptionalIntParam(r, "minTokens")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	maxTokens, err := parseOptionalIntParam(r, "maxTokens")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	hasErrors, err := parseOptionalBoolParam(r, "hasErrors")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = db.SessionSortStartTime
+	}
+	if !db.IsValidSessionSort(sortBy) {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "sort must be 'startTime', 'tokens', 'duration', 'errors', or 'context'")
+		return
+	}
+	order := query.Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "order must be 'asc' or 'desc'")
+		return
+	}
+
+	limit, err := parseLimitParam(r, maxSessionListLimit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if limit > maxSessionListLimit {
+		limit = maxSessionListLimit
+	}
+
+	params := SessionListParams{
+		ProjectName: query.Get("project"),
+		GroupID:     groupID,
+		GitBranch:   query.Get("branch"),
+		Model:       query.Get("model"),
+		ToolName:    query.Get("tool"),
+		Version:     query.Get("version"),
+		From:        from,
+		To:          to,
+		MinTokens:   minTokens,
+		MaxTokens:   maxTokens,
+		HasErrors:   hasErrors,
+		SortBy:      sortBy,
+		Order:       order,
+		Cursor:      query.Get("cursor"),
+		Limit:       limit,
+	}
+
+	result, err := h.service.ListSessions(params)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInvalidCursor):
+			writeJSONError(w, http.StatusBadRequest, "bad_request", "cursor is invalid for this sort order")
+		case errors.Is(err, db.ErrProjectGroupNotFound):
+			// 絞り込み対象のグループが存在しない場合は404
+			writeJSONError(w, http.StatusNotFound, "not_found", err.Error())
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
 }
 
 // getSessionHandler returns a specific session
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -30,6 +31,36 @@ type MockSessionService struct {
 	DailyStats           *DailyStatsResponse
 	GroupDailyStats      *GroupDailyStatsResponse
 	ProjectDailyStats    *ProjectDailyStatsResponse
+	ToolStats            *ToolStatsResponse
+	SearchResponse       *SearchResponse
+	LastSearchParams     SearchParams
+	LastSessionListParams SessionListParams
+	SessionSummary       *SessionDetailSummaryResponse
+	SessionEntries       *SessionEntryListResponse
+	LastSessionEntryParams SessionEntryParams
+	ConversationTree     *ConversationTreeResponse
+	ContextUsage         *ContextUsageResponse
+	RetryStats           *RetryStatsResponse
+	CommandStats         *CommandStatsResponse
+	SessionFiles         *SessionFilesResponse
+	ProjectFiles         *ProjectFilesResponse
+	LastProjectFilesParams ProjectFilesParams
+	MCPServers           *MCPServerListResponse
+	MCPServerDetail      *MCPServerDetailResponse
+	LastMCPStatsParams   MCPStatsParams
+	BashStats            *BashStatsResponse
+	LastBashStatsParams  BashStatsParams
+	InterruptionStats    *InterruptionStatsResponse
+	LastStatsVersion     string
+	Versions             *VersionListResponse
+	VersionComparison    *VersionComparisonResponse
+	LastCompareDays      int
+	CommitStats          *CommitStatsResponse
+	LastCommitStatsParams CommitStatsParams
+	Tasks                *TaskListResponse
+	LastTaskListParams   TaskListParams
+	ErrorPatterns        *ErrorPatternListResponse
+	ErrorOccurrences     *ErrorOccurrenceListResponse
 	ShouldError          bool
 	err                  error
 }
@@ -41,11 +72,49 @@ func (m *MockSessionService) ListProjects() ([]ProjectResponse, error) {
 	return m.projects, nil
 }
 
-func (m *MockSessionService) ListSessions(projectName string) ([]SessionSummary, error) {
+func (m *MockSessionService) ListSessions(params SessionListParams) (*SessionListResponse, error) {
+	m.LastSessionListParams = params
 	if m.err != nil {
 		return nil, m.err
 	}
-	return m.sessions, nil
+	return &SessionListResponse{Sessions: m.sessions, Total: len(m.sessions)}, nil
+}
+
+func (m *MockSessionService) GetSessionSummary(projectName, sessionID string) (*SessionDetailSummaryResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.SessionSummary, nil
+}
+
+func (m *MockSessionService) ListSessionEntries(projectName, sessionID string, params SessionEntryParams) (*SessionEntryListResponse, error) {
+	m.LastSessionEntryParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.SessionEntries, nil
+}
+
+func (m *MockSessionService) GetConversationTree(projectName, sessionID string) (*ConversationTreeResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.ConversationTree, nil
+}
+
+func (m *MockSessionService) ListTasks(params TaskListParams) (*TaskListResponse, error) {
+	m.LastTaskListParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.Tasks, nil
+}
+
+func (m *MockSessionService) GetContextUsage(projectName, sessionID string) (*ContextUsageResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.ContextUsage, nil
 }
 
 func (m *MockSessionService) GetSession(projectName, sessionID string) (*SessionDetailResponse, error) {
@@ -62,14 +131,16 @@ func (m *MockSessionService) Analyze(projectNames []string) (*AnalyzeResponse, e
 	return m.analyze, nil
 }
 
-func (m *MockSessionService) GetProjectStats(projectName string) (*ProjectStatsResponse, error) {
+func (m *MockSessionService) GetProjectStats(projectName, version string) (*ProjectStatsResponse, error) {
+	m.LastStatsVersion = version
 	if m.err != nil {
 		return nil, m.err
 	}
 	return m.stats, nil
 }
 
-func (m *MockSessionService) GetProjectTimeline(projectName, period string, limit int) (*TimeSeriesResponse, error) {
+func (m *MockSessionService) GetProjectTimeline(projectName, period string, limit int, version string) (*TimeSeriesResponse, error) {
+	m.LastStatsVersion = version
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -90,28 +161,32 @@ func (m *MockSessionService) GetProjectGroup(groupID int64) (*ProjectGroupDetail
 	return m.ProjectGroupDetail, nil
 }
 
-func (m *MockSessionService) GetProjectGroupStats(groupID int64) (*ProjectGroupStatsResponse, error) {
+func (m *MockSessionService) GetProjectGroupStats(groupID int64, version string) (*ProjectGroupStatsResponse, error) {
+	m.LastStatsVersion = version
 	if m.ShouldError || m.err != nil {
 		return nil, m.err
 	}
 	return m.ProjectGroupStats, nil
 }
 
-func (m *MockSessionService) GetProjectGroupTimeline(groupID int64, period string, limit int) (*TimeSeriesResponse, error) {
+func (m *MockSessionService) GetProjectGroupTimeline(groupID int64, period string, limit int, version string) (*TimeSeriesResponse, error) {
+	m.LastStatsVersion = version
 	if m.ShouldError || m.err != nil {
 		return nil, m.err
 	}
 	return m.ProjectGroupTimeline, nil
 }
 
-func (m *MockSessionService) GetTotalStats() (*TotalStatsResponse, error) {
+func (m *MockSessionService) GetTotalStats(version string) (*TotalStatsResponse, error) {
+	m.LastStatsVersion = version
 	if m.err != nil {
 		return nil, m.err
 	}
 	return m.TotalStats, nil
 }
 
-func (m *MockSessionService) GetTotalTimeline(period string, limit int) (*TimeSeriesResponse, error) {
+func (m *MockSessionService) GetTotalTimeline(period string, limit int, version string) (*TimeSeriesResponse, error) {
+	m.LastStatsVersion = version
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -139,6 +214,121 @@ func (m *MockSessionService) GetProjectDailyStats(projectName string, date strin
 	return m.ProjectDailyStats, nil
 }
 
+func (m *MockSessionService) GetToolStats(projectName string, groupID *int64, version string) (*ToolStatsResponse, error) {
+	m.LastStatsVersion = version
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.ToolStats, nil
+}
+
+func (m *MockSessionService) GetRetryStats(projectName string, groupID *int64, version string) (*RetryStatsResponse, error) {
+	m.LastStatsVersion = version
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.RetryStats, nil
+}
+
+func (m *MockSessionService) GetCommandStats(projectName string, groupID *int64) (*CommandStatsResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.CommandStats, nil
+}
+
+func (m *MockSessionService) GetSessionFiles(projectName, sessionID string) (*SessionFilesResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.SessionFiles, nil
+}
+
+func (m *MockSessionService) GetProjectFiles(projectName string, params ProjectFilesParams) (*ProjectFilesResponse, error) {
+	m.LastProjectFilesParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.ProjectFiles, nil
+}
+
+func (m *MockSessionService) ListMCPServers(params MCPStatsParams) (*MCPServerListResponse, error) {
+	m.LastMCPStatsParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.MCPServers, nil
+}
+
+func (m *MockSessionService) GetMCPServer(server string, params MCPStatsParams) (*MCPServerDetailResponse, error) {
+	m.LastMCPStatsParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.MCPServerDetail, nil
+}
+
+func (m *MockSessionService) GetBashStats(params BashStatsParams) (*BashStatsResponse, error) {
+	m.LastBashStatsParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.BashStats, nil
+}
+
+func (m *MockSessionService) GetInterruptionStats(projectName string, groupID *int64, version string) (*InterruptionStatsResponse, error) {
+	m.LastStatsVersion = version
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.InterruptionStats, nil
+}
+
+func (m *MockSessionService) ListVersions(projectName string, groupID *int64) (*VersionListResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.Versions, nil
+}
+
+func (m *MockSessionService) CompareVersion(version string, days int, projectName string, groupID *int64) (*VersionComparisonResponse, error) {
+	m.LastCompareDays = days
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.VersionComparison, nil
+}
+
+func (m *MockSessionService) GetCommitStats(params CommitStatsParams) (*CommitStatsResponse, error) {
+	m.LastCommitStatsParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.CommitStats, nil
+}
+
+func (m *MockSessionService) Search(params SearchParams) (*SearchResponse, error) {
+	m.LastSearchParams = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.SearchResponse, nil
+}
+
+func (m *MockSessionService) ListErrorPatterns(projectName string, groupID *int64, toolName string, limit, offset int) (*ErrorPatternListResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.ErrorPatterns, nil
+}
+
+func (m *MockSessionService) GetErrorOccurrences(patternID int64, limit, offset int) (*ErrorOccurrenceListResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.ErrorOccurrences, nil
+}
+
 func TestHealthHandler(t *testing.T) {
 	mockDB := &db.DB{}
 	mockParser := parser.NewParser(t.TempDir())
@@ -249,6 +439,139 @@ func TestListSessionsHandler(t *testing.T) {
 	}
 }
 
+func TestListSessionsHandler_Params(t *testing.T) {
+	newHandler := func(service SessionService) *Handler {
+		mockDB := &db.DB{}
+		mockParser := parser.NewParser(t.TempDir())
+		mockScanManager := scanner.NewScanManager(mockDB, mockParser)
+		return NewHandler(service, mockScanManager)
+	}
+
+	t.Run("フィルタと並び順がサービスに渡される", func(t *testing.T) {
+		mockService := &MockSessionService{}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions?project=p&groupId=3&branch=main&model=claude-opus-4-5&tool=Bash&version=2.0.0&from=2026-01-01&to=2026-01-31&minTokens=100&maxTokens=5000&hasErrors=true&sort=tokens&order=asc&cursor=abc&limit=5000", nil)
+		w := httptest.NewRecorder()
+
+		handler.listSessionsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		params := mockService.LastSessionListParams
+		if params.ProjectName != "p" || params.GroupID == nil || *params.GroupID != 3 || params.GitBranch != "main" {
+			t.Errorf("Unexpected scope params: %+v", params)
+		}
+		if params.Model != "claude-opus-4-5" || params.ToolName != "Bash" || params.Version != "2.0.0" {
+			t.Errorf("Unexpected filter params: %+v", params)
+		}
+		if params.From != "2026-01-01" || params.To != "2026-01-31" {
+			t.Errorf("Unexpected date range: %s - %s", params.From, params.To)
+		}
+		if params.MinTokens == nil || *params.MinTokens != 100 || params.MaxTokens == nil || *params.MaxTokens != 5000 {
+			t.Errorf("Unexpected token range: %v - %v", params.MinTokens, params.MaxTokens)
+		}
+		if params.HasErrors == nil || !*params.HasErrors {
+			t.Errorf("Expected hasErrors=true, got %v", params.HasErrors)
+		}
+		if params.SortBy != "tokens" || params.Order != "asc" || params.Cursor != "abc" {
+			t.Errorf("Unexpected sort params: %+v", params)
+		}
+		if params.Limit != maxSessionListLimit {
+			t.Errorf("Expected limit to be capped at %d, got %d", maxSessionListLimit, params.Limit)
+		}
+	})
+
+	t.Run("省略時は開始時刻の降順", func(t *testing.T) {
+		mockService := &MockSessionService{}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		w := httptest.NewRecorder()
+
+		handler.listSessionsHandler(w, req)
+
+		params := mockService.LastSessionListParams
+		if params.SortBy != "startTime" || params.Order != "desc" || params.Limit != maxSessionListLimit {
+			t.Errorf("Unexpected default params: %+v", params)
+		}
+		if params.MinTokens != nil || params.HasErrors != nil {
+			t.Errorf("Expected optional filters to be nil: %+v", params)
+		}
+	})
+
+	t.Run("不正なパラメータは400", func(t *testing.T) {
+		tests := []string{
+			"groupId=abc",
+			"from=2026/01/01",
+			"minTokens=-1",
+			"maxTokens=many",
+			"hasErrors=yes",
+			"sort=cost",
+			"order=up",
+			"limit=0",
+		}
+		for _, query := range tests {
+			t.Run(query, func(t *testing.T) {
+				handler := newHandler(&MockSessionService{})
+
+				req := httptest.NewRequest(http.MethodGet, "/api/sessions?"+query, nil)
+				w := httptest.NewRecorder()
+
+				handler.listSessionsHandler(w, req)
+
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("Expected status 400, got %d", w.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("不正なカーソルは400", func(t *testing.T) {
+		mockService := &MockSessionService{err: fmt.Errorf("failed to list sessions: %w", db.ErrInvalidCursor)}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions?cursor=broken", nil)
+		w := httptest.NewRecorder()
+
+		handler.listSessionsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("存在しないグループは404", func(t *testing.T) {
+		mockService := &MockSessionService{err: fmt.Errorf("group not found: %w", db.ErrProjectGroupNotFound)}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions?groupId=99", nil)
+		w := httptest.NewRecorder()
+
+		handler.listSessionsHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("グループ指定時もその他のエラーは500", func(t *testing.T) {
+		mockService := &MockSessionService{err: errors.New("database is locked")}
+		handler := newHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions?groupId=3", nil)
+		w := httptest.NewRecorder()
+
+		handler.listSessionsHandler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+	})
+}
+
 func TestGetSessionHandler(t *testing.T) {
 	startTime := time.Date(2026, 1, 11, 3, 24, 10, 0, time.UTC)
 	endTime := time.Date(2026, 1, 11, 3, 30, 0, 0, time.UTC)
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"sort"
 	"time"
 
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
 	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/gitutil"
 	"github.com/a-tak/ccloganalysis/internal/logger"
 	"github.com/a-tak/ccloganalysis/internal/parser"
 )
@@ -85,32 +88,54 @@ func (s *DatabaseSessionService) ListProjects() ([]ProjectResponse, error) {
 	return projects, nil
 }
 
-// ListSessions returns all sessions for a project (or all projects if projectName is empty)
-func (s *DatabaseSessionService) ListSessions(projectName string) ([]SessionSummary, error) {
-	var projectID *int64
-	var err error
+// ListSessions returns a page of sessions matching the filters (all projects if projectName is empty)
+func (s *DatabaseSessionService) ListSessions(params SessionListParams) (*SessionListResponse, error) {
+	filter := db.SessionListFilter{
+		GroupID:   params.GroupID,
+		GitBranch: params.GitBranch,
+		Model:     params.Model,
+		ToolName:  params.ToolName,
+		Version:   params.Version,
+		From:      params.From,
+		To:        params.To,
+		MinTokens: params.MinTokens,
+		MaxTokens: params.MaxTokens,
+		HasErrors: params.HasErrors,
+		SortBy:    params.SortBy,
+		Ascending: params.Order == "asc",
+		Cursor:    params.Cursor,
+		Limit:     params.Limit,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = maxSessionListLimit
+	}
 
 	// プロジェクト名が指定されている場合、プロジェクトIDを取得
-	if projectName != "" {
-		project, err := s.db.GetProjectByName(projectName)
+	if params.ProjectName != "" {
+		project, err := s.db.GetProjectByName(params.ProjectName)
 		if err != nil {
 			// プロジェクトが存在しない場合は警告を出力して空のリストを返す
 			s.logger.WarnWithContext("Project not found in database", map[string]interface{}{
-				"project": projectName,
+				"project": params.ProjectName,
 			})
-			return []SessionSummary{}, nil
+			return &SessionListResponse{Sessions: []SessionSummary{}}, nil
 		}
-		projectID = &project.ID
+		filter.ProjectID = &project.ID
 	}
 
-	// セッション一覧を取得（limit=1000, offset=0）
-	sessionRows, err := s.db.ListSessions(projectID, 1000, 0)
+	if params.GroupID != nil {
+		if _, err := s.db.GetProjectGroupByID(*params.GroupID); err != nil {
+			return nil, fmt.Errorf("group not found: %w", err)
+		}
+	}
+
+	page, err := s.db.ListSessionsPage(filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	summaries := make([]SessionSummary, 0, len(sessionRows))
-	for _, row := range sessionRows {
+	summaries := make([]SessionSummary, 0, len(page.Sessions))
+	for _, row := range page.Sessions {
 		// プロジェクト名を取得
 		project, err := s.db.GetProjectByID(row.ProjectID)
 		if err != nil {
@@ -126,34 +151,37 @@ func (s *DatabaseSessionService) ListSessions(projectName string) ([]SessionSumm
 			StartTime:        row.StartTime,
 			EndTime:          row.EndTime,
 			TotalTokens:      totalTokens,
+			EstimatedCostUSD: row.TotalCostUSD,
 			ErrorCount:       row.ErrorCount,
 			FirstUserMessage: row.FirstUserMessage,
+			SubagentCount:    row.SubagentCount,
+			SubagentTokens:   row.SubagentTokens,
+
+			PeakContextTokens: row.PeakContextTokens,
+			CompactionCount:   row.CompactionCount,
+			RetryCount:        row.RetryCount,
 		})
 	}
 
-	return summaries, nil
+	return &SessionListResponse{
+		Sessions:   summaries,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}, nil
 }
 
-// GetSession returns detailed session information from the database
-func (s *DatabaseSessionService) GetSession(projectName, sessionID string) (*SessionDetailResponse, error) {
-	// プロジェクトの存在確認
-	project, err := s.db.GetProjectByName(projectName)
-	if err != nil {
-		return nil, fmt.Errorf("project not found: %w", err)
-	}
-
-	// セッションを取得
-	session, err := s.db.GetSession(sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
-	}
-
-	// プロジェクトパスを設定（セッションがどのプロジェクトに属するか確認するため）
-	// 実際には、GetSessionで既にProjectPathが設定されているはず
-	if session.ProjectPath == "" {
-		session.ProjectPath = project.DecodedPath
-	}
+// sessionOverview holds the token, cost, subagent and task figures shared by the session detail and summary
+type sessionOverview struct {
+	totalTokens TokenSummaryResponse
+	totalCost   float64
+	modelUsage  []ModelUsageResponse
+	subagents   []SubagentRunResponse
+	agentTokens AgentTokenSplitResponse
+	tasks       []TaskResponse
+}
 
+// buildSessionOverview converts the token usage of a session and collects its subagent runs and tasks
+func (s *DatabaseSessionService) buildSessionOverview(session *parser.Session) (*sessionOverview, error) {
 	// トークン集計を変換
 	totalTokens := TokenSummaryResponse{
 		InputTokens:              session.TotalTokens.InputTokens,
@@ -163,9 +191,12 @@ func (s *DatabaseSessionService) GetSession(projectName, sessionID string) (*Ses
 		TotalTokens:              session.TotalTokens.InputTokens + session.TotalTokens.OutputTokens,
 	}
 
-	// モデル使用量を変換
+	// モデル使用量を変換（推定コストはセッション開始時点の料金で算出）
 	modelUsage := make([]ModelUsageResponse, 0, len(session.ModelUsage))
+	totalCost := 0.0
 	for model, usage := range session.ModelUsage {
+		cost := s.db.EstimateCost(model, session.StartTime, usage)
+		totalCost += cost
 		modelUsage = append(modelUsage, ModelUsageResponse{
 			Model: model,
 			Tokens: TokenSummaryResponse{
@@ -175,17 +206,133 @@ func (s *DatabaseSessionService) GetSession(projectName, sessionID string) (*Ses
 				CacheReadInputTokens:     usage.CacheReadInputTokens,
 				TotalTokens:              usage.InputTokens + usage.OutputTokens,
 			},
+			EstimatedCostUSD: cost,
+		})
+	}
+
+	// サブエージェントの実行を取得（サブエージェント自身のセッションは子を持たない）
+	subagents := []SubagentRunResponse{}
+	agentTokens := AgentTokenSplitResponse{
+		MainAgent:        totalTokens,
+		MainAgentCostUSD: totalCost,
+	}
+	if session.AgentID == "" {
+		runs, err := s.db.ListSubagentRuns(session.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list subagent runs: %w", err)
+		}
+		for _, run := range runs {
+			tokens := TokenSummaryResponse{
+				InputTokens:              run.TotalInputTokens,
+				OutputTokens:             run.TotalOutputTokens,
+				CacheCreationInputTokens: run.TotalCacheCreationTokens,
+				CacheReadInputTokens:     run.TotalCacheReadTokens,
+				TotalTokens:              run.TotalInputTokens + run.TotalOutputTokens,
+			}
+			subagents = append(subagents, SubagentRunResponse{
+				SessionID:        run.SessionID,
+				AgentID:          run.AgentID,
+				ToolUseID:        run.ToolUseID,
+				Description:      run.Description,
+				SubagentType:     run.SubagentType,
+				StartTime:        run.StartTime,
+				EndTime:          run.EndTime,
+				Tokens:           tokens,
+				EstimatedCostUSD: run.TotalCostUSD,
+				ErrorCount:       run.ErrorCount,
+			})
+			addTokenSummary(&agentTokens.Subagents, tokens)
+			agentTokens.SubagentsCostUSD += run.TotalCostUSD
+		}
+		agentTokens.SubagentRuns = len(runs)
+	}
+
+	// ユーザー入力ごとのタスクを取得
+	taskRows, err := s.db.GetSessionTasks(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session tasks: %w", err)
+	}
+
+	return &sessionOverview{
+		totalTokens: totalTokens,
+		totalCost:   totalCost,
+		modelUsage:  modelUsage,
+		subagents:   subagents,
+		agentTokens: agentTokens,
+		tasks:       convertTaskRows(taskRows),
+	}, nil
+}
+
+// convertTaskRows converts task rows to API responses
+func convertTaskRows(rows []*db.TaskRow) []TaskResponse {
+	tasks := make([]TaskResponse, 0, len(rows))
+	for _, row := range rows {
+		tasks = append(tasks, TaskResponse{
+			SessionID:       row.SessionID,
+			ProjectName:     row.ProjectName,
+			GitBranch:       row.GitBranch,
+			Index:           row.Index,
+			StartUUID:       row.StartUUID,
+			Prompt:          row.Prompt,
+			StartTime:       row.StartTime,
+			EndTime:         row.EndTime,
+			DurationSeconds: row.DurationSeconds,
+			Tokens: TokenSummaryResponse{
+				InputTokens:              row.InputTokens,
+				OutputTokens:             row.OutputTokens,
+				CacheCreationInputTokens: row.CacheCreationTokens,
+				CacheReadInputTokens:     row.CacheReadTokens,
+				TotalTokens:              row.InputTokens + row.OutputTokens,
+			},
+			EntryCount:    row.EntryCount,
+			ToolCallCount: row.ToolCallCount,
+			ErrorCount:    row.ErrorCount,
 		})
 	}
+	return tasks
+}
+
+// GetSession returns detailed session information from the database
+func (s *DatabaseSessionService) GetSession(projectName, sessionID string) (*SessionDetailResponse, error) {
+	// プロジェクトの存在確認
+	project, err := s.db.GetProjectByName(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+
+	// セッションを取得
+	session, err := s.db.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// プロジェクトパスを設定（セッションがどのプロジェクトに属するか確認するため）
+	// 実際には、GetSessionで既にProjectPathが設定されているはず
+	if session.ProjectPath == "" {
+		session.ProjectPath = project.DecodedPath
+	}
+
+	overview, err := s.buildSessionOverview(session)
+	if err != nil {
+		return nil, err
+	}
 
 	// ツール呼び出しを変換
 	toolCalls := make([]ToolCallResponse, 0, len(session.ToolCalls))
 	for _, tc := range session.ToolCalls {
+		var durationMs *int64
+		if tc.HasResult {
+			ms := tc.Duration.Milliseconds()
+			durationMs = &ms
+		}
 		toolCalls = append(toolCalls, ToolCallResponse{
-			Timestamp: tc.Timestamp,
-			Name:      tc.Name,
-			Input:     tc.Input,
-			IsError:   tc.IsError,
+			Timestamp:  tc.Timestamp,
+			Name:       tc.Name,
+			Input:      tc.Input,
+			IsError:    tc.IsError,
+			Result:     tc.Result,
+			DurationMs: durationMs,
+			AgentID:    tc.AgentID,
 		})
 	}
 
@@ -212,21 +359,273 @@ func (s *DatabaseSessionService) GetSession(projectName, sessionID string) (*Ses
 	duration := session.EndTime.Sub(session.StartTime)
 
 	return &SessionDetailResponse{
-		ID:          session.ID,
-		ProjectName: projectName,
-		ProjectPath: session.ProjectPath,
-		GitBranch:   session.GitBranch,
-		StartTime:   session.StartTime,
-		EndTime:     session.EndTime,
-		Duration:    formatDuration(duration),
-		TotalTokens: totalTokens,
-		ModelUsage:  modelUsage,
-		ToolCalls:   toolCalls,
-		Messages:    messages,
-		ErrorCount:  session.ErrorCount,
+		ID:               session.ID,
+		ProjectName:      projectName,
+		ProjectPath:      session.ProjectPath,
+		GitBranch:        session.GitBranch,
+		StartTime:        session.StartTime,
+		EndTime:          session.EndTime,
+		Duration:         formatDuration(duration),
+		TotalTokens:      overview.totalTokens,
+		EstimatedCostUSD: overview.totalCost,
+		ModelUsage:       overview.modelUsage,
+		ToolCalls:        toolCalls,
+		Messages:         messages,
+		ErrorCount:       session.ErrorCount,
+		ParentSessionID:  session.ParentSessionID,
+		AgentID:          session.AgentID,
+		Subagents:        overview.subagents,
+		AgentTokens:      overview.agentTokens,
+		Tasks:            overview.tasks,
 	}, nil
 }
 
+// GetSessionSummary returns session details without loading the conversation and tool calls
+func (s *DatabaseSessionService) GetSessionSummary(projectName, sessionID string) (*SessionDetailSummaryResponse, error) {
+	project, err := s.db.GetProjectByName(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+
+	session, err := s.db.GetSessionHeader(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.ProjectPath == "" {
+		session.ProjectPath = project.DecodedPath
+	}
+
+	overview, err := s.buildSessionOverview(session)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.db.GetSessionContentCounts(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count session contents: %w", err)
+	}
+
+	return &SessionDetailSummaryResponse{
+		ID:               session.ID,
+		ProjectName:      projectName,
+		ProjectPath:      session.ProjectPath,
+		GitBranch:        session.GitBranch,
+		StartTime:        session.StartTime,
+		EndTime:          session.EndTime,
+		Duration:         formatDuration(session.EndTime.Sub(session.StartTime)),
+		TotalTokens:      overview.totalTokens,
+		EstimatedCostUSD: overview.totalCost,
+		ModelUsage:       overview.modelUsage,
+		ErrorCount:       session.ErrorCount,
+		EntryCount:       counts.Entries,
+		MessageCount:     counts.Messages,
+		ToolCallCount:    counts.ToolCalls,
+		ParentSessionID:  session.ParentSessionID,
+		AgentID:          session.AgentID,
+		Subagents:        overview.subagents,
+		AgentTokens:      overview.agentTokens,
+		Tasks:            overview.tasks,
+	}, nil
+}
+
+// ListSessionEntries returns a page of the log entries of a session in timestamp order
+func (s *DatabaseSessionService) ListSessionEntries(projectName, sessionID string, params SessionEntryParams) (*SessionEntryListResponse, error) {
+	if _, err := s.db.GetProjectByName(projectName); err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+	if _, err := s.db.GetSessionHeader(sessionID); err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	page, err := s.db.ListSessionEntries(db.EntryListFilter{
+		SessionID: sessionID,
+		EntryType: params.EntryType,
+		Role:      params.Role,
+		Cursor:    params.Cursor,
+		Limit:     params.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session entries: %w", err)
+	}
+
+	entries := make([]SessionEntryResponse, 0, len(page.Entries))
+	for _, entry := range page.Entries {
+		item := SessionEntryResponse{
+			UUID:      entry.UUID,
+			Type:      entry.Type,
+			Timestamp: entry.Timestamp,
+		}
+		if entry.ParentUUID != nil {
+			item.ParentUUID = *entry.ParentUUID
+		}
+		if entry.Message != nil {
+			item.Role = entry.Message.Role
+			item.Model = entry.Message.Model
+			content := entry.Message.Content
+			if params.OmitToolIO {
+				content = omitToolIO(content)
+			}
+			item.Content = content
+		}
+		entries = append(entries, item)
+	}
+
+	return &SessionEntryListResponse{
+		Entries:    entries,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+// ListTasks returns a page of the tasks of top-level sessions
+func (s *DatabaseSessionService) ListTasks(params TaskListParams) (*TaskListResponse, error) {
+	projectID, groupID, err := s.resolveScope(params.ProjectName, params.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.db.ListTasks(db.TaskListFilter{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		GitBranch: params.GitBranch,
+		From:      params.From,
+		To:        params.To,
+		SortBy:    params.SortBy,
+		Ascending: params.Order == "asc",
+		Limit:     params.Limit,
+		Offset:    params.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	return &TaskListResponse{
+		Tasks: convertTaskRows(page.Tasks),
+		Total: page.Total,
+	}, nil
+}
+
+// GetContextUsage returns the context size of each API request and the compactions of a session
+func (s *DatabaseSessionService) GetContextUsage(projectName, sessionID string) (*ContextUsageResponse, error) {
+	if _, err := s.db.GetProjectByName(projectName); err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+
+	usage, err := s.db.GetContextUsage(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get context usage: %w", err)
+	}
+
+	points := make([]ContextPointResponse, 0, len(usage.Points))
+	for _, point := range usage.Points {
+		points = append(points, ContextPointResponse{
+			UUID:                     point.UUID,
+			Timestamp:                point.Timestamp,
+			Model:                    point.Model,
+			InputTokens:              point.InputTokens,
+			CacheCreationInputTokens: point.CacheCreationTokens,
+			CacheReadInputTokens:     point.CacheReadTokens,
+			ContextTokens:            point.ContextTokens,
+		})
+	}
+
+	compactions := make([]CompactionResponse, 0, len(usage.Compactions))
+	for _, compaction := range usage.Compactions {
+		compactions = append(compactions, CompactionResponse{
+			UUID:      compaction.UUID,
+			Timestamp: compaction.Timestamp,
+			Trigger:   compaction.Trigger,
+			PreTokens: compaction.PreTokens,
+		})
+	}
+
+	return &ContextUsageResponse{
+		SessionID:         sessionID,
+		PeakContextTokens: usage.PeakContextTokens,
+		CompactionCount:   usage.CompactionCount,
+		Points:            points,
+		Compactions:       compactions,
+	}, nil
+}
+
+// GetConversationTree rebuilds the conversation tree of a session and separates the main path from abandoned branches
+func (s *DatabaseSessionService) GetConversationTree(projectName, sessionID string) (*ConversationTreeResponse, error) {
+	if _, err := s.db.GetProjectByName(projectName); err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+	if _, err := s.db.GetSessionHeader(sessionID); err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	tree, err := s.db.GetConversationTree(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation tree: %w", err)
+	}
+
+	nodes := make([]ConversationNodeResponse, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		node := ConversationNodeResponse{
+			UUID:         entry.UUID,
+			ParentUUID:   entry.ParentUUID,
+			Type:         entry.Type,
+			Timestamp:    entry.Timestamp,
+			IsPrompt:     entry.IsPrompt,
+			MainPath:     tree.MainPath[entry.UUID],
+			InputTokens:  entry.InputTokens,
+			OutputTokens: entry.OutputTokens,
+		}
+		if branch, ok := tree.BranchOf[entry.UUID]; ok {
+			node.Branch = &branch
+		}
+		nodes = append(nodes, node)
+	}
+
+	branches := make([]AbandonedBranchResponse, 0, len(tree.Branches))
+	for _, branch := range tree.Branches {
+		branches = append(branches, AbandonedBranchResponse{
+			ForkUUID:     branch.ForkUUID,
+			FirstUUID:    branch.FirstUUID,
+			EntryCount:   branch.EntryCount,
+			InputTokens:  branch.InputTokens,
+			OutputTokens: branch.OutputTokens,
+			TotalTokens:  branch.InputTokens + branch.OutputTokens,
+			StartTime:    branch.StartTime,
+			EndTime:      branch.EndTime,
+		})
+	}
+
+	roots := tree.Roots
+	if roots == nil {
+		roots = []string{}
+	}
+	inputTokens, outputTokens := tree.AbandonedTokens()
+
+	return &ConversationTreeResponse{
+		SessionID:       sessionID,
+		Roots:           roots,
+		Nodes:           nodes,
+		Branches:        branches,
+		MainPathEntries: len(tree.MainPath),
+		Abandoned: AbandonedBranchSummaryResponse{
+			Branches:     len(tree.Branches),
+			InputTokens:  inputTokens,
+			OutputTokens: outputTokens,
+			TotalTokens:  inputTokens + outputTokens,
+		},
+	}, nil
+}
+
+// omitToolIO returns a copy of the content blocks without tool inputs and tool results
+func omitToolIO(content []parser.Content) []parser.Content {
+	result := make([]parser.Content, len(content))
+	for i, c := range content {
+		c.Input = nil
+		c.ToolResultContent = nil
+		result[i] = c
+	}
+	return result
+}
+
 // Analyze triggers log analysis and synchronization to database
 func (s *DatabaseSessionService) Analyze(projectNames []string) (*AnalyzeResponse, error) {
 	if s.parser == nil {
@@ -278,7 +677,7 @@ func (s *DatabaseSessionService) Analyze(projectNames []string) (*AnalyzeRespons
 }
 
 // GetProjectStats returns project-level statistics
-func (s *DatabaseSessionService) GetProjectStats(projectName string) (*ProjectStatsResponse, error) {
+func (s *DatabaseSessionService) GetProjectStats(projectName, version string) (*ProjectStatsResponse, error) {
 	// プロジェクトの存在確認
 	project, err := s.db.GetProjectByName(projectName)
 	if err != nil {
@@ -286,11 +685,16 @@ func (s *DatabaseSessionService) GetProjectStats(projectName string) (*ProjectSt
 	}
 
 	// プロジェクト統計を取得
-	stats, err := s.db.GetProjectStats(project.ID)
+	stats, err := s.db.GetProjectStats(project.ID, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project stats: %w", err)
 	}
 
+	agentSplit, err := s.db.GetAgentTokenSplit(&project.ID, nil, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent token split: %w", err)
+	}
+
 	return &ProjectStatsResponse{
 		TotalSessions:            stats.TotalSessions,
 		TotalInputTokens:         stats.TotalInputTokens,
@@ -298,15 +702,30 @@ func (s *DatabaseSessionService) GetProjectStats(projectName string) (*ProjectSt
 		TotalCacheCreationTokens: stats.TotalCacheCreationTokens,
 		TotalCacheReadTokens:     stats.TotalCacheReadTokens,
 		TotalTokens:              stats.TotalInputTokens + stats.TotalOutputTokens,
+		EstimatedCostUSD:         stats.EstimatedCostUSD,
 		AvgTokens:                stats.AvgTokens,
 		FirstSession:             stats.FirstSession,
 		LastSession:              stats.LastSession,
 		ErrorRate:                stats.ErrorRate,
+		ActiveSeconds:            stats.ActiveSeconds,
+		IdleSeconds:              stats.IdleSeconds,
+		AgentTokens:              convertToAgentTokenSplitResponse(agentSplit),
+		AbandonedBranches: AbandonedBranchSummaryResponse{
+			Branches:     stats.AbandonedBranches,
+			InputTokens:  stats.AbandonedInputTokens,
+			OutputTokens: stats.AbandonedOutputTokens,
+			TotalTokens:  stats.AbandonedInputTokens + stats.AbandonedOutputTokens,
+		},
+		Context: ContextSummaryResponse{
+			AvgPeakContextTokens: stats.AvgPeakContextTokens,
+			MaxPeakContextTokens: stats.MaxPeakContextTokens,
+			Compactions:          stats.Compactions,
+		},
 	}, nil
 }
 
 // GetProjectTimeline returns time-series statistics for a project
-func (s *DatabaseSessionService) GetProjectTimeline(projectName, period string, limit int) (*TimeSeriesResponse, error) {
+func (s *DatabaseSessionService) GetProjectTimeline(projectName, period string, limit int, version string) (*TimeSeriesResponse, error) {
 	// プロジェクトの存在確認
 	project, err := s.db.GetProjectByName(projectName)
 	if err != nil {
@@ -324,7 +743,7 @@ func (s *DatabaseSessionService) GetProjectTimeline(projectName, period string,
 	}
 
 	// 時系列統計を取得
-	timeSeriesStats, err := s.db.GetTimeSeriesStats(project.ID, period, limit)
+	timeSeriesStats, err := s.db.GetTimeSeriesStats(project.ID, period, limit, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get timeline stats: %w", err)
 	}
@@ -459,7 +878,7 @@ func (s *DatabaseSessionService) GetProjectGroup(groupID int64) (*ProjectGroupDe
 }
 
 // GetProjectGroupStats returns statistics for a project group
-func (s *DatabaseSessionService) GetProjectGroupStats(groupID int64) (*ProjectGroupStatsResponse, error) {
+func (s *DatabaseSessionService) GetProjectGroupStats(groupID int64, version string) (*ProjectGroupStatsResponse, error) {
 	// グループの存在確認
 	_, err := s.db.GetProjectGroupByID(groupID)
 	if err != nil {
@@ -467,11 +886,16 @@ func (s *DatabaseSessionService) GetProjectGroupStats(groupID int64) (*ProjectGr
 	}
 
 	// グループ統計を取得
-	stats, err := s.db.GetGroupStats(groupID)
+	stats, err := s.db.GetGroupStats(groupID, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get group stats: %w", err)
 	}
 
+	agentSplit, err := s.db.GetAgentTokenSplit(nil, &groupID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent token split: %w", err)
+	}
+
 	return &ProjectGroupStatsResponse{
 		TotalProjects:            stats.TotalProjects,
 		TotalSessions:            stats.TotalSessions,
@@ -479,15 +903,19 @@ func (s *DatabaseSessionService) GetProjectGroupStats(groupID int64) (*ProjectGr
 		TotalOutputTokens:        stats.TotalOutputTokens,
 		TotalCacheCreationTokens: stats.TotalCacheCreationTokens,
 		TotalCacheReadTokens:     stats.TotalCacheReadTokens,
+		EstimatedCostUSD:         stats.EstimatedCostUSD,
 		AvgTokens:                stats.AvgTokens,
 		FirstSession:             stats.FirstSession,
 		LastSession:              stats.LastSession,
 		ErrorRate:                stats.ErrorRate,
+		ActiveSeconds:            stats.ActiveSeconds,
+		IdleSeconds:              stats.IdleSeconds,
+		AgentTokens:              convertToAgentTokenSplitResponse(agentSplit),
 	}, nil
 }
 
 // GetProjectGroupTimeline returns time-series statistics for a project group
-func (s *DatabaseSessionService) GetProjectGroupTimeline(groupID int64, period string, limit int) (*TimeSeriesResponse, error) {
+func (s *DatabaseSessionService) GetProjectGroupTimeline(groupID int64, period string, limit int, version string) (*TimeSeriesResponse, error) {
 	// グループの存在確認
 	_, err := s.db.GetProjectGroupByID(groupID)
 	if err != nil {
@@ -505,7 +933,7 @@ func (s *DatabaseSessionService) GetProjectGroupTimeline(groupID int64, period s
 	}
 
 	// 時系列統計を取得
-	timeSeriesStats, err := s.db.GetGroupTimeSeriesStats(groupID, period, limit)
+	timeSeriesStats, err := s.db.GetGroupTimeSeriesStats(groupID, period, limit, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get group timeline stats: %w", err)
 	}
@@ -517,12 +945,17 @@ func (s *DatabaseSessionService) GetProjectGroupTimeline(groupID int64, period s
 }
 
 // GetTotalStats returns total statistics across all projects
-func (s *DatabaseSessionService) GetTotalStats() (*TotalStatsResponse, error) {
-	stats, err := s.db.GetTotalStats()
+func (s *DatabaseSessionService) GetTotalStats(version string) (*TotalStatsResponse, error) {
+	stats, err := s.db.GetTotalStats(version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total stats: %w", err)
 	}
 
+	agentSplit, err := s.db.GetAgentTokenSplit(nil, nil, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent token split: %w", err)
+	}
+
 	return &TotalStatsResponse{
 		TotalGroups:              stats.TotalGroups,
 		TotalProjects:            stats.TotalProjects,
@@ -532,15 +965,19 @@ func (s *DatabaseSessionService) GetTotalStats() (*TotalStatsResponse, error) {
 		TotalCacheCreationTokens: stats.TotalCacheCreationTokens,
 		TotalCacheReadTokens:     stats.TotalCacheReadTokens,
 		TotalTokens:              stats.TotalInputTokens + stats.TotalOutputTokens,
+		EstimatedCostUSD:         stats.EstimatedCostUSD,
 		AvgTokens:                stats.AvgTokens,
 		FirstSession:             stats.FirstSession,
 		LastSession:              stats.LastSession,
 		ErrorRate:                stats.ErrorRate,
+		ActiveSeconds:            stats.ActiveSeconds,
+		IdleSeconds:              stats.IdleSeconds,
+		AgentTokens:              convertToAgentTokenSplitResponse(agentSplit),
 	}, nil
 }
 
 // GetTotalTimeline returns time-series statistics across all projects
-func (s *DatabaseSessionService) GetTotalTimeline(period string, limit int) (*TimeSeriesResponse, error) {
+func (s *DatabaseSessionService) GetTotalTimeline(period string, limit int, version string) (*TimeSeriesResponse, error) {
 	// periodのデフォルト値
 	if period == "" {
 		period = "day"
@@ -552,7 +989,7 @@ func (s *DatabaseSessionService) GetTotalTimeline(period string, limit int) (*Ti
 	}
 
 	// 時系列統計を取得
-	timeSeriesStats, err := s.db.GetTotalTimeSeriesStats(period, limit)
+	timeSeriesStats, err := s.db.GetTotalTimeSeriesStats(period, limit, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total timeline stats: %w", err)
 	}
@@ -659,6 +1096,782 @@ func (s *DatabaseSessionService) GetProjectDailyStats(projectName string, date s
 	}, nil
 }
 
+// resolveScope converts an optional project name and group ID into database filter IDs
+func (s *DatabaseSessionService) resolveScope(projectName string, groupID *int64) (*int64, *int64, error) {
+	var projectID *int64
+	if projectName != "" {
+		project, err := s.db.GetProjectByName(projectName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("project not found: %w", err)
+		}
+		projectID = &project.ID
+	}
+
+	if groupID != nil {
+		if _, err := s.db.GetProjectGroupByID(*groupID); err != nil {
+			return nil, nil, fmt.Errorf("group not found: %w", err)
+		}
+	}
+
+	return projectID, groupID, nil
+}
+
+// GetToolStats returns per-tool error rates and latency percentiles
+func (s *DatabaseSessionService) GetToolStats(projectName string, groupID *int64, version string) (*ToolStatsResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetToolStats(projectID, groupID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tool stats: %w", err)
+	}
+
+	tools := make([]ToolStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		tools = append(tools, ToolStatsItem{
+			ToolName:     stat.ToolName,
+			CallCount:    stat.CallCount,
+			ErrorCount:   stat.ErrorCount,
+			ErrorRate:    stat.ErrorRate,
+			P50LatencyMs: stat.P50LatencyMs,
+			P95LatencyMs: stat.P95LatencyMs,
+		})
+	}
+
+	return &ToolStatsResponse{
+		Tools: tools,
+	}, nil
+}
+
+// GetRetryStats returns retry counts and first-attempt success rates overall, per tool and per model
+func (s *DatabaseSessionService) GetRetryStats(projectName string, groupID *int64, version string) (*RetryStatsResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetRetryStats(projectID, groupID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retry stats: %w", err)
+	}
+
+	return &RetryStatsResponse{
+		Total:  convertRetryStats(stats.Total),
+		Tools:  convertRetryStatsList(stats.ByTool),
+		Models: convertRetryStatsList(stats.ByModel),
+	}, nil
+}
+
+// convertRetryStatsList converts a list of db.RetryStats to API items
+func convertRetryStatsList(stats []db.RetryStats) []RetryStatsItem {
+	items := make([]RetryStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, convertRetryStats(stat))
+	}
+	return items
+}
+
+// convertRetryStats converts db.RetryStats to an API item
+func convertRetryStats(stat db.RetryStats) RetryStatsItem {
+	return RetryStatsItem{
+		ToolName:              stat.ToolName,
+		Model:                 stat.Model,
+		Operations:            stat.Operations,
+		FirstAttemptSuccesses: stat.FirstAttemptSuccesses,
+		FirstAttemptRate:      stat.FirstAttemptRate,
+		Retries:               stat.Retries,
+		Recovered:             stat.Recovered,
+	}
+}
+
+// GetCommandStats returns the frequency of slash commands and the tokens spent after them, overall and per project
+func (s *DatabaseSessionService) GetCommandStats(projectName string, groupID *int64) (*CommandStatsResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetCommandStats(projectID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get command stats: %w", err)
+	}
+
+	return &CommandStatsResponse{
+		Commands: convertCommandStatsList(stats.ByCommand),
+		Projects: convertCommandStatsList(stats.ByProject),
+	}, nil
+}
+
+// convertCommandStatsList converts a list of db.CommandStats to API items
+func convertCommandStatsList(stats []db.CommandStats) []CommandStatsItem {
+	items := make([]CommandStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		totalTokens := stat.InputTokens + stat.OutputTokens
+		items = append(items, CommandStatsItem{
+			Name:        stat.Name,
+			Custom:      stat.Custom,
+			ProjectName: stat.ProjectName,
+			Invocations: stat.Invocations,
+			Sessions:    stat.Sessions,
+			Projects:    stat.Projects,
+			Tokens: TokenSummaryResponse{
+				InputTokens:              stat.InputTokens,
+				OutputTokens:             stat.OutputTokens,
+				CacheCreationInputTokens: stat.CacheCreationTokens,
+				CacheReadInputTokens:     stat.CacheReadTokens,
+				TotalTokens:              totalTokens,
+			},
+			AvgTokens: float64(totalTokens) / float64(stat.Invocations),
+		})
+	}
+	return items
+}
+
+// GetSessionFiles returns the files edited in a session with their line changes
+func (s *DatabaseSessionService) GetSessionFiles(projectName, sessionID string) (*SessionFilesResponse, error) {
+	if _, err := s.db.GetProjectByName(projectName); err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+	if _, err := s.db.GetSessionHeader(sessionID); err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	stats, err := s.db.GetSessionFileEdits(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session file edits: %w", err)
+	}
+
+	response := &SessionFilesResponse{
+		SessionID:    sessionID,
+		FilesTouched: len(stats),
+		Files:        convertFileEditStats(stats),
+	}
+	for _, stat := range stats {
+		response.Edits += stat.Edits
+		response.LinesAdded += stat.LinesAdded
+		response.LinesRemoved += stat.LinesRemoved
+	}
+	return response, nil
+}
+
+// GetProjectFiles returns the most edited files of a project and its churn per period
+func (s *DatabaseSessionService) GetProjectFiles(projectName string, params ProjectFilesParams) (*ProjectFilesResponse, error) {
+	project, err := s.db.GetProjectByName(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+
+	if params.Period == "" {
+		params.Period = "day"
+	}
+	filter := db.FileEditFilter{From: params.From, To: params.To}
+
+	hotFiles, err := s.db.GetProjectHotFiles(project.ID, filter, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hot files: %w", err)
+	}
+
+	churn, err := s.db.GetProjectFileChurn(project.ID, params.Period, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file churn: %w", err)
+	}
+
+	churnItems := make([]FileChurnItem, 0, len(churn))
+	for _, c := range churn {
+		churnItems = append(churnItems, FileChurnItem{
+			Period:       c.Period,
+			Edits:        c.Edits,
+			FilesTouched: c.FilesTouched,
+			LinesAdded:   c.LinesAdded,
+			LinesRemoved: c.LinesRemoved,
+		})
+	}
+
+	return &ProjectFilesResponse{
+		ProjectName: projectName,
+		Period:      params.Period,
+		Files:       convertFileEditStats(hotFiles),
+		Churn:       churnItems,
+	}, nil
+}
+
+// convertFileEditStats converts a list of db.FileEditStats to API items
+func convertFileEditStats(stats []db.FileEditStats) []FileEditItem {
+	items := make([]FileEditItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, FileEditItem{
+			FilePath:     stat.FilePath,
+			Edits:        stat.Edits,
+			Sessions:     stat.Sessions,
+			LinesAdded:   stat.LinesAdded,
+			LinesRemoved: stat.LinesRemoved,
+			LastEditedAt: stat.LastEditedAt,
+		})
+	}
+	return items
+}
+
+// mcpFilter resolves the project and group of MCP statistics parameters
+func (s *DatabaseSessionService) mcpFilter(params MCPStatsParams) (db.MCPFilter, error) {
+	projectID, groupID, err := s.resolveScope(params.ProjectName, params.GroupID)
+	if err != nil {
+		return db.MCPFilter{}, err
+	}
+	return db.MCPFilter{ProjectID: projectID, GroupID: groupID, From: params.From, To: params.To}, nil
+}
+
+// ListMCPServers returns the call, error, latency and result size statistics of each MCP server
+func (s *DatabaseSessionService) ListMCPServers(params MCPStatsParams) (*MCPServerListResponse, error) {
+	filter, err := s.mcpFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetMCPServerStats(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP server stats: %w", err)
+	}
+
+	return &MCPServerListResponse{
+		Servers: convertMCPStatsList(stats, false),
+	}, nil
+}
+
+// GetMCPServer returns the statistics of an MCP server broken down by tool, project and day
+func (s *DatabaseSessionService) GetMCPServer(server string, params MCPStatsParams) (*MCPServerDetailResponse, error) {
+	filter, err := s.mcpFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := s.db.GetMCPServerDetail(server, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP server detail: %w", err)
+	}
+	if detail == nil {
+		return nil, fmt.Errorf("MCP server not found: %s", server)
+	}
+
+	return &MCPServerDetailResponse{
+		Server:   convertMCPStats(detail.Server, false),
+		Tools:    convertMCPStatsList(detail.ByTool, false),
+		Projects: convertMCPStatsList(detail.ByProject, false),
+		Daily:    convertMCPStatsList(detail.Daily, true),
+	}, nil
+}
+
+// convertMCPStatsList converts a list of db.MCPStats to API items
+func convertMCPStatsList(stats []db.MCPStats, daily bool) []MCPStatsItem {
+	items := make([]MCPStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, convertMCPStats(stat, daily))
+	}
+	return items
+}
+
+// convertMCPStats converts db.MCPStats to an API item
+// 日別集計の場合は集計キーを日付として返す
+func convertMCPStats(stat db.MCPStats, daily bool) MCPStatsItem {
+	item := MCPStatsItem{
+		CallCount:        stat.CallCount,
+		ErrorCount:       stat.ErrorCount,
+		ErrorRate:        stat.ErrorRate,
+		P50LatencyMs:     stat.P50LatencyMs,
+		P95LatencyMs:     stat.P95LatencyMs,
+		TotalResultBytes: stat.TotalResultBytes,
+		AvgResultBytes:   stat.AvgResultBytes,
+		MaxResultBytes:   stat.MaxResultBytes,
+		Tools:            stat.Tools,
+		Sessions:         stat.Sessions,
+	}
+	if daily {
+		item.Date = stat.Key
+	} else {
+		item.Name = stat.Key
+	}
+	return item
+}
+
+// GetBashStats returns the outcome statistics of Bash commands per family and project, and the most failing commands
+func (s *DatabaseSessionService) GetBashStats(params BashStatsParams) (*BashStatsResponse, error) {
+	projectID, groupID, err := s.resolveScope(params.ProjectName, params.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := db.BashFilter{ProjectID: projectID, GroupID: groupID, Version: params.Version, From: params.From, To: params.To}
+	stats, err := s.db.GetBashStats(filter, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Bash stats: %w", err)
+	}
+
+	commands := make([]FailingBashCommandItem, 0, len(stats.FailingCommands))
+	for _, cmd := range stats.FailingCommands {
+		commands = append(commands, FailingBashCommandItem{
+			Command:      cmd.Command,
+			Program:      cmd.Program,
+			Family:       cmd.Family,
+			Count:        cmd.Count,
+			FailureCount: cmd.FailureCount,
+			FailureRate:  cmd.FailureRate,
+			LastError:    cmd.LastError,
+			LastFailedAt: cmd.LastFailedAt,
+		})
+	}
+
+	return &BashStatsResponse{
+		Families:        convertBashFamilyStats(stats.ByFamily),
+		Projects:        convertBashFamilyStats(stats.ByProject),
+		FailingCommands: commands,
+	}, nil
+}
+
+// convertBashFamilyStats converts a list of db.BashFamilyStats to API items
+func convertBashFamilyStats(stats []db.BashFamilyStats) []BashFamilyStatsItem {
+	items := make([]BashFamilyStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, BashFamilyStatsItem{
+			Family:           stat.Family,
+			ProjectName:      stat.ProjectName,
+			Count:            stat.Count,
+			FailureCount:     stat.FailureCount,
+			InterruptedCount: stat.InterruptedCount,
+			FailureRate:      stat.FailureRate,
+			P50DurationMs:    stat.P50DurationMs,
+			P95DurationMs:    stat.P95DurationMs,
+			TotalDurationMs:  stat.TotalDurationMs,
+		})
+	}
+	return items
+}
+
+// GetInterruptionStats returns the user interruptions and interruption rates overall and per project, model and tool
+func (s *DatabaseSessionService) GetInterruptionStats(projectName string, groupID *int64, version string) (*InterruptionStatsResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetInterruptionStats(projectID, groupID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interruption stats: %w", err)
+	}
+
+	return &InterruptionStatsResponse{
+		Total:    convertInterruptionStats(stats.Total),
+		Projects: convertInterruptionStatsList(stats.ByProject),
+		Models:   convertInterruptionStatsList(stats.ByModel),
+		Tools:    convertInterruptionStatsList(stats.ByTool),
+	}, nil
+}
+
+// convertInterruptionStatsList converts a list of db.InterruptionStats to API items
+func convertInterruptionStatsList(stats []db.InterruptionStats) []InterruptionStatsItem {
+	items := make([]InterruptionStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, convertInterruptionStats(stat))
+	}
+	return items
+}
+
+// convertInterruptionStats converts db.InterruptionStats to an API item
+func convertInterruptionStats(stat db.InterruptionStats) InterruptionStatsItem {
+	return InterruptionStatsItem{
+		Name:       stat.Key,
+		Interrupts: stat.Interrupts,
+		Rejections: stat.Rejections,
+		Cancels:    stat.Cancels,
+		Total:      stat.Total,
+		BaseCount:  stat.BaseCount,
+		Rate:       stat.Rate,
+	}
+}
+
+// ListVersions retrieves statistics per Claude Code version, in the order the versions first appeared
+func (s *DatabaseSessionService) ListVersions(projectName string, groupID *int64) (*VersionListResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.db.GetVersionStats(projectID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version stats: %w", err)
+	}
+
+	versions := make([]VersionStatsItem, 0, len(stats))
+	for _, stat := range stats {
+		versions = append(versions, convertVersionStats(stat))
+	}
+
+	return &VersionListResponse{Versions: versions}, nil
+}
+
+// CompareVersion compares the sessions within days before and after a version first appeared
+func (s *DatabaseSessionService) CompareVersion(version string, days int, projectName string, groupID *int64) (*VersionComparisonResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison, err := s.db.GetVersionComparison(version, days, projectID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version comparison: %w", err)
+	}
+	if comparison == nil {
+		return nil, fmt.Errorf("version not found: %s", version)
+	}
+
+	return &VersionComparisonResponse{
+		Version:         comparison.Version,
+		PreviousVersion: comparison.PreviousVersion,
+		FirstSeen:       comparison.FirstSeen,
+		Days:            comparison.Days,
+		Before:          convertVersionStats(comparison.Before),
+		After:           convertVersionStats(comparison.After),
+	}, nil
+}
+
+// convertVersionStats converts db.VersionStats to an API item
+func convertVersionStats(stat db.VersionStats) VersionStatsItem {
+	return VersionStatsItem{
+		Version:             stat.Version,
+		FirstSeen:           stat.FirstSeen,
+		LastSeen:            stat.LastSeen,
+		Sessions:            stat.Sessions,
+		InputTokens:         stat.InputTokens,
+		OutputTokens:        stat.OutputTokens,
+		CacheCreationTokens: stat.CacheCreationTokens,
+		CacheReadTokens:     stat.CacheReadTokens,
+		EstimatedCostUSD:    stat.CostUSD,
+		AvgTokens:           stat.AvgTokens,
+		ErrorCount:          stat.ErrorCount,
+		ErrorRate:           stat.ErrorRate,
+		Retries:             stat.Retries,
+		RetriesPerSession:   stat.RetriesPerSession,
+	}
+}
+
+// GetCommitStats correlates the sessions with the commits of their local git repositories
+// Commits made during a session or shortly after it ends are attributed to that session.
+// Returns GitAvailable false (with no commits) if the git command is not available.
+func (s *DatabaseSessionService) GetCommitStats(params CommitStatsParams) (*CommitStatsResponse, error) {
+	projectID, groupID, err := s.resolveScope(params.ProjectName, params.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.db.ListCommitSessions(db.CommitFilter{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		From:      params.From,
+		To:        params.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit sessions: %w", err)
+	}
+
+	response := &CommitStatsResponse{
+		GitAvailable:           gitutil.IsGitAvailable(),
+		Commits:                []CommitItem{},
+		SessionsWithoutCommits: []UncommittedSessionItem{},
+		UnreadableGitRoots:     []string{},
+	}
+	for _, session := range sessions {
+		response.Summary.Sessions++
+		response.Summary.TotalTokens += session.TotalTokens
+		response.Summary.EstimatedCostUSD += session.CostUSD
+	}
+	if !response.GitAvailable {
+		return response, nil
+	}
+
+	// リポジトリごとにセッションの期間内のコミットを読み込み、セッションに割り当てる
+	sessionsByRoot := make(map[string][]int)
+	var roots []string
+	for i, session := range sessions {
+		if _, ok := sessionsByRoot[session.GitRoot]; !ok {
+			roots = append(roots, session.GitRoot)
+		}
+		sessionsByRoot[session.GitRoot] = append(sessionsByRoot[session.GitRoot], i)
+	}
+
+	sessionCommits := make([][]gitutil.Commit, len(sessions))
+	unreadable := make(map[string]bool)
+	for _, root := range roots {
+		indices := sessionsByRoot[root]
+		windows := make([]analyzer.CommitSession, len(indices))
+		since, until := sessions[indices[0]].StartTime, time.Time{}
+		for i, index := range indices {
+			session := sessions[index]
+			windows[i] = analyzer.CommitSession{GitBranch: session.GitBranch, StartTime: session.StartTime, EndTime: session.EndTime}
+			if session.EndTime.After(until) {
+				until = session.EndTime
+			}
+		}
+
+		commits, err := gitutil.ReadCommits(root, since, until.Add(analyzer.CommitAttributionWindow))
+		if err != nil {
+			// リポジトリが移動・削除された場合などはそのリポジトリを除いて集計する
+			s.logger.WarnWithContext("Failed to read git commits", map[string]interface{}{
+				"gitRoot": root,
+				"error":   err.Error(),
+			})
+			unreadable[root] = true
+			response.UnreadableGitRoots = append(response.UnreadableGitRoots, root)
+			continue
+		}
+
+		for _, commit := range commits {
+			attributed := analyzer.AttributeCommit(windows, commit.Branch, commit.Time)
+			if attributed < 0 {
+				response.Summary.UnattributedCommits++
+				continue
+			}
+			index := indices[attributed]
+			sessionCommits[index] = append(sessionCommits[index], commit)
+		}
+	}
+
+	for i, session := range sessions {
+		commits := sessionCommits[i]
+		if unreadable[session.GitRoot] {
+			// コミットを読み込めていないため、コミットのないセッションとは判定できない
+			continue
+		}
+		if len(commits) == 0 {
+			response.SessionsWithoutCommits = append(response.SessionsWithoutCommits, UncommittedSessionItem{
+				SessionID:        session.ID,
+				ProjectName:      session.ProjectName,
+				GitBranch:        session.GitBranch,
+				StartTime:        session.StartTime,
+				EndTime:          session.EndTime,
+				TotalTokens:      session.TotalTokens,
+				EstimatedCostUSD: session.CostUSD,
+				FirstUserMessage: session.FirstUserMessage,
+			})
+			continue
+		}
+
+		response.Summary.SessionsWithCommits++
+		for _, commit := range commits {
+			response.Summary.Commits++
+			if commit.CoAuthoredByClaude {
+				response.Summary.ClaudeCoAuthoredCommits++
+			}
+			response.Commits = append(response.Commits, CommitItem{
+				Hash:               commit.Hash,
+				Subject:            commit.Subject,
+				AuthorName:         commit.AuthorName,
+				AuthorEmail:        commit.AuthorEmail,
+				Branch:             commit.Branch,
+				CommittedAt:        commit.Time,
+				CoAuthoredByClaude: commit.CoAuthoredByClaude,
+				SessionID:          session.ID,
+				ProjectName:        session.ProjectName,
+				TotalTokens:        session.TotalTokens / len(commits),
+				EstimatedCostUSD:   session.CostUSD / float64(len(commits)),
+			})
+		}
+	}
+
+	if response.Summary.Commits > 0 {
+		response.Summary.TokensPerCommit = float64(response.Summary.TotalTokens) / float64(response.Summary.Commits)
+		response.Summary.CostPerCommit = response.Summary.EstimatedCostUSD / float64(response.Summary.Commits)
+	}
+
+	// コミットは新しい順、コミットのないセッションはコストの高い順
+	sort.Slice(response.Commits, func(i, j int) bool {
+		return response.Commits[i].CommittedAt.After(response.Commits[j].CommittedAt)
+	})
+	sort.SliceStable(response.SessionsWithoutCommits, func(i, j int) bool {
+		return response.SessionsWithoutCommits[i].EstimatedCostUSD > response.SessionsWithoutCommits[j].EstimatedCostUSD
+	})
+	if params.Limit > 0 {
+		if len(response.Commits) > params.Limit {
+			response.Commits = response.Commits[:params.Limit]
+		}
+		if len(response.SessionsWithoutCommits) > params.Limit {
+			response.SessionsWithoutCommits = response.SessionsWithoutCommits[:params.Limit]
+		}
+	}
+
+	return response, nil
+}
+
+// Search runs a full-text search over conversation history
+func (s *DatabaseSessionService) Search(params SearchParams) (*SearchResponse, error) {
+	projectID, groupID, err := s.resolveScope(params.ProjectName, params.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := db.SearchFilter{
+		Query:     params.Query,
+		ProjectID: projectID,
+		GroupID:   groupID,
+		GitBranch: params.GitBranch,
+		Role:      params.Role,
+		Limit:     params.Limit,
+		Offset:    params.Offset,
+	}
+	if params.From != "" {
+		from, err := time.Parse("2006-01-02", params.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date: %w", err)
+		}
+		filter.From = from
+	}
+	if params.To != "" {
+		to, err := time.Parse("2006-01-02", params.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date: %w", err)
+		}
+		// 終了日を含めるため翌日0時未満で絞り込む
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	results, total, err := s.db.Search(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	items := make([]SearchResultItem, 0, len(results))
+	for _, result := range results {
+		items = append(items, SearchResultItem{
+			SessionID:   result.SessionID,
+			ProjectName: result.ProjectName,
+			GitBranch:   result.GitBranch,
+			EntryUUID:   result.EntryUUID,
+			SourceType:  result.SourceType,
+			Role:        result.Role,
+			ToolName:    result.ToolName,
+			Timestamp:   result.Timestamp,
+			Snippet:     result.Snippet,
+		})
+	}
+
+	return &SearchResponse{
+		Query:   params.Query,
+		Total:   total,
+		Limit:   params.Limit,
+		Offset:  params.Offset,
+		Results: items,
+	}, nil
+}
+
+// ListErrorPatterns returns recurring error patterns ordered by occurrence count
+func (s *DatabaseSessionService) ListErrorPatterns(projectName string, groupID *int64, toolName string, limit, offset int) (*ErrorPatternListResponse, error) {
+	projectID, groupID, err := s.resolveScope(projectName, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.ListErrorPatterns(db.ErrorPatternFilter{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		ToolName:  toolName,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list error patterns: %w", err)
+	}
+
+	patterns := make([]ErrorPatternResponse, 0, len(rows))
+	for _, row := range rows {
+		patterns = append(patterns, convertToErrorPatternResponse(row))
+	}
+
+	return &ErrorPatternListResponse{
+		Patterns: patterns,
+	}, nil
+}
+
+// GetErrorOccurrences returns an error pattern and its occurrences, newest first
+func (s *DatabaseSessionService) GetErrorOccurrences(patternID int64, limit, offset int) (*ErrorOccurrenceListResponse, error) {
+	pattern, err := s.db.GetErrorPattern(patternID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.ListErrorOccurrences(patternID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list error occurrences: %w", err)
+	}
+
+	occurrences := make([]ErrorOccurrenceResponse, 0, len(rows))
+	for _, row := range rows {
+		var input interface{}
+		if row.InputJSON != "" {
+			// 入力が壊れている場合はnilのまま返す
+			_ = json.Unmarshal([]byte(row.InputJSON), &input)
+		}
+		occurrences = append(occurrences, ErrorOccurrenceResponse{
+			ID:           row.ID,
+			SessionID:    row.SessionID,
+			ProjectName:  row.ProjectName,
+			GitBranch:    row.GitBranch,
+			ToolName:     row.ToolName,
+			Input:        input,
+			ErrorMessage: row.ResultText,
+			OccurredAt:   row.OccurredAt,
+		})
+	}
+
+	return &ErrorOccurrenceListResponse{
+		Pattern:     convertToErrorPatternResponse(pattern),
+		Occurrences: occurrences,
+	}, nil
+}
+
+// convertToErrorPatternResponse converts a database error pattern row to API format
+func convertToErrorPatternResponse(row *db.ErrorPatternRow) ErrorPatternResponse {
+	return ErrorPatternResponse{
+		ID:              row.ID,
+		ToolName:        row.ToolName,
+		ErrorMessage:    row.ErrorMessage,
+		OccurrenceCount: row.OccurrenceCount,
+		SessionCount:    row.SessionCount,
+		ProjectCount:    row.ProjectCount,
+		FirstSeen:       row.FirstSeen,
+		LastSeen:        row.LastSeen,
+	}
+}
+
+// convertToAgentTokenSplitResponse converts a database agent token split to API format
+func convertToAgentTokenSplitResponse(split *db.AgentTokenSplit) AgentTokenSplitResponse {
+	return AgentTokenSplitResponse{
+		MainAgent:        convertAgentTokens(split.MainAgent),
+		Subagents:        convertAgentTokens(split.Subagents),
+		MainAgentCostUSD: split.MainAgent.CostUSD,
+		SubagentsCostUSD: split.Subagents.CostUSD,
+		SubagentRuns:     split.SubagentRuns,
+	}
+}
+
+// convertAgentTokens converts database agent tokens to a token summary
+func convertAgentTokens(tokens db.AgentTokens) TokenSummaryResponse {
+	return TokenSummaryResponse{
+		InputTokens:              tokens.InputTokens,
+		OutputTokens:             tokens.OutputTokens,
+		CacheCreationInputTokens: tokens.CacheCreationTokens,
+		CacheReadInputTokens:     tokens.CacheReadTokens,
+		TotalTokens:              tokens.InputTokens + tokens.OutputTokens,
+	}
+}
+
+// addTokenSummary accumulates token counts into dst
+func addTokenSummary(dst *TokenSummaryResponse, src TokenSummaryResponse) {
+	dst.InputTokens += src.InputTokens
+	dst.OutputTokens += src.OutputTokens
+	dst.CacheCreationInputTokens += src.CacheCreationInputTokens
+	dst.CacheReadInputTokens += src.CacheReadInputTokens
+	dst.TotalTokens += src.TotalTokens
+}
+
 // formatDuration formats a duration as a human-readable string
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
@@ -674,6 +1887,10 @@ func formatDuration(d time.Duration) string {
 func convertToTimeSeriesDataPoints(stats []db.TimeSeriesStats) []TimeSeriesDataPoint {
 	dataPoints := make([]TimeSeriesDataPoint, 0, len(stats))
 	for _, ts := range stats {
+		modelStats := make([]ModelTokensDataPoint, 0, len(ts.ModelStats))
+		for _, m := range ts.ModelStats {
+			modelStats = append(modelStats, ModelTokensDataPoint{Model: m.Model, Tokens: m.Tokens})
+		}
 		dataPoints = append(dataPoints, TimeSeriesDataPoint{
 			PeriodStart:              ts.PeriodStart,
 			PeriodEnd:                ts.PeriodEnd,
@@ -683,6 +1900,10 @@ func convertToTimeSeriesDataPoints(stats []db.TimeSeriesStats) []TimeSeriesDataP
 			TotalCacheCreationTokens: ts.TotalCacheCreationTokens,
 			TotalCacheReadTokens:     ts.TotalCacheReadTokens,
 			TotalTokens:              ts.TotalInputTokens + ts.TotalOutputTokens,
+			EstimatedCostUSD:         ts.EstimatedCostUSD,
+			ActiveSeconds:            ts.ActiveSeconds,
+			IdleSeconds:              ts.IdleSeconds,
+			ModelStats:               modelStats,
 		})
 	}
 	return dataPoints
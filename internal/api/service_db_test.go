@@ -2,12 +2,15 @@ package api
 
 import (
 	"bytes"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/gitutil"
 	"github.com/a-tak/ccloganalysis/internal/logger"
 	"github.com/a-tak/ccloganalysis/internal/parser"
 	_ "modernc.org/sqlite"
@@ -248,10 +251,11 @@ func TestDatabaseSessionService_ListSessions(t *testing.T) {
 	createTestData(t, database)
 
 	t.Run("全プロジェクトのセッション一覧を返す", func(t *testing.T) {
-		sessions, err := service.ListSessions("")
+		result, err := service.ListSessions(SessionListParams{})
 		if err != nil {
 			t.Fatalf("ListSessions failed: %v", err)
 		}
+		sessions := result.Sessions
 
 		if len(sessions) != 3 {
 			t.Fatalf("Expected 3 sessions, got %d", len(sessions))
@@ -266,10 +270,11 @@ func TestDatabaseSessionService_ListSessions(t *testing.T) {
 	})
 
 	t.Run("特定プロジェクトのセッション一覧を返す", func(t *testing.T) {
-		sessions, err := service.ListSessions("test-project-1")
+		result, err := service.ListSessions(SessionListParams{ProjectName: "test-project-1"})
 		if err != nil {
 			t.Fatalf("ListSessions failed: %v", err)
 		}
+		sessions := result.Sessions
 
 		if len(sessions) != 2 {
 			t.Fatalf("Expected 2 sessions, got %d", len(sessions))
@@ -283,11 +288,35 @@ func TestDatabaseSessionService_ListSessions(t *testing.T) {
 		}
 	})
 
+	t.Run("カーソルで次のページを取得できる", func(t *testing.T) {
+		first, err := service.ListSessions(SessionListParams{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListSessions failed: %v", err)
+		}
+		if first.Total != 3 || len(first.Sessions) != 2 || first.NextCursor == "" {
+			t.Fatalf("Unexpected first page: total=%d sessions=%d cursor=%q", first.Total, len(first.Sessions), first.NextCursor)
+		}
+
+		second, err := service.ListSessions(SessionListParams{Limit: 2, Cursor: first.NextCursor})
+		if err != nil {
+			t.Fatalf("ListSessions failed: %v", err)
+		}
+		if len(second.Sessions) != 1 || second.NextCursor != "" {
+			t.Fatalf("Unexpected second page: sessions=%d cursor=%q", len(second.Sessions), second.NextCursor)
+		}
+		for _, session := range first.Sessions {
+			if session.ID == second.Sessions[0].ID {
+				t.Errorf("Session %s returned on both pages", session.ID)
+			}
+		}
+	})
+
 	t.Run("存在しないプロジェクト名で空のリストを返す", func(t *testing.T) {
-		sessions, err := service.ListSessions("non-existent-project")
+		result, err := service.ListSessions(SessionListParams{ProjectName: "non-existent-project"})
 		if err != nil {
 			t.Fatalf("ListSessions failed: %v", err)
 		}
+		sessions := result.Sessions
 
 		if len(sessions) != 0 {
 			t.Errorf("Expected 0 sessions, got %d", len(sessions))
@@ -309,10 +338,11 @@ func TestDatabaseSessionService_ListSessions(t *testing.T) {
 		}
 
 		// 存在しないプロジェクトでListSessionsを呼び出す
-		sessions, err := service.ListSessions("non-existent-project")
+		result, err := service.ListSessions(SessionListParams{ProjectName: "non-existent-project"})
 		if err != nil {
 			t.Fatalf("ListSessions failed: %v", err)
 		}
+		sessions := result.Sessions
 
 		if len(sessions) != 0 {
 			t.Errorf("Expected 0 sessions, got %d", len(sessions))
@@ -332,10 +362,11 @@ func TestDatabaseSessionService_ListSessions(t *testing.T) {
 	})
 
 	t.Run("SessionSummaryにFirstUserMessageが含まれる", func(t *testing.T) {
-		sessions, err := service.ListSessions("test-project-1")
+		result, err := service.ListSessions(SessionListParams{ProjectName: "test-project-1"})
 		if err != nil {
 			t.Fatalf("ListSessions failed: %v", err)
 		}
+		sessions := result.Sessions
 
 		if len(sessions) < 2 {
 			t.Fatalf("Expected at least 2 sessions, got %d", len(sessions))
@@ -364,10 +395,11 @@ func TestDatabaseSessionService_ListSessions(t *testing.T) {
 	})
 
 	t.Run("データベース層からFirstUserMessageが正しく伝播する", func(t *testing.T) {
-		sessions, err := service.ListSessions("")
+		result, err := service.ListSessions(SessionListParams{})
 		if err != nil {
 			t.Fatalf("ListSessions failed: %v", err)
 		}
+		sessions := result.Sessions
 
 		if len(sessions) != 3 {
 			t.Fatalf("Expected 3 sessions, got %d", len(sessions))
@@ -453,6 +485,573 @@ func TestDatabaseSessionService_GetSession(t *testing.T) {
 	})
 }
 
+func TestDatabaseSessionService_GetSessionSummary(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("会話を含まないセッション概要を返す", func(t *testing.T) {
+		summary, err := service.GetSessionSummary("test-project-1", "session-1")
+		if err != nil {
+			t.Fatalf("GetSessionSummary failed: %v", err)
+		}
+
+		if summary.ID != "session-1" || summary.TotalTokens.TotalTokens != 150 || len(summary.ModelUsage) != 1 {
+			t.Errorf("Unexpected summary: %+v", summary)
+		}
+		if summary.EntryCount != 1 || summary.MessageCount != 1 || summary.ToolCallCount != 1 {
+			t.Errorf("Unexpected counts: entries=%d messages=%d toolCalls=%d", summary.EntryCount, summary.MessageCount, summary.ToolCallCount)
+		}
+	})
+
+	t.Run("存在しないセッションIDでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetSessionSummary("test-project-1", "non-existent-session"); err == nil {
+			t.Error("Expected error for non-existent session, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_GetConversationTree(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	// a1 から2回入力したセッション（最初の入力 u2 は巻き戻しで破棄）
+	now := time.Now().Truncate(time.Second)
+	entry := func(uuid, parent, role string, offset int, text string) parser.LogEntry {
+		e := parser.LogEntry{
+			Type:      role,
+			Timestamp: now.Add(time.Duration(offset) * time.Second),
+			SessionID: "tree-session",
+			UUID:      uuid,
+			Message: &parser.Message{
+				Role:    role,
+				Content: []parser.Content{{Type: "text", Text: text}},
+			},
+		}
+		if parent != "" {
+			e.ParentUUID = &parent
+		}
+		return e
+	}
+	session := &parser.Session{
+		ID:          "tree-session",
+		ProjectPath: "{project-path}/test-project-1",
+		GitBranch:   "main",
+		StartTime:   now,
+		EndTime:     now.Add(time.Minute),
+		Entries: []parser.LogEntry{
+			entry("u1", "", "user", 0, "Start"),
+			entry("a1", "u1", "assistant", 1, "Hi"),
+			entry("u2", "a1", "user", 2, "First try"),
+			entry("a2", "u2", "assistant", 3, "Answer"),
+			entry("u2b", "a1", "user", 4, "Second try"),
+			entry("a2b", "u2b", "assistant", 5, "Better answer"),
+		},
+	}
+	if err := database.CreateSession(session, "test-project-1", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("破棄された分岐とメインパスを返す", func(t *testing.T) {
+		tree, err := service.GetConversationTree("test-project-1", "tree-session")
+		if err != nil {
+			t.Fatalf("GetConversationTree failed: %v", err)
+		}
+
+		if len(tree.Nodes) != 6 || tree.MainPathEntries != 4 || len(tree.Roots) != 1 {
+			t.Errorf("Unexpected tree: nodes=%d main=%d roots=%v", len(tree.Nodes), tree.MainPathEntries, tree.Roots)
+		}
+		if len(tree.Branches) != 1 || tree.Branches[0].FirstUUID != "u2" || tree.Abandoned.Branches != 1 {
+			t.Errorf("Unexpected branches: %+v", tree.Branches)
+		}
+		for _, node := range tree.Nodes {
+			abandoned := node.UUID == "u2" || node.UUID == "a2"
+			if node.MainPath == abandoned || (node.Branch != nil) != abandoned {
+				t.Errorf("Unexpected node: %+v", node)
+			}
+		}
+	})
+
+	t.Run("存在しないセッションIDでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetConversationTree("test-project-1", "non-existent-session"); err == nil {
+			t.Error("Expected error for non-existent session, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_GetContextUsage(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("セッションのコンテキスト使用量を返す", func(t *testing.T) {
+		usage, err := service.GetContextUsage("test-project-1", "session-1")
+		if err != nil {
+			t.Fatalf("GetContextUsage failed: %v", err)
+		}
+		if usage.SessionID != "session-1" || usage.Points == nil || usage.Compactions == nil {
+			t.Errorf("Unexpected usage: %+v", usage)
+		}
+		for _, point := range usage.Points {
+			if point.ContextTokens != point.InputTokens+point.CacheCreationInputTokens+point.CacheReadInputTokens {
+				t.Errorf("Unexpected context tokens: %+v", point)
+			}
+			if point.ContextTokens > usage.PeakContextTokens {
+				t.Errorf("Point %+v exceeds peak %d", point, usage.PeakContextTokens)
+			}
+		}
+	})
+
+	t.Run("存在しないセッションIDでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetContextUsage("test-project-1", "non-existent-session"); err == nil {
+			t.Error("Expected error for non-existent session, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_ListTasks(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("プロジェクトのタスクを返す", func(t *testing.T) {
+		result, err := service.ListTasks(TaskListParams{ProjectName: "test-project-1", Limit: 100})
+		if err != nil {
+			t.Fatalf("ListTasks failed: %v", err)
+		}
+		if result.Tasks == nil || result.Total != len(result.Tasks) {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		for _, task := range result.Tasks {
+			if task.ProjectName != "test-project-1" || task.Index < 1 {
+				t.Errorf("Unexpected task: %+v", task)
+			}
+		}
+	})
+
+	t.Run("セッション詳細にタスクを含める", func(t *testing.T) {
+		detail, err := service.GetSession("test-project-1", "session-1")
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if detail.Tasks == nil {
+			t.Error("Expected non-nil tasks")
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.ListTasks(TaskListParams{ProjectName: "non-existent-project", Limit: 100}); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_GetRetryStats(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("再試行統計を返す", func(t *testing.T) {
+		stats, err := service.GetRetryStats("test-project-1", nil, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+		if stats.Tools == nil || stats.Models == nil {
+			t.Errorf("Expected non-nil lists, got %+v", stats)
+		}
+		if stats.Total.Operations < stats.Total.FirstAttemptSuccesses {
+			t.Errorf("Unexpected total: %+v", stats.Total)
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetRetryStats("non-existent-project", nil, ""); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_GetCommandStats(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("コマンド統計を返す", func(t *testing.T) {
+		stats, err := service.GetCommandStats("test-project-1", nil)
+		if err != nil {
+			t.Fatalf("GetCommandStats failed: %v", err)
+		}
+		if stats.Commands == nil || stats.Projects == nil {
+			t.Errorf("Expected non-nil lists, got %+v", stats)
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetCommandStats("non-existent-project", nil); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_MCPServers(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("サーバー一覧を返す", func(t *testing.T) {
+		result, err := service.ListMCPServers(MCPStatsParams{ProjectName: "test-project-1"})
+		if err != nil {
+			t.Fatalf("ListMCPServers failed: %v", err)
+		}
+		if result.Servers == nil {
+			t.Error("Expected non-nil servers")
+		}
+	})
+
+	t.Run("呼び出しのないサーバーでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetMCPServer("non-existent-server", MCPStatsParams{}); err == nil {
+			t.Error("Expected error for non-existent server, got nil")
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.ListMCPServers(MCPStatsParams{ProjectName: "non-existent-project"}); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_BashStats(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("Bashコマンドの統計を返す", func(t *testing.T) {
+		result, err := service.GetBashStats(BashStatsParams{ProjectName: "test-project-1", Limit: 10})
+		if err != nil {
+			t.Fatalf("GetBashStats failed: %v", err)
+		}
+		if result.Families == nil || result.Projects == nil || result.FailingCommands == nil {
+			t.Errorf("Expected non-nil lists, got %+v", result)
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetBashStats(BashStatsParams{ProjectName: "non-existent-project"}); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_InterruptionStats(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("中断の統計を返す", func(t *testing.T) {
+		result, err := service.GetInterruptionStats("test-project-1", nil, "")
+		if err != nil {
+			t.Fatalf("GetInterruptionStats failed: %v", err)
+		}
+		if result.Projects == nil || result.Models == nil || result.Tools == nil {
+			t.Errorf("Expected non-nil lists, got %+v", result)
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetInterruptionStats("non-existent-project", nil, ""); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_Versions(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("バージョンごとの統計を返す", func(t *testing.T) {
+		result, err := service.ListVersions("test-project-1", nil)
+		if err != nil {
+			t.Fatalf("ListVersions failed: %v", err)
+		}
+		if result.Versions == nil {
+			t.Errorf("Expected non-nil list, got %+v", result)
+		}
+	})
+
+	t.Run("存在しないバージョンでエラーを返す", func(t *testing.T) {
+		if _, err := service.CompareVersion("9.9.9", 7, "", nil); err == nil {
+			t.Error("Expected error for non-existent version, got nil")
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.ListVersions("non-existent-project", nil); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_CommitStats(t *testing.T) {
+	if !gitutil.IsGitAvailable() {
+		t.Skip("git command not available")
+	}
+
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	// テスト用のリポジトリにコミットを作成
+	repo := t.TempDir()
+	git := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	git("2025-06-01T09:00:00Z", "init", "-q", "-b", "main")
+	for _, commit := range []struct{ date, message string }{
+		{"2025-06-01T10:30:00Z", "Add parser\n\nCo-Authored-By: Claude <noreply@anthropic.com>"},
+		{"2025-06-01T11:10:00Z", "Fix parser"},
+		{"2025-06-01T15:00:00Z", "Manual change"},
+	} {
+		file := filepath.Join(repo, "file.txt")
+		if err := os.WriteFile(file, []byte(commit.message), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		git(commit.date, "add", "file.txt")
+		git(commit.date, "commit", "-q", "-m", commit.message)
+	}
+
+	if _, err := database.CreateProjectWithGitRoot("commit-project", repo, repo); err != nil {
+		t.Fatalf("CreateProjectWithGitRoot failed: %v", err)
+	}
+	for _, session := range []struct {
+		id    string
+		start time.Time
+	}{
+		{"commit-session-1", time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{"commit-session-2", time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)},
+	} {
+		err := database.CreateSession(&parser.Session{
+			ID:          session.id,
+			ProjectPath: repo,
+			GitBranch:   "main",
+			StartTime:   session.start,
+			EndTime:     session.start.Add(time.Hour),
+			TotalTokens: parser.TokenSummary{InputTokens: 100, OutputTokens: 50},
+		}, "commit-project", time.Now())
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+
+	t.Run("コミットをセッションに割り当てる", func(t *testing.T) {
+		result, err := service.GetCommitStats(CommitStatsParams{ProjectName: "commit-project", Limit: 10})
+		if err != nil {
+			t.Fatalf("GetCommitStats failed: %v", err)
+		}
+
+		summary := result.Summary
+		if !result.GitAvailable || summary.Sessions != 2 || summary.SessionsWithCommits != 1 || summary.Commits != 2 {
+			t.Errorf("Unexpected summary: %+v", summary)
+		}
+		// セッション終了から猶予期間を過ぎたコミットは割り当てない
+		if summary.ClaudeCoAuthoredCommits != 1 || summary.UnattributedCommits != 1 || summary.TokensPerCommit != 150 {
+			t.Errorf("Unexpected summary: %+v", summary)
+		}
+
+		if len(result.Commits) != 2 {
+			t.Fatalf("Expected 2 commits, got %+v", result.Commits)
+		}
+		// 新しい順に返し、セッションのトークン数を按分する
+		if result.Commits[0].Subject != "Fix parser" || result.Commits[0].SessionID != "commit-session-1" || result.Commits[0].TotalTokens != 75 {
+			t.Errorf("Unexpected commit: %+v", result.Commits[0])
+		}
+		if !result.Commits[1].CoAuthoredByClaude {
+			t.Errorf("Expected Claude co-author: %+v", result.Commits[1])
+		}
+
+		if len(result.SessionsWithoutCommits) != 1 || result.SessionsWithoutCommits[0].SessionID != "commit-session-2" {
+			t.Errorf("Unexpected sessions without commits: %+v", result.SessionsWithoutCommits)
+		}
+	})
+
+	t.Run("読み込めないリポジトリのセッションはコミットのないセッションに含めない", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing")
+		if _, err := database.CreateProjectWithGitRoot("missing-repo-project", missing, missing); err != nil {
+			t.Fatalf("CreateProjectWithGitRoot failed: %v", err)
+		}
+		start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+		err := database.CreateSession(&parser.Session{
+			ID:          "missing-repo-session",
+			ProjectPath: missing,
+			StartTime:   start,
+			EndTime:     start.Add(time.Hour),
+		}, "missing-repo-project", time.Now())
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		result, err := service.GetCommitStats(CommitStatsParams{ProjectName: "missing-repo-project"})
+		if err != nil {
+			t.Fatalf("GetCommitStats failed: %v", err)
+		}
+		if result.Summary.Sessions != 1 || len(result.SessionsWithoutCommits) != 0 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		if len(result.UnreadableGitRoots) != 1 || result.UnreadableGitRoots[0] != missing {
+			t.Errorf("Unexpected unreadable git roots: %+v", result.UnreadableGitRoots)
+		}
+	})
+
+	t.Run("存在しないプロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetCommitStats(CommitStatsParams{ProjectName: "non-existent-project"}); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_Files(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	t.Run("セッションで編集したファイルを返す", func(t *testing.T) {
+		files, err := service.GetSessionFiles("test-project-1", "session-1")
+		if err != nil {
+			t.Fatalf("GetSessionFiles failed: %v", err)
+		}
+		if files.SessionID != "session-1" || files.Files == nil || files.FilesTouched != len(files.Files) {
+			t.Errorf("Unexpected files: %+v", files)
+		}
+	})
+
+	t.Run("プロジェクトのファイル統計を返す", func(t *testing.T) {
+		files, err := service.GetProjectFiles("test-project-1", ProjectFilesParams{Limit: 10})
+		if err != nil {
+			t.Fatalf("GetProjectFiles failed: %v", err)
+		}
+		if files.Period != "day" || files.Files == nil || files.Churn == nil {
+			t.Errorf("Unexpected files: %+v", files)
+		}
+	})
+
+	t.Run("存在しないセッション・プロジェクトでエラーを返す", func(t *testing.T) {
+		if _, err := service.GetSessionFiles("test-project-1", "non-existent-session"); err == nil {
+			t.Error("Expected error for non-existent session, got nil")
+		}
+		if _, err := service.GetProjectFiles("non-existent-project", ProjectFilesParams{}); err == nil {
+			t.Error("Expected error for non-existent project, got nil")
+		}
+	})
+}
+
+func TestDatabaseSessionService_ListSessionEntries(t *testing.T) {
+	service, database := setupTestDBService(t)
+	defer database.Close()
+
+	createTestData(t, database)
+
+	// ツール入力・結果を含むセッションを追加
+	now := time.Now().Truncate(time.Second)
+	parentUUID := "tool-entry-1"
+	session := &parser.Session{
+		ID:          "tool-session",
+		ProjectPath: "{project-path}/test-project-1",
+		GitBranch:   "main",
+		StartTime:   now,
+		EndTime:     now.Add(time.Minute),
+		Entries: []parser.LogEntry{
+			{
+				Type:      "assistant",
+				Timestamp: now,
+				SessionID: "tool-session",
+				UUID:      "tool-entry-1",
+				Message: &parser.Message{
+					Model: "claude-sonnet-4-5",
+					Role:  "assistant",
+					Content: []parser.Content{
+						{Type: "text", Text: "Reading"},
+						{Type: "tool_use", ID: "toolu_1", Name: "Read", Input: map[string]interface{}{"file_path": "/big.go"}},
+					},
+				},
+			},
+			{
+				Type:       "user",
+				Timestamp:  now.Add(time.Second),
+				SessionID:  "tool-session",
+				UUID:       "tool-entry-2",
+				ParentUUID: &parentUUID,
+				Message: &parser.Message{
+					Role:    "user",
+					Content: []parser.Content{{Type: "tool_result", ToolUseID: "toolu_1", ToolResultContent: "package main"}},
+				},
+			},
+		},
+	}
+	if err := database.CreateSession(session, "test-project-1", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("ツール入力・結果を省略できる", func(t *testing.T) {
+		result, err := service.ListSessionEntries("test-project-1", "tool-session", SessionEntryParams{OmitToolIO: true, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListSessionEntries failed: %v", err)
+		}
+		if result.Total != 2 || len(result.Entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d (total %d)", len(result.Entries), result.Total)
+		}
+
+		first := result.Entries[0].Content.([]parser.Content)
+		if first[0].Text != "Reading" || first[1].Name != "Read" || first[1].Input != nil {
+			t.Errorf("Expected tool input to be omitted: %+v", first)
+		}
+		second := result.Entries[1]
+		if second.ParentUUID != "tool-entry-1" || second.Content.([]parser.Content)[0].ToolResultContent != nil {
+			t.Errorf("Expected tool result to be omitted: %+v", second)
+		}
+	})
+
+	t.Run("省略しない場合はツール入力を含む", func(t *testing.T) {
+		result, err := service.ListSessionEntries("test-project-1", "tool-session", SessionEntryParams{Limit: 1})
+		if err != nil {
+			t.Fatalf("ListSessionEntries failed: %v", err)
+		}
+		if len(result.Entries) != 1 || result.NextCursor == "" {
+			t.Fatalf("Expected 1 entry with next cursor, got %d (cursor %q)", len(result.Entries), result.NextCursor)
+		}
+		if result.Entries[0].Content.([]parser.Content)[1].Input == nil {
+			t.Error("Expected tool input to be included")
+		}
+	})
+
+	t.Run("存在しないセッションIDでエラーを返す", func(t *testing.T) {
+		if _, err := service.ListSessionEntries("test-project-1", "non-existent-session", SessionEntryParams{Limit: 10}); err == nil {
+			t.Error("Expected error for non-existent session, got nil")
+		}
+	})
+}
+
 func TestDatabaseSessionService_Analyze(t *testing.T) {
 	service, database := setupTestDBService(t)
 	defer database.Close()
@@ -5,20 +5,40 @@ import "time"
 // SessionService defines the interface for session operations
 type SessionService interface {
 	ListProjects() ([]ProjectResponse, error)
-	ListSessions(projectName string) ([]SessionSummary, error)
+	ListSessions(params SessionListParams) (*SessionListResponse, error)
 	GetSession(projectName, sessionID string) (*SessionDetailResponse, error)
+	GetSessionSummary(projectName, sessionID string) (*SessionDetailSummaryResponse, error)
+	ListSessionEntries(projectName, sessionID string, params SessionEntryParams) (*SessionEntryListResponse, error)
+	GetConversationTree(projectName, sessionID string) (*ConversationTreeResponse, error)
+	GetContextUsage(projectName, sessionID string) (*ContextUsageResponse, error)
+	ListTasks(params TaskListParams) (*TaskListResponse, error)
 	Analyze(projectNames []string) (*AnalyzeResponse, error)
-	GetProjectStats(projectName string) (*ProjectStatsResponse, error)
-	GetProjectTimeline(projectName, period string, limit int) (*TimeSeriesResponse, error)
+	GetProjectStats(projectName, version string) (*ProjectStatsResponse, error)
+	GetProjectTimeline(projectName, period string, limit int, version string) (*TimeSeriesResponse, error)
 	ListProjectGroups() ([]ProjectGroupResponse, error)
 	GetProjectGroup(groupID int64) (*ProjectGroupDetailResponse, error)
-	GetProjectGroupStats(groupID int64) (*ProjectGroupStatsResponse, error)
-	GetProjectGroupTimeline(groupID int64, period string, limit int) (*TimeSeriesResponse, error)
-	GetTotalStats() (*TotalStatsResponse, error)
-	GetTotalTimeline(period string, limit int) (*TimeSeriesResponse, error)
+	GetProjectGroupStats(groupID int64, version string) (*ProjectGroupStatsResponse, error)
+	GetProjectGroupTimeline(groupID int64, period string, limit int, version string) (*TimeSeriesResponse, error)
+	GetTotalStats(version string) (*TotalStatsResponse, error)
+	GetTotalTimeline(period string, limit int, version string) (*TimeSeriesResponse, error)
 	GetDailyStats(date string) (*DailyStatsResponse, error)
 	GetGroupDailyStats(groupID int64, date string) (*GroupDailyStatsResponse, error)
 	GetProjectDailyStats(projectName string, date string) (*ProjectDailyStatsResponse, error)
+	GetToolStats(projectName string, groupID *int64, version string) (*ToolStatsResponse, error)
+	GetRetryStats(projectName string, groupID *int64, version string) (*RetryStatsResponse, error)
+	GetCommandStats(projectName string, groupID *int64) (*CommandStatsResponse, error)
+	GetSessionFiles(projectName, sessionID string) (*SessionFilesResponse, error)
+	GetProjectFiles(projectName string, params ProjectFilesParams) (*ProjectFilesResponse, error)
+	ListMCPServers(params MCPStatsParams) (*MCPServerListResponse, error)
+	GetMCPServer(server string, params MCPStatsParams) (*MCPServerDetailResponse, error)
+	GetBashStats(params BashStatsParams) (*BashStatsResponse, error)
+	GetInterruptionStats(projectName string, groupID *int64, version string) (*InterruptionStatsResponse, error)
+	ListVersions(projectName string, groupID *int64) (*VersionListResponse, error)
+	CompareVersion(version string, days int, projectName string, groupID *int64) (*VersionComparisonResponse, error)
+	GetCommitStats(params CommitStatsParams) (*CommitStatsResponse, error)
+	Search(params SearchParams) (*SearchResponse, error)
+	ListErrorPatterns(projectName string, groupID *int64, toolName string, limit, offset int) (*ErrorPatternListResponse, error)
+	GetErrorOccurrences(patternID int64, limit, offset int) (*ErrorOccurrenceListResponse, error)
 }
 
 // HealthResponse represents the health check response
@@ -47,13 +67,41 @@ type SessionSummary struct {
 	StartTime        time.Time `json:"startTime"`
 	EndTime          time.Time `json:"endTime"`
 	TotalTokens      int       `json:"totalTokens"`
+	EstimatedCostUSD float64   `json:"estimatedCostUsd"`
 	ErrorCount       int       `json:"errorCount"`
 	FirstUserMessage string    `json:"firstUserMessage"`
+	SubagentCount    int       `json:"subagentCount"`
+	SubagentTokens   int       `json:"subagentTokens"` // サブエージェントのトークン数（TotalTokensには含まない）
+
+	PeakContextTokens int `json:"peakContextTokens"`
+	CompactionCount   int `json:"compactionCount"`
+	RetryCount        int `json:"retryCount"`
+}
+
+// SessionListParams holds the filters, sort order and cursor for listing sessions
+type SessionListParams struct {
+	ProjectName string
+	GroupID     *int64
+	GitBranch   string
+	Model       string
+	ToolName    string
+	Version     string
+	From        string // YYYY-MM-DD（含む）
+	To          string // YYYY-MM-DD（含む）
+	MinTokens   *int
+	MaxTokens   *int
+	HasErrors   *bool
+	SortBy      string // "startTime", "tokens", "duration", "errors"
+	Order       string // "asc", "desc"
+	Cursor      string
+	Limit       int
 }
 
 // SessionListResponse represents the list of sessions
 type SessionListResponse struct {
-	Sessions []SessionSummary `json:"sessions"`
+	Sessions   []SessionSummary `json:"sessions"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"nextCursor,omitempty"`
 }
 
 // TokenSummaryResponse represents token usage in API response
@@ -67,16 +115,20 @@ type TokenSummaryResponse struct {
 
 // ModelUsageResponse represents per-model token usage
 type ModelUsageResponse struct {
-	Model  string               `json:"model"`
-	Tokens TokenSummaryResponse `json:"tokens"`
+	Model            string               `json:"model"`
+	Tokens           TokenSummaryResponse `json:"tokens"`
+	EstimatedCostUSD float64              `json:"estimatedCostUsd"`
 }
 
 // ToolCallResponse represents a tool call in API response
 type ToolCallResponse struct {
-	Timestamp time.Time   `json:"timestamp"`
-	Name      string      `json:"name"`
-	Input     interface{} `json:"input"`
-	IsError   bool        `json:"isError"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Name       string      `json:"name"`
+	Input      interface{} `json:"input"`
+	IsError    bool        `json:"isError"`
+	Result     string      `json:"result,omitempty"`
+	DurationMs *int64      `json:"durationMs,omitempty"` // tool_result未受信の場合はnil
+	AgentID    string      `json:"agentId,omitempty"`    // Taskツールが起動したサブエージェントのID
 }
 
 // MessageResponse represents a message in conversation history
@@ -90,18 +142,205 @@ type MessageResponse struct {
 
 // SessionDetailResponse represents detailed session info
 type SessionDetailResponse struct {
-	ID          string               `json:"id"`
-	ProjectName string               `json:"projectName"`
-	ProjectPath string               `json:"projectPath"`
-	GitBranch   string               `json:"gitBranch"`
-	StartTime   time.Time            `json:"startTime"`
-	EndTime     time.Time            `json:"endTime"`
-	Duration    string               `json:"duration"`
-	TotalTokens TokenSummaryResponse `json:"totalTokens"`
-	ModelUsage  []ModelUsageResponse `json:"modelUsage"`
-	ToolCalls   []ToolCallResponse   `json:"toolCalls"`
-	Messages    []MessageResponse    `json:"messages"`
-	ErrorCount  int                  `json:"errorCount"`
+	ID               string               `json:"id"`
+	ProjectName      string               `json:"projectName"`
+	ProjectPath      string               `json:"projectPath"`
+	GitBranch        string               `json:"gitBranch"`
+	StartTime        time.Time            `json:"startTime"`
+	EndTime          time.Time            `json:"endTime"`
+	Duration         string               `json:"duration"`
+	TotalTokens      TokenSummaryResponse `json:"totalTokens"`
+	EstimatedCostUSD float64              `json:"estimatedCostUsd"`
+	ModelUsage       []ModelUsageResponse `json:"modelUsage"`
+	ToolCalls        []ToolCallResponse   `json:"toolCalls"`
+	Messages         []MessageResponse    `json:"messages"`
+	ErrorCount       int                  `json:"errorCount"`
+
+	// サブエージェント関連
+	ParentSessionID string                  `json:"parentSessionId,omitempty"` // サブエージェントのセッションの場合のみ
+	AgentID         string                  `json:"agentId,omitempty"`         // サブエージェントのセッションの場合のみ
+	Subagents       []SubagentRunResponse   `json:"subagents"`
+	AgentTokens     AgentTokenSplitResponse `json:"agentTokens"`
+
+	// ユーザー入力ごとのタスク
+	Tasks []TaskResponse `json:"tasks"`
+}
+
+// SessionDetailSummaryResponse represents session details without the conversation and tool calls
+// 会話本体は /entries でページ単位に取得する
+type SessionDetailSummaryResponse struct {
+	ID               string               `json:"id"`
+	ProjectName      string               `json:"projectName"`
+	ProjectPath      string               `json:"projectPath"`
+	GitBranch        string               `json:"gitBranch"`
+	StartTime        time.Time            `json:"startTime"`
+	EndTime          time.Time            `json:"endTime"`
+	Duration         string               `json:"duration"`
+	TotalTokens      TokenSummaryResponse `json:"totalTokens"`
+	EstimatedCostUSD float64              `json:"estimatedCostUsd"`
+	ModelUsage       []ModelUsageResponse `json:"modelUsage"`
+	ErrorCount       int                  `json:"errorCount"`
+	EntryCount       int                  `json:"entryCount"`
+	MessageCount     int                  `json:"messageCount"`
+	ToolCallCount    int                  `json:"toolCallCount"`
+
+	// サブエージェント関連
+	ParentSessionID string                  `json:"parentSessionId,omitempty"`
+	AgentID         string                  `json:"agentId,omitempty"`
+	Subagents       []SubagentRunResponse   `json:"subagents"`
+	AgentTokens     AgentTokenSplitResponse `json:"agentTokens"`
+
+	// ユーザー入力ごとのタスク
+	Tasks []TaskResponse `json:"tasks"`
+}
+
+// TaskResponse represents a task of a session (from a user prompt to the next one)
+type TaskResponse struct {
+	SessionID       string               `json:"sessionId"`
+	ProjectName     string               `json:"projectName"`
+	GitBranch       string               `json:"gitBranch"`
+	Index           int                  `json:"index"`
+	StartUUID       string               `json:"startUuid"`
+	Prompt          string               `json:"prompt"`
+	StartTime       time.Time            `json:"startTime"`
+	EndTime         time.Time            `json:"endTime"`
+	DurationSeconds int                  `json:"durationSeconds"`
+	Tokens          TokenSummaryResponse `json:"tokens"`
+	EntryCount      int                  `json:"entryCount"`
+	ToolCallCount   int                  `json:"toolCallCount"`
+	ErrorCount      int                  `json:"errorCount"`
+}
+
+// TaskListParams holds the filters, sort order and paging for listing tasks
+type TaskListParams struct {
+	ProjectName string
+	GroupID     *int64
+	GitBranch   string
+	From        string
+	To          string
+	SortBy      string
+	Order       string // "asc" or "desc"
+	Limit       int
+	Offset      int
+}
+
+// TaskListResponse represents a page of tasks
+type TaskListResponse struct {
+	Tasks []TaskResponse `json:"tasks"`
+	Total int            `json:"total"`
+}
+
+// SessionEntryParams holds the filters and cursor for listing the entries of a session
+type SessionEntryParams struct {
+	EntryType  string // "user", "assistant" など
+	Role       string // "user", "assistant"
+	OmitToolIO bool   // tool_useの入力とtool_resultの内容を省略する
+	Cursor     string
+	Limit      int
+}
+
+// SessionEntryResponse represents a log entry in the paginated conversation
+type SessionEntryResponse struct {
+	UUID       string      `json:"uuid"`
+	ParentUUID string      `json:"parentUuid,omitempty"`
+	Type       string      `json:"type"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Role       string      `json:"role,omitempty"`
+	Model      string      `json:"model,omitempty"`
+	Content    interface{} `json:"content,omitempty"`
+}
+
+// SessionEntryListResponse represents a page of session entries
+type SessionEntryListResponse struct {
+	Entries    []SessionEntryResponse `json:"entries"`
+	Total      int                    `json:"total"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+// ContextUsageResponse represents the context size of each API request and the compactions of a session
+type ContextUsageResponse struct {
+	SessionID         string                 `json:"sessionId"`
+	PeakContextTokens int                    `json:"peakContextTokens"`
+	CompactionCount   int                    `json:"compactionCount"`
+	Points            []ContextPointResponse `json:"points"`
+	Compactions       []CompactionResponse   `json:"compactions"`
+}
+
+// ContextPointResponse represents the context size of a single API request
+type ContextPointResponse struct {
+	UUID                     string    `json:"uuid"`
+	Timestamp                time.Time `json:"timestamp"`
+	Model                    string    `json:"model"`
+	InputTokens              int       `json:"inputTokens"`
+	CacheCreationInputTokens int       `json:"cacheCreationInputTokens"`
+	CacheReadInputTokens     int       `json:"cacheReadInputTokens"`
+	ContextTokens            int       `json:"contextTokens"` // 入力 + キャッシュ作成 + キャッシュ読取
+}
+
+// CompactionResponse represents a compaction of the conversation context
+type CompactionResponse struct {
+	UUID      string    `json:"uuid"`
+	Timestamp time.Time `json:"timestamp"`
+	Trigger   string    `json:"trigger"` // "auto" または "manual"
+	PreTokens int       `json:"preTokens"`
+}
+
+// ConversationTreeResponse represents the conversation tree of a session rebuilt from parentUuid
+type ConversationTreeResponse struct {
+	SessionID       string                         `json:"sessionId"`
+	Roots           []string                       `json:"roots"`
+	Nodes           []ConversationNodeResponse     `json:"nodes"`
+	Branches        []AbandonedBranchResponse      `json:"branches"`
+	MainPathEntries int                            `json:"mainPathEntries"`
+	Abandoned       AbandonedBranchSummaryResponse `json:"abandoned"`
+}
+
+// ConversationNodeResponse represents a log entry in the conversation tree
+type ConversationNodeResponse struct {
+	UUID         string    `json:"uuid"`
+	ParentUUID   string    `json:"parentUuid,omitempty"`
+	Type         string    `json:"type"`
+	Timestamp    time.Time `json:"timestamp"`
+	IsPrompt     bool      `json:"isPrompt"`
+	MainPath     bool      `json:"mainPath"`
+	Branch       *int      `json:"branch,omitempty"` // 破棄された分岐のbranchesのインデックス
+	InputTokens  int       `json:"inputTokens"`
+	OutputTokens int       `json:"outputTokens"`
+}
+
+// AbandonedBranchResponse represents a conversation branch abandoned by a rewind or an edited prompt
+type AbandonedBranchResponse struct {
+	ForkUUID     string    `json:"forkUuid"`
+	FirstUUID    string    `json:"firstUuid"`
+	EntryCount   int       `json:"entryCount"`
+	InputTokens  int       `json:"inputTokens"`
+	OutputTokens int       `json:"outputTokens"`
+	TotalTokens  int       `json:"totalTokens"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+}
+
+// SubagentRunResponse represents a subagent run spawned by a session
+type SubagentRunResponse struct {
+	SessionID        string               `json:"sessionId"`
+	AgentID          string               `json:"agentId"`
+	ToolUseID        string               `json:"toolUseId,omitempty"`
+	Description      string               `json:"description,omitempty"`
+	SubagentType     string               `json:"subagentType,omitempty"`
+	StartTime        time.Time            `json:"startTime"`
+	EndTime          time.Time            `json:"endTime"`
+	Tokens           TokenSummaryResponse `json:"tokens"`
+	EstimatedCostUSD float64              `json:"estimatedCostUsd"`
+	ErrorCount       int                  `json:"errorCount"`
+}
+
+// AgentTokenSplitResponse represents token usage split by main agent and subagents
+type AgentTokenSplitResponse struct {
+	MainAgent        TokenSummaryResponse `json:"mainAgent"`
+	Subagents        TokenSummaryResponse `json:"subagents"`
+	MainAgentCostUSD float64              `json:"mainAgentCostUsd"`
+	SubagentsCostUSD float64              `json:"subagentsCostUsd"`
+	SubagentRuns     int                  `json:"subagentRuns"`
 }
 
 // AnalyzeRequest represents the analyze request body
@@ -112,11 +351,11 @@ type AnalyzeRequest struct {
 
 // AnalyzeResponse represents the analyze response
 type AnalyzeResponse struct {
-	Status          string `json:"status"`
-	SessionsFound   int    `json:"sessionsFound"`
-	SessionsParsed  int    `json:"sessionsParsed"`
-	ErrorCount      int    `json:"errorCount,omitempty"`
-	Message         string `json:"message,omitempty"`
+	Status         string `json:"status"`
+	SessionsFound  int    `json:"sessionsFound"`
+	SessionsParsed int    `json:"sessionsParsed"`
+	ErrorCount     int    `json:"errorCount,omitempty"`
+	Message        string `json:"message,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -133,10 +372,32 @@ type ProjectStatsResponse struct {
 	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
 	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
 	TotalTokens              int       `json:"totalTokens"`
+	EstimatedCostUSD         float64   `json:"estimatedCostUsd"`
 	AvgTokens                float64   `json:"avgTokens"`
 	FirstSession             time.Time `json:"firstSession"`
 	LastSession              time.Time `json:"lastSession"`
 	ErrorRate                float64   `json:"errorRate"`
+	ActiveSeconds            int       `json:"activeSeconds"` // AIの作業時間（トップレベルのセッション）
+	IdleSeconds              int       `json:"idleSeconds"`   // ユーザーの入力待ちなどの待機時間
+
+	AgentTokens       AgentTokenSplitResponse        `json:"agentTokens"`
+	AbandonedBranches AbandonedBranchSummaryResponse `json:"abandonedBranches"`
+	Context           ContextSummaryResponse         `json:"context"`
+}
+
+// ContextSummaryResponse represents the context pressure of the top-level sessions
+type ContextSummaryResponse struct {
+	AvgPeakContextTokens float64 `json:"avgPeakContextTokens"`
+	MaxPeakContextTokens int     `json:"maxPeakContextTokens"`
+	Compactions          int     `json:"compactions"`
+}
+
+// AbandonedBranchSummaryResponse represents the conversation branches abandoned by rewinds or edited prompts
+type AbandonedBranchSummaryResponse struct {
+	Branches     int `json:"branches"`
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
 }
 
 // BranchStatsResponse represents statistics per branch
@@ -153,19 +414,29 @@ type BranchStatsResponse struct {
 
 // TimeSeriesDataPoint represents a single data point in time series
 type TimeSeriesDataPoint struct {
-	PeriodStart              time.Time `json:"periodStart"`
-	PeriodEnd                time.Time `json:"periodEnd"`
-	SessionCount             int       `json:"sessionCount"`
-	TotalInputTokens         int       `json:"totalInputTokens"`
-	TotalOutputTokens        int       `json:"totalOutputTokens"`
-	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
-	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
-	TotalTokens              int       `json:"totalTokens"`
+	PeriodStart              time.Time              `json:"periodStart"`
+	PeriodEnd                time.Time              `json:"periodEnd"`
+	SessionCount             int                    `json:"sessionCount"`
+	TotalInputTokens         int                    `json:"totalInputTokens"`
+	TotalOutputTokens        int                    `json:"totalOutputTokens"`
+	TotalCacheCreationTokens int                    `json:"totalCacheCreationTokens"`
+	TotalCacheReadTokens     int                    `json:"totalCacheReadTokens"`
+	TotalTokens              int                    `json:"totalTokens"`
+	EstimatedCostUSD         float64                `json:"estimatedCostUsd"`
+	ActiveSeconds            int                    `json:"activeSeconds"`
+	IdleSeconds              int                    `json:"idleSeconds"`
+	ModelStats               []ModelTokensDataPoint `json:"modelStats"`
+}
+
+// ModelTokensDataPoint represents tokens (input + output) used by a model within a period
+type ModelTokensDataPoint struct {
+	Model  string `json:"model"`
+	Tokens int    `json:"tokens"`
 }
 
 // TimeSeriesResponse represents time-series statistics response
 type TimeSeriesResponse struct {
-	Period string                 `json:"period"`
+	Period string                `json:"period"`
 	Data   []TimeSeriesDataPoint `json:"data"`
 }
 
@@ -203,10 +474,15 @@ type ProjectGroupStatsResponse struct {
 	TotalOutputTokens        int       `json:"totalOutputTokens"`
 	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
 	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
+	EstimatedCostUSD         float64   `json:"estimatedCostUsd"`
 	AvgTokens                float64   `json:"avgTokens"`
 	FirstSession             time.Time `json:"firstSession"`
 	LastSession              time.Time `json:"lastSession"`
 	ErrorRate                float64   `json:"errorRate"`
+	ActiveSeconds            int       `json:"activeSeconds"` // AIの作業時間（トップレベルのセッション）
+	IdleSeconds              int       `json:"idleSeconds"`   // ユーザーの入力待ちなどの待機時間
+
+	AgentTokens AgentTokenSplitResponse `json:"agentTokens"`
 }
 
 // ScanStatusResponse represents the scan status
@@ -232,10 +508,15 @@ type TotalStatsResponse struct {
 	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
 	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
 	TotalTokens              int       `json:"totalTokens"`
+	EstimatedCostUSD         float64   `json:"estimatedCostUsd"`
 	AvgTokens                float64   `json:"avgTokens"`
 	FirstSession             time.Time `json:"firstSession"`
 	LastSession              time.Time `json:"lastSession"`
 	ErrorRate                float64   `json:"errorRate"`
+	ActiveSeconds            int       `json:"activeSeconds"` // AIの作業時間（トップレベルのセッション）
+	IdleSeconds              int       `json:"idleSeconds"`   // ユーザーの入力待ちなどの待機時間
+
+	AgentTokens AgentTokenSplitResponse `json:"agentTokens"`
 }
 
 // DailyGroupStatsResponse represents group-wise statistics for a specific date
@@ -295,3 +576,360 @@ type ProjectDailyStatsResponse struct {
 	Date     string                 `json:"date"`
 	Sessions []DailySessionResponse `json:"sessions"`
 }
+
+// ToolStatsItem represents usage statistics for a single tool
+type ToolStatsItem struct {
+	ToolName     string  `json:"toolName"`
+	CallCount    int     `json:"callCount"`
+	ErrorCount   int     `json:"errorCount"`
+	ErrorRate    float64 `json:"errorRate"`
+	P50LatencyMs int64   `json:"p50LatencyMs"`
+	P95LatencyMs int64   `json:"p95LatencyMs"`
+}
+
+// ToolStatsResponse represents the response for per-tool statistics
+type ToolStatsResponse struct {
+	Tools []ToolStatsItem `json:"tools"`
+}
+
+// RetryStatsItem represents retry and first-attempt success statistics
+type RetryStatsItem struct {
+	ToolName              string  `json:"toolName,omitempty"`
+	Model                 string  `json:"model,omitempty"`
+	Operations            int     `json:"operations"`
+	FirstAttemptSuccesses int     `json:"firstAttemptSuccesses"`
+	FirstAttemptRate      float64 `json:"firstAttemptRate"`
+	Retries               int     `json:"retries"`
+	Recovered             int     `json:"recovered"`
+}
+
+// RetryStatsResponse represents the response for retry statistics overall, per tool and per model
+type RetryStatsResponse struct {
+	Total  RetryStatsItem   `json:"total"`
+	Tools  []RetryStatsItem `json:"tools"`
+	Models []RetryStatsItem `json:"models"`
+}
+
+// CommandStatsItem represents usage statistics of a slash command
+type CommandStatsItem struct {
+	Name        string               `json:"name"`
+	Custom      bool                 `json:"custom"`
+	ProjectName string               `json:"projectName,omitempty"`
+	Invocations int                  `json:"invocations"`
+	Sessions    int                  `json:"sessions"`
+	Projects    int                  `json:"projects"`
+	Tokens      TokenSummaryResponse `json:"tokens"`    // コマンドから次のユーザー入力までのトークン数
+	AvgTokens   float64              `json:"avgTokens"` // 1回あたりのトークン数（入力+出力）
+}
+
+// CommandStatsResponse represents the response for slash command statistics overall and per project
+type CommandStatsResponse struct {
+	Commands []CommandStatsItem `json:"commands"`
+	Projects []CommandStatsItem `json:"projects"`
+}
+
+// FileEditItem represents the edits made to a file by the Edit, MultiEdit and Write tools
+type FileEditItem struct {
+	FilePath     string    `json:"filePath"`
+	Edits        int       `json:"edits"`
+	Sessions     int       `json:"sessions"`
+	LinesAdded   int       `json:"linesAdded"`
+	LinesRemoved int       `json:"linesRemoved"`
+	LastEditedAt time.Time `json:"lastEditedAt"`
+}
+
+// SessionFilesResponse represents the files edited in a session
+type SessionFilesResponse struct {
+	SessionID    string         `json:"sessionId"`
+	FilesTouched int            `json:"filesTouched"`
+	Edits        int            `json:"edits"`
+	LinesAdded   int            `json:"linesAdded"`
+	LinesRemoved int            `json:"linesRemoved"`
+	Files        []FileEditItem `json:"files"`
+}
+
+// ProjectFilesParams holds the filters, churn period and number of hot files for project file statistics
+type ProjectFilesParams struct {
+	Period string // "day", "week" or "month"
+	From   string
+	To     string
+	Limit  int
+}
+
+// FileChurnItem represents the edits made within a period
+type FileChurnItem struct {
+	Period       string `json:"period"`
+	Edits        int    `json:"edits"`
+	FilesTouched int    `json:"filesTouched"`
+	LinesAdded   int    `json:"linesAdded"`
+	LinesRemoved int    `json:"linesRemoved"`
+}
+
+// ProjectFilesResponse represents the most edited files of a project and its churn over time
+type ProjectFilesResponse struct {
+	ProjectName string          `json:"projectName"`
+	Period      string          `json:"period"`
+	Files       []FileEditItem  `json:"files"`
+	Churn       []FileChurnItem `json:"churn"`
+}
+
+// MCPStatsParams holds the filters for MCP server statistics
+type MCPStatsParams struct {
+	ProjectName string
+	GroupID     *int64
+	From        string
+	To          string
+}
+
+// MCPStatsItem represents call, error, latency and result size statistics of MCP tool calls
+type MCPStatsItem struct {
+	Name             string  `json:"name,omitempty"` // サーバー名・ツール名・プロジェクト名（日別集計では省略）
+	Date             string  `json:"date,omitempty"` // 日別集計の場合のみ
+	CallCount        int     `json:"callCount"`
+	ErrorCount       int     `json:"errorCount"`
+	ErrorRate        float64 `json:"errorRate"`
+	P50LatencyMs     int64   `json:"p50LatencyMs"`
+	P95LatencyMs     int64   `json:"p95LatencyMs"`
+	TotalResultBytes int64   `json:"totalResultBytes"`
+	AvgResultBytes   float64 `json:"avgResultBytes"`
+	MaxResultBytes   int     `json:"maxResultBytes"`
+	Tools            int     `json:"tools"`
+	Sessions         int     `json:"sessions"`
+}
+
+// MCPServerListResponse represents the response for per-server MCP statistics
+type MCPServerListResponse struct {
+	Servers []MCPStatsItem `json:"servers"`
+}
+
+// MCPServerDetailResponse represents the statistics of an MCP server broken down by tool, project and day
+type MCPServerDetailResponse struct {
+	Server   MCPStatsItem   `json:"server"`
+	Tools    []MCPStatsItem `json:"tools"`
+	Projects []MCPStatsItem `json:"projects"`
+	Daily    []MCPStatsItem `json:"daily"`
+}
+
+// BashStatsParams holds the filters for Bash command statistics
+type BashStatsParams struct {
+	ProjectName string
+	GroupID     *int64
+	Version     string
+	From        string
+	To          string
+	Limit       int // よく失敗するコマンドの件数
+}
+
+// BashFamilyStatsItem represents the outcome statistics of a Bash command family
+type BashFamilyStatsItem struct {
+	Family           string  `json:"family"`
+	ProjectName      string  `json:"projectName,omitempty"` // プロジェクト別集計の場合のみ
+	Count            int     `json:"count"`
+	FailureCount     int     `json:"failureCount"`
+	InterruptedCount int     `json:"interruptedCount"`
+	FailureRate      float64 `json:"failureRate"`
+	P50DurationMs    int64   `json:"p50DurationMs"`
+	P95DurationMs    int64   `json:"p95DurationMs"`
+	TotalDurationMs  int64   `json:"totalDurationMs"`
+}
+
+// FailingBashCommandItem represents a Bash command that failed repeatedly
+type FailingBashCommandItem struct {
+	Command      string    `json:"command"`
+	Program      string    `json:"program"`
+	Family       string    `json:"family"`
+	Count        int       `json:"count"`
+	FailureCount int       `json:"failureCount"`
+	FailureRate  float64   `json:"failureRate"`
+	LastError    string    `json:"lastError"`
+	LastFailedAt time.Time `json:"lastFailedAt"`
+}
+
+// BashStatsResponse represents Bash command statistics per family, per project and the most failing commands
+type BashStatsResponse struct {
+	Families        []BashFamilyStatsItem    `json:"families"`
+	Projects        []BashFamilyStatsItem    `json:"projects"`
+	FailingCommands []FailingBashCommandItem `json:"failingCommands"`
+}
+
+// InterruptionStatsItem represents the user interruptions of a project, model or tool
+type InterruptionStatsItem struct {
+	Name       string  `json:"name,omitempty"` // プロジェクト名・モデル名・ツール名（全体の場合は省略）
+	Interrupts int     `json:"interrupts"`
+	Rejections int     `json:"rejections"`
+	Cancels    int     `json:"cancels"`
+	Total      int     `json:"total"`
+	BaseCount  int     `json:"baseCount"`
+	Rate       float64 `json:"rate"`
+}
+
+// InterruptionStatsResponse represents interruption statistics overall and per project, model and tool
+type InterruptionStatsResponse struct {
+	Total    InterruptionStatsItem   `json:"total"`
+	Projects []InterruptionStatsItem `json:"projects"`
+	Models   []InterruptionStatsItem `json:"models"`
+	Tools    []InterruptionStatsItem `json:"tools"`
+}
+
+// VersionStatsItem represents the statistics of a Claude Code version (or of a comparison period)
+type VersionStatsItem struct {
+	Version             string    `json:"version,omitempty"` // 比較期間の場合は省略
+	FirstSeen           time.Time `json:"firstSeen"`
+	LastSeen            time.Time `json:"lastSeen"`
+	Sessions            int       `json:"sessions"`
+	InputTokens         int       `json:"inputTokens"`
+	OutputTokens        int       `json:"outputTokens"`
+	CacheCreationTokens int       `json:"cacheCreationTokens"`
+	CacheReadTokens     int       `json:"cacheReadTokens"`
+	EstimatedCostUSD    float64   `json:"estimatedCostUsd"`
+	AvgTokens           float64   `json:"avgTokens"`
+	ErrorCount          int       `json:"errorCount"`
+	ErrorRate           float64   `json:"errorRate"`
+	Retries             int       `json:"retries"`
+	RetriesPerSession   float64   `json:"retriesPerSession"`
+}
+
+// VersionListResponse represents statistics per Claude Code version
+type VersionListResponse struct {
+	Versions []VersionStatsItem `json:"versions"`
+}
+
+// VersionComparisonResponse represents the sessions before and after a version first appeared
+type VersionComparisonResponse struct {
+	Version         string           `json:"version"`
+	PreviousVersion string           `json:"previousVersion,omitempty"`
+	FirstSeen       time.Time        `json:"firstSeen"`
+	Days            int              `json:"days"`
+	Before          VersionStatsItem `json:"before"`
+	After           VersionStatsItem `json:"after"`
+}
+
+// CommitStatsParams holds the filters for correlating sessions with git commits
+type CommitStatsParams struct {
+	ProjectName string
+	GroupID     *int64
+	From        string // YYYY-MM-DD（含む、セッションの開始日）
+	To          string // YYYY-MM-DD（含む、セッションの開始日）
+	Limit       int    // コミット・コミットのないセッションそれぞれの最大件数
+}
+
+// CommitSummary summarizes the commits produced by the sessions
+type CommitSummary struct {
+	Sessions                int     `json:"sessions"`
+	SessionsWithCommits     int     `json:"sessionsWithCommits"`
+	Commits                 int     `json:"commits"`
+	ClaudeCoAuthoredCommits int     `json:"claudeCoAuthoredCommits"`
+	UnattributedCommits     int     `json:"unattributedCommits"` // セッションの期間外のコミット
+	TotalTokens             int     `json:"totalTokens"`
+	EstimatedCostUSD        float64 `json:"estimatedCostUsd"`
+	TokensPerCommit         float64 `json:"tokensPerCommit"`
+	CostPerCommit           float64 `json:"costPerCommit"`
+}
+
+// CommitItem represents a git commit attributed to a session
+type CommitItem struct {
+	Hash               string    `json:"hash"`
+	Subject            string    `json:"subject"`
+	AuthorName         string    `json:"authorName"`
+	AuthorEmail        string    `json:"authorEmail"`
+	Branch             string    `json:"branch"`
+	CommittedAt        time.Time `json:"committedAt"`
+	CoAuthoredByClaude bool      `json:"coAuthoredByClaude"`
+	SessionID          string    `json:"sessionId"`
+	ProjectName        string    `json:"projectName"`
+	TotalTokens        int       `json:"totalTokens"`      // セッションのトークン数をコミット数で按分
+	EstimatedCostUSD   float64   `json:"estimatedCostUsd"` // セッションのコストをコミット数で按分
+}
+
+// UncommittedSessionItem represents a session that produced no commits
+type UncommittedSessionItem struct {
+	SessionID        string    `json:"sessionId"`
+	ProjectName      string    `json:"projectName"`
+	GitBranch        string    `json:"gitBranch"`
+	StartTime        time.Time `json:"startTime"`
+	EndTime          time.Time `json:"endTime"`
+	TotalTokens      int       `json:"totalTokens"`
+	EstimatedCostUSD float64   `json:"estimatedCostUsd"`
+	FirstUserMessage string    `json:"firstUserMessage"`
+}
+
+// CommitStatsResponse represents the git commits correlated with sessions
+type CommitStatsResponse struct {
+	GitAvailable           bool                     `json:"gitAvailable"`
+	Summary                CommitSummary            `json:"summary"`
+	Commits                []CommitItem             `json:"commits"`
+	SessionsWithoutCommits []UncommittedSessionItem `json:"sessionsWithoutCommits"`
+
+	// 読み込めなかったリポジトリ（移動・削除された場合など）。そのセッションはコミットの有無を判定しない
+	UnreadableGitRoots []string `json:"unreadableGitRoots"`
+}
+
+// SearchParams holds the query and filters for full-text search
+type SearchParams struct {
+	Query       string
+	ProjectName string
+	GroupID     *int64
+	GitBranch   string
+	Role        string // "user", "assistant", "tool"
+	From        string // YYYY-MM-DD（含む）
+	To          string // YYYY-MM-DD（含む）
+	Limit       int
+	Offset      int
+}
+
+// SearchResultItem represents a single search hit
+type SearchResultItem struct {
+	SessionID   string    `json:"sessionId"`
+	ProjectName string    `json:"projectName"`
+	GitBranch   string    `json:"gitBranch"`
+	EntryUUID   string    `json:"entryUuid,omitempty"`
+	SourceType  string    `json:"sourceType"`
+	Role        string    `json:"role"`
+	ToolName    string    `json:"toolName,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Snippet     string    `json:"snippet"`
+}
+
+// SearchResponse represents the response for full-text search
+type SearchResponse struct {
+	Query   string             `json:"query"`
+	Total   int                `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+	Results []SearchResultItem `json:"results"`
+}
+
+// ErrorPatternResponse represents a recurring error pattern
+type ErrorPatternResponse struct {
+	ID              int64     `json:"id"`
+	ToolName        string    `json:"toolName"`
+	ErrorMessage    string    `json:"errorMessage"`
+	OccurrenceCount int       `json:"occurrenceCount"`
+	SessionCount    int       `json:"sessionCount"`
+	ProjectCount    int       `json:"projectCount"`
+	FirstSeen       time.Time `json:"firstSeen"`
+	LastSeen        time.Time `json:"lastSeen"`
+}
+
+// ErrorPatternListResponse represents the list of error patterns
+type ErrorPatternListResponse struct {
+	Patterns []ErrorPatternResponse `json:"patterns"`
+}
+
+// ErrorOccurrenceResponse represents a single occurrence of an error pattern
+type ErrorOccurrenceResponse struct {
+	ID           int64       `json:"id"`
+	SessionID    string      `json:"sessionId"`
+	ProjectName  string      `json:"projectName"`
+	GitBranch    string      `json:"gitBranch"`
+	ToolName     string      `json:"toolName"`
+	Input        interface{} `json:"input"`
+	ErrorMessage string      `json:"errorMessage"`
+	OccurredAt   time.Time   `json:"occurredAt"`
+}
+
+// ErrorOccurrenceListResponse represents the occurrences of an error pattern
+type ErrorOccurrenceListResponse struct {
+	Pattern     ErrorPatternResponse      `json:"pattern"`
+	Occurrences []ErrorOccurrenceResponse `json:"occurrences"`
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// SetIdleCutoff replaces the longest gap between entries counted as AI work time
+// Call RecalculateActivityTimes afterwards to apply the new cutoff to stored sessions.
+func (db *DB) SetIdleCutoff(cutoff time.Duration) {
+	if cutoff <= 0 {
+		cutoff = analyzer.DefaultIdleCutoff
+	}
+	db.idleCutoff = cutoff
+}
+
+// ParseIdleCutoff parses an idle cutoff given in seconds (e.g. the IDLE_CUTOFF environment variable)
+func ParseIdleCutoff(value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("idle cutoff must be a positive number of seconds: %q", value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// RecalculateActivityTimes recomputes active_seconds and idle_seconds for every session
+// using the current idle cutoff
+func (db *DB) RecalculateActivityTimes() error {
+	rows, err := db.conn.Query("SELECT id FROM sessions")
+	if err != nil {
+		return fmt.Errorf("failed to query sessions: %w", err)
+	}
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan session ID: %w", err)
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating sessions: %w", err)
+	}
+	rows.Close()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range sessionIDs {
+		if err := db.updateActivityTime(tx, id, 0); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// updateActivityTime adds the active and idle time of the log entries stored after afterEntryID to a session
+// afterEntryID に 0 を渡すとセッション全体から計算し直す
+func (db *DB) updateActivityTime(tx *sql.Tx, sessionID string, afterEntryID int64) error {
+	entries, err := loadTreeEntries(tx, sessionID, afterEntryID)
+	if err != nil {
+		return err
+	}
+
+	var activity analyzer.ActivityTime
+	var last time.Time
+	if afterEntryID > 0 {
+		latest, err := latestTreeEntry(tx, sessionID, afterEntryID)
+		if err != nil {
+			return err
+		}
+		if latest != nil {
+			last = latest.Timestamp
+		}
+		// 保存済みのエントリより前の時刻のエントリは既存の間隔を分割するため、全体から計算し直す
+		for _, entry := range entries {
+			if !entry.Timestamp.IsZero() && entry.Timestamp.Before(last) {
+				return db.updateActivityTime(tx, sessionID, 0)
+			}
+		}
+
+		var activeMs, idleMs int64
+		err = tx.QueryRow("SELECT active_ms, idle_ms FROM sessions WHERE id = ?", sessionID).Scan(&activeMs, &idleMs)
+		if err != nil {
+			return fmt.Errorf("failed to get activity time: %w", err)
+		}
+		activity.Active = time.Duration(activeMs) * time.Millisecond
+		activity.Idle = time.Duration(idleMs) * time.Millisecond
+	}
+
+	activity = activity.Append(last, entries, db.idleCutoff)
+	_, err = tx.Exec(`
+		UPDATE sessions SET active_seconds = ?, idle_seconds = ?, active_ms = ?, idle_ms = ?
+		WHERE id = ?
+	`, activity.ActiveSeconds(), activity.IdleSeconds(), activity.Active.Milliseconds(), activity.Idle.Milliseconds(), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update activity time: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// createActivitySession returns a session with 40 seconds of AI work and 2 hours of waiting for the user
+func createActivitySession() *parser.Session {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	entry := func(uuid, entryType string, offset time.Duration, content parser.Content) parser.LogEntry {
+		return parser.LogEntry{
+			Type:      entryType,
+			Timestamp: start.Add(offset),
+			SessionID: "activity-session",
+			UUID:      uuid,
+			Message:   &parser.Message{Role: entryType, Content: []parser.Content{content}},
+		}
+	}
+	text := func(s string) parser.Content { return parser.Content{Type: "text", Text: s} }
+
+	return &parser.Session{
+		ID:          "activity-session",
+		ProjectPath: "/path/to/activity",
+		GitBranch:   "main",
+		StartTime:   start,
+		EndTime:     start.Add(2*time.Hour + 40*time.Second),
+		ModelUsage:  map[string]parser.TokenSummary{},
+		Entries: []parser.LogEntry{
+			entry("u1", "user", 0, text("Run the tests")),
+			entry("a1", "assistant", 10*time.Second, parser.Content{Type: "tool_use", ID: "toolu_1", Name: "Bash"}),
+			entry("r1", "user", 30*time.Second, parser.Content{Type: "tool_result", ToolUseID: "toolu_1", ToolResultContent: "ok"}),
+			entry("a2", "assistant", 35*time.Second, text("All tests pass")),
+			// 2時間後に次の入力
+			entry("u2", "user", 2*time.Hour+35*time.Second, text("Thanks")),
+			entry("a3", "assistant", 2*time.Hour+40*time.Second, text("You're welcome")),
+		},
+	}
+}
+
+func TestActivityTime(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectID, err := database.CreateProject("activity-project", "/path/to/activity")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if err := database.CreateSession(createActivitySession(), "activity-project", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	activity := func(t *testing.T) (int, int) {
+		t.Helper()
+		var active, idle int
+		err := database.conn.QueryRow(
+			"SELECT active_seconds, idle_seconds FROM sessions WHERE id = 'activity-session'",
+		).Scan(&active, &idle)
+		if err != nil {
+			t.Fatalf("Failed to query session: %v", err)
+		}
+		return active, idle
+	}
+
+	t.Run("作業時間と待機時間を記録する", func(t *testing.T) {
+		active, idle := activity(t)
+		if active != 40 || idle != 2*3600 {
+			t.Errorf("Expected 40s active / 7200s idle, got %d / %d", active, idle)
+		}
+	})
+
+	t.Run("統計と時系列に反映される", func(t *testing.T) {
+		stats, err := database.GetProjectStats(projectID, "")
+		if err != nil {
+			t.Fatalf("GetProjectStats failed: %v", err)
+		}
+		if stats.ActiveSeconds != 40 || stats.IdleSeconds != 2*3600 {
+			t.Errorf("Unexpected project stats: %+v", stats)
+		}
+
+		total, err := database.GetTotalStats("")
+		if err != nil {
+			t.Fatalf("GetTotalStats failed: %v", err)
+		}
+		if total.ActiveSeconds != 40 {
+			t.Errorf("Expected 40s active in total stats, got %d", total.ActiveSeconds)
+		}
+
+		timeline, err := database.GetTimeSeriesStats(projectID, "day", 30, "")
+		if err != nil {
+			t.Fatalf("GetTimeSeriesStats failed: %v", err)
+		}
+		if len(timeline) != 1 || timeline[0].ActiveSeconds != 40 || timeline[0].IdleSeconds != 2*3600 {
+			t.Errorf("Unexpected timeline: %+v", timeline)
+		}
+	})
+
+	t.Run("追記分の作業時間を加算する", func(t *testing.T) {
+		full := createActivitySession()
+		head := *full
+		head.ID = "activity-append"
+		head.Entries = full.Entries[:4]
+		head.EndTime = full.Entries[3].Timestamp
+		if err := database.CreateSession(&head, "activity-project", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		delta := &parser.Session{ModelUsage: map[string]parser.TokenSummary{}, Entries: full.Entries[4:], EndTime: full.EndTime}
+		if err := database.AppendSession("activity-append", delta, "activity-project", "activity-append.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		var active, idle int
+		var activeMs, idleMs int64
+		err := database.conn.QueryRow(
+			"SELECT active_seconds, idle_seconds, active_ms, idle_ms FROM sessions WHERE id = 'activity-append'",
+		).Scan(&active, &idle, &activeMs, &idleMs)
+		if err != nil {
+			t.Fatalf("Failed to query session: %v", err)
+		}
+		if active != 40 || idle != 2*3600 || activeMs != 40000 || idleMs != 2*3600*1000 {
+			t.Errorf("Expected 40s active / 7200s idle, got %d / %d (%dms / %dms)", active, idle, activeMs, idleMs)
+		}
+	})
+
+	t.Run("上限を変更して再計算できる", func(t *testing.T) {
+		database.SetIdleCutoff(10 * time.Second)
+		defer database.SetIdleCutoff(0)
+
+		if err := database.RecalculateActivityTimes(); err != nil {
+			t.Fatalf("RecalculateActivityTimes failed: %v", err)
+		}
+
+		// ツール実行の20秒のうち10秒が待機時間になる
+		active, idle := activity(t)
+		if active != 30 || idle != 2*3600+10 {
+			t.Errorf("Expected 30s active / 7210s idle, got %d / %d", active, idle)
+		}
+	})
+}
+
+func TestParseIdleCutoff(t *testing.T) {
+	if cutoff, err := ParseIdleCutoff("300"); err != nil || cutoff != 5*time.Minute {
+		t.Errorf("Expected 5m, got %v (%v)", cutoff, err)
+	}
+	for _, value := range []string{"0", "-1", "5m", ""} {
+		if _, err := ParseIdleCutoff(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// maxBashCommandLength is the maximum number of characters of a command stored in bash_commands
+const maxBashCommandLength = 1000
+
+// BashFilter holds the filters for Bash command statistics
+type BashFilter struct {
+	ProjectID *int64
+	GroupID   *int64
+	Version   string // Claude Codeのバージョン（sessions.cli_version）
+	From      string // YYYY-MM-DD（含む）
+	To        string // YYYY-MM-DD（含む）
+}
+
+// BashFamilyStats represents the outcome statistics of a command family
+type BashFamilyStats struct {
+	Family      string
+	ProjectName string // プロジェクト別集計の場合のみ
+
+	Count            int
+	FailureCount     int
+	InterruptedCount int
+	FailureRate      float64
+	P50DurationMs    int64
+	P95DurationMs    int64
+	TotalDurationMs  int64
+}
+
+// FailingBashCommand represents a command that failed repeatedly
+type FailingBashCommand struct {
+	Command      string
+	Program      string
+	Family       string
+	Count        int
+	FailureCount int
+	FailureRate  float64
+	LastError    string // 最後に失敗したときのstderr（なければ結果テキスト）
+	LastFailedAt time.Time
+}
+
+// BashStatsResult holds Bash command statistics per family, per project and family, and the most failing commands
+type BashStatsResult struct {
+	ByFamily        []BashFamilyStats
+	ByProject       []BashFamilyStats
+	FailingCommands []FailingBashCommand
+}
+
+// updateBashCommands classifies the Bash tool calls of a session stored after afterToolCallID
+// 分類は呼び出しの入力だけで決まるため、追記時は追記された呼び出しだけを分類する。0 を渡すとセッション全体から分類し直す
+func updateBashCommands(tx *sql.Tx, sessionID string, afterToolCallID int64) error {
+	if afterToolCallID == 0 {
+		if _, err := tx.Exec("DELETE FROM bash_commands WHERE session_id = ?", sessionID); err != nil {
+			return fmt.Errorf("failed to delete Bash commands: %w", err)
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, COALESCE(input_json, '')
+		FROM tool_calls
+		WHERE session_id = ? AND id > ? AND tool_name = 'Bash'
+		ORDER BY id
+	`, sessionID, afterToolCallID)
+	if err != nil {
+		return fmt.Errorf("failed to query Bash tool calls: %w", err)
+	}
+
+	type classifiedCall struct {
+		toolCallID int64
+		command    analyzer.BashCommand
+	}
+	var calls []classifiedCall
+	for rows.Next() {
+		var toolCallID int64
+		var input string
+		if err := rows.Scan(&toolCallID, &input); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan Bash tool call: %w", err)
+		}
+		if command, ok := analyzer.ParseBashCommand(input); ok {
+			calls = append(calls, classifiedCall{toolCallID: toolCallID, command: command})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating Bash tool calls: %w", err)
+	}
+	rows.Close()
+
+	if len(calls) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO bash_commands (session_id, tool_call_id, command, program, family)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare Bash command statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, call := range calls {
+		command := truncate(call.command.Command, maxBashCommandLength)
+		_, err := stmt.Exec(sessionID, call.toolCallID, command, call.command.Program, call.command.Family)
+		if err != nil {
+			return fmt.Errorf("failed to insert Bash command: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// bashCall is a classified Bash tool call loaded for aggregation
+type bashCall struct {
+	command     string
+	program     string
+	family      string
+	projectName string
+	timestamp   string
+	isError     bool
+	interrupted bool
+	durationMs  sql.NullInt64
+	errorText   string
+}
+
+// bashAggregate accumulates the Bash calls of one key
+type bashAggregate struct {
+	stats     BashFamilyStats
+	durations []int64
+}
+
+// add accumulates a call
+func (agg *bashAggregate) add(call bashCall) {
+	agg.stats.Count++
+	if call.isError {
+		agg.stats.FailureCount++
+	}
+	if call.interrupted {
+		agg.stats.InterruptedCount++
+	}
+	if call.durationMs.Valid {
+		agg.durations = append(agg.durations, call.durationMs.Int64)
+		agg.stats.TotalDurationMs += call.durationMs.Int64
+	}
+}
+
+// result finalizes the failure rate and percentiles
+func (agg *bashAggregate) result() BashFamilyStats {
+	stats := agg.stats
+	if stats.Count > 0 {
+		stats.FailureRate = float64(stats.FailureCount) / float64(stats.Count)
+	}
+	sort.Slice(agg.durations, func(i, j int) bool { return agg.durations[i] < agg.durations[j] })
+	stats.P50DurationMs = percentile(agg.durations, 50)
+	stats.P95DurationMs = percentile(agg.durations, 95)
+	return stats
+}
+
+// queryBashCalls loads the classified Bash calls matching the filter (oldest first)
+func (db *DB) queryBashCalls(filter BashFilter) ([]bashCall, error) {
+	// tool_calls.timestampはGoのtime.Time形式で保存されておりDATE()で解釈できないため、先頭10文字を日付とする
+	query := `
+		SELECT bc.command, bc.program, bc.family, p.name, tc.timestamp,
+		       tc.is_error AND tc.interruption_type IS NULL, tc.interrupted, tc.duration_ms,
+		       COALESCE(NULLIF(tc.stderr, ''), tc.result_text, '')
+		FROM bash_commands bc
+		INNER JOIN tool_calls tc ON bc.tool_call_id = tc.id
+		INNER JOIN sessions s ON bc.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+		WHERE 1 = 1
+	`
+
+	var args []interface{}
+	if filter.ProjectID != nil {
+		query += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		query += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.Version != "" {
+		query += " AND s.cli_version = ?"
+		args = append(args, filter.Version)
+	}
+	if filter.From != "" {
+		query += " AND SUBSTR(tc.timestamp, 1, 10) >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += " AND SUBSTR(tc.timestamp, 1, 10) <= ?"
+		args = append(args, filter.To)
+	}
+	query += " ORDER BY tc.timestamp, tc.id"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Bash commands: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []bashCall
+	for rows.Next() {
+		var call bashCall
+		err := rows.Scan(
+			&call.command, &call.program, &call.family, &call.projectName, &call.timestamp,
+			&call.isError, &call.interrupted, &call.durationMs, &call.errorText,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Bash command: %w", err)
+		}
+		calls = append(calls, call)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating Bash commands: %w", err)
+	}
+
+	return calls, nil
+}
+
+// GetBashStats retrieves the outcome statistics of Bash commands per family and per project,
+// and the commands that failed most often (limit <= 0 returns all failing commands)
+func (db *DB) GetBashStats(filter BashFilter, limit int) (*BashStatsResult, error) {
+	calls, err := db.queryBashCalls(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	families := make(map[string]*bashAggregate)
+	projects := make(map[string]*bashAggregate)
+	commands := make(map[string]*FailingBashCommand)
+	for _, call := range calls {
+		agg, ok := families[call.family]
+		if !ok {
+			agg = &bashAggregate{stats: BashFamilyStats{Family: call.family}}
+			families[call.family] = agg
+		}
+		agg.add(call)
+
+		projectKey := call.projectName + "\x00" + call.family
+		agg, ok = projects[projectKey]
+		if !ok {
+			agg = &bashAggregate{stats: BashFamilyStats{Family: call.family, ProjectName: call.projectName}}
+			projects[projectKey] = agg
+		}
+		agg.add(call)
+
+		cmd, ok := commands[call.command]
+		if !ok {
+			cmd = &FailingBashCommand{Command: call.command, Program: call.program, Family: call.family}
+			commands[call.command] = cmd
+		}
+		cmd.Count++
+		if call.isError {
+			// 時刻順に読み込んでいるため最後に見た失敗が最新
+			cmd.FailureCount++
+			cmd.LastError = call.errorText
+			cmd.LastFailedAt, _ = parseDateTime(call.timestamp)
+		}
+	}
+
+	result := &BashStatsResult{
+		ByFamily:        []BashFamilyStats{},
+		ByProject:       []BashFamilyStats{},
+		FailingCommands: []FailingBashCommand{},
+	}
+
+	// ファミリーは表示順（analyzer.BashFamilies）に並べる
+	for _, family := range analyzer.BashFamilies {
+		if agg, ok := families[family]; ok {
+			result.ByFamily = append(result.ByFamily, agg.result())
+		}
+	}
+
+	for _, agg := range projects {
+		result.ByProject = append(result.ByProject, agg.result())
+	}
+	sort.Slice(result.ByProject, func(i, j int) bool {
+		a, b := result.ByProject[i], result.ByProject[j]
+		if a.ProjectName != b.ProjectName {
+			return a.ProjectName < b.ProjectName
+		}
+		return a.Count > b.Count
+	})
+
+	for _, cmd := range commands {
+		if cmd.FailureCount == 0 {
+			continue
+		}
+		cmd.FailureRate = float64(cmd.FailureCount) / float64(cmd.Count)
+		result.FailingCommands = append(result.FailingCommands, *cmd)
+	}
+	// 失敗回数の多い順（同数の場合はコマンド順）
+	sort.Slice(result.FailingCommands, func(i, j int) bool {
+		a, b := result.FailingCommands[i], result.FailingCommands[j]
+		if a.FailureCount != b.FailureCount {
+			return a.FailureCount > b.FailureCount
+		}
+		return a.Command < b.Command
+	})
+	if limit > 0 && len(result.FailingCommands) > limit {
+		result.FailingCommands = result.FailingCommands[:limit]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestBashStats(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectAID, err := database.CreateProject("bash-project-a", "/path/to/bash-project-a")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if _, err := database.CreateProject("bash-project-b", "/path/to/bash-project-b"); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	day1 := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	bash := func(id string, timestamp time.Time, command string, isError bool, duration time.Duration) parser.ToolCall {
+		return parser.ToolCall{
+			ID: id, Timestamp: timestamp, Name: "Bash", IsError: isError, HasResult: true, Duration: duration,
+			Input: map[string]interface{}{"command": command},
+		}
+	}
+
+	sessionA := createTestSession("bash-a")
+	failedTest := bash("t2", day1.Add(time.Minute), "go test ./...", true, 4*time.Second)
+	failedTest.Stderr = "--- FAIL: TestA"
+	interrupted := bash("t4", day2, "npm run dev", false, 30*time.Second)
+	interrupted.Interrupted = true
+	sessionA.ToolCalls = []parser.ToolCall{
+		bash("t1", day1, "go test ./...", false, 2*time.Second),
+		failedTest,
+		bash("t3", day1.Add(2*time.Minute), "git status", false, 100*time.Millisecond),
+		interrupted,
+		{ID: "t5", Timestamp: day2, Name: "Read", HasResult: true, Input: map[string]interface{}{"file_path": "/a.go"}},
+	}
+	if err := database.CreateSession(sessionA, "bash-project-a", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	sessionB := createTestSession("bash-b")
+	sessionB.ToolCalls = []parser.ToolCall{
+		bash("t6", day2, "go test ./...", true, time.Second),
+		bash("t7", day2, "cd web && npm install", true, 10*time.Second),
+	}
+	sessionB.ToolCalls[0].Result = "exit status 1"
+	if err := database.CreateSession(sessionB, "bash-project-b", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("ファミリーごとに集計する", func(t *testing.T) {
+		result, err := database.GetBashStats(BashFilter{}, 10)
+		if err != nil {
+			t.Fatalf("GetBashStats failed: %v", err)
+		}
+
+		// 表示順: git, test, package, custom
+		if len(result.ByFamily) != 4 {
+			t.Fatalf("Expected 4 families, got %+v", result.ByFamily)
+		}
+		if result.ByFamily[0].Family != "git" || result.ByFamily[0].Count != 1 {
+			t.Errorf("Unexpected git stats: %+v", result.ByFamily[0])
+		}
+		test := result.ByFamily[1]
+		if test.Family != "test" || test.Count != 3 || test.FailureCount != 2 || test.P50DurationMs != 2000 || test.P95DurationMs != 4000 {
+			t.Errorf("Unexpected test stats: %+v", test)
+		}
+		if result.ByFamily[2].Family != "package" || result.ByFamily[2].FailureRate != 1 {
+			t.Errorf("Unexpected package stats: %+v", result.ByFamily[2])
+		}
+		if result.ByFamily[3].Family != "custom" || result.ByFamily[3].InterruptedCount != 1 {
+			t.Errorf("Unexpected custom stats: %+v", result.ByFamily[3])
+		}
+
+		if len(result.ByProject) != 5 || result.ByProject[0].ProjectName != "bash-project-a" || result.ByProject[0].Family != "test" {
+			t.Errorf("Unexpected project stats: %+v", result.ByProject)
+		}
+	})
+
+	t.Run("よく失敗するコマンドを返す", func(t *testing.T) {
+		result, err := database.GetBashStats(BashFilter{}, 10)
+		if err != nil {
+			t.Fatalf("GetBashStats failed: %v", err)
+		}
+		if len(result.FailingCommands) != 2 {
+			t.Fatalf("Expected 2 failing commands, got %+v", result.FailingCommands)
+		}
+
+		goTest := result.FailingCommands[0]
+		if goTest.Command != "go test ./..." || goTest.Count != 3 || goTest.FailureCount != 2 {
+			t.Errorf("Unexpected failing command: %+v", goTest)
+		}
+		// stderrがない場合は結果テキストを使う
+		if goTest.LastError != "exit status 1" || !goTest.LastFailedAt.Equal(day2) {
+			t.Errorf("Unexpected last failure: %q at %v", goTest.LastError, goTest.LastFailedAt)
+		}
+		if result.FailingCommands[1].Program != "npm" || result.FailingCommands[1].Family != "package" {
+			t.Errorf("Unexpected failing command: %+v", result.FailingCommands[1])
+		}
+	})
+
+	t.Run("プロジェクトと期間で絞り込む", func(t *testing.T) {
+		result, err := database.GetBashStats(BashFilter{ProjectID: &projectAID, To: "2025-06-01"}, 10)
+		if err != nil {
+			t.Fatalf("GetBashStats failed: %v", err)
+		}
+		if len(result.ByFamily) != 2 || result.ByFamily[1].Count != 2 {
+			t.Errorf("Unexpected filtered families: %+v", result.ByFamily)
+		}
+		if len(result.FailingCommands) != 1 || result.FailingCommands[0].LastError != "--- FAIL: TestA" {
+			t.Errorf("Unexpected filtered failing commands: %+v", result.FailingCommands)
+		}
+	})
+	t.Run("追記分の呼び出しだけを分類する", func(t *testing.T) {
+		projectCID, err := database.CreateProject("bash-project-c", "/path/to/bash-project-c")
+		if err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+		session := createTestSession("bash-c")
+		session.ToolCalls = []parser.ToolCall{bash("t8", day1, "git status", false, time.Second)}
+		if err := database.CreateSession(session, "bash-project-c", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		delta := &parser.Session{
+			ModelUsage: map[string]parser.TokenSummary{},
+			EndTime:    session.EndTime,
+			ToolCalls:  []parser.ToolCall{bash("t9", day1.Add(time.Minute), "git diff", false, time.Second)},
+		}
+		if err := database.AppendSession(session.ID, delta, "bash-project-c", "bash-c.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		result, err := database.GetBashStats(BashFilter{ProjectID: &projectCID}, 10)
+		if err != nil {
+			t.Fatalf("GetBashStats failed: %v", err)
+		}
+		if len(result.ByFamily) != 1 || result.ByFamily[0].Family != "git" || result.ByFamily[0].Count != 2 {
+			t.Errorf("Unexpected appended families: %+v", result.ByFamily)
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// CommandStats represents usage statistics of a slash command
+type CommandStats struct {
+	Name        string
+	Custom      bool
+	ProjectName string // プロジェクト別集計の場合のみ
+
+	Invocations int
+	Sessions    int
+	Projects    int
+
+	// コマンドから次のユーザー入力までのトークン数の合計
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+}
+
+// CommandStatsResult holds slash command statistics overall and per project
+type CommandStatsResult struct {
+	ByCommand []CommandStats
+	ByProject []CommandStats
+}
+
+// updateSessionCommands extracts the slash commands of a session and stores them with the usage of the turns that follow
+// afterEntryID に 0 を渡すとセッション全体から抽出し直す。それ以外は追記されたエントリだけを読み、
+// 最後のコマンドのターンが続いていればその使用量に加算する。セッションのタスクを更新する前に呼ぶこと
+func updateSessionCommands(tx *sql.Tx, sessionID string, afterEntryID int64) error {
+	if afterEntryID == 0 {
+		return extractSessionCommands(tx, sessionID)
+	}
+
+	entries, err := loadTaskEntries(tx, sessionID, afterEntryID)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// 既存のエントリより前に割り込む追記はターンの区切りが変わるため、全体から抽出し直す
+	latest, err := latestTreeEntry(tx, sessionID, afterEntryID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && entries[0].Timestamp.Before(latest.Timestamp) {
+		return extractSessionCommands(tx, sessionID)
+	}
+
+	openID, open, err := openCommandInvocation(tx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	invocations := analyzer.ContinueCommands(open, entries)
+	if open != nil {
+		_, err := tx.Exec(`
+			UPDATE command_invocations
+			SET entry_count = ?, input_tokens = ?, output_tokens = ?,
+			    cache_creation_tokens = ?, cache_read_tokens = ?
+			WHERE id = ?
+		`, open.EntryCount, open.InputTokens, open.OutputTokens,
+			open.CacheCreationTokens, open.CacheReadTokens, openID)
+		if err != nil {
+			return fmt.Errorf("failed to update command invocation %s: %w", open.Name, err)
+		}
+	}
+
+	return insertCommandInvocations(tx, sessionID, invocations)
+}
+
+// extractSessionCommands replaces the slash commands of a session with the ones extracted from all its entries
+func extractSessionCommands(tx *sql.Tx, sessionID string) error {
+	entries, err := loadTaskEntries(tx, sessionID, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM command_invocations WHERE session_id = ?", sessionID); err != nil {
+		return fmt.Errorf("failed to delete command invocations: %w", err)
+	}
+
+	return insertCommandInvocations(tx, sessionID, analyzer.ExtractCommands(entries))
+}
+
+// openCommandInvocation returns the stored command whose turn has not ended yet, or nil if there is none
+// 最後のタスクがコマンドで始まっている場合、そのコマンドのターンは次のユーザー入力まで続く
+func openCommandInvocation(tx *sql.Tx, sessionID string) (int64, *analyzer.CommandInvocation, error) {
+	var id int64
+	var invocation analyzer.CommandInvocation
+	var timestampStr string
+	err := tx.QueryRow(`
+		SELECT id, entry_uuid, timestamp, name, args, is_custom, entry_count,
+		       input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens
+		FROM command_invocations
+		WHERE session_id = ? AND entry_uuid = (
+			SELECT start_uuid FROM session_tasks
+			WHERE session_id = ?
+			ORDER BY task_index DESC
+			LIMIT 1
+		)
+		ORDER BY id DESC
+		LIMIT 1
+	`, sessionID, sessionID).Scan(
+		&id, &invocation.UUID, &timestampStr, &invocation.Name, &invocation.Args, &invocation.Custom,
+		&invocation.EntryCount, &invocation.InputTokens, &invocation.OutputTokens,
+		&invocation.CacheCreationTokens, &invocation.CacheReadTokens,
+	)
+	if err == sql.ErrNoRows {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get open command invocation: %w", err)
+	}
+	invocation.Timestamp, _ = parseDateTime(timestampStr)
+	return id, &invocation, nil
+}
+
+// insertCommandInvocations inserts the slash commands of a session
+func insertCommandInvocations(tx *sql.Tx, sessionID string, invocations []analyzer.CommandInvocation) error {
+	if len(invocations) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO command_invocations (
+			session_id, entry_uuid, timestamp, name, args, is_custom, entry_count,
+			input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare command invocation statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, invocation := range invocations {
+		_, err := stmt.Exec(
+			sessionID, invocation.UUID, formatSortableTime(invocation.Timestamp),
+			invocation.Name, truncate(invocation.Args, 200), invocation.Custom, invocation.EntryCount,
+			invocation.InputTokens, invocation.OutputTokens, invocation.CacheCreationTokens, invocation.CacheReadTokens,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert command invocation %s: %w", invocation.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetCommandStats retrieves the frequency of slash commands and the tokens spent after them, overall and per project
+// projectID / groupID が指定された場合はそのプロジェクト・グループのセッションに絞り込む
+func (db *DB) GetCommandStats(projectID, groupID *int64) (*CommandStatsResult, error) {
+	where := " WHERE 1 = 1"
+	var args []interface{}
+	if projectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if groupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *groupID)
+	}
+
+	result := &CommandStatsResult{}
+	var err error
+	result.ByCommand, err = db.queryCommandStats("''", where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ByProject, err = db.queryCommandStats("p.name", where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// queryCommandStats aggregates command_invocations per command (and projectExpr), ordered by frequency
+func (db *DB) queryCommandStats(projectExpr, where string, args []interface{}) ([]CommandStats, error) {
+	query := `
+		SELECT ci.name, MAX(ci.is_custom), ` + projectExpr + ` as project_name,
+		       COUNT(*) as invocations,
+		       COUNT(DISTINCT ci.session_id),
+		       COUNT(DISTINCT s.project_id),
+		       SUM(ci.input_tokens), SUM(ci.output_tokens),
+		       SUM(ci.cache_creation_tokens), SUM(ci.cache_read_tokens)
+		FROM command_invocations ci
+		INNER JOIN sessions s ON ci.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+	` + where + `
+		GROUP BY ci.name, project_name
+		ORDER BY invocations DESC, project_name, ci.name
+	`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query command invocations: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []CommandStats{}
+	for rows.Next() {
+		var stat CommandStats
+		err := rows.Scan(
+			&stat.Name, &stat.Custom, &stat.ProjectName,
+			&stat.Invocations, &stat.Sessions, &stat.Projects,
+			&stat.InputTokens, &stat.OutputTokens, &stat.CacheCreationTokens, &stat.CacheReadTokens,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan command invocations: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating command invocations: %w", err)
+	}
+
+	return stats, nil
+}
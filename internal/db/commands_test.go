@@ -0,0 +1,199 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// createCommandSession returns a session running /review (with its expanded prompt) and /clear
+func createCommandSession(id string) *parser.Session {
+	start := time.Date(2025, 6, 3, 9, 0, 0, 0, time.UTC)
+	entry := func(uuid, entryType string, seconds int, text string, output int) parser.LogEntry {
+		e := parser.LogEntry{
+			Type:      entryType,
+			Timestamp: start.Add(time.Duration(seconds) * time.Second),
+			SessionID: id,
+			UUID:      id + "-" + uuid,
+			Message:   &parser.Message{Role: entryType, Content: []parser.Content{{Type: "text", Text: text}}},
+		}
+		if entryType == "assistant" {
+			e.Message.Usage = &parser.Usage{InputTokens: 10, OutputTokens: output}
+		}
+		return e
+	}
+
+	expanded := entry("m1", "user", 11, "Review the pull request and report problems", 0)
+	expanded.IsMeta = true
+
+	return &parser.Session{
+		ID:          id,
+		ProjectPath: "/path/to/" + id,
+		GitBranch:   "main",
+		StartTime:   start,
+		EndTime:     start.Add(60 * time.Second),
+		ModelUsage:  map[string]parser.TokenSummary{},
+		Entries: []parser.LogEntry{
+			entry("u1", "user", 0, "Fix the bug", 0),
+			entry("a1", "assistant", 5, "Done", 100),
+			entry("c1", "user", 10, "<command-name>/team:review</command-name>\n<command-args>42</command-args>", 0),
+			expanded,
+			entry("a2", "assistant", 20, "Looks good", 300),
+			entry("c2", "user", 30, "<command-name>/clear</command-name>", 0),
+			entry("u2", "user", 40, "Add a README", 0),
+			entry("a3", "assistant", 50, "Added", 50),
+		},
+	}
+}
+
+func TestCommandStats(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectID, err := database.CreateProject("command-a", "/path/to/command-a")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if _, err := database.CreateProject("command-b", "/path/to/command-b"); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if err := database.CreateSession(createCommandSession("command-a"), "command-a", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := database.CreateSession(createCommandSession("command-b"), "command-b", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("コマンドごとの回数と後続のトークン数を返す", func(t *testing.T) {
+		stats, err := database.GetCommandStats(nil, nil)
+		if err != nil {
+			t.Fatalf("GetCommandStats failed: %v", err)
+		}
+		if len(stats.ByCommand) != 2 {
+			t.Fatalf("Expected 2 commands, got %+v", stats.ByCommand)
+		}
+
+		byName := make(map[string]CommandStats)
+		for _, stat := range stats.ByCommand {
+			byName[stat.Name] = stat
+		}
+		// 展開されたプロンプト（isMeta）ではターンを区切らない
+		review := byName["/team:review"]
+		if !review.Custom || review.Invocations != 2 || review.Sessions != 2 || review.Projects != 2 || review.OutputTokens != 600 {
+			t.Errorf("Unexpected /team:review stats: %+v", review)
+		}
+		clear := byName["/clear"]
+		if clear.Custom || clear.Invocations != 2 || clear.OutputTokens != 0 {
+			t.Errorf("Unexpected /clear stats: %+v", clear)
+		}
+
+		if len(stats.ByProject) != 4 || stats.ByProject[0].ProjectName == "" {
+			t.Errorf("Unexpected per-project stats: %+v", stats.ByProject)
+		}
+	})
+
+	t.Run("タスクは展開されたプロンプトで分割しない", func(t *testing.T) {
+		tasks, err := database.GetSessionTasks("command-a")
+		if err != nil {
+			t.Fatalf("GetSessionTasks failed: %v", err)
+		}
+		if len(tasks) != 4 {
+			t.Errorf("Expected 4 tasks, got %d", len(tasks))
+		}
+	})
+
+	t.Run("圧縮後の要約はユーザー入力とみなさない", func(t *testing.T) {
+		compactDB, _ := setupTestDB(t)
+		defer compactDB.Close()
+		compactProjectID, err := compactDB.CreateProject("command-compact", "/path/to/command-compact")
+		if err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+
+		// /team:review のターンの途中でコンテキストが圧縮される
+		session := createCommandSession("command-compact")
+		summary := session.Entries[4]
+		summary.UUID = "command-compact-s1"
+		summary.Timestamp = summary.Timestamp.Add(time.Second)
+		summary.Type = "user"
+		summary.IsCompactSummary = true
+		summary.Message = &parser.Message{Role: "user", Content: []parser.Content{{Type: "text", Text: "This session is being continued from a previous conversation."}}}
+		reply := session.Entries[4]
+		reply.UUID = "command-compact-a4"
+		reply.Timestamp = summary.Timestamp.Add(time.Second)
+		session.Entries = append(session.Entries[:5:5], append([]parser.LogEntry{summary, reply}, session.Entries[5:]...)...)
+		if err := compactDB.CreateSession(session, "command-compact", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		tasks, err := compactDB.GetSessionTasks(session.ID)
+		if err != nil {
+			t.Fatalf("GetSessionTasks failed: %v", err)
+		}
+		if len(tasks) != 4 {
+			t.Errorf("Expected 4 tasks, got %d", len(tasks))
+		}
+
+		stats, err := compactDB.GetCommandStats(&compactProjectID, nil)
+		if err != nil {
+			t.Fatalf("GetCommandStats failed: %v", err)
+		}
+		for _, stat := range stats.ByCommand {
+			if stat.Name == "/team:review" && stat.OutputTokens != 600 {
+				t.Errorf("Expected the /team:review turn to continue after the summary, got %+v", stat)
+			}
+		}
+	})
+
+	t.Run("プロジェクトで絞り込める", func(t *testing.T) {
+		stats, err := database.GetCommandStats(&projectID, nil)
+		if err != nil {
+			t.Fatalf("GetCommandStats failed: %v", err)
+		}
+		if len(stats.ByCommand) != 2 || stats.ByCommand[0].Invocations != 1 || len(stats.ByProject) != 2 {
+			t.Errorf("Unexpected project stats: %+v", stats)
+		}
+	})
+	t.Run("追記分は続いているコマンドのターンに加算する", func(t *testing.T) {
+		appendDB, _ := setupTestDB(t)
+		defer appendDB.Close()
+		appendProjectID, err := appendDB.CreateProject("command-append", "/path/to/command-append")
+		if err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+
+		// /team:review の応答を受け取る前まで
+		full := createCommandSession("command-append")
+		head := *full
+		head.Entries = full.Entries[:4]
+		head.EndTime = full.Entries[3].Timestamp
+		if err := appendDB.CreateSession(&head, "command-append", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		delta := &parser.Session{
+			ModelUsage: map[string]parser.TokenSummary{},
+			EndTime:    full.EndTime,
+			Entries:    full.Entries[4:],
+		}
+		if err := appendDB.AppendSession(full.ID, delta, "command-append", "command-append.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		stats, err := appendDB.GetCommandStats(&appendProjectID, nil)
+		if err != nil {
+			t.Fatalf("GetCommandStats failed: %v", err)
+		}
+		byName := make(map[string]CommandStats)
+		for _, stat := range stats.ByCommand {
+			byName[stat.Name] = stat
+		}
+		if review := byName["/team:review"]; review.Invocations != 1 || review.OutputTokens != 300 {
+			t.Errorf("Unexpected /team:review stats: %+v", review)
+		}
+		if clear := byName["/clear"]; clear.Invocations != 1 || clear.OutputTokens != 0 {
+			t.Errorf("Unexpected /clear stats: %+v", clear)
+		}
+	})
+}
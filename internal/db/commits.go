@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// CommitFilter holds the filters for the sessions correlated with git commits
+type CommitFilter struct {
+	ProjectID *int64
+	GroupID   *int64
+	From      string // YYYY-MM-DD（含む）
+	To        string // YYYY-MM-DD（含む）
+}
+
+// CommitSessionRow represents a top-level session of a project under Git control
+type CommitSessionRow struct {
+	ID               string
+	ProjectName      string
+	GitRoot          string
+	GitBranch        string
+	StartTime        time.Time
+	EndTime          time.Time
+	TotalTokens      int     // 入力+出力（サブエージェントを含む）
+	CostUSD          float64 // サブエージェントを含む
+	FirstUserMessage string
+}
+
+// ListCommitSessions retrieves the top-level sessions of projects with a git root, oldest first
+func (db *DB) ListCommitSessions(filter CommitFilter) ([]CommitSessionRow, error) {
+	query := `
+		SELECT s.id, p.name, p.git_root, COALESCE(s.git_branch, ''), s.start_time, s.end_time,
+		       s.total_input_tokens + s.total_output_tokens
+		           + COALESCE(SUM(sub.total_input_tokens + sub.total_output_tokens), 0),
+		       s.total_cost_usd + COALESCE(SUM(sub.total_cost_usd), 0),
+		       COALESCE(s.first_user_message, '')
+		FROM sessions s
+		INNER JOIN projects p ON s.project_id = p.id
+		LEFT JOIN sessions sub ON sub.parent_session_id = s.id
+		WHERE s.parent_session_id IS NULL AND COALESCE(p.git_root, '') != ''
+	`
+
+	var args []interface{}
+	if filter.ProjectID != nil {
+		query += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		query += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.From != "" {
+		query += " AND DATE(s.start_time) >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += " AND DATE(s.start_time) <= ?"
+		args = append(args, filter.To)
+	}
+	query += " GROUP BY s.id ORDER BY julianday(s.start_time), s.id"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commit sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []CommitSessionRow{}
+	for rows.Next() {
+		var session CommitSessionRow
+		var startTime, endTime string
+		err := rows.Scan(
+			&session.ID, &session.ProjectName, &session.GitRoot, &session.GitBranch, &startTime, &endTime,
+			&session.TotalTokens, &session.CostUSD, &session.FirstUserMessage,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan commit session: %w", err)
+		}
+		session.StartTime, _ = parseDateTime(startTime)
+		session.EndTime, _ = parseDateTime(endTime)
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commit sessions: %w", err)
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListCommitSessions(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectAID, err := database.CreateProjectWithGitRoot("commit-project-a", "/path/to/commit-project-a", "/path/to/repo")
+	if err != nil {
+		t.Fatalf("CreateProjectWithGitRoot failed: %v", err)
+	}
+	if _, err := database.CreateProject("commit-project-b", "/path/to/commit-project-b"); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	day1 := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	create := func(suffix, projectName string, startTime time.Time) {
+		session := createTestSession(suffix)
+		session.StartTime = startTime
+		session.EndTime = startTime.Add(time.Hour)
+		if err := database.CreateSession(session, projectName, time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+	create("commit-2", "commit-project-a", day1.Add(24*time.Hour))
+	create("commit-1", "commit-project-a", day1)
+	// Git管理外のプロジェクトのセッションは含めない
+	create("commit-3", "commit-project-b", day1)
+
+	t.Run("Git管理下のセッションを開始順に返す", func(t *testing.T) {
+		sessions, err := database.ListCommitSessions(CommitFilter{})
+		if err != nil {
+			t.Fatalf("ListCommitSessions failed: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("Expected 2 sessions, got %+v", sessions)
+		}
+
+		first := sessions[0]
+		if first.ID != "test-session-commit-1" || first.GitRoot != "/path/to/repo" || first.GitBranch != "main" {
+			t.Errorf("Unexpected session: %+v", first)
+		}
+		if !first.StartTime.Equal(day1) || !first.EndTime.Equal(day1.Add(time.Hour)) || first.TotalTokens != 150 {
+			t.Errorf("Unexpected session window: %+v", first)
+		}
+	})
+
+	t.Run("プロジェクトと開始日で絞り込む", func(t *testing.T) {
+		sessions, err := database.ListCommitSessions(CommitFilter{ProjectID: &projectAID, From: "2025-06-02"})
+		if err != nil {
+			t.Fatalf("ListCommitSessions failed: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].ID != "test-session-commit-2" {
+			t.Errorf("Unexpected sessions: %+v", sessions)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// contextTokensExpr is the context size of the API request of a log entry
+// 入力トークンとキャッシュの作成・読取トークンの合計がリクエスト時のコンテキストサイズになる
+const contextTokensExpr = "(le.input_tokens + le.cache_creation_tokens + le.cache_read_tokens)"
+
+// ContextPoint is the context size of a single API request
+type ContextPoint struct {
+	UUID                string
+	Timestamp           time.Time
+	Model               string
+	InputTokens         int
+	CacheCreationTokens int
+	CacheReadTokens     int
+	ContextTokens       int
+}
+
+// ContextUsage is the context-size series and the compactions of a session
+type ContextUsage struct {
+	PeakContextTokens int
+	CompactionCount   int
+	Points            []ContextPoint
+	Compactions       []parser.Compaction
+}
+
+// insertCompactions records the context compactions of a session within a transaction
+func insertCompactions(tx *sql.Tx, sessionID string, compactions []parser.Compaction) error {
+	if len(compactions) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO session_compactions (session_id, uuid, timestamp, trigger, pre_tokens)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare compaction statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, compaction := range compactions {
+		_, err = stmt.Exec(sessionID, compaction.UUID, compaction.Timestamp, compaction.Trigger, compaction.PreTokens)
+		if err != nil {
+			return fmt.Errorf("failed to insert compaction %s: %w", compaction.UUID, err)
+		}
+	}
+
+	return nil
+}
+
+// updateContextStats adds the log entries stored after afterEntryID to the peak context size of a session
+// and recounts its compactions
+// afterEntryID に 0 を渡すと保存済みの値を使わず、セッション全体のエントリから集計する
+func updateContextStats(tx *sql.Tx, sessionID string, afterEntryID int64) error {
+	peak := "peak_context_tokens"
+	if afterEntryID == 0 {
+		peak = "0"
+	}
+
+	_, err := tx.Exec(`
+		UPDATE sessions SET
+			peak_context_tokens = MAX(`+peak+`, (
+				SELECT COALESCE(MAX(`+contextTokensExpr+`), 0)
+				FROM log_entries le WHERE le.session_id = sessions.id AND le.id > ?
+			)),
+			compaction_count = (SELECT COUNT(*) FROM session_compactions WHERE session_id = sessions.id)
+		WHERE id = ?
+	`, afterEntryID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update context stats: %w", err)
+	}
+	return nil
+}
+
+// GetContextUsage retrieves the context size of each API request and the compactions of a session
+func (db *DB) GetContextUsage(sessionID string) (*ContextUsage, error) {
+	usage := &ContextUsage{
+		Points:      []ContextPoint{},
+		Compactions: []parser.Compaction{},
+	}
+
+	err := db.conn.QueryRow(
+		"SELECT peak_context_tokens, compaction_count FROM sessions WHERE id = ?", sessionID,
+	).Scan(&usage.PeakContextTokens, &usage.CompactionCount)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session: %w", err)
+	}
+
+	// トークン数を記録したエントリ（API応答ごとに最初のエントリ）のみを対象とする
+	rows, err := db.conn.Query(`
+		SELECT le.uuid, le.timestamp, COALESCE(m.model, ''),
+		       le.input_tokens, le.cache_creation_tokens, le.cache_read_tokens,
+		       `+contextTokensExpr+`
+		FROM log_entries le
+		LEFT JOIN messages m ON le.id = m.log_entry_id
+		WHERE le.session_id = ? AND le.entry_type = 'assistant' AND `+contextTokensExpr+` > 0
+		ORDER BY le.timestamp, le.id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query context usage: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var point ContextPoint
+		err := rows.Scan(
+			&point.UUID, &point.Timestamp, &point.Model,
+			&point.InputTokens, &point.CacheCreationTokens, &point.CacheReadTokens,
+			&point.ContextTokens,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan context usage: %w", err)
+		}
+		usage.Points = append(usage.Points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating context usage: %w", err)
+	}
+
+	compactionRows, err := db.conn.Query(`
+		SELECT uuid, timestamp, trigger, pre_tokens
+		FROM session_compactions
+		WHERE session_id = ?
+		ORDER BY timestamp, id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query compactions: %w", err)
+	}
+	defer compactionRows.Close()
+
+	for compactionRows.Next() {
+		var compaction parser.Compaction
+		err := compactionRows.Scan(&compaction.UUID, &compaction.Timestamp, &compaction.Trigger, &compaction.PreTokens)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan compaction: %w", err)
+		}
+		usage.Compactions = append(usage.Compactions, compaction)
+	}
+	if err := compactionRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating compactions: %w", err)
+	}
+
+	return usage, nil
+}
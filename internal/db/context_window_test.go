@@ -0,0 +1,173 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// createCompactedSession returns a session that was auto-compacted once
+// 圧縮前のコンテキストは 155,000 トークン、圧縮後は 12,010 トークン
+func createCompactedSession() *parser.Session {
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	assistant := func(uuid, msgID string, offset time.Duration, usage parser.Usage) parser.LogEntry {
+		return parser.LogEntry{
+			Type:      "assistant",
+			Timestamp: start.Add(offset),
+			SessionID: "compacted-session",
+			UUID:      uuid,
+			RequestID: "req_" + msgID,
+			Message: &parser.Message{
+				Model:   "claude-sonnet-4-5",
+				ID:      msgID,
+				Role:    "assistant",
+				Content: []parser.Content{{Type: "text", Text: "OK"}},
+				Usage:   &usage,
+			},
+		}
+	}
+
+	before := parser.Usage{InputTokens: 10, OutputTokens: 50, CacheCreationInputTokens: 40000, CacheReadInputTokens: 114990}
+	after := parser.Usage{InputTokens: 10, OutputTokens: 20, CacheCreationInputTokens: 12000}
+	session := &parser.Session{
+		ID:          "compacted-session",
+		ProjectPath: "/path/to/context",
+		GitBranch:   "main",
+		StartTime:   start,
+		EndTime:     start.Add(10 * time.Minute),
+		ModelUsage:  map[string]parser.TokenSummary{},
+		Entries: []parser.LogEntry{
+			{Type: "user", Timestamp: start, SessionID: "compacted-session", UUID: "u1",
+				Message: &parser.Message{Role: "user", Content: []parser.Content{{Type: "text", Text: "Fix the build"}}}},
+			assistant("a1", "msg_01", time.Second, before),
+			// 同じAPI応答の2行目はコンテキストサイズに含めない
+			assistant("a1-2", "msg_01", 2*time.Second, before),
+			{Type: "system", Subtype: "compact_boundary", Timestamp: start.Add(5 * time.Minute), SessionID: "compacted-session", UUID: "c1",
+				CompactMetadata: &parser.CompactMetadata{Trigger: "auto", PreTokens: 155060}},
+			assistant("a2", "msg_02", 10*time.Minute, after),
+		},
+		Compactions: []parser.Compaction{
+			{UUID: "c1", Timestamp: start.Add(5 * time.Minute), Trigger: "auto", PreTokens: 155060},
+		},
+	}
+	for _, usage := range []struct {
+		key   string
+		usage parser.Usage
+	}{{"msg_01:req_msg_01", before}, {"msg_02:req_msg_02", after}} {
+		var tokens parser.TokenSummary
+		tokens.Add(&usage.usage)
+		session.TotalTokens.Add(&usage.usage)
+		session.MessageUsages = append(session.MessageUsages, parser.MessageUsage{Key: usage.key, Model: "claude-sonnet-4-5", Tokens: tokens})
+	}
+	return session
+}
+
+func TestContextUsage(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectID, err := database.CreateProject("context-project", "/path/to/context")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	session := createCompactedSession()
+	if err := database.CreateSession(session, "context-project", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("API応答ごとのコンテキストサイズと圧縮を返す", func(t *testing.T) {
+		usage, err := database.GetContextUsage("compacted-session")
+		if err != nil {
+			t.Fatalf("GetContextUsage failed: %v", err)
+		}
+
+		if usage.PeakContextTokens != 155000 || usage.CompactionCount != 1 {
+			t.Errorf("Expected peak 155000 and 1 compaction, got %d / %d", usage.PeakContextTokens, usage.CompactionCount)
+		}
+		if len(usage.Points) != 2 {
+			t.Fatalf("Expected 2 points, got %+v", usage.Points)
+		}
+		if usage.Points[0].UUID != "a1" || usage.Points[1].ContextTokens != 12010 || usage.Points[1].Model != "claude-sonnet-4-5" {
+			t.Errorf("Unexpected points: %+v", usage.Points)
+		}
+		if len(usage.Compactions) != 1 || usage.Compactions[0].Trigger != "auto" || usage.Compactions[0].PreTokens != 155060 {
+			t.Errorf("Unexpected compactions: %+v", usage.Compactions)
+		}
+	})
+
+	t.Run("再解析しても圧縮は重複しない", func(t *testing.T) {
+		if err := database.UpdateSession(createCompactedSession(), "context-project", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+
+		usage, err := database.GetContextUsage("compacted-session")
+		if err != nil {
+			t.Fatalf("GetContextUsage failed: %v", err)
+		}
+		if usage.CompactionCount != 1 || len(usage.Compactions) != 1 || len(usage.Points) != 2 {
+			t.Errorf("Unexpected usage after update: %+v", usage)
+		}
+	})
+
+	t.Run("プロジェクト統計とセッション一覧に反映される", func(t *testing.T) {
+		stats, err := database.GetProjectStats(projectID, "")
+		if err != nil {
+			t.Fatalf("GetProjectStats failed: %v", err)
+		}
+		if stats.MaxPeakContextTokens != 155000 || stats.AvgPeakContextTokens != 155000 || stats.Compactions != 1 {
+			t.Errorf("Unexpected project stats: %+v", stats)
+		}
+
+		page, err := database.ListSessionsPage(SessionListFilter{SortBy: SessionSortContext, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListSessionsPage failed: %v", err)
+		}
+		if len(page.Sessions) != 1 || page.Sessions[0].PeakContextTokens != 155000 || page.Sessions[0].CompactionCount != 1 {
+			t.Errorf("Unexpected sessions: %+v", page.Sessions)
+		}
+	})
+
+	t.Run("存在しないセッションはエラー", func(t *testing.T) {
+		if _, err := database.GetContextUsage("missing"); err == nil {
+			t.Error("Expected error for missing session, got nil")
+		}
+	})
+
+	t.Run("追記分のコンテキストサイズと圧縮を加算する", func(t *testing.T) {
+		appendDB, _ := setupTestDB(t)
+		defer appendDB.Close()
+		if _, err := appendDB.CreateProject("context-project", "/path/to/context"); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+
+		full := createCompactedSession()
+		head := *full
+		head.Entries = full.Entries[:3]
+		head.Compactions = nil
+		head.MessageUsages = full.MessageUsages[:1]
+		if err := appendDB.CreateSession(&head, "context-project", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		delta := &parser.Session{
+			ModelUsage:    map[string]parser.TokenSummary{},
+			EndTime:       full.EndTime,
+			Entries:       full.Entries[3:],
+			Compactions:   full.Compactions,
+			MessageUsages: full.MessageUsages[1:],
+		}
+		if err := appendDB.AppendSession(full.ID, delta, "context-project", "compacted-session.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		usage, err := appendDB.GetContextUsage(full.ID)
+		if err != nil {
+			t.Fatalf("GetContextUsage failed: %v", err)
+		}
+		if usage.PeakContextTokens != 155000 || usage.CompactionCount != 1 || len(usage.Points) != 2 {
+			t.Errorf("Unexpected usage after append: %+v", usage)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadTreeEntries loads the fields of a session's log entries needed to build the conversation tree
+// afterEntryID が 0 より大きい場合はそれより後に保存されたエントリ（追記分）だけを読み込む
+func loadTreeEntries(q rowQuerier, sessionID string, afterEntryID int64) ([]analyzer.TreeEntry, error) {
+	// tool_resultを含まないユーザーメッセージをユーザー入力とみなす
+	query := `
+		SELECT le.uuid, COALESCE(le.parent_uuid, ''), le.entry_type, le.timestamp,
+		       le.entry_type = 'user' AND m.content_json IS NOT NULL
+		           AND m.content_json NOT LIKE '%"type":"tool_result"%',
+		       le.input_tokens, le.output_tokens
+		FROM log_entries le
+		LEFT JOIN messages m ON le.id = m.log_entry_id
+		WHERE le.session_id = ? AND le.id > ?
+		ORDER BY le.timestamp, le.id
+	`
+	rows, err := q.Query(query, sessionID, afterEntryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []analyzer.TreeEntry
+	for rows.Next() {
+		var entry analyzer.TreeEntry
+		var timestamp time.Time
+		err := rows.Scan(
+			&entry.UUID, &entry.ParentUUID, &entry.Type, &timestamp,
+			&entry.IsPrompt, &entry.InputTokens, &entry.OutputTokens,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log entry: %w", err)
+		}
+		entry.Timestamp = timestamp
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating log entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// latestTreeEntry returns the latest of the log entries of a session stored up to lastEntryID, or nil if there are none
+func latestTreeEntry(tx *sql.Tx, sessionID string, lastEntryID int64) (*analyzer.TreeEntry, error) {
+	var entry analyzer.TreeEntry
+	var timestamp time.Time
+	err := tx.QueryRow(`
+		SELECT uuid, timestamp
+		FROM log_entries
+		WHERE session_id = ? AND id <= ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT 1
+	`, sessionID, lastEntryID).Scan(&entry.UUID, &timestamp)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest log entry: %w", err)
+	}
+	entry.Timestamp = timestamp
+	return &entry, nil
+}
+
+// updateConversationTree stores the abandoned branch totals of a session's conversation tree
+// afterEntryID に 0 を渡すとツリー全体から計算する。追記分が既存のメインパスを延ばすだけの場合は集計値が変わらないため何もしない
+func updateConversationTree(tx *sql.Tx, sessionID string, afterEntryID int64) error {
+	if afterEntryID > 0 {
+		extends, err := extendsLatestEntry(tx, sessionID, afterEntryID)
+		if err != nil {
+			return err
+		}
+		if extends {
+			return nil
+		}
+	}
+
+	// 巻き戻しや編集で分岐すると既存のエントリがメインパスから外れるため、ツリー全体から計算し直す
+	entries, err := loadTreeEntries(tx, sessionID, 0)
+	if err != nil {
+		return err
+	}
+
+	tree := analyzer.BuildConversationTree(entries)
+	inputTokens, outputTokens := tree.AbandonedTokens()
+
+	_, err = tx.Exec(`
+		UPDATE sessions SET
+			abandoned_branches = ?,
+			abandoned_entries = ?,
+			abandoned_input_tokens = ?,
+			abandoned_output_tokens = ?
+		WHERE id = ?
+	`, len(tree.Branches), tree.AbandonedEntries(), inputTokens, outputTokens, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation tree: %w", err)
+	}
+
+	return nil
+}
+
+// extendsLatestEntry reports whether the log entries stored after afterEntryID only continue the conversation
+// from the latest stored entry or start new roots
+// 最新のエントリはそのセグメントのメインパスの末尾にあるため、その子を追加してもメインパスが延びるだけで分岐は生まれない
+func extendsLatestEntry(tx *sql.Tx, sessionID string, afterEntryID int64) (bool, error) {
+	latest, err := latestTreeEntry(tx, sessionID, afterEntryID)
+	if err != nil || latest == nil {
+		return false, err
+	}
+	entries, err := loadTreeEntries(tx, sessionID, afterEntryID)
+	if err != nil {
+		return false, err
+	}
+
+	stmt, err := tx.Prepare("SELECT COUNT(*) FROM log_entries WHERE session_id = ? AND parent_uuid = ? AND id <= ?")
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare child entry statement: %w", err)
+	}
+	defer stmt.Close()
+
+	tip := *latest
+	for _, entry := range entries {
+		if entry.Timestamp.Before(tip.Timestamp) {
+			return false, nil
+		}
+		if entry.ParentUUID != tip.UUID && entry.ParentUUID != "" && entry.ParentUUID != entry.UUID {
+			return false, nil
+		}
+		// 先に保存された子があると、親として追記されたエントリがそのセグメントに加わる
+		var children int
+		if err := stmt.QueryRow(sessionID, entry.UUID, afterEntryID).Scan(&children); err != nil {
+			return false, fmt.Errorf("failed to count child entries: %w", err)
+		}
+		if children > 0 {
+			return false, nil
+		}
+		tip = entry
+	}
+
+	return true, nil
+}
+
+// GetConversationTree rebuilds the conversation tree of a session from parentUuid
+func (db *DB) GetConversationTree(sessionID string) (*analyzer.ConversationTree, error) {
+	entries, err := loadTreeEntries(db.conn, sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return analyzer.BuildConversationTree(entries), nil
+}
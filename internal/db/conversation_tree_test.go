@@ -0,0 +1,148 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// treeLine returns a log line with a parentUuid
+// role が assistant の場合は指定したトークン数の使用量を持つ
+func treeLine(uuid, parent, role, timestamp, content string, tokens int) string {
+	parentJSON := "null"
+	if parent != "" {
+		parentJSON = `"` + parent + `"`
+	}
+	usage := ""
+	message := `{"role":"user","content":[` + content + `]}`
+	if role == "assistant" {
+		usage = `,"usage":{"input_tokens":` + strconv.Itoa(tokens) + `,"output_tokens":10}`
+		message = `{"model":"claude-sonnet-4-5","id":"msg_` + uuid + `","role":"assistant","content":[` + content + `]` + usage + `}`
+	}
+	return `{"type":"` + role + `","timestamp":"` + timestamp + `","sessionId":"tree-session","uuid":"` + uuid +
+		`","parentUuid":` + parentJSON + `,"cwd":"/path/to/tree","requestId":"req_` + uuid + `","message":` + message + "}\n"
+}
+
+func TestConversationTree(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	claudeDir := filepath.Join(t.TempDir(), ".claude", "projects")
+	projectDir := filepath.Join(claudeDir, "tree-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	p := parser.NewParser(claudeDir)
+
+	text := func(s string) string { return `{"type":"text","text":"` + s + `"}` }
+	toolUse := `{"type":"tool_use","id":"toolu_1","name":"Read","input":{"file_path":"/a.go"}}`
+	toolResult := `{"type":"tool_result","tool_use_id":"toolu_1","content":"package a"}`
+
+	// u2 -> a2 -> r2 -> a3 を巻き戻し、a1 から u2b で入力し直す
+	content := treeLine("u1", "", "user", "2025-06-01T10:00:00Z", text("Start"), 0) +
+		treeLine("a1", "u1", "assistant", "2025-06-01T10:00:01Z", text("Hi"), 100) +
+		treeLine("u2", "a1", "user", "2025-06-01T10:00:02Z", text("Read a.go"), 0) +
+		treeLine("a2", "u2", "assistant", "2025-06-01T10:00:03Z", toolUse, 200) +
+		treeLine("r2", "a2", "user", "2025-06-01T10:00:04Z", toolResult, 0) +
+		treeLine("a3", "r2", "assistant", "2025-06-01T10:00:05Z", text("Done"), 300)
+	path := filepath.Join(projectDir, "tree-session.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+
+	if _, err := SyncAll(database, p); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	abandonedTokens := func(t *testing.T) (int, int) {
+		t.Helper()
+		var branches, input int
+		err := database.conn.QueryRow(
+			"SELECT abandoned_branches, abandoned_input_tokens FROM sessions WHERE id = 'tree-session'",
+		).Scan(&branches, &input)
+		if err != nil {
+			t.Fatalf("Failed to query session: %v", err)
+		}
+		return branches, input
+	}
+
+	t.Run("分岐がなければ破棄されたトークンは0", func(t *testing.T) {
+		branches, input := abandonedTokens(t)
+		if branches != 0 || input != 0 {
+			t.Errorf("Expected no abandoned branches, got %d branches / %d tokens", branches, input)
+		}
+	})
+
+	t.Run("追記で巻き戻しが起きると以前の分岐が破棄される", func(t *testing.T) {
+		appended := content +
+			treeLine("u2b", "a1", "user", "2025-06-01T10:01:00Z", text("Read b.go instead"), 0) +
+			treeLine("a2b", "u2b", "assistant", "2025-06-01T10:01:01Z", text("OK"), 400)
+		if err := os.WriteFile(path, []byte(appended), 0644); err != nil {
+			t.Fatalf("Failed to write session file: %v", err)
+		}
+		modTime := time.Now().Add(5 * time.Second)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+
+		if _, err := SyncIncremental(database, p); err != nil {
+			t.Fatalf("SyncIncremental failed: %v", err)
+		}
+
+		branches, input := abandonedTokens(t)
+		if branches != 1 || input != 500 {
+			t.Errorf("Expected 1 branch with 500 input tokens, got %d / %d", branches, input)
+		}
+
+		tree, err := database.GetConversationTree("tree-session")
+		if err != nil {
+			t.Fatalf("GetConversationTree failed: %v", err)
+		}
+		if len(tree.Branches) != 1 || tree.Branches[0].FirstUUID != "u2" || tree.Branches[0].EntryCount != 4 {
+			t.Errorf("Unexpected branches: %+v", tree.Branches)
+		}
+		if !tree.MainPath["a2b"] || tree.MainPath["r2"] {
+			t.Errorf("Unexpected main path: %v", tree.MainPath)
+		}
+
+		project, err := database.GetProjectByName("tree-project")
+		if err != nil {
+			t.Fatalf("GetProjectByName failed: %v", err)
+		}
+		stats, err := database.GetProjectStats(project.ID, "")
+		if err != nil {
+			t.Fatalf("GetProjectStats failed: %v", err)
+		}
+		if stats.AbandonedBranches != 1 || stats.AbandonedInputTokens != 500 || stats.AbandonedOutputTokens != 20 {
+			t.Errorf("Unexpected project stats: %+v", stats)
+		}
+	})
+
+	t.Run("最新のエントリに続く追記では破棄された分岐が変わらない", func(t *testing.T) {
+		continued := content +
+			treeLine("u2b", "a1", "user", "2025-06-01T10:01:00Z", text("Read b.go instead"), 0) +
+			treeLine("a2b", "u2b", "assistant", "2025-06-01T10:01:01Z", text("OK"), 400) +
+			treeLine("u3b", "a2b", "user", "2025-06-01T10:02:00Z", text("Thanks"), 0) +
+			treeLine("a3b", "u3b", "assistant", "2025-06-01T10:02:01Z", text("Bye"), 500)
+		if err := os.WriteFile(path, []byte(continued), 0644); err != nil {
+			t.Fatalf("Failed to write session file: %v", err)
+		}
+		modTime := time.Now().Add(10 * time.Second)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+
+		if _, err := SyncIncremental(database, p); err != nil {
+			t.Fatalf("SyncIncremental failed: %v", err)
+		}
+
+		branches, input := abandonedTokens(t)
+		if branches != 1 || input != 500 {
+			t.Errorf("Expected 1 branch with 500 input tokens, got %d / %d", branches, input)
+		}
+	})
+}
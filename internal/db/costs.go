@@ -0,0 +1,121 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+	"github.com/a-tak/ccloganalysis/internal/pricing"
+)
+
+// SetPricingTable replaces the pricing table used for cost estimation
+// Call RecalculateCosts afterwards to apply the new rates to stored sessions.
+func (db *DB) SetPricingTable(table *pricing.Table) {
+	if table == nil {
+		table = pricing.DefaultTable()
+	}
+	db.pricing = table
+}
+
+// EstimateCost returns the estimated USD cost for a model's token usage at the given time
+func (db *DB) EstimateCost(model string, at time.Time, tokens parser.TokenSummary) float64 {
+	return db.pricing.Cost(model, at, tokens)
+}
+
+// estimateSessionCosts returns the per-model costs and the session total
+// セッション開始時刻の料金を適用する
+func (db *DB) estimateSessionCosts(session *parser.Session) (map[string]float64, float64) {
+	costs := make(map[string]float64, len(session.ModelUsage))
+	total := 0.0
+	for model, tokens := range session.ModelUsage {
+		cost := db.EstimateCost(model, session.StartTime, tokens)
+		costs[model] = cost
+		total += cost
+	}
+	return costs, total
+}
+
+// RecalculateCosts recomputes cost_usd for every model_usage row and total_cost_usd for every session
+// using the current pricing table
+func (db *DB) RecalculateCosts() error {
+	query := `
+		SELECT mu.id, mu.model, s.start_time,
+		       mu.input_tokens, mu.output_tokens,
+		       mu.cache_creation_tokens, mu.cache_read_tokens,
+		       mu.cache_creation_5m_tokens, mu.cache_creation_1h_tokens
+		FROM model_usage mu
+		INNER JOIN sessions s ON mu.session_id = s.id
+	`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to query model usage: %w", err)
+	}
+
+	type usageCost struct {
+		id   int64
+		cost float64
+	}
+	var costs []usageCost
+
+	for rows.Next() {
+		var id int64
+		var model, startTimeStr string
+		var tokens parser.TokenSummary
+		err := rows.Scan(
+			&id, &model, &startTimeStr,
+			&tokens.InputTokens, &tokens.OutputTokens,
+			&tokens.CacheCreationInputTokens, &tokens.CacheReadInputTokens,
+			&tokens.CacheCreation5mInputTokens, &tokens.CacheCreation1hInputTokens,
+		)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan model usage: %w", err)
+		}
+
+		// 日時がパースできない場合は最新の料金を適用
+		startTime, err := parseDateTime(startTimeStr)
+		if err != nil {
+			startTime = time.Now()
+		}
+
+		costs = append(costs, usageCost{id: id, cost: db.EstimateCost(model, startTime, tokens)})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating model usage: %w", err)
+	}
+	rows.Close()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE model_usage SET cost_usd = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare cost update statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range costs {
+		if _, err := stmt.Exec(c.cost, c.id); err != nil {
+			return fmt.Errorf("failed to update model usage cost: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE sessions SET total_cost_usd = (
+			SELECT COALESCE(SUM(mu.cost_usd), 0) FROM model_usage mu WHERE mu.session_id = sessions.id
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to update session costs: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
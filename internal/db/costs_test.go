@@ -0,0 +1,81 @@
+package db
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/pricing"
+)
+
+func TestSessionCost(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectName := "cost-test-project"
+	projectID, err := db.CreateProject(projectName, "/path/to/cost-test-project")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	session := createTestSession("cost")
+	if err := db.CreateSession(session, projectName, time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// claude-sonnet-4系: input 100*$3 + output 50*$15 + cache write 200*$3.75 + cache read 150*$0.30 (per MTok)
+	expectedCost := (100*3.0 + 50*15.0 + 200*3.75 + 150*0.30) / 1_000_000
+
+	t.Run("セッション作成時に推定コストが保存される", func(t *testing.T) {
+		var sessionCost, modelCost float64
+		err := db.conn.QueryRow("SELECT total_cost_usd FROM sessions WHERE id = ?", session.ID).Scan(&sessionCost)
+		if err != nil {
+			t.Fatalf("Failed to query session cost: %v", err)
+		}
+		err = db.conn.QueryRow("SELECT cost_usd FROM model_usage WHERE session_id = ?", session.ID).Scan(&modelCost)
+		if err != nil {
+			t.Fatalf("Failed to query model usage cost: %v", err)
+		}
+
+		if math.Abs(sessionCost-expectedCost) > 1e-12 {
+			t.Errorf("Expected session cost %v, got %v", expectedCost, sessionCost)
+		}
+		if math.Abs(modelCost-expectedCost) > 1e-12 {
+			t.Errorf("Expected model cost %v, got %v", expectedCost, modelCost)
+		}
+	})
+
+	t.Run("プロジェクト統計に推定コストが含まれる", func(t *testing.T) {
+		stats, err := db.GetProjectStats(projectID, "")
+		if err != nil {
+			t.Fatalf("GetProjectStats failed: %v", err)
+		}
+		if math.Abs(stats.EstimatedCostUSD-expectedCost) > 1e-12 {
+			t.Errorf("Expected project cost %v, got %v", expectedCost, stats.EstimatedCostUSD)
+		}
+	})
+
+	t.Run("料金表を変更して再計算できる", func(t *testing.T) {
+		db.SetPricingTable(pricing.NewTable([]pricing.Entry{
+			{
+				ModelPrefix:   "claude-sonnet-4-5",
+				EffectiveFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				Rates:         pricing.Rates{Input: 1_000_000},
+			},
+		}))
+		defer db.SetPricingTable(nil)
+
+		if err := db.RecalculateCosts(); err != nil {
+			t.Fatalf("RecalculateCosts failed: %v", err)
+		}
+
+		stats, err := db.GetTotalStats("")
+		if err != nil {
+			t.Fatalf("GetTotalStats failed: %v", err)
+		}
+		// input 100トークン × $1/token
+		if math.Abs(stats.EstimatedCostUSD-100) > 1e-9 {
+			t.Errorf("Expected total cost 100, got %v", stats.EstimatedCostUSD)
+		}
+	})
+}
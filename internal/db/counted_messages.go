@@ -0,0 +1,109 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// withoutCountedUsage returns a copy of the session whose token totals exclude API responses counted by another session
+// Responses are counted once per message key across all sessions: a resumed session replays the history of
+// the original session into a new file, and an appended tail may repeat the last response of the parsed lines.
+// The response is owned by the session with the earliest start time (then the smallest ID), so the result does not
+// depend on the order in which files are parsed. When sessionID takes over a response from a later session, that
+// session is marked for reparse so that its totals drop the response on the next sync.
+// Returns the copy and the keys of the responses counted for sessionID.
+func withoutCountedUsage(tx *sql.Tx, session *parser.Session, sessionID string, startTime time.Time) (*parser.Session, []string, error) {
+	if len(session.MessageUsages) == 0 {
+		return session, nil, nil
+	}
+
+	stmt, err := tx.Prepare(`
+		SELECT cm.session_id, COALESCE(s.start_time, '')
+		FROM counted_messages cm
+		LEFT JOIN sessions s ON s.id = cm.session_id
+		WHERE cm.message_key = ?
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare counted message statement: %w", err)
+	}
+	defer stmt.Close()
+
+	counted := *session
+	counted.ModelUsage = make(map[string]parser.TokenSummary, len(session.ModelUsage))
+	for model, tokens := range session.ModelUsage {
+		counted.ModelUsage[model] = tokens
+	}
+
+	var newKeys []string
+	for _, usage := range session.MessageUsages {
+		var ownerID, ownerStartTime string
+		err := stmt.QueryRow(usage.Key).Scan(&ownerID, &ownerStartTime)
+		if err == sql.ErrNoRows {
+			newKeys = append(newKeys, usage.Key)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check counted message %s: %w", usage.Key, err)
+		}
+
+		if ownerID != sessionID && startsBefore(sessionID, startTime, ownerID, ownerStartTime) {
+			// 後から始まったセッションが集計済みの応答は、先に始まったこのセッションで集計し直す
+			// （所有者の変更はrecordCountedMessagesで記録する）
+			if _, err := tx.Exec("UPDATE sessions SET needs_reparse = 1 WHERE id = ?", ownerID); err != nil {
+				return nil, nil, fmt.Errorf("failed to mark session %s for reparse: %w", ownerID, err)
+			}
+			newKeys = append(newKeys, usage.Key)
+			continue
+		}
+
+		// 集計済みの応答はトークン数から除外する
+		counted.TotalTokens.Subtract(usage.Tokens)
+		modelTokens := counted.ModelUsage[usage.Model]
+		modelTokens.Subtract(usage.Tokens)
+		if modelTokens == (parser.TokenSummary{}) {
+			delete(counted.ModelUsage, usage.Model)
+		} else {
+			counted.ModelUsage[usage.Model] = modelTokens
+		}
+	}
+
+	return &counted, newKeys, nil
+}
+
+// startsBefore reports whether a session owns an API response before the session currently counting it
+// 開始時刻が同じ場合はIDの小さいセッションを優先する
+func startsBefore(sessionID string, startTime time.Time, ownerID, ownerStartTime string) bool {
+	ownerStart, err := parseDateTime(ownerStartTime)
+	if err != nil || startTime.Before(ownerStart) {
+		return true
+	}
+	return startTime.Equal(ownerStart) && sessionID < ownerID
+}
+
+// recordCountedMessages records the API responses whose usage was counted for a session
+// Responses taken over from a later session are moved to this session.
+func recordCountedMessages(tx *sql.Tx, sessionID string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO counted_messages (message_key, session_id) VALUES (?, ?)
+		ON CONFLICT(message_key) DO UPDATE SET session_id = excluded.session_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare counted message statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, key := range keys {
+		if _, err := stmt.Exec(key, sessionID); err != nil {
+			return fmt.Errorf("failed to record counted message %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
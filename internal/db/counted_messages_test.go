@@ -0,0 +1,268 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// streamedLine returns an assistant line of a streamed API response with the given content block
+func streamedLine(sessionID, uuid, messageID, requestID, timestamp, content string) string {
+	return `{"type":"assistant","timestamp":"` + timestamp + `","sessionId":"` + sessionID + `","uuid":"` + uuid +
+		`","cwd":"/path/to/stream","requestId":"` + requestID + `","message":{"model":"claude-sonnet-4-5","id":"` + messageID +
+		`","role":"assistant","content":[` + content + `],"usage":{"input_tokens":100,"output_tokens":40}}}` + "\n"
+}
+
+// userLine returns a user line with text content
+func userLine(sessionID, uuid, timestamp, text string) string {
+	return `{"type":"user","timestamp":"` + timestamp + `","sessionId":"` + sessionID + `","uuid":"` + uuid +
+		`","cwd":"/path/to/stream","message":{"role":"user","content":"` + text + `"}}` + "\n"
+}
+
+func TestCountedMessages(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	claudeDir := filepath.Join(t.TempDir(), ".claude", "projects")
+	projectDir := filepath.Join(claudeDir, "stream-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	p := parser.NewParser(claudeDir)
+
+	writeFile := func(t *testing.T, name, content string, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(projectDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	sessionTokens := func(t *testing.T, sessionID string) (int, int) {
+		t.Helper()
+		session, err := database.GetSession(sessionID)
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		return session.TotalTokens.InputTokens, session.ModelUsage["claude-sonnet-4-5"].InputTokens
+	}
+
+	// 元のセッション: msg_a は思考・テキストの2行
+	original := userLine("original", "o-1", "2025-02-01T10:00:00Z", "Start") +
+		streamedLine("original", "o-2", "msg_a", "req_a", "2025-02-01T10:00:01Z", `{"type":"thinking","thinking":"hmm"}`) +
+		streamedLine("original", "o-3", "msg_a", "req_a", "2025-02-01T10:00:02Z", `{"type":"text","text":"Hello"}`)
+	writeFile(t, "original.jsonl", original, time.Now())
+
+	// 再開したセッション: msg_a を再生し、新しい応答 msg_b を追加
+	resumed := userLine("resumed", "r-1", "2025-02-01T10:00:00Z", "Start") +
+		streamedLine("resumed", "r-2", "msg_a", "req_a", "2025-02-01T10:00:01Z", `{"type":"thinking","thinking":"hmm"}`) +
+		streamedLine("resumed", "r-3", "msg_a", "req_a", "2025-02-01T10:00:02Z", `{"type":"text","text":"Hello"}`) +
+		userLine("resumed", "r-4", "2025-02-02T09:00:00Z", "Continue") +
+		streamedLine("resumed", "r-5", "msg_b", "req_b", "2025-02-02T09:00:01Z", `{"type":"text","text":"Continuing"}`)
+	writeFile(t, "resumed.jsonl", resumed, time.Now())
+
+	if _, err := SyncAll(database, p); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	t.Run("再開したセッションが再生した応答は重複して集計されない", func(t *testing.T) {
+		originalTokens, _ := sessionTokens(t, "original")
+		resumedTokens, resumedModelTokens := sessionTokens(t, "resumed")
+
+		// msg_a と msg_b がそれぞれ1回だけ集計される
+		if originalTokens+resumedTokens != 200 {
+			t.Errorf("Expected 200 input tokens in total, got %d + %d", originalTokens, resumedTokens)
+		}
+		if resumedTokens != resumedModelTokens {
+			t.Errorf("Expected model usage to match session totals, got %d / %d", resumedTokens, resumedModelTokens)
+		}
+
+		stats, err := database.GetTotalStats("")
+		if err != nil {
+			t.Fatalf("GetTotalStats failed: %v", err)
+		}
+		if stats.TotalInputTokens != 200 {
+			t.Errorf("Expected 200 total input tokens, got %d", stats.TotalInputTokens)
+		}
+	})
+
+	t.Run("追記された行が解析済みの応答の続きでも重複して集計されない", func(t *testing.T) {
+		before, _ := sessionTokens(t, "original")
+
+		appended := original +
+			streamedLine("original", "o-4", "msg_a", "req_a", "2025-02-01T10:00:03Z", `{"type":"tool_use","id":"toolu_a","name":"Read","input":{}}`)
+		writeFile(t, "original.jsonl", appended, time.Now().Add(5*time.Second))
+
+		if _, err := SyncIncremental(database, p); err != nil {
+			t.Fatalf("SyncIncremental failed: %v", err)
+		}
+
+		after, _ := sessionTokens(t, "original")
+		if after != before {
+			t.Errorf("Expected input tokens to stay %d, got %d", before, after)
+		}
+
+		session, err := database.GetSession("original")
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if len(session.ToolCalls) != 1 {
+			t.Errorf("Expected the appended tool call to be stored, got %d", len(session.ToolCalls))
+		}
+	})
+
+	t.Run("再解析が必要なセッションは変更がなくても集計し直される", func(t *testing.T) {
+		// 重複して集計された旧データを再現
+		_, err := database.conn.Exec("UPDATE sessions SET total_input_tokens = 999, needs_reparse = 1 WHERE id = 'resumed'")
+		if err != nil {
+			t.Fatalf("Failed to update session: %v", err)
+		}
+		if _, err := database.conn.Exec("DELETE FROM session_files"); err != nil {
+			t.Fatalf("Failed to delete session file positions: %v", err)
+		}
+
+		result, err := SyncAll(database, p)
+		if err != nil {
+			t.Fatalf("SyncAll failed: %v", err)
+		}
+		if len(result.UpdatedSessions) != 1 || result.UpdatedSessions[0].SessionID != "resumed" {
+			t.Errorf("Expected resumed to be reparsed, got %+v", result.UpdatedSessions)
+		}
+
+		originalTokens, _ := sessionTokens(t, "original")
+		resumedTokens, _ := sessionTokens(t, "resumed")
+		if originalTokens+resumedTokens != 200 {
+			t.Errorf("Expected 200 input tokens in total, got %d + %d", originalTokens, resumedTokens)
+		}
+
+		targets, err := database.ListReparseTargets()
+		if err != nil {
+			t.Fatalf("ListReparseTargets failed: %v", err)
+		}
+		if targets.Len() != 0 {
+			t.Errorf("Expected no sessions needing reparse, got %d", targets.Len())
+		}
+	})
+
+	t.Run("ファイル名とセッションIDが異なるセッションも再解析される", func(t *testing.T) {
+		content := userLine("inner-id", "i-1", "2025-02-03T10:00:00Z", "Start") +
+			streamedLine("inner-id", "i-2", "msg_c", "req_c", "2025-02-03T10:00:01Z", `{"type":"text","text":"Hi"}`)
+		writeFile(t, "renamed.jsonl", content, time.Now().Add(-time.Hour))
+		if _, err := SyncSessionFiles(database, p, "stream-project", []string{"renamed.jsonl"}); err != nil {
+			t.Fatalf("SyncSessionFiles failed: %v", err)
+		}
+
+		_, err := database.conn.Exec("UPDATE sessions SET total_input_tokens = 999, needs_reparse = 1 WHERE id = 'inner-id'")
+		if err != nil {
+			t.Fatalf("Failed to update session: %v", err)
+		}
+
+		// ファイルは前回のスキャンより前に更新されている
+		if _, err := SyncIncremental(database, p); err != nil {
+			t.Fatalf("SyncIncremental failed: %v", err)
+		}
+
+		tokens, _ := sessionTokens(t, "inner-id")
+		if tokens != 100 {
+			t.Errorf("Expected inner-id to be reparsed with 100 input tokens, got %d", tokens)
+		}
+	})
+}
+
+func TestCountedMessagesOwnership(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	claudeDir := filepath.Join(t.TempDir(), ".claude", "projects")
+	projectDir := filepath.Join(claudeDir, "owner-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	p := parser.NewParser(claudeDir)
+
+	writeFile := func(t *testing.T, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	sessionTokens := func(t *testing.T, sessionID string) int {
+		t.Helper()
+		session, err := database.GetSession(sessionID)
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		return session.TotalTokens.InputTokens
+	}
+
+	// 再開したセッション（後に始まった）を先に同期する
+	writeFile(t, "later.jsonl", userLine("later", "l-1", "2025-03-02T10:00:00Z", "Resume")+
+		streamedLine("later", "l-2", "msg_a", "req_a", "2025-03-01T10:00:01Z", `{"type":"text","text":"Hello"}`))
+	if _, err := SyncSessionFiles(database, p, "owner-project", []string{"later.jsonl"}); err != nil {
+		t.Fatalf("SyncSessionFiles failed: %v", err)
+	}
+	if tokens := sessionTokens(t, "later"); tokens != 100 {
+		t.Fatalf("Expected later to count msg_a, got %d", tokens)
+	}
+
+	writeFile(t, "earlier.jsonl", userLine("earlier", "e-1", "2025-03-01T10:00:00Z", "Start")+
+		streamedLine("earlier", "e-2", "msg_a", "req_a", "2025-03-01T10:00:01Z", `{"type":"text","text":"Hello"}`))
+	if _, err := SyncSessionFiles(database, p, "owner-project", []string{"earlier.jsonl"}); err != nil {
+		t.Fatalf("SyncSessionFiles failed: %v", err)
+	}
+
+	t.Run("先に始まったセッションが応答を集計する", func(t *testing.T) {
+		if tokens := sessionTokens(t, "earlier"); tokens != 100 {
+			t.Errorf("Expected earlier to own msg_a, got %d", tokens)
+		}
+
+		var owner string
+		if err := database.conn.QueryRow("SELECT session_id FROM counted_messages WHERE message_key = 'msg_a:req_a'").Scan(&owner); err != nil {
+			t.Fatalf("Failed to query counted message: %v", err)
+		}
+		if owner != "earlier" {
+			t.Errorf("Expected earlier to own msg_a, got %s", owner)
+		}
+	})
+
+	t.Run("応答を奪われたセッションは同じ同期で集計し直される", func(t *testing.T) {
+		targets, err := database.ListReparseTargets()
+		if err != nil {
+			t.Fatalf("ListReparseTargets failed: %v", err)
+		}
+		if targets.Len() != 0 {
+			t.Errorf("Expected no sessions to need reparse, got %+v", targets.Files())
+		}
+		if tokens := sessionTokens(t, "later"); tokens != 0 {
+			t.Errorf("Expected later to drop msg_a, got %d", tokens)
+		}
+		if tokens := sessionTokens(t, "earlier"); tokens != 100 {
+			t.Errorf("Expected earlier to keep msg_a, got %d", tokens)
+		}
+	})
+
+	t.Run("応答を奪われたセッションへの追記で応答を集計し直さない", func(t *testing.T) {
+		f, err := os.OpenFile(filepath.Join(projectDir, "later.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open later.jsonl: %v", err)
+		}
+		_, err = f.WriteString(streamedLine("later", "l-3", "msg_b", "req_b", "2025-03-02T10:00:05Z", `{"type":"text","text":"Next"}`))
+		f.Close()
+		if err != nil {
+			t.Fatalf("Failed to append to later.jsonl: %v", err)
+		}
+
+		if _, err := SyncSessionFiles(database, p, "owner-project", []string{"later.jsonl"}); err != nil {
+			t.Fatalf("SyncSessionFiles failed: %v", err)
+		}
+		if tokens := sessionTokens(t, "later"); tokens != 100 {
+			t.Errorf("Expected later to count only msg_b, got %d", tokens)
+		}
+	})
+}
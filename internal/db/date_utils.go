@@ -4,6 +4,14 @@ import (
 	"time"
 )
 
+// sortableTimestampFormat is a fixed-width UTC format so that stored timestamps compare correctly as strings
+const sortableTimestampFormat = "2006-01-02T15:04:05.000Z"
+
+// formatSortableTime formats a time with sortableTimestampFormat in UTC
+func formatSortableTime(t time.Time) string {
+	return t.UTC().Format(sortableTimestampFormat)
+}
+
 // generateDateRange generates a list of dates that a session covers.
 // Returns dates in "YYYY-MM-DD" format.
 func generateDateRange(startTime, endTime time.Time) []string {
@@ -4,7 +4,11 @@ import (
 	"database/sql"
 	_ "embed"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+	"github.com/a-tak/ccloganalysis/internal/pricing"
 	_ "modernc.org/sqlite"
 )
 
@@ -20,9 +24,97 @@ var migration004SQL string
 //go:embed migrations/005_file_mod_time.sql
 var migration005SQL string
 
+//go:embed migrations/006_cost_estimation.sql
+var migration006SQL string
+
+//go:embed migrations/007_tool_call_results.sql
+var migration007SQL string
+
+//go:embed migrations/008_search_index.sql
+var migration008SQL string
+
+//go:embed migrations/009_error_patterns.sql
+var migration009SQL string
+
+//go:embed migrations/010_period_statistics.sql
+var migration010SQL string
+
+//go:embed migrations/011_subagents.sql
+var migration011SQL string
+
+//go:embed migrations/012_session_files.sql
+var migration012SQL string
+
+//go:embed migrations/013_counted_messages.sql
+var migration013SQL string
+
+//go:embed migrations/014_conversation_tree.sql
+var migration014SQL string
+
+//go:embed migrations/015_context_window.sql
+var migration015SQL string
+
+//go:embed migrations/016_activity_time.sql
+var migration016SQL string
+
+//go:embed migrations/017_tool_attempts.sql
+var migration017SQL string
+
+//go:embed migrations/018_session_tasks.sql
+var migration018SQL string
+
+//go:embed migrations/019_commands.sql
+var migration019SQL string
+
+//go:embed migrations/020_mcp_tools.sql
+var migration020SQL string
+
+//go:embed migrations/021_file_edits.sql
+var migration021SQL string
+
+//go:embed migrations/022_bash_commands.sql
+var migration022SQL string
+
+//go:embed migrations/023_interruptions.sql
+var migration023SQL string
+
+//go:embed migrations/024_cli_versions.sql
+var migration024SQL string
+
+//go:embed migrations/025_period_trigger_columns.sql
+var migration025SQL string
+
+//go:embed migrations/026_session_file_parse_state.sql
+var migration026SQL string
+
+//go:embed migrations/027_activity_milliseconds.sql
+var migration027SQL string
+
+//go:embed migrations/028_log_entry_parent_index.sql
+var migration028SQL string
+
+//go:embed migrations/029_tool_attempt_calls.sql
+var migration029SQL string
+
+//go:embed migrations/030_file_edit_tool_calls.sql
+var migration030SQL string
+
+//go:embed migrations/031_tool_call_interruptions.sql
+var migration031SQL string
+
+//go:embed migrations/032_compact_summary_entries.sql
+var migration032SQL string
+
 // DB wraps the SQLite database connection
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	pricing *pricing.Table
+
+	// 作業時間として数える処理時間の上限（これを超えた分は待機時間）
+	idleCutoff time.Duration
+
+	// period_statisticsの再計算を直列化する
+	periodStatsMu sync.Mutex
 }
 
 // NewDB creates a new database connection and initializes the schema
@@ -50,7 +142,11 @@ func NewDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{
+		conn:       conn,
+		pricing:    pricing.DefaultTable(),
+		idleCutoff: analyzer.DefaultIdleCutoff,
+	}
 
 	// スキーマの適用
 	if err := db.Migrate(); err != nil {
@@ -117,6 +213,192 @@ func (db *DB) runMigrations() error {
 		return fmt.Errorf("failed to apply migration 005: %w", err)
 	}
 
+	// マイグレーション006を実行
+	err = db.applyMigration("006", migration006SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 006: %w", err)
+	}
+
+	// 既存セッションのコストを算出（006で追加したカラムのバックフィル）
+	err = db.applyDataMigration("006_cost_backfill", db.RecalculateCosts)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 006_cost_backfill: %w", err)
+	}
+
+	// マイグレーション007を実行
+	err = db.applyMigration("007", migration007SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 007: %w", err)
+	}
+
+	// マイグレーション008を実行
+	err = db.applyMigration("008", migration008SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 008: %w", err)
+	}
+
+	// 既存セッションを検索インデックスに登録
+	err = db.applyDataMigration("008_search_backfill", db.RebuildSearchIndex)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 008_search_backfill: %w", err)
+	}
+
+	// マイグレーション009を実行
+	err = db.applyMigration("009", migration009SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 009: %w", err)
+	}
+
+	// 既存の失敗したツール呼び出しからエラーパターンを作成
+	err = db.applyDataMigration("009_error_pattern_backfill", db.RebuildErrorPatterns)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 009_error_pattern_backfill: %w", err)
+	}
+
+	// マイグレーション010を実行
+	err = db.applyMigration("010", migration010SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 010: %w", err)
+	}
+
+	// マイグレーション011を実行
+	err = db.applyMigration("011", migration011SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 011: %w", err)
+	}
+
+	// マイグレーション012を実行
+	err = db.applyMigration("012", migration012SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 012: %w", err)
+	}
+
+	// マイグレーション013を実行
+	err = db.applyMigration("013", migration013SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 013: %w", err)
+	}
+
+	// マイグレーション014を実行
+	err = db.applyMigration("014", migration014SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 014: %w", err)
+	}
+
+	// マイグレーション015を実行
+	err = db.applyMigration("015", migration015SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 015: %w", err)
+	}
+
+	// マイグレーション016を実行
+	err = db.applyMigration("016", migration016SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 016: %w", err)
+	}
+
+	// マイグレーション017を実行
+	err = db.applyMigration("017", migration017SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 017: %w", err)
+	}
+
+	// マイグレーション018を実行
+	err = db.applyMigration("018", migration018SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 018: %w", err)
+	}
+
+	// マイグレーション019を実行
+	err = db.applyMigration("019", migration019SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 019: %w", err)
+	}
+
+	// マイグレーション020を実行
+	err = db.applyMigration("020", migration020SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 020: %w", err)
+	}
+
+	// マイグレーション021を実行
+	err = db.applyMigration("021", migration021SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 021: %w", err)
+	}
+
+	// マイグレーション022を実行
+	err = db.applyMigration("022", migration022SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 022: %w", err)
+	}
+
+	// マイグレーション023を実行
+	err = db.applyMigration("023", migration023SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 023: %w", err)
+	}
+
+	// マイグレーション024を実行
+	err = db.applyMigration("024", migration024SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 024: %w", err)
+	}
+
+	// マイグレーション025を実行
+	err = db.applyMigration("025", migration025SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 025: %w", err)
+	}
+
+	// マイグレーション026を実行
+	err = db.applyMigration("026", migration026SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 026: %w", err)
+	}
+
+	// マイグレーション027を実行
+	err = db.applyMigration("027", migration027SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 027: %w", err)
+	}
+
+	// マイグレーション028を実行
+	err = db.applyMigration("028", migration028SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 028: %w", err)
+	}
+
+	// マイグレーション029を実行
+	err = db.applyMigration("029", migration029SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 029: %w", err)
+	}
+
+	// マイグレーション030を実行
+	err = db.applyMigration("030", migration030SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 030: %w", err)
+	}
+
+	// マイグレーション031を実行
+	err = db.applyMigration("031", migration031SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 031: %w", err)
+	}
+
+	// マイグレーション032を実行
+	err = db.applyMigration("032", migration032SQL)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration 032: %w", err)
+	}
+
+	// 解析方法が変わった場合は既存のセッションを再解析させる
+	err = db.applyDataMigration(fmt.Sprintf("analysis_v%d", analysisVersion), db.RequestReparse)
+	if err != nil {
+		return fmt.Errorf("failed to request reparse for analysis version %d: %w", analysisVersion, err)
+	}
+
 	return nil
 }
 
@@ -148,3 +430,28 @@ func (db *DB) applyMigration(version string, sql string) error {
 
 	return nil
 }
+
+// applyDataMigration runs a Go-based data migration once and records it like a SQL migration
+func (db *DB) applyDataMigration(version string, migrate func() error) error {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+
+	if count > 0 {
+		// 既に適用済み
+		return nil
+	}
+
+	if err := migrate(); err != nil {
+		return fmt.Errorf("failed to execute data migration: %w", err)
+	}
+
+	_, err = db.conn.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version)
+	if err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return nil
+}
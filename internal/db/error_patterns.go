@@ -0,0 +1,364 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// ErrorPatternRow represents an error pattern with occurrence statistics
+type ErrorPatternRow struct {
+	ID              int64
+	ToolName        string
+	ErrorMessage    string // 正規化済みメッセージ
+	OccurrenceCount int
+	SessionCount    int
+	ProjectCount    int
+	FirstSeen       time.Time
+	LastSeen        time.Time
+}
+
+// ErrorPatternFilter holds the filters for listing error patterns
+type ErrorPatternFilter struct {
+	ProjectID *int64
+	GroupID   *int64
+	ToolName  string
+	Limit     int
+	Offset    int
+}
+
+// ErrorOccurrenceRow represents a single occurrence of an error pattern
+type ErrorOccurrenceRow struct {
+	ID          int64
+	SessionID   string
+	ProjectName string
+	GitBranch   string
+	ToolName    string
+	InputJSON   string
+	ResultText  string // 正規化前のエラーメッセージ
+	OccurredAt  time.Time
+}
+
+// failedToolCall is a stored tool call whose result was an error
+type failedToolCall struct {
+	id       int64
+	toolCall parser.ToolCall
+}
+
+// sessionErrorPatternIDs returns the IDs of the patterns that have occurrences in a session
+func sessionErrorPatternIDs(tx *sql.Tx, sessionID string) ([]int64, error) {
+	rows, err := tx.Query("SELECT DISTINCT pattern_id FROM error_occurrences WHERE session_id = ?", sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query error occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan pattern ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// recordErrorPatterns upserts the patterns of failed tool calls and records their occurrences
+// stalePatternIDs are patterns whose occurrences were removed and need their counts refreshed
+func recordErrorPatterns(tx *sql.Tx, sessionID string, calls []failedToolCall, stalePatternIDs []int64) error {
+	affected := make(map[int64]bool)
+	for _, id := range stalePatternIDs {
+		affected[id] = true
+	}
+
+	if len(calls) > 0 {
+		patternStmt, err := tx.Prepare(`
+			INSERT INTO error_patterns (
+				pattern_hash, tool_name, error_message, occurrence_count, first_seen, last_seen
+			) VALUES (?, ?, ?, 0, ?, ?)
+			ON CONFLICT(pattern_hash) DO NOTHING
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare error pattern statement: %w", err)
+		}
+		defer patternStmt.Close()
+
+		occurrenceStmt, err := tx.Prepare(`
+			INSERT INTO error_occurrences (pattern_id, tool_call_id, session_id, occurred_at)
+			VALUES (?, ?, ?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare error occurrence statement: %w", err)
+		}
+		defer occurrenceStmt.Close()
+
+		for _, call := range calls {
+			// ユーザーによる拒否・キャンセルはツールのエラーとして扱わない
+			if call.toolCall.InterruptionType != "" {
+				continue
+			}
+
+			normalized := analyzer.NormalizeErrorMessage(call.toolCall.Result)
+			hash := analyzer.ErrorPatternHash(call.toolCall.Name, normalized)
+			occurredAt := formatSortableTime(call.toolCall.Timestamp)
+
+			if _, err := patternStmt.Exec(hash, call.toolCall.Name, normalized, occurredAt, occurredAt); err != nil {
+				return fmt.Errorf("failed to upsert error pattern: %w", err)
+			}
+
+			var patternID int64
+			if err := tx.QueryRow("SELECT id FROM error_patterns WHERE pattern_hash = ?", hash).Scan(&patternID); err != nil {
+				return fmt.Errorf("failed to get error pattern ID: %w", err)
+			}
+
+			if _, err := occurrenceStmt.Exec(patternID, call.id, sessionID, occurredAt); err != nil {
+				return fmt.Errorf("failed to insert error occurrence: %w", err)
+			}
+			affected[patternID] = true
+		}
+	}
+
+	return refreshErrorPatterns(tx, affected)
+}
+
+// refreshErrorPatterns recomputes occurrence_count / first_seen / last_seen from error_occurrences
+// 発生記録がなくなったパターンは削除する
+func refreshErrorPatterns(tx *sql.Tx, patternIDs map[int64]bool) error {
+	if len(patternIDs) == 0 {
+		return nil
+	}
+
+	updateStmt, err := tx.Prepare(`
+		UPDATE error_patterns SET
+			occurrence_count = (SELECT COUNT(*) FROM error_occurrences WHERE pattern_id = error_patterns.id),
+			first_seen = COALESCE((SELECT MIN(occurred_at) FROM error_occurrences WHERE pattern_id = error_patterns.id), first_seen),
+			last_seen = COALESCE((SELECT MAX(occurred_at) FROM error_occurrences WHERE pattern_id = error_patterns.id), last_seen)
+		WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare error pattern update statement: %w", err)
+	}
+	defer updateStmt.Close()
+
+	for id := range patternIDs {
+		if _, err := updateStmt.Exec(id); err != nil {
+			return fmt.Errorf("failed to update error pattern %d: %w", id, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM error_patterns WHERE occurrence_count = 0"); err != nil {
+		return fmt.Errorf("failed to delete empty error patterns: %w", err)
+	}
+
+	return nil
+}
+
+// RebuildErrorPatterns recomputes all error patterns from the stored failed tool calls
+func (db *DB) RebuildErrorPatterns() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM error_occurrences"); err != nil {
+		return fmt.Errorf("failed to delete error occurrences: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM error_patterns"); err != nil {
+		return fmt.Errorf("failed to delete error patterns: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, session_id, tool_name, timestamp, result_text
+		FROM tool_calls
+		WHERE is_error = 1
+		ORDER BY session_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query failed tool calls: %w", err)
+	}
+
+	callsBySession := make(map[string][]failedToolCall)
+	for rows.Next() {
+		var call failedToolCall
+		var sessionID string
+		var resultText sql.NullString
+		if err := rows.Scan(&call.id, &sessionID, &call.toolCall.Name, &call.toolCall.Timestamp, &resultText); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan tool call: %w", err)
+		}
+		call.toolCall.Result = resultText.String
+		callsBySession[sessionID] = append(callsBySession[sessionID], call)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating tool calls: %w", err)
+	}
+	rows.Close()
+
+	for sessionID, calls := range callsBySession {
+		if err := recordErrorPatterns(tx, sessionID, calls, nil); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListErrorPatterns returns error patterns ordered by occurrence count
+// 絞り込みが指定された場合、件数・日時は対象セッション内の発生記録のみで集計する
+func (db *DB) ListErrorPatterns(filter ErrorPatternFilter) ([]*ErrorPatternRow, error) {
+	query := `
+		SELECT ep.id, ep.tool_name, ep.error_message,
+		       COUNT(eo.id) as occurrence_count,
+		       COUNT(DISTINCT eo.session_id) as session_count,
+		       COUNT(DISTINCT s.project_id) as project_count,
+		       MIN(eo.occurred_at) as first_seen,
+		       MAX(eo.occurred_at) as last_seen
+		FROM error_patterns ep
+		INNER JOIN error_occurrences eo ON eo.pattern_id = ep.id
+		INNER JOIN sessions s ON eo.session_id = s.id
+		WHERE 1 = 1
+	`
+
+	var args []interface{}
+	if filter.ProjectID != nil {
+		query += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		query += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.ToolName != "" {
+		query += " AND ep.tool_name = ?"
+		args = append(args, filter.ToolName)
+	}
+
+	query += `
+		GROUP BY ep.id
+		ORDER BY occurrence_count DESC, last_seen DESC
+		LIMIT ? OFFSET ?
+	`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query error patterns: %w", err)
+	}
+	defer rows.Close()
+
+	var patterns []*ErrorPatternRow
+	for rows.Next() {
+		var pattern ErrorPatternRow
+		var firstSeenStr, lastSeenStr string
+
+		err := rows.Scan(
+			&pattern.ID, &pattern.ToolName, &pattern.ErrorMessage,
+			&pattern.OccurrenceCount, &pattern.SessionCount, &pattern.ProjectCount,
+			&firstSeenStr, &lastSeenStr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan error pattern: %w", err)
+		}
+
+		pattern.FirstSeen, _ = parseDateTime(firstSeenStr)
+		pattern.LastSeen, _ = parseDateTime(lastSeenStr)
+		patterns = append(patterns, &pattern)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating error patterns: %w", err)
+	}
+
+	return patterns, nil
+}
+
+// GetErrorPattern retrieves a single error pattern by ID
+func (db *DB) GetErrorPattern(id int64) (*ErrorPatternRow, error) {
+	query := `
+		SELECT ep.id, ep.tool_name, ep.error_message, ep.occurrence_count,
+		       COUNT(DISTINCT eo.session_id) as session_count,
+		       COUNT(DISTINCT s.project_id) as project_count,
+		       ep.first_seen, ep.last_seen
+		FROM error_patterns ep
+		LEFT JOIN error_occurrences eo ON eo.pattern_id = ep.id
+		LEFT JOIN sessions s ON eo.session_id = s.id
+		WHERE ep.id = ?
+		GROUP BY ep.id
+	`
+
+	var pattern ErrorPatternRow
+	var firstSeenStr, lastSeenStr string
+	err := db.conn.QueryRow(query, id).Scan(
+		&pattern.ID, &pattern.ToolName, &pattern.ErrorMessage, &pattern.OccurrenceCount,
+		&pattern.SessionCount, &pattern.ProjectCount,
+		&firstSeenStr, &lastSeenStr,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("error pattern not found: %d", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query error pattern: %w", err)
+	}
+
+	pattern.FirstSeen, _ = parseDateTime(firstSeenStr)
+	pattern.LastSeen, _ = parseDateTime(lastSeenStr)
+
+	return &pattern, nil
+}
+
+// ListErrorOccurrences returns the occurrences of an error pattern, newest first
+func (db *DB) ListErrorOccurrences(patternID int64, limit, offset int) ([]*ErrorOccurrenceRow, error) {
+	query := `
+		SELECT eo.id, eo.session_id, p.name, s.git_branch,
+		       tc.tool_name, tc.input_json, tc.result_text, eo.occurred_at
+		FROM error_occurrences eo
+		INNER JOIN tool_calls tc ON eo.tool_call_id = tc.id
+		INNER JOIN sessions s ON eo.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+		WHERE eo.pattern_id = ?
+		ORDER BY eo.occurred_at DESC, eo.id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := db.conn.Query(query, patternID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query error occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var occurrences []*ErrorOccurrenceRow
+	for rows.Next() {
+		var occurrence ErrorOccurrenceRow
+		var inputJSON, resultText sql.NullString
+		var occurredAtStr string
+
+		err := rows.Scan(
+			&occurrence.ID, &occurrence.SessionID, &occurrence.ProjectName, &occurrence.GitBranch,
+			&occurrence.ToolName, &inputJSON, &resultText, &occurredAtStr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan error occurrence: %w", err)
+		}
+
+		occurrence.InputJSON = inputJSON.String
+		occurrence.ResultText = resultText.String
+		occurrence.OccurredAt, _ = parseDateTime(occurredAtStr)
+		occurrences = append(occurrences, &occurrence)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating error occurrences: %w", err)
+	}
+
+	return occurrences, nil
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestErrorPatterns(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectAID, err := db.CreateProject("error-project-a", "/path/to/error-project-a")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	if _, err := db.CreateProject("error-project-b", "/path/to/error-project-b"); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	// 数値とパスだけが異なるエラーは同じパターンにまとめられる
+	sessionA := createTestSession("error-a")
+	base := sessionA.StartTime
+	sessionA.ToolCalls = []parser.ToolCall{
+		{ID: "e1", Timestamp: base, Name: "Bash", HasResult: true, IsError: true, Result: "Exit code 1\nfile /src/a.go not found"},
+		{ID: "e2", Timestamp: base.Add(time.Minute), Name: "Bash", HasResult: true, IsError: true, Result: "Exit code 2\nfile /src/b.go not found"},
+		{ID: "e3", Timestamp: base, Name: "Read", HasResult: true, IsError: true, Result: "File does not exist."},
+		{ID: "ok", Timestamp: base, Name: "Bash", HasResult: true, Result: "done"},
+	}
+	if err := db.CreateSession(sessionA, "error-project-a", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	sessionB := createTestSession("error-b")
+	sessionB.ToolCalls = []parser.ToolCall{
+		{ID: "e4", Timestamp: base.Add(time.Hour), Name: "Bash", HasResult: true, IsError: true, Result: "Exit code 127\nfile /tmp/c.go not found"},
+	}
+	if err := db.CreateSession(sessionB, "error-project-b", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("正規化したメッセージでパターンを集計できる", func(t *testing.T) {
+		patterns, err := db.ListErrorPatterns(ErrorPatternFilter{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListErrorPatterns failed: %v", err)
+		}
+		if len(patterns) != 2 {
+			t.Fatalf("Expected 2 patterns, got %d", len(patterns))
+		}
+
+		bash := patterns[0]
+		if bash.ToolName != "Bash" || bash.ErrorMessage != "Exit code <N> file <PATH> not found" {
+			t.Errorf("Unexpected pattern: %+v", bash)
+		}
+		if bash.OccurrenceCount != 3 || bash.SessionCount != 2 || bash.ProjectCount != 2 {
+			t.Errorf("Expected 3 occurrences / 2 sessions / 2 projects, got %d / %d / %d",
+				bash.OccurrenceCount, bash.SessionCount, bash.ProjectCount)
+		}
+		if !bash.FirstSeen.Equal(base) || !bash.LastSeen.Equal(base.Add(time.Hour)) {
+			t.Errorf("Unexpected first/last seen: %v / %v", bash.FirstSeen, bash.LastSeen)
+		}
+	})
+
+	t.Run("プロジェクトとツール名で絞り込める", func(t *testing.T) {
+		patterns, err := db.ListErrorPatterns(ErrorPatternFilter{ProjectID: &projectAID, ToolName: "Bash", Limit: 10})
+		if err != nil {
+			t.Fatalf("ListErrorPatterns failed: %v", err)
+		}
+		if len(patterns) != 1 || patterns[0].OccurrenceCount != 2 || patterns[0].ProjectCount != 1 {
+			t.Fatalf("Expected 1 Bash pattern with 2 occurrences, got %+v", patterns)
+		}
+	})
+
+	t.Run("発生箇所を新しい順に取得できる", func(t *testing.T) {
+		patterns, err := db.ListErrorPatterns(ErrorPatternFilter{ToolName: "Bash", Limit: 10})
+		if err != nil {
+			t.Fatalf("ListErrorPatterns failed: %v", err)
+		}
+		occurrences, err := db.ListErrorOccurrences(patterns[0].ID, 10, 0)
+		if err != nil {
+			t.Fatalf("ListErrorOccurrences failed: %v", err)
+		}
+		if len(occurrences) != 3 {
+			t.Fatalf("Expected 3 occurrences, got %d", len(occurrences))
+		}
+		if occurrences[0].SessionID != sessionB.ID || occurrences[0].ProjectName != "error-project-b" {
+			t.Errorf("Expected newest occurrence from session B, got %+v", occurrences[0])
+		}
+		if occurrences[0].ResultText != "Exit code 127\nfile /tmp/c.go not found" {
+			t.Errorf("Expected raw error message, got %q", occurrences[0].ResultText)
+		}
+	})
+
+	t.Run("存在しないパターンはエラー", func(t *testing.T) {
+		if _, err := db.GetErrorPattern(99999); err == nil {
+			t.Error("Expected error for unknown pattern")
+		}
+	})
+
+	t.Run("セッション更新時に件数が再計算される", func(t *testing.T) {
+		// Readのエラーが解消され、Bashのエラーが1件に減った
+		sessionA.ToolCalls = sessionA.ToolCalls[:1]
+		if err := db.UpdateSession(sessionA, "error-project-a", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+
+		patterns, err := db.ListErrorPatterns(ErrorPatternFilter{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListErrorPatterns failed: %v", err)
+		}
+		if len(patterns) != 1 {
+			t.Fatalf("Expected the Read pattern to be removed, got %d patterns", len(patterns))
+		}
+		if patterns[0].OccurrenceCount != 2 {
+			t.Errorf("Expected 2 occurrences, got %d", patterns[0].OccurrenceCount)
+		}
+
+		pattern, err := db.GetErrorPattern(patterns[0].ID)
+		if err != nil {
+			t.Fatalf("GetErrorPattern failed: %v", err)
+		}
+		if pattern.OccurrenceCount != 2 || !pattern.FirstSeen.Equal(base) {
+			t.Errorf("Expected stored counts to be refreshed, got %+v", pattern)
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// FileEditStats represents the edits made to a file
+type FileEditStats struct {
+	FilePath     string
+	Edits        int // 編集ツールの呼び出し回数
+	Sessions     int
+	LinesAdded   int
+	LinesRemoved int
+	LastEditedAt time.Time
+}
+
+// FileChurn represents the edits made within a period
+type FileChurn struct {
+	Period       string // 期間キー（YYYY-MM-DD / YYYY-Www / YYYY-MM）
+	Edits        int
+	FilesTouched int
+	LinesAdded   int
+	LinesRemoved int
+}
+
+// FileEditFilter holds the filters for project file statistics
+type FileEditFilter struct {
+	From string // YYYY-MM-DD（含む）
+	To   string // YYYY-MM-DD（含む）
+}
+
+// updateFileEdits records the file changes of the successful Edit, MultiEdit and Write calls of a session
+// afterToolCallID に 0 を渡すと保存済みの全ツール呼び出しから記録し直す。それ以外は追記された呼び出しの変更だけを加え、
+// 追記で後からエラー結果を受け取った呼び出し（failedCalls）の変更を取り除く
+func updateFileEdits(tx *sql.Tx, sessionID string, afterToolCallID int64, failedCalls []failedToolCall) error {
+	if afterToolCallID == 0 {
+		if _, err := tx.Exec("DELETE FROM file_edits WHERE session_id = ?", sessionID); err != nil {
+			return fmt.Errorf("failed to delete file edits: %w", err)
+		}
+	} else if len(failedCalls) > 0 {
+		deleteStmt, err := tx.Prepare("DELETE FROM file_edits WHERE tool_call_id = ?")
+		if err != nil {
+			return fmt.Errorf("failed to prepare file edit delete statement: %w", err)
+		}
+		defer deleteStmt.Close()
+
+		for _, call := range failedCalls {
+			if _, err := deleteStmt.Exec(call.id); err != nil {
+				return fmt.Errorf("failed to delete file edits of tool call %d: %w", call.id, err)
+			}
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, timestamp, tool_name, COALESCE(input_json, '')
+		FROM tool_calls
+		WHERE session_id = ? AND id > ? AND tool_name IN ('Edit', 'MultiEdit', 'Write') AND NOT is_error
+		ORDER BY timestamp, id
+	`, sessionID, afterToolCallID)
+	if err != nil {
+		return fmt.Errorf("failed to query edit tool calls: %w", err)
+	}
+
+	type timedEdit struct {
+		toolCallID int64
+		timestamp  time.Time
+		toolName   string
+		edit       analyzer.FileEdit
+	}
+	var edits []timedEdit
+	for rows.Next() {
+		var toolCallID int64
+		var timestamp time.Time
+		var toolName, input string
+		if err := rows.Scan(&toolCallID, &timestamp, &toolName, &input); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan edit tool call: %w", err)
+		}
+		if edit, ok := analyzer.ParseFileEdit(toolName, input); ok {
+			edits = append(edits, timedEdit{toolCallID: toolCallID, timestamp: timestamp, toolName: toolName, edit: edit})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating edit tool calls: %w", err)
+	}
+	rows.Close()
+
+	if len(edits) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO file_edits (session_id, tool_call_id, timestamp, file_path, tool_name, lines_added, lines_removed)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare file edit statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range edits {
+		_, err := stmt.Exec(
+			sessionID, e.toolCallID, formatSortableTime(e.timestamp), e.edit.FilePath, e.toolName,
+			e.edit.LinesAdded, e.edit.LinesRemoved,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert file edit %s: %w", e.edit.FilePath, err)
+		}
+	}
+
+	return nil
+}
+
+// queryFileEditStats aggregates file_edits per file, ordered by the number of edits
+func (db *DB) queryFileEditStats(where string, args []interface{}, limit int) ([]FileEditStats, error) {
+	query := `
+		SELECT fe.file_path, COUNT(*) as edits, COUNT(DISTINCT fe.session_id),
+		       SUM(fe.lines_added), SUM(fe.lines_removed), MAX(fe.timestamp)
+		FROM file_edits fe
+		INNER JOIN sessions s ON fe.session_id = s.id
+	` + where + `
+		GROUP BY fe.file_path
+		ORDER BY edits DESC, fe.file_path
+	`
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query file edits: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []FileEditStats{}
+	for rows.Next() {
+		var stat FileEditStats
+		var lastEditedAt string
+		err := rows.Scan(&stat.FilePath, &stat.Edits, &stat.Sessions, &stat.LinesAdded, &stat.LinesRemoved, &lastEditedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file edits: %w", err)
+		}
+		stat.LastEditedAt, _ = parseDateTime(lastEditedAt)
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file edits: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetSessionFileEdits retrieves the files edited in a session, most edited first
+func (db *DB) GetSessionFileEdits(sessionID string) ([]FileEditStats, error) {
+	return db.queryFileEditStats(" WHERE fe.session_id = ?", []interface{}{sessionID}, 0)
+}
+
+// projectFileEditWhere builds the condition selecting the file edits of a project within the filter
+func projectFileEditWhere(projectID int64, filter FileEditFilter) (string, []interface{}) {
+	where := " WHERE s.project_id = ?"
+	args := []interface{}{projectID}
+	if filter.From != "" {
+		where += " AND DATE(fe.timestamp) >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where += " AND DATE(fe.timestamp) <= ?"
+		args = append(args, filter.To)
+	}
+	return where, args
+}
+
+// GetProjectHotFiles retrieves the most edited files of a project (limit <= 0 returns all files)
+func (db *DB) GetProjectHotFiles(projectID int64, filter FileEditFilter, limit int) ([]FileEditStats, error) {
+	where, args := projectFileEditWhere(projectID, filter)
+	return db.queryFileEditStats(where, args, limit)
+}
+
+// GetProjectFileChurn retrieves the edits of a project per period (oldest first)
+func (db *DB) GetProjectFileChurn(projectID int64, period string, filter FileEditFilter) ([]FileChurn, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	where, args := projectFileEditWhere(projectID, filter)
+	rows, err := db.conn.Query(`
+		SELECT DATE(fe.timestamp), fe.file_path, COUNT(*), SUM(fe.lines_added), SUM(fe.lines_removed)
+		FROM file_edits fe
+		INNER JOIN sessions s ON fe.session_id = s.id
+	`+where+`
+		GROUP BY DATE(fe.timestamp), fe.file_path
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query file churn: %w", err)
+	}
+	defer rows.Close()
+
+	// 期間ごとに集計（週・月の期間キーはGo側で算出）
+	churns := make(map[string]*FileChurn)
+	files := make(map[string]map[string]bool)
+	for rows.Next() {
+		var date, filePath string
+		var edits, added, removed int
+		if err := rows.Scan(&date, &filePath, &edits, &added, &removed); err != nil {
+			return nil, fmt.Errorf("failed to scan file churn: %w", err)
+		}
+
+		key := getPeriodKey(date, period)
+		churn, ok := churns[key]
+		if !ok {
+			churn = &FileChurn{Period: key}
+			churns[key] = churn
+			files[key] = make(map[string]bool)
+		}
+		churn.Edits += edits
+		churn.LinesAdded += added
+		churn.LinesRemoved += removed
+		files[key][filePath] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file churn: %w", err)
+	}
+
+	result := make([]FileChurn, 0, len(churns))
+	for key, churn := range churns {
+		churn.FilesTouched = len(files[key])
+		result = append(result, *churn)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Period < result[j].Period })
+
+	return result, nil
+}
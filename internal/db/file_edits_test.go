@@ -0,0 +1,140 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestFileEdits(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectID, err := database.CreateProject("edit-project", "/path/to/edit-project")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	day1 := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC) // 月曜日
+	day2 := time.Date(2025, 6, 10, 10, 0, 0, 0, time.UTC)
+	edit := func(id string, timestamp time.Time, name string, isError bool, input map[string]interface{}) parser.ToolCall {
+		return parser.ToolCall{ID: id, Timestamp: timestamp, Name: name, IsError: isError, HasResult: true, Input: input}
+	}
+
+	sessionA := createTestSession("edit-a")
+	sessionA.ToolCalls = []parser.ToolCall{
+		edit("t1", day1, "Write", false, map[string]interface{}{"file_path": "/src/a.go", "content": "package a\n\nfunc A() {}\n"}),
+		edit("t2", day1.Add(time.Minute), "Edit", false, map[string]interface{}{"file_path": "/src/a.go", "old_string": "func A() {}", "new_string": "func A() {\n}"}),
+		// 失敗した編集は数えない
+		edit("t3", day1.Add(2*time.Minute), "Edit", true, map[string]interface{}{"file_path": "/src/b.go", "old_string": "x", "new_string": "y"}),
+		edit("t4", day1.Add(3*time.Minute), "Read", false, map[string]interface{}{"file_path": "/src/c.go"}),
+	}
+	if err := database.CreateSession(sessionA, "edit-project", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	sessionB := createTestSession("edit-b")
+	sessionB.ToolCalls = []parser.ToolCall{
+		edit("t5", day2, "MultiEdit", false, map[string]interface{}{
+			"file_path": "/src/a.go",
+			"edits":     []interface{}{map[string]interface{}{"old_string": "a\nb", "new_string": "a"}},
+		}),
+		edit("t6", day2, "Edit", false, map[string]interface{}{"file_path": "/src/b.go", "old_string": "x", "new_string": "y"}),
+	}
+	if err := database.CreateSession(sessionB, "edit-project", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("セッションで編集したファイルを返す", func(t *testing.T) {
+		files, err := database.GetSessionFileEdits("test-session-edit-a")
+		if err != nil {
+			t.Fatalf("GetSessionFileEdits failed: %v", err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("Expected 1 file, got %+v", files)
+		}
+		if files[0].FilePath != "/src/a.go" || files[0].Edits != 2 || files[0].LinesAdded != 5 || files[0].LinesRemoved != 1 {
+			t.Errorf("Unexpected file stats: %+v", files[0])
+		}
+		if !files[0].LastEditedAt.Equal(day1.Add(time.Minute)) {
+			t.Errorf("Unexpected last edited time: %v", files[0].LastEditedAt)
+		}
+	})
+
+	t.Run("プロジェクトでよく編集されるファイルを返す", func(t *testing.T) {
+		files, err := database.GetProjectHotFiles(projectID, FileEditFilter{}, 10)
+		if err != nil {
+			t.Fatalf("GetProjectHotFiles failed: %v", err)
+		}
+		if len(files) != 2 || files[0].FilePath != "/src/a.go" || files[0].Edits != 3 || files[0].Sessions != 2 {
+			t.Errorf("Unexpected hot files: %+v", files)
+		}
+
+		files, err = database.GetProjectHotFiles(projectID, FileEditFilter{From: "2025-06-10"}, 10)
+		if err != nil {
+			t.Fatalf("GetProjectHotFiles failed: %v", err)
+		}
+		if len(files) != 2 || files[0].Edits != 1 {
+			t.Errorf("Unexpected filtered hot files: %+v", files)
+		}
+	})
+
+	t.Run("期間ごとの変更量を返す", func(t *testing.T) {
+		churn, err := database.GetProjectFileChurn(projectID, "week", FileEditFilter{})
+		if err != nil {
+			t.Fatalf("GetProjectFileChurn failed: %v", err)
+		}
+		if len(churn) != 2 {
+			t.Fatalf("Expected 2 weeks, got %+v", churn)
+		}
+		if churn[0].Period != "2025-W23" || churn[0].Edits != 2 || churn[0].FilesTouched != 1 || churn[0].LinesAdded != 5 {
+			t.Errorf("Unexpected first week: %+v", churn[0])
+		}
+		if churn[1].Period != "2025-W24" || churn[1].Edits != 2 || churn[1].FilesTouched != 2 || churn[1].LinesRemoved != 2 {
+			t.Errorf("Unexpected second week: %+v", churn[1])
+		}
+	})
+
+	t.Run("不正な期間はエラー", func(t *testing.T) {
+		if _, err := database.GetProjectFileChurn(projectID, "year", FileEditFilter{}); err == nil {
+			t.Error("Expected error for invalid period, got nil")
+		}
+	})
+	t.Run("追記分の編集を加え、後からエラーになった編集を除く", func(t *testing.T) {
+		if _, err := database.CreateProject("edit-append", "/path/to/edit-append"); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+
+		// 結果を受け取る前の書き込み
+		session := createTestSession("edit-append")
+		write := edit("t7", day1, "Write", false, map[string]interface{}{"file_path": "/src/c.go", "content": "package c\n"})
+		write.HasResult = false
+		session.ToolCalls = []parser.ToolCall{write}
+		if err := database.CreateSession(session, "edit-append", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		delta := &parser.Session{
+			ModelUsage: map[string]parser.TokenSummary{},
+			EndTime:    session.EndTime,
+			ToolCalls: []parser.ToolCall{
+				edit("t8", day1.Add(time.Minute), "Edit", false, map[string]interface{}{"file_path": "/src/d.go", "old_string": "x", "new_string": "y"}),
+			},
+			UnmatchedToolResults: []parser.ToolResult{
+				{ToolUseID: "t7", Timestamp: day1.Add(30 * time.Second), IsError: true, Result: "permission denied"},
+			},
+		}
+		if err := database.AppendSession(session.ID, delta, "edit-append", "edit-append.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		files, err := database.GetSessionFileEdits(session.ID)
+		if err != nil {
+			t.Fatalf("GetSessionFileEdits failed: %v", err)
+		}
+		if len(files) != 1 || files[0].FilePath != "/src/d.go" || files[0].Edits != 1 {
+			t.Errorf("Unexpected file edits: %+v", files)
+		}
+	})
+}
@@ -14,10 +14,15 @@ type GroupStats struct {
 	TotalOutputTokens        int       `json:"totalOutputTokens"`
 	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
 	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
+	EstimatedCostUSD         float64   `json:"estimatedCostUsd"`
 	AvgTokens                float64   `json:"avgTokens"`
 	FirstSession             time.Time `json:"firstSession"`
 	LastSession              time.Time `json:"lastSession"`
 	ErrorRate                float64   `json:"errorRate"`
+
+	// トップレベルのセッションの作業時間と待機時間
+	ActiveSeconds int `json:"activeSeconds"`
+	IdleSeconds   int `json:"idleSeconds"`
 }
 
 // DailyProjectStats represents project-level statistics for a specific date
@@ -33,22 +38,35 @@ type DailyProjectStats struct {
 }
 
 // GetGroupStats retrieves overall statistics for a project group
-func (db *DB) GetGroupStats(groupID int64) (*GroupStats, error) {
+// version が指定された場合はそのバージョンのセッションに絞り込む
+func (db *DB) GetGroupStats(groupID int64, version string) (*GroupStats, error) {
+	// バージョンの条件はLEFT JOINに付け、該当セッションのないプロジェクトも数える
+	joinCondition := "p.id = s.project_id"
+	var args []interface{}
+	if version != "" {
+		joinCondition += " AND s.cli_version = ?"
+		args = append(args, version)
+	}
+	args = append(args, groupID)
+
 	query := `
 		SELECT
 			COUNT(DISTINCT p.id) as total_projects,
-			COUNT(s.id) as total_sessions,
+			COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END) as total_sessions,
 			COALESCE(SUM(s.total_input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(s.total_output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(s.total_cache_creation_tokens), 0) as total_cache_creation_tokens,
 			COALESCE(SUM(s.total_cache_read_tokens), 0) as total_cache_read_tokens,
-			COALESCE(AVG(s.total_input_tokens + s.total_output_tokens), 0) as avg_tokens,
+			COALESCE(SUM(s.total_cost_usd), 0) as total_cost_usd,
+			COALESCE(CAST(SUM(s.total_input_tokens + s.total_output_tokens) AS REAL) / NULLIF(COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END), 0), 0) as avg_tokens,
 			MIN(s.start_time) as first_session,
 			MAX(s.end_time) as last_session,
-			CAST(SUM(CASE WHEN s.error_count > 0 THEN 1 ELSE 0 END) AS REAL) / NULLIF(COUNT(s.id), 0) as error_rate
+			CAST(SUM(CASE WHEN s.parent_session_id IS NULL AND s.error_count > 0 THEN 1 ELSE 0 END) AS REAL) / NULLIF(COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END), 0) as error_rate,
+			COALESCE(SUM(CASE WHEN s.parent_session_id IS NULL THEN s.active_seconds END), 0) as active_seconds,
+			COALESCE(SUM(CASE WHEN s.parent_session_id IS NULL THEN s.idle_seconds END), 0) as idle_seconds
 		FROM project_group_mappings pgm
 		INNER JOIN projects p ON pgm.project_id = p.id
-		LEFT JOIN sessions s ON p.id = s.project_id
+		LEFT JOIN sessions s ON ` + joinCondition + `
 		WHERE pgm.group_id = ?
 	`
 
@@ -56,17 +74,20 @@ func (db *DB) GetGroupStats(groupID int64) (*GroupStats, error) {
 	var firstSessionStr, lastSessionStr sql.NullString
 	var errorRate sql.NullFloat64
 
-	err := db.conn.QueryRow(query, groupID).Scan(
+	err := db.conn.QueryRow(query, args...).Scan(
 		&stats.TotalProjects,
 		&stats.TotalSessions,
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheCreationTokens,
 		&stats.TotalCacheReadTokens,
+		&stats.EstimatedCostUSD,
 		&stats.AvgTokens,
 		&firstSessionStr,
 		&lastSessionStr,
 		&errorRate,
+		&stats.ActiveSeconds,
+		&stats.IdleSeconds,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query group stats: %w", err)
@@ -98,98 +119,13 @@ func (db *DB) GetGroupStats(groupID int64) (*GroupStats, error) {
 // GetGroupTimeSeriesStats retrieves time-series statistics for a project group
 // period can be "day", "week", or "month"
 // limit specifies the maximum number of periods to return (default: 30)
-func (db *DB) GetGroupTimeSeriesStats(groupID int64, period string, limit int) ([]TimeSeriesStats, error) {
-	if limit <= 0 {
-		limit = 30
-	}
-
-	// 期間ごとのグループ化SQL
-	var dateFormat string
-	switch period {
-	case "day":
-		dateFormat = "%Y-%m-%d"
-	case "week":
-		// SQLiteのweek開始日は日曜日
-		dateFormat = "%Y-%W"
-	case "month":
-		dateFormat = "%Y-%m"
-	default:
-		return nil, fmt.Errorf("invalid period: %s (must be day, week, or month)", period)
-	}
-
-	query := fmt.Sprintf(`
-		SELECT
-			STRFTIME('%s', s.start_time) as period_group,
-			MIN(DATE(s.start_time)) as period_start,
-			MAX(DATE(s.start_time)) as period_end,
-			COUNT(*) as session_count,
-			COALESCE(SUM(s.total_input_tokens), 0) as total_input_tokens,
-			COALESCE(SUM(s.total_output_tokens), 0) as total_output_tokens,
-			COALESCE(SUM(s.total_cache_creation_tokens), 0) as total_cache_creation_tokens,
-			COALESCE(SUM(s.total_cache_read_tokens), 0) as total_cache_read_tokens
-		FROM project_group_mappings pgm
-		INNER JOIN projects p ON pgm.project_id = p.id
-		INNER JOIN sessions s ON p.id = s.project_id
-		WHERE pgm.group_id = ? AND s.start_time > '0001-01-02'  -- SQLiteの最小日付より後のデータのみを対象
-		GROUP BY period_group
-		ORDER BY period_start DESC
-		LIMIT ?
-	`, dateFormat)
-
-	rows, err := db.conn.Query(query, groupID, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query group time series stats: %w", err)
-	}
-	defer rows.Close()
-
-	var timeSeriesStats []TimeSeriesStats
-	for rows.Next() {
-		var stats TimeSeriesStats
-		var periodGroup sql.NullString
-		var periodStartStr, periodEndStr sql.NullString
-
-		err := rows.Scan(
-			&periodGroup,
-			&periodStartStr,
-			&periodEndStr,
-			&stats.SessionCount,
-			&stats.TotalInputTokens,
-			&stats.TotalOutputTokens,
-			&stats.TotalCacheCreationTokens,
-			&stats.TotalCacheReadTokens,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan group time series stats: %w", err)
-		}
-
-		// NULL値をスキップ
-		if !periodStartStr.Valid || !periodEndStr.Valid {
-			continue
-		}
-
-		// 日付文字列をtime.Timeに変換
-		stats.PeriodStart, err = parseDateTime(periodStartStr.String)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse period start: %w", err)
-		}
-		stats.PeriodEnd, err = parseDateTime(periodEndStr.String)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse period end: %w", err)
-		}
-
-		timeSeriesStats = append(timeSeriesStats, stats)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating group time series stats: %w", err)
+// version が指定された場合はそのバージョンのセッションだけを集計する
+func (db *DB) GetGroupTimeSeriesStats(groupID int64, period string, limit int, version string) ([]TimeSeriesStats, error) {
+	if version != "" {
+		return db.getVersionPeriodStatistics(period, limit, version, "s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)", groupID)
 	}
-
-	// 結果を逆順に並び替え（古い順にする）
-	for i, j := 0, len(timeSeriesStats)-1; i < j; i, j = i+1, j-1 {
-		timeSeriesStats[i], timeSeriesStats[j] = timeSeriesStats[j], timeSeriesStats[i]
-	}
-
-	return timeSeriesStats, nil
+	// グループ内の各プロジェクトの集計行を期間ごとに合算
+	return db.getPeriodStatistics(period, limit, "project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)", groupID)
 }
 
 // GetGroupDailyProjectStats retrieves project-wise statistics for a group on a specific date
@@ -199,7 +135,7 @@ func (db *DB) GetGroupDailyProjectStats(groupID int64, date string) ([]DailyProj
 		SELECT
 			p.id as project_id,
 			p.name as project_name,
-			COUNT(s.id) as session_count,
+			COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END) as session_count,
 			COALESCE(SUM(s.total_input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(s.total_output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(s.total_cache_creation_tokens), 0) as total_cache_creation_tokens,
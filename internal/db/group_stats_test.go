@@ -73,7 +73,7 @@ func TestGetGroupStats(t *testing.T) {
 		}
 
 		// グループ統計を取得
-		stats, err := db.GetGroupStats(groupID)
+		stats, err := db.GetGroupStats(groupID, "")
 		if err != nil {
 			t.Fatalf("GetGroupStats failed: %v", err)
 		}
@@ -115,7 +115,7 @@ func TestGetGroupStats(t *testing.T) {
 		}
 
 		// グループ統計を取得
-		stats, err := db.GetGroupStats(groupID)
+		stats, err := db.GetGroupStats(groupID, "")
 		if err != nil {
 			t.Fatalf("GetGroupStats failed: %v", err)
 		}
@@ -154,7 +154,7 @@ func TestGetGroupStats(t *testing.T) {
 		}
 
 		// グループ統計を取得
-		stats, err := db.GetGroupStats(groupID)
+		stats, err := db.GetGroupStats(groupID, "")
 		if err != nil {
 			t.Fatalf("GetGroupStats failed: %v", err)
 		}
@@ -234,7 +234,7 @@ func TestGetGroupTimeSeriesStats(t *testing.T) {
 
 	t.Run("グループ全体の日別時系列統計を取得できる", func(t *testing.T) {
 		// 日別の時系列統計を取得
-		timeSeriesStats, err := db.GetGroupTimeSeriesStats(groupID, "day", 30)
+		timeSeriesStats, err := db.GetGroupTimeSeriesStats(groupID, "day", 30, "")
 		if err != nil {
 			t.Fatalf("GetGroupTimeSeriesStats failed: %v", err)
 		}
@@ -287,7 +287,7 @@ func TestGetGroupTimeSeriesStats(t *testing.T) {
 
 	t.Run("期間パラメータで集計単位を変更できる", func(t *testing.T) {
 		// 週別の時系列統計を取得（全て同じ週なので1件になる）
-		weekStats, err := db.GetGroupTimeSeriesStats(groupID, "week", 30)
+		weekStats, err := db.GetGroupTimeSeriesStats(groupID, "week", 30, "")
 		if err != nil {
 			t.Fatalf("GetGroupTimeSeriesStats with week failed: %v", err)
 		}
@@ -303,7 +303,7 @@ func TestGetGroupTimeSeriesStats(t *testing.T) {
 		}
 
 		// 月別の時系列統計を取得（全て同じ月なので1件になる）
-		monthStats, err := db.GetGroupTimeSeriesStats(groupID, "month", 30)
+		monthStats, err := db.GetGroupTimeSeriesStats(groupID, "month", 30, "")
 		if err != nil {
 			t.Fatalf("GetGroupTimeSeriesStats with month failed: %v", err)
 		}
@@ -327,7 +327,7 @@ func TestGetGroupTimeSeriesStats(t *testing.T) {
 		}
 
 		// 時系列統計を取得
-		timeSeriesStats, err := db.GetGroupTimeSeriesStats(emptyGroupID, "day", 30)
+		timeSeriesStats, err := db.GetGroupTimeSeriesStats(emptyGroupID, "day", 30, "")
 		if err != nil {
 			t.Fatalf("GetGroupTimeSeriesStats failed: %v", err)
 		}
@@ -339,7 +339,7 @@ func TestGetGroupTimeSeriesStats(t *testing.T) {
 	})
 
 	t.Run("無効な期間パラメータでエラーを返す", func(t *testing.T) {
-		_, err := db.GetGroupTimeSeriesStats(groupID, "invalid", 30)
+		_, err := db.GetGroupTimeSeriesStats(groupID, "invalid", 30, "")
 		if err == nil {
 			t.Error("Expected error for invalid period, got nil")
 		}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// InterruptionStats represents the user interruptions of a project, model or tool
+type InterruptionStats struct {
+	Key string // 集計キー（プロジェクト名・モデル名・ツール名、全体の場合は空）
+
+	Interrupts int // 応答中の中断
+	Rejections int // ツールの実行許可の拒否
+	Cancels    int // ツール実行中のキャンセル
+	Total      int
+
+	// 中断率の分母（全体・プロジェクト: タスク数、モデル: 応答数、ツール: 呼び出し回数）
+	BaseCount int
+	Rate      float64
+}
+
+// InterruptionStatsResult holds interruption statistics overall and per project, model and tool
+type InterruptionStatsResult struct {
+	Total     InterruptionStats
+	ByProject []InterruptionStats
+	ByModel   []InterruptionStats
+	ByTool    []InterruptionStats
+}
+
+// insertInterruptions records the user interruptions of a session within a transaction
+// 追記分の解析では対応するtool_useが解析範囲にない場合があるため、ツール名とモデルは保存済みのツール呼び出しから補う
+func insertInterruptions(tx *sql.Tx, sessionID string, interruptions []parser.Interruption) error {
+	if len(interruptions) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO session_interruptions (session_id, entry_uuid, timestamp, type, tool_use_id, tool_name, model)
+		VALUES (?, ?, ?, ?, ?,
+			COALESCE(?, (SELECT tool_name FROM tool_calls WHERE session_id = ? AND tool_use_id = ? ORDER BY id LIMIT 1)),
+			COALESCE(?, (SELECT model FROM tool_calls WHERE session_id = ? AND tool_use_id = ? ORDER BY id LIMIT 1)))
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare interruption statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, interruption := range interruptions {
+		toolUseID := nullIfEmpty(interruption.ToolUseID)
+		_, err = stmt.Exec(
+			sessionID, interruption.UUID, formatSortableTime(interruption.Timestamp), interruption.Type, toolUseID,
+			nullIfEmpty(interruption.ToolName), sessionID, toolUseID,
+			nullIfEmpty(interruption.Model), sessionID, toolUseID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert interruption %s: %w", interruption.UUID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetInterruptionStats retrieves the user interruptions and interruption rates overall and per project, model and tool
+// projectID / groupID が指定された場合はそのプロジェクト・グループのセッションに、version が指定された場合はそのバージョンのセッションに絞り込む
+func (db *DB) GetInterruptionStats(projectID, groupID *int64, version string) (*InterruptionStatsResult, error) {
+	where := " WHERE 1 = 1"
+	var args []interface{}
+	if projectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if groupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *groupID)
+	}
+	if version != "" {
+		where += " AND s.cli_version = ?"
+		args = append(args, version)
+	}
+
+	// タスク数（ユーザー入力で始まるタスク）
+	taskBase := `
+		SELECT %s, COUNT(*)
+		FROM session_tasks st
+		INNER JOIN sessions s ON st.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+	` + where + ` AND st.start_uuid != ''
+		GROUP BY 1
+	`
+
+	result := &InterruptionStatsResult{}
+
+	totals, err := db.queryInterruptionStats("''", fmt.Sprintf(taskBase, "''"), where, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(totals) > 0 {
+		result.Total = totals[0]
+	}
+
+	result.ByProject, err = db.queryInterruptionStats("p.name", fmt.Sprintf(taskBase, "p.name"), where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// 応答数（同じAPI応答の複数行は1回と数え、合成メッセージは除く）
+	responseBase := `
+		SELECT m.model, COUNT(DISTINCT CASE WHEN COALESCE(le.request_id, '') != ''
+		                                    THEN le.session_id || ':' || le.request_id ELSE le.id END)
+		FROM log_entries le
+		INNER JOIN messages m ON m.log_entry_id = le.id
+		INNER JOIN sessions s ON le.session_id = s.id
+	` + where + ` AND le.entry_type = 'assistant' AND m.model IS NOT NULL AND m.model NOT IN ('', '<synthetic>')
+		GROUP BY m.model
+	`
+	result.ByModel, err = db.queryInterruptionStats("si.model", responseBase, where+" AND si.model IS NOT NULL", args)
+	if err != nil {
+		return nil, err
+	}
+
+	toolBase := `
+		SELECT tc.tool_name, COUNT(*)
+		FROM tool_calls tc
+		INNER JOIN sessions s ON tc.session_id = s.id
+	` + where + `
+		GROUP BY tc.tool_name
+	`
+	result.ByTool, err = db.queryInterruptionStats("si.tool_name", toolBase, where+" AND si.tool_name IS NOT NULL", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// queryInterruptionStats counts the interruptions per keyExpr and combines them with the base counts per key
+// baseQuery returns (key, count) rows; keys with neither interruptions nor a base count are omitted.
+// 中断の多い順（同数の場合は分母の多い順・キー順）に並べる
+func (db *DB) queryInterruptionStats(keyExpr, baseQuery, where string, args []interface{}) ([]InterruptionStats, error) {
+	stats := make(map[string]*InterruptionStats)
+	statFor := func(key string) *InterruptionStats {
+		stat, ok := stats[key]
+		if !ok {
+			stat = &InterruptionStats{Key: key}
+			stats[key] = stat
+		}
+		return stat
+	}
+
+	baseRows, err := db.conn.Query(baseQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interruption base counts: %w", err)
+	}
+	for baseRows.Next() {
+		var key string
+		var count int
+		if err := baseRows.Scan(&key, &count); err != nil {
+			baseRows.Close()
+			return nil, fmt.Errorf("failed to scan interruption base count: %w", err)
+		}
+		statFor(key).BaseCount = count
+	}
+	if err := baseRows.Err(); err != nil {
+		baseRows.Close()
+		return nil, fmt.Errorf("error iterating interruption base counts: %w", err)
+	}
+	baseRows.Close()
+
+	rows, err := db.conn.Query(`
+		SELECT `+keyExpr+` as key, si.type, COUNT(*)
+		FROM session_interruptions si
+		INNER JOIN sessions s ON si.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+	`+where+`
+		GROUP BY key, si.type
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interruptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, interruptionType string
+		var count int
+		if err := rows.Scan(&key, &interruptionType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan interruptions: %w", err)
+		}
+		stat := statFor(key)
+		switch interruptionType {
+		case parser.InterruptionTypeInterrupt:
+			stat.Interrupts += count
+		case parser.InterruptionTypeRejection:
+			stat.Rejections += count
+		case parser.InterruptionTypeCancel:
+			stat.Cancels += count
+		}
+		stat.Total += count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating interruptions: %w", err)
+	}
+
+	result := make([]InterruptionStats, 0, len(stats))
+	for _, stat := range stats {
+		if stat.BaseCount > 0 {
+			stat.Rate = float64(stat.Total) / float64(stat.BaseCount)
+		}
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.BaseCount != b.BaseCount {
+			return a.BaseCount > b.BaseCount
+		}
+		return a.Key < b.Key
+	})
+
+	return result, nil
+}
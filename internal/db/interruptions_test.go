@@ -0,0 +1,156 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestInterruptionStats(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectAID, err := database.CreateProject("interrupt-project-a", "/path/to/interrupt-project-a")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if _, err := database.CreateProject("interrupt-project-b", "/path/to/interrupt-project-b"); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	sessionA := createTestSession("interrupt-a")
+	editInput := map[string]interface{}{"file_path": "/src/a.go", "old_string": "a", "new_string": "b"}
+	sessionA.ToolCalls = []parser.ToolCall{
+		{
+			ID: "toolu_1", Timestamp: now, Name: "Edit", Input: editInput, Model: "claude-sonnet-4-5", IsError: true, HasResult: true,
+			Result: "The user doesn't want to proceed with this tool use.", InterruptionType: parser.InterruptionTypeRejection,
+		},
+		{
+			ID: "toolu_2", Timestamp: now.Add(time.Minute), Name: "Bash", Model: "claude-sonnet-4-5", IsError: true, HasResult: true,
+			Result: "[Request interrupted by user for tool use]", InterruptionType: parser.InterruptionTypeCancel,
+		},
+	}
+	sessionA.Interruptions = []parser.Interruption{
+		{UUID: "i-1", Timestamp: now, Type: parser.InterruptionTypeInterrupt, Model: "claude-sonnet-4-5"},
+		{UUID: "i-2", Timestamp: now, Type: parser.InterruptionTypeRejection, ToolUseID: "toolu_1", ToolName: "Edit", Model: "claude-sonnet-4-5"},
+		// 追記分の解析ではツール名とモデルが空になる
+		{UUID: "i-3", Timestamp: now.Add(time.Minute), Type: parser.InterruptionTypeCancel, ToolUseID: "toolu_2"},
+	}
+	if err := database.CreateSession(sessionA, "interrupt-project-a", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	sessionB := createTestSession("interrupt-b")
+	if err := database.CreateSession(sessionB, "interrupt-project-b", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("全体とプロジェクトごとの中断率を返す", func(t *testing.T) {
+		result, err := database.GetInterruptionStats(nil, nil, "")
+		if err != nil {
+			t.Fatalf("GetInterruptionStats failed: %v", err)
+		}
+
+		total := result.Total
+		if total.Interrupts != 1 || total.Rejections != 1 || total.Cancels != 1 || total.Total != 3 || total.BaseCount != 2 || total.Rate != 1.5 {
+			t.Errorf("Unexpected total: %+v", total)
+		}
+
+		if len(result.ByProject) != 2 {
+			t.Fatalf("Expected 2 projects, got %+v", result.ByProject)
+		}
+		if result.ByProject[0].Key != "interrupt-project-a" || result.ByProject[0].Rate != 3 {
+			t.Errorf("Unexpected project stats: %+v", result.ByProject[0])
+		}
+		if result.ByProject[1].Key != "interrupt-project-b" || result.ByProject[1].Total != 0 || result.ByProject[1].BaseCount != 1 {
+			t.Errorf("Unexpected project stats: %+v", result.ByProject[1])
+		}
+	})
+
+	t.Run("モデルごとの中断率は応答数を分母にする", func(t *testing.T) {
+		result, err := database.GetInterruptionStats(nil, nil, "")
+		if err != nil {
+			t.Fatalf("GetInterruptionStats failed: %v", err)
+		}
+		if len(result.ByModel) != 1 {
+			t.Fatalf("Expected 1 model, got %+v", result.ByModel)
+		}
+		model := result.ByModel[0]
+		if model.Key != "claude-sonnet-4-5" || model.Total != 3 || model.BaseCount != 2 {
+			t.Errorf("Unexpected model stats: %+v", model)
+		}
+	})
+
+	t.Run("ツールごとの中断率は呼び出し回数を分母にする", func(t *testing.T) {
+		result, err := database.GetInterruptionStats(&projectAID, nil, "")
+		if err != nil {
+			t.Fatalf("GetInterruptionStats failed: %v", err)
+		}
+		if len(result.ByTool) != 2 {
+			t.Fatalf("Expected 2 tools, got %+v", result.ByTool)
+		}
+		// ツール名は保存済みのツール呼び出しから補われる
+		if result.ByTool[0].Key != "Bash" || result.ByTool[0].Cancels != 1 || result.ByTool[0].Rate != 1 {
+			t.Errorf("Unexpected tool stats: %+v", result.ByTool[0])
+		}
+		if result.ByTool[1].Key != "Edit" || result.ByTool[1].Rejections != 1 {
+			t.Errorf("Unexpected tool stats: %+v", result.ByTool[1])
+		}
+	})
+
+	t.Run("拒否・キャンセルはツールのエラーとして集計しない", func(t *testing.T) {
+		patterns, err := database.ListErrorPatterns(ErrorPatternFilter{ProjectID: &projectAID, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListErrorPatterns failed: %v", err)
+		}
+		if len(patterns) != 0 {
+			t.Errorf("Expected no error patterns, got %+v", patterns)
+		}
+
+		stats, err := database.GetToolStats(&projectAID, nil, "")
+		if err != nil {
+			t.Fatalf("GetToolStats failed: %v", err)
+		}
+		for _, stat := range stats {
+			if stat.ErrorCount != 0 {
+				t.Errorf("Expected no errors of %s, got %+v", stat.ToolName, stat)
+			}
+		}
+
+		// 拒否された編集をやり直しても再試行とみなさない
+		retryDB, _ := setupTestDB(t)
+		defer retryDB.Close()
+		if _, err := retryDB.CreateProject("interrupt-retry", "/path/to/interrupt-retry"); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+		retried := createTestSession("interrupt-retry")
+		retried.ToolCalls = append([]parser.ToolCall{}, sessionA.ToolCalls[0],
+			parser.ToolCall{ID: "toolu_3", Timestamp: now.Add(2 * time.Minute), Name: "Edit", Input: editInput, Model: "claude-sonnet-4-5", HasResult: true})
+		if err := retryDB.CreateSession(retried, "interrupt-retry", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		var retryCount int
+		if err := retryDB.conn.QueryRow("SELECT retry_count FROM sessions WHERE id = ?", retried.ID).Scan(&retryCount); err != nil {
+			t.Fatalf("Failed to get retry count: %v", err)
+		}
+		if retryCount != 0 {
+			t.Errorf("Expected no retries, got %d", retryCount)
+		}
+	})
+
+	t.Run("セッションを更新しても重複しない", func(t *testing.T) {
+		if err := database.UpdateSession(sessionA, "interrupt-project-a", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+		result, err := database.GetInterruptionStats(&projectAID, nil, "")
+		if err != nil {
+			t.Fatalf("GetInterruptionStats failed: %v", err)
+		}
+		if result.Total.Total != 3 {
+			t.Errorf("Expected 3 interruptions, got %+v", result.Total)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// MCPFilter holds the filters for MCP server statistics
+type MCPFilter struct {
+	ProjectID *int64
+	GroupID   *int64
+	From      string // YYYY-MM-DD（含む）
+	To        string // YYYY-MM-DD（含む）
+}
+
+// MCPStats represents call, error, latency and result size statistics of MCP tool calls
+type MCPStats struct {
+	Key string // 集計キー（サーバー名・ツール名・プロジェクト名・日付）
+
+	CallCount    int
+	ErrorCount   int
+	ErrorRate    float64
+	P50LatencyMs int64
+	P95LatencyMs int64
+
+	// 結果テキストのバイト数（コンテキストに追加される大きさの目安）
+	TotalResultBytes int64
+	AvgResultBytes   float64
+	MaxResultBytes   int
+
+	Tools    int // 呼び出されたツールの種類
+	Sessions int
+}
+
+// MCPServerDetail holds the statistics of an MCP server broken down by tool, project and day
+type MCPServerDetail struct {
+	Server    MCPStats
+	ByTool    []MCPStats
+	ByProject []MCPStats
+	Daily     []MCPStats // 日付順
+}
+
+// mcpCall is an MCP tool call loaded for aggregation
+type mcpCall struct {
+	server      string
+	tool        string
+	projectName string
+	date        string
+	sessionID   string
+	isError     bool
+	durationMs  sql.NullInt64
+	resultSize  int
+}
+
+// mcpAggregate accumulates MCP tool calls of one key
+type mcpAggregate struct {
+	stats     MCPStats
+	durations []int64
+	tools     map[string]bool
+	sessions  map[string]bool
+}
+
+// add accumulates a call
+func (agg *mcpAggregate) add(call mcpCall) {
+	agg.stats.CallCount++
+	if call.isError {
+		agg.stats.ErrorCount++
+	}
+	if call.durationMs.Valid {
+		agg.durations = append(agg.durations, call.durationMs.Int64)
+	}
+	agg.stats.TotalResultBytes += int64(call.resultSize)
+	if call.resultSize > agg.stats.MaxResultBytes {
+		agg.stats.MaxResultBytes = call.resultSize
+	}
+	agg.tools[call.tool] = true
+	agg.sessions[call.sessionID] = true
+}
+
+// result finalizes the rates, percentiles and distinct counts
+func (agg *mcpAggregate) result() MCPStats {
+	stats := agg.stats
+	if stats.CallCount > 0 {
+		stats.ErrorRate = float64(stats.ErrorCount) / float64(stats.CallCount)
+		stats.AvgResultBytes = float64(stats.TotalResultBytes) / float64(stats.CallCount)
+	}
+	sort.Slice(agg.durations, func(i, j int) bool { return agg.durations[i] < agg.durations[j] })
+	stats.P50LatencyMs = percentile(agg.durations, 50)
+	stats.P95LatencyMs = percentile(agg.durations, 95)
+	stats.Tools = len(agg.tools)
+	stats.Sessions = len(agg.sessions)
+	return stats
+}
+
+// aggregateMCPCalls groups calls by key and returns the stats ordered by call count (or by key when byKey is set)
+func aggregateMCPCalls(calls []mcpCall, key func(mcpCall) string, byKey bool) []MCPStats {
+	aggregates := make(map[string]*mcpAggregate)
+	for _, call := range calls {
+		k := key(call)
+		agg, ok := aggregates[k]
+		if !ok {
+			agg = &mcpAggregate{
+				stats:    MCPStats{Key: k},
+				tools:    make(map[string]bool),
+				sessions: make(map[string]bool),
+			}
+			aggregates[k] = agg
+		}
+		agg.add(call)
+	}
+
+	stats := make([]MCPStats, 0, len(aggregates))
+	for _, agg := range aggregates {
+		stats = append(stats, agg.result())
+	}
+
+	// 呼び出し回数の多い順（同数の場合はキー順）
+	sort.Slice(stats, func(i, j int) bool {
+		if !byKey && stats[i].CallCount != stats[j].CallCount {
+			return stats[i].CallCount > stats[j].CallCount
+		}
+		return stats[i].Key < stats[j].Key
+	})
+	return stats
+}
+
+// queryMCPCalls loads the MCP tool calls matching the filter (and the server if not empty)
+func (db *DB) queryMCPCalls(server string, filter MCPFilter) ([]mcpCall, error) {
+	// tool_calls.timestampはGoのtime.Time形式（2006-01-02 15:04:05 +0000 UTC）で保存されておりDATE()で解釈できないため、先頭10文字を日付とする
+	query := `
+		SELECT tc.mcp_server, tc.mcp_tool, p.name, SUBSTR(tc.timestamp, 1, 10), tc.session_id,
+		       tc.is_error AND tc.interruption_type IS NULL, tc.duration_ms, tc.result_size
+		FROM tool_calls tc
+		INNER JOIN sessions s ON tc.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+		WHERE tc.mcp_server IS NOT NULL
+	`
+
+	var args []interface{}
+	if server != "" {
+		query += " AND tc.mcp_server = ?"
+		args = append(args, server)
+	}
+	if filter.ProjectID != nil {
+		query += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		query += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.From != "" {
+		query += " AND SUBSTR(tc.timestamp, 1, 10) >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += " AND SUBSTR(tc.timestamp, 1, 10) <= ?"
+		args = append(args, filter.To)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query MCP tool calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []mcpCall
+	for rows.Next() {
+		var call mcpCall
+		err := rows.Scan(
+			&call.server, &call.tool, &call.projectName, &call.date, &call.sessionID,
+			&call.isError, &call.durationMs, &call.resultSize,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan MCP tool call: %w", err)
+		}
+		calls = append(calls, call)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating MCP tool calls: %w", err)
+	}
+
+	return calls, nil
+}
+
+// GetMCPServerStats retrieves the call, error, latency and result size statistics of each MCP server
+func (db *DB) GetMCPServerStats(filter MCPFilter) ([]MCPStats, error) {
+	calls, err := db.queryMCPCalls("", filter)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateMCPCalls(calls, func(call mcpCall) string { return call.server }, false), nil
+}
+
+// GetMCPServerDetail retrieves the statistics of an MCP server broken down by tool, project and day
+// Returns nil if the server has no calls matching the filter.
+func (db *DB) GetMCPServerDetail(server string, filter MCPFilter) (*MCPServerDetail, error) {
+	calls, err := db.queryMCPCalls(server, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	total := aggregateMCPCalls(calls, func(call mcpCall) string { return call.server }, false)
+	return &MCPServerDetail{
+		Server:    total[0],
+		ByTool:    aggregateMCPCalls(calls, func(call mcpCall) string { return call.tool }, false),
+		ByProject: aggregateMCPCalls(calls, func(call mcpCall) string { return call.projectName }, false),
+		Daily:     aggregateMCPCalls(calls, func(call mcpCall) string { return call.date }, true),
+	}, nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestMCPServerStats(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectAID, err := database.CreateProject("mcp-project-a", "/path/to/mcp-project-a")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if _, err := database.CreateProject("mcp-project-b", "/path/to/mcp-project-b"); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	day1 := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	call := func(id string, timestamp time.Time, name string, isError bool, resultSize int, duration time.Duration) parser.ToolCall {
+		return parser.ToolCall{
+			ID: id, Timestamp: timestamp, Name: name, IsError: isError,
+			HasResult: true, ResultSize: resultSize, Duration: duration,
+		}
+	}
+
+	sessionA := createTestSession("mcp-a")
+	sessionA.ToolCalls = []parser.ToolCall{
+		call("t1", day1, "mcp__github__create_issue", false, 1000, 800*time.Millisecond),
+		call("t2", day1, "mcp__github__get_file", true, 200, 200*time.Millisecond),
+		call("t3", day2, "mcp__github__get_file", false, 30000, 400*time.Millisecond),
+		call("t4", day2, "Bash", false, 50, 100*time.Millisecond),
+	}
+	if err := database.CreateSession(sessionA, "mcp-project-a", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	sessionB := createTestSession("mcp-b")
+	sessionB.ToolCalls = []parser.ToolCall{
+		call("t5", day2, "mcp__plugin_playwright__browser_snapshot", false, 50000, time.Second),
+		call("t6", day2, "mcp__github__get_file", false, 800, 300*time.Millisecond),
+	}
+	if err := database.CreateSession(sessionB, "mcp-project-b", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("サーバーごとに集計する", func(t *testing.T) {
+		stats, err := database.GetMCPServerStats(MCPFilter{})
+		if err != nil {
+			t.Fatalf("GetMCPServerStats failed: %v", err)
+		}
+		if len(stats) != 2 {
+			t.Fatalf("Expected 2 servers, got %+v", stats)
+		}
+
+		github := stats[0]
+		if github.Key != "github" || github.CallCount != 4 || github.ErrorCount != 1 || github.ErrorRate != 0.25 {
+			t.Errorf("Unexpected github stats: %+v", github)
+		}
+		if github.TotalResultBytes != 32000 || github.MaxResultBytes != 30000 || github.Tools != 2 || github.Sessions != 2 {
+			t.Errorf("Unexpected github result stats: %+v", github)
+		}
+		if github.P50LatencyMs != 300 || github.P95LatencyMs != 800 {
+			t.Errorf("Unexpected github latency: %+v", github)
+		}
+		if stats[1].Key != "plugin_playwright" || stats[1].AvgResultBytes != 50000 {
+			t.Errorf("Unexpected playwright stats: %+v", stats[1])
+		}
+	})
+
+	t.Run("プロジェクトと期間で絞り込める", func(t *testing.T) {
+		stats, err := database.GetMCPServerStats(MCPFilter{ProjectID: &projectAID, From: "2025-06-02", To: "2025-06-02"})
+		if err != nil {
+			t.Fatalf("GetMCPServerStats failed: %v", err)
+		}
+		if len(stats) != 1 || stats[0].Key != "github" || stats[0].CallCount != 1 {
+			t.Errorf("Unexpected filtered stats: %+v", stats)
+		}
+	})
+
+	t.Run("サーバーの詳細をツール・プロジェクト・日付別に返す", func(t *testing.T) {
+		detail, err := database.GetMCPServerDetail("github", MCPFilter{})
+		if err != nil {
+			t.Fatalf("GetMCPServerDetail failed: %v", err)
+		}
+		if detail == nil || detail.Server.CallCount != 4 {
+			t.Fatalf("Unexpected detail: %+v", detail)
+		}
+		if len(detail.ByTool) != 2 || detail.ByTool[0].Key != "get_file" || detail.ByTool[0].CallCount != 3 {
+			t.Errorf("Unexpected per-tool stats: %+v", detail.ByTool)
+		}
+		if len(detail.ByProject) != 2 || detail.ByProject[0].Key != "mcp-project-a" {
+			t.Errorf("Unexpected per-project stats: %+v", detail.ByProject)
+		}
+		if len(detail.Daily) != 2 || detail.Daily[0].Key != "2025-06-01" || detail.Daily[1].CallCount != 2 {
+			t.Errorf("Unexpected daily stats: %+v", detail.Daily)
+		}
+	})
+
+	t.Run("呼び出しのないサーバーはnil", func(t *testing.T) {
+		detail, err := database.GetMCPServerDetail("unknown", MCPFilter{})
+		if err != nil {
+			t.Fatalf("GetMCPServerDetail failed: %v", err)
+		}
+		if detail != nil {
+			t.Errorf("Expected nil detail, got %+v", detail)
+		}
+	})
+}
@@ -0,0 +1,10 @@
+-- Migration 006: Cost Estimation
+-- Purpose: Store cache creation TTL breakdown and estimated USD cost per model and per session
+
+-- モデル使用量: キャッシュ作成トークンのTTL別内訳と推定コスト
+ALTER TABLE model_usage ADD COLUMN cache_creation_5m_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE model_usage ADD COLUMN cache_creation_1h_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE model_usage ADD COLUMN cost_usd REAL NOT NULL DEFAULT 0;
+
+-- セッション: 推定コスト合計（非正規化：統計クエリで頻繁に集計される）
+ALTER TABLE sessions ADD COLUMN total_cost_usd REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- Migration 007: Tool Call Results
+-- Purpose: Pair tool_use with tool_result (tool_use_id) and store the latency between them
+
+-- tool_use_id: tool_resultとの対応付けに使用したID
+-- duration_ms: tool_useからtool_resultまでの経過時間（tool_result未受信の場合はNULL）
+ALTER TABLE tool_calls ADD COLUMN tool_use_id TEXT;
+ALTER TABLE tool_calls ADD COLUMN duration_ms INTEGER;
@@ -0,0 +1,39 @@
+-- Migration 008: Full-Text Search Index
+-- Purpose: Search conversation history (message text, tool inputs and tool results) with FTS5
+
+-- 検索対象ドキュメント（メッセージ本文・ツール入力・ツール結果を1行ずつ保持）
+CREATE TABLE IF NOT EXISTS search_documents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    entry_uuid TEXT,                      -- メッセージの場合のみ
+    source_type TEXT NOT NULL,            -- 'message', 'tool_input', 'tool_result'
+    role TEXT NOT NULL,                   -- 'user', 'assistant', 'tool'
+    tool_name TEXT,                       -- ツールの場合のみ
+    timestamp TEXT NOT NULL,              -- UTC（YYYY-MM-DDTHH:MM:SS.sssZ、文字列比較可能）
+    content TEXT NOT NULL,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_documents_session ON search_documents(session_id);
+CREATE INDEX IF NOT EXISTS idx_search_documents_timestamp ON search_documents(timestamp);
+
+-- FTS5インデックス（外部コンテンツテーブル）
+-- trigramトークナイザで日本語を含む部分一致検索に対応
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+    content,
+    content = 'search_documents',
+    content_rowid = 'id',
+    tokenize = 'trigram'
+);
+
+-- search_documentsとFTSインデックスを同期するトリガー
+CREATE TRIGGER IF NOT EXISTS search_documents_ai AFTER INSERT ON search_documents
+BEGIN
+    INSERT INTO search_index (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_documents_ad AFTER DELETE ON search_documents
+BEGIN
+    INSERT INTO search_index (search_index, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+END;
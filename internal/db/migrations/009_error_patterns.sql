@@ -0,0 +1,5 @@
+-- Migration 009: Error Pattern Detection
+-- Purpose: Index error_occurrences for lookups by tool call (used when a session is re-synced)
+
+CREATE INDEX IF NOT EXISTS idx_error_occurrences_tool_call ON error_occurrences(tool_call_id);
+CREATE INDEX IF NOT EXISTS idx_error_patterns_last_seen ON error_patterns(last_seen);
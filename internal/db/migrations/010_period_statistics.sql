@@ -0,0 +1,37 @@
+-- Migration 010: Period Statistics Rollup
+-- Purpose: Materialize day/week/month statistics in period_statistics and recompute only the periods touched by changed sessions
+
+ALTER TABLE period_statistics ADD COLUMN total_cost_usd REAL NOT NULL DEFAULT 0;
+
+-- 再計算が必要なセッションの期間（RefreshPeriodStatisticsで処理後に削除）
+CREATE TABLE IF NOT EXISTS period_statistics_dirty (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL,
+    start_time TEXT NOT NULL,
+    end_time TEXT NOT NULL
+);
+
+-- sessionsの変更を検知するトリガー（変更前と変更後の両方の期間を再計算対象にする）
+CREATE TRIGGER IF NOT EXISTS sessions_period_ai AFTER INSERT ON sessions
+BEGIN
+    INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+    VALUES (NEW.project_id, NEW.start_time, NEW.end_time);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sessions_period_ad AFTER DELETE ON sessions
+BEGIN
+    INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+    VALUES (OLD.project_id, OLD.start_time, OLD.end_time);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sessions_period_au AFTER UPDATE ON sessions
+BEGIN
+    INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+    VALUES (OLD.project_id, OLD.start_time, OLD.end_time);
+    INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+    VALUES (NEW.project_id, NEW.start_time, NEW.end_time);
+END;
+
+-- 既存セッションを全て再計算対象にする
+INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+SELECT project_id, start_time, end_time FROM sessions;
@@ -0,0 +1,13 @@
+-- Migration 011: Subagent Sessions
+-- Purpose: Link subagent (sidechain) transcripts to their parent session and to the Task tool_use that spawned them
+
+-- parent_session_id: サブエージェントを起動したセッションのID（トップレベルのセッションはNULL）
+-- agent_id: サブエージェントのID（agent-<agentId>.jsonl）
+ALTER TABLE sessions ADD COLUMN parent_session_id TEXT;
+ALTER TABLE sessions ADD COLUMN agent_id TEXT;
+
+-- agent_id: Taskツールが起動したサブエージェントのID（toolUseResult.agentId）
+ALTER TABLE tool_calls ADD COLUMN agent_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_parent_session ON sessions(parent_session_id);
+CREATE INDEX IF NOT EXISTS idx_tool_calls_agent ON tool_calls(agent_id);
@@ -0,0 +1,20 @@
+-- Migration 012: Session File Positions
+-- Purpose: Parse only the lines appended to a growing session file instead of reparsing the whole file
+
+-- セッションファイルごとの解析済み位置
+-- byte_offset: 最後に解析した行の末尾のバイト位置
+-- last_uuid: 最後に解析したエントリのUUID（ファイルの書き換え検出用）
+CREATE TABLE IF NOT EXISTS session_files (
+    project_id INTEGER NOT NULL,
+    rel_path TEXT NOT NULL,               -- プロジェクトディレクトリからの相対パス
+    session_id TEXT NOT NULL,
+    byte_offset INTEGER NOT NULL DEFAULT 0,
+    last_uuid TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (project_id, rel_path),
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_files_session ON session_files(session_id);
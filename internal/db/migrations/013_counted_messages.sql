@@ -0,0 +1,15 @@
+-- Migration 013: Counted Messages
+-- Purpose: Count the token usage of each API response once, even when it is repeated on several lines or replayed into a resumed session's file
+
+-- トークン使用量を集計済みのAPI応答（message.id + requestId）と、その使用量を集計したセッション
+CREATE TABLE IF NOT EXISTS counted_messages (
+    message_key TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_counted_messages_session ON counted_messages(session_id);
+
+-- needs_reparse: 次回の同期でファイル全体を再解析する必要があるセッション（UpdateSessionで解除）
+ALTER TABLE sessions ADD COLUMN needs_reparse INTEGER NOT NULL DEFAULT 0;
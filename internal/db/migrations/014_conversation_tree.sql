@@ -0,0 +1,12 @@
+-- Migration 014: Conversation Tree
+-- Purpose: Keep the token usage of each log entry and the tokens spent on branches abandoned by rewinds or edited prompts
+
+-- エントリごとのトークン使用量（API応答の最初のエントリにのみ記録し、重複して集計しない）
+ALTER TABLE log_entries ADD COLUMN input_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE log_entries ADD COLUMN output_tokens INTEGER NOT NULL DEFAULT 0;
+
+-- parentUuidから再構築した会話ツリーのうち、メインパスから外れた分岐の集計
+ALTER TABLE sessions ADD COLUMN abandoned_branches INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN abandoned_entries INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN abandoned_input_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN abandoned_output_tokens INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+-- Migration 015: Context Window
+-- Purpose: Track how large the context of each API request grew and when the conversation was compacted
+
+-- エントリごとのキャッシュトークン数（input_tokens と合わせてリクエスト時のコンテキストサイズになる）
+ALTER TABLE log_entries ADD COLUMN cache_creation_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE log_entries ADD COLUMN cache_read_tokens INTEGER NOT NULL DEFAULT 0;
+
+-- コンテキストの圧縮（自動圧縮または /compact）
+-- trigger: "auto" または "manual"、pre_tokens: 圧縮前のコンテキストのトークン数
+CREATE TABLE IF NOT EXISTS session_compactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    uuid TEXT NOT NULL DEFAULT '',
+    timestamp DATETIME NOT NULL,
+    trigger TEXT NOT NULL DEFAULT '',
+    pre_tokens INTEGER NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_compactions_session ON session_compactions(session_id);
+
+-- セッション中の最大のコンテキストサイズと圧縮回数
+ALTER TABLE sessions ADD COLUMN peak_context_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN compaction_count INTEGER NOT NULL DEFAULT 0;
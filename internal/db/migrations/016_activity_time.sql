@@ -0,0 +1,10 @@
+-- Migration 016: Activity Time
+-- Purpose: Split the elapsed time of each session into AI work time and user wait time
+
+-- active_seconds: AIが応答を生成している、またはツールが実行されている時間
+-- idle_seconds: ユーザーの入力待ちなどのアイドル時間（上限を超えた処理時間を含む）
+ALTER TABLE sessions ADD COLUMN active_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN idle_seconds INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE period_statistics ADD COLUMN active_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE period_statistics ADD COLUMN idle_seconds INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,27 @@
+-- Migration 017: Tool Attempts
+-- Purpose: Detect retries of failed tool calls and measure the first-attempt success rate per tool and model
+
+-- ツール呼び出しを行ったモデル
+ALTER TABLE tool_calls ADD COLUMN model TEXT;
+
+-- 試行チェーン（ツール呼び出しと、その失敗後の再試行をまとめたもの）
+-- attempt_count: 最初の試行を含む試行回数（再試行回数は attempt_count - 1）
+-- first_attempt_success: 最初の試行で成功したか
+-- succeeded: 最後の試行が成功したか
+CREATE TABLE IF NOT EXISTS tool_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    tool_name TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    started_at DATETIME NOT NULL,
+    attempt_count INTEGER NOT NULL DEFAULT 1,
+    first_attempt_success BOOLEAN NOT NULL DEFAULT 0,
+    succeeded BOOLEAN NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tool_attempts_session ON tool_attempts(session_id);
+
+-- セッション中の再試行回数
+ALTER TABLE sessions ADD COLUMN retry_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,29 @@
+-- Migration 018: Session Tasks
+-- Purpose: Split sessions into tasks at each user prompt so that KPIs can be measured per task
+
+-- タスク（ユーザー入力から次のユーザー入力までの区間）
+-- task_index: セッション内の通し番号（1始まり）
+-- start_uuid: タスクを開始したユーザー入力のエントリ（Warmupのみのセッションでは空）
+-- prompt: タスクを開始したユーザー入力（100文字まで）
+CREATE TABLE IF NOT EXISTS session_tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    task_index INTEGER NOT NULL,
+    start_uuid TEXT NOT NULL DEFAULT '',
+    prompt TEXT NOT NULL DEFAULT '',
+    start_time DATETIME NOT NULL,
+    end_time DATETIME NOT NULL,
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    entry_count INTEGER NOT NULL DEFAULT 0,
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+    tool_call_count INTEGER NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_tasks_session ON session_tasks(session_id, task_index);
+CREATE INDEX IF NOT EXISTS idx_session_tasks_start_time ON session_tasks(start_time);
@@ -0,0 +1,29 @@
+-- Migration 019: Slash Commands
+-- Purpose: Record slash command invocations and the tokens spent in the turns that follow them
+
+-- Claude Codeが自動で挿入したユーザーメッセージ（カスタムコマンドの展開内容など）
+ALTER TABLE log_entries ADD COLUMN is_meta BOOLEAN NOT NULL DEFAULT 0;
+
+-- スラッシュコマンドの実行
+-- name: スラッシュを含むコマンド名（例: /compact）
+-- is_custom: 組み込みコマンドではない（.claude/commands などに定義されたコマンド）
+-- entry_count / *_tokens: コマンドから次のユーザー入力までのエントリとトークン数
+CREATE TABLE IF NOT EXISTS command_invocations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    entry_uuid TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    name TEXT NOT NULL,
+    args TEXT NOT NULL DEFAULT '',
+    is_custom BOOLEAN NOT NULL DEFAULT 0,
+    entry_count INTEGER NOT NULL DEFAULT 0,
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_command_invocations_session ON command_invocations(session_id);
+CREATE INDEX IF NOT EXISTS idx_command_invocations_name ON command_invocations(name);
@@ -0,0 +1,11 @@
+-- Migration 020: MCP Tools
+-- Purpose: Break down tool calls by MCP server and measure the size of tool results
+
+-- MCPツール（mcp__<server>__<tool>）のサーバー名とツール名（組み込みツールはNULL）
+ALTER TABLE tool_calls ADD COLUMN mcp_server TEXT;
+ALTER TABLE tool_calls ADD COLUMN mcp_tool TEXT;
+
+-- 切り詰める前の結果テキストのバイト数
+ALTER TABLE tool_calls ADD COLUMN result_size INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_tool_calls_mcp_server ON tool_calls(mcp_server);
@@ -0,0 +1,19 @@
+-- Migration 021: File Edits
+-- Purpose: Record the files changed by the Edit, MultiEdit and Write tools to find the most rewritten files
+
+-- 編集ツールの呼び出しごとの変更（エラーになった呼び出しは含まない）
+-- lines_added / lines_removed: old_string と new_string で異なる行数（Writeは全行を追加とみなす）
+CREATE TABLE IF NOT EXISTS file_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    file_path TEXT NOT NULL,
+    tool_name TEXT NOT NULL,
+    lines_added INTEGER NOT NULL DEFAULT 0,
+    lines_removed INTEGER NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_edits_session ON file_edits(session_id);
+CREATE INDEX IF NOT EXISTS idx_file_edits_file_path ON file_edits(file_path);
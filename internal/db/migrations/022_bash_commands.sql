@@ -0,0 +1,26 @@
+-- Migration 022: Bash Commands
+-- Purpose: Classify Bash tool calls by command family and keep their output to analyze failures
+
+-- toolUseResult に含まれるBashの出力（切り詰め済み）と中断フラグ
+ALTER TABLE tool_calls ADD COLUMN stdout TEXT;
+ALTER TABLE tool_calls ADD COLUMN stderr TEXT;
+ALTER TABLE tool_calls ADD COLUMN interrupted BOOLEAN NOT NULL DEFAULT 0;
+
+-- Bashツールの呼び出しごとのコマンドと分類
+-- program: 分類に使ったプログラム名（cd や環境変数の代入、sudo などは除く）
+-- family: git / test / build / package / lint / file / custom
+CREATE TABLE IF NOT EXISTS bash_commands (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    tool_call_id INTEGER NOT NULL,
+    command TEXT NOT NULL,
+    program TEXT NOT NULL,
+    family TEXT NOT NULL,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (tool_call_id) REFERENCES tool_calls(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bash_commands_session ON bash_commands(session_id);
+CREATE INDEX IF NOT EXISTS idx_bash_commands_tool_call ON bash_commands(tool_call_id);
+CREATE INDEX IF NOT EXISTS idx_bash_commands_family ON bash_commands(family);
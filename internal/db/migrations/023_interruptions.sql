@@ -0,0 +1,22 @@
+-- Migration 023: Interruptions
+-- Purpose: Record user interruptions, tool rejections and tool cancellations as distinct events
+
+-- ユーザーによる中断
+-- type: interrupt（応答中の中断）/ rejection（ツールの実行許可を拒否）/ cancel（ツールの実行中にESCでキャンセル）
+-- tool_use_id / tool_name: 拒否・キャンセルされたツール呼び出し（応答の中断の場合はNULL）
+-- model: 中断された応答を出力していたモデル
+CREATE TABLE IF NOT EXISTS session_interruptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    entry_uuid TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    type TEXT NOT NULL,
+    tool_use_id TEXT,
+    tool_name TEXT,
+    model TEXT,
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_interruptions_session ON session_interruptions(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_interruptions_type ON session_interruptions(type);
@@ -0,0 +1,16 @@
+-- Migration 024: CLI Versions
+-- Purpose: Record the Claude Code version of each session to compare statistics across versions
+
+-- セッションを実行したClaude Codeのバージョン（最初にversionを持つエントリの値）
+ALTER TABLE sessions ADD COLUMN cli_version TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_cli_version ON sessions(cli_version);
+
+-- 既存のセッションはログエントリから設定する
+UPDATE sessions SET cli_version = (
+    SELECT le.version
+    FROM log_entries le
+    WHERE le.session_id = sessions.id AND COALESCE(le.version, '') != ''
+    ORDER BY le.timestamp, le.id
+    LIMIT 1
+);
@@ -0,0 +1,20 @@
+-- Migration 025: Period Trigger Columns
+-- Purpose: Mark periods dirty only when a session column used by period_statistics changes
+
+-- 集計に使わない列（needs_reparse、first_user_messageなど）の更新では再計算しない
+DROP TRIGGER IF EXISTS sessions_period_au;
+
+CREATE TRIGGER IF NOT EXISTS sessions_period_au
+AFTER UPDATE OF
+    start_time, end_time,
+    total_input_tokens, total_output_tokens,
+    total_cache_creation_tokens, total_cache_read_tokens, total_cost_usd,
+    active_seconds, idle_seconds,
+    project_id, parent_session_id
+ON sessions
+BEGIN
+    INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+    VALUES (OLD.project_id, OLD.start_time, OLD.end_time);
+    INSERT INTO period_statistics_dirty (project_id, start_time, end_time)
+    VALUES (NEW.project_id, NEW.start_time, NEW.end_time);
+END;
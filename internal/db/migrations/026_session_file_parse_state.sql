@@ -0,0 +1,7 @@
+-- Migration 026: Session File Parse State
+-- Purpose: Carry the parser state across incremental parses of a session file
+
+-- last_model: 直前に応答したモデル（追記分の中断を記録するモデル）
+-- tool_interrupted: 最後のユーザーエントリでツールの拒否・キャンセルを記録したか（続く中断通知を二重に数えないため）
+ALTER TABLE session_files ADD COLUMN last_model TEXT;
+ALTER TABLE session_files ADD COLUMN tool_interrupted INTEGER NOT NULL DEFAULT 0;
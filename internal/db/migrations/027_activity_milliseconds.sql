@@ -0,0 +1,12 @@
+-- Migration 027: Activity Milliseconds
+-- Purpose: Add the activity time of appended log entries to a session without recalculating the whole timeline
+
+-- active_ms / idle_ms: active_seconds / idle_seconds の秒未満を含む値（追記分の作業時間を加算する元になる）
+ALTER TABLE sessions ADD COLUMN active_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN idle_ms INTEGER NOT NULL DEFAULT 0;
+
+-- 既存のセッションは秒単位の値から引き継ぐ
+UPDATE sessions SET active_ms = active_seconds * 1000, idle_ms = idle_seconds * 1000;
+
+-- 追記前の最新のエントリを引くためのインデックス
+CREATE INDEX IF NOT EXISTS idx_log_entries_session_timestamp ON log_entries(session_id, timestamp);
@@ -0,0 +1,5 @@
+-- Migration 028: Log Entry Parent Index
+-- Purpose: Check whether appended log entries change the conversation tree without loading the whole session
+
+-- 追記されたエントリを親とする保存済みのエントリを探すためのインデックス
+CREATE INDEX IF NOT EXISTS idx_log_entries_session_parent ON log_entries(session_id, parent_uuid);
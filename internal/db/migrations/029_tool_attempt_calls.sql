@@ -0,0 +1,10 @@
+-- Migration 029: Tool Attempt Calls
+-- Purpose: Link tool calls to their attempt chains so that an append only detects the chains that can still change
+
+-- attempt_id: 呼び出しが属する試行チェーン（チェーンを削除すると未割り当てに戻る）
+ALTER TABLE tool_calls ADD COLUMN attempt_id INTEGER REFERENCES tool_attempts(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_tool_calls_attempt ON tool_calls(attempt_id);
+
+-- settled: 最後の試行が成功し、その結果も記録済みのチェーン（後から呼び出しや結果が追記されても変わらない）
+ALTER TABLE tool_attempts ADD COLUMN settled BOOLEAN NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_tool_attempts_unsettled ON tool_attempts(session_id) WHERE NOT settled;
@@ -0,0 +1,6 @@
+-- Migration 030: File Edit Tool Calls
+-- Purpose: Link file edits to their tool calls so that an append only records the edits of the appended calls
+
+-- tool_call_id: 変更を行った編集ツールの呼び出し（既存の行は次回の再解析で設定される）
+ALTER TABLE file_edits ADD COLUMN tool_call_id INTEGER REFERENCES tool_calls(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_file_edits_tool_call ON file_edits(tool_call_id);
@@ -0,0 +1,6 @@
+-- Migration 031: Tool Call Interruptions
+-- Purpose: Tell tool calls rejected or cancelled by the user apart from tool errors
+
+-- interruption_type: rejection / cancel（ユーザーが実行を拒否・キャンセルした呼び出し。is_errorは立つがツールのエラーとしては数えない）
+-- 既存の行は次回の再解析で設定される
+ALTER TABLE tool_calls ADD COLUMN interruption_type TEXT;
@@ -0,0 +1,5 @@
+-- Migration 032: Compact Summary Entries
+-- Purpose: Keep the summary inserted after a context compaction from being counted as a user prompt
+
+-- 圧縮後の会話の先頭に挿入された要約のユーザーメッセージ（既存の行は次回の再解析で設定される）
+ALTER TABLE log_entries ADD COLUMN is_compact_summary BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,548 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// periodTypes lists the period types materialized in period_statistics
+var periodTypes = []string{"day", "week", "month"}
+
+// ModelTokenStats represents the tokens (input + output) used by a model within a period
+type ModelTokenStats struct {
+	Model  string `json:"model"`
+	Tokens int    `json:"tokens"`
+}
+
+// periodStatsKey identifies a single row of period_statistics
+// projectIDが0の場合は全プロジェクトの集計行（project_id IS NULL）を表す
+type periodStatsKey struct {
+	projectID  int64
+	periodType string
+	periodKey  string
+}
+
+// periodAggregate accumulates the statistics of a single period
+type periodAggregate struct {
+	stats  TimeSeriesStats
+	models map[string]int
+}
+
+// validatePeriod checks that period is one of the supported period types
+func validatePeriod(period string) error {
+	for _, periodType := range periodTypes {
+		if period == periodType {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid period: %s (must be day, week, or month)", period)
+}
+
+// RefreshPeriodStatistics recomputes the period_statistics rows touched by sessions changed since the last refresh
+// 変更はsessionsのトリガーでperiod_statistics_dirtyに記録される
+func (db *DB) RefreshPeriodStatistics() error {
+	db.periodStatsMu.Lock()
+	defer db.periodStatsMu.Unlock()
+
+	rows, err := db.conn.Query("SELECT id, project_id, start_time, end_time FROM period_statistics_dirty ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to query dirty periods: %w", err)
+	}
+
+	var maxDirtyID int64
+	touched := make(map[periodStatsKey]bool)
+	for rows.Next() {
+		var id, projectID int64
+		var startTimeStr, endTimeStr string
+		if err := rows.Scan(&id, &projectID, &startTimeStr, &endTimeStr); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan dirty period: %w", err)
+		}
+		maxDirtyID = id
+
+		startTime, endTime, ok := parseSessionRange(startTimeStr, endTimeStr)
+		if !ok {
+			continue
+		}
+		forEachPeriod(startTime, endTime, func(periodType, periodKey string) {
+			touched[periodStatsKey{projectID: projectID, periodType: periodType, periodKey: periodKey}] = true
+			touched[periodStatsKey{periodType: periodType, periodKey: periodKey}] = true
+		})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating dirty periods: %w", err)
+	}
+	rows.Close()
+
+	if maxDirtyID == 0 {
+		return nil
+	}
+
+	aggregates, err := db.aggregatePeriods(touched)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insertStmt, err := tx.Prepare(`
+		INSERT INTO period_statistics (
+			period_type, period_start, period_end, project_id,
+			session_count, total_input_tokens, total_output_tokens,
+			total_cache_creation_tokens, total_cache_read_tokens, total_cost_usd,
+			active_seconds, idle_seconds, model_stats_json, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare period statistics statement: %w", err)
+	}
+	defer insertStmt.Close()
+
+	for key := range touched {
+		periodStart, periodEnd := getPeriodRange(key.periodKey, key.periodType)
+		var projectID interface{}
+		if key.projectID != 0 {
+			projectID = key.projectID
+		}
+
+		_, err := tx.Exec(
+			"DELETE FROM period_statistics WHERE period_type = ? AND period_start = ? AND project_id IS ?",
+			key.periodType, periodStart.Format("2006-01-02"), projectID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete period statistics: %w", err)
+		}
+
+		// セッションがなくなった期間は行を削除したままにする
+		agg, ok := aggregates[key]
+		if !ok {
+			continue
+		}
+
+		modelStatsJSON, err := json.Marshal(sortedModelStats(agg.models))
+		if err != nil {
+			return fmt.Errorf("failed to marshal model stats: %w", err)
+		}
+
+		_, err = insertStmt.Exec(
+			key.periodType, periodStart.Format("2006-01-02"), periodEnd.Format("2006-01-02"), projectID,
+			agg.stats.SessionCount, agg.stats.TotalInputTokens, agg.stats.TotalOutputTokens,
+			agg.stats.TotalCacheCreationTokens, agg.stats.TotalCacheReadTokens, agg.stats.EstimatedCostUSD,
+			agg.stats.ActiveSeconds, agg.stats.IdleSeconds, string(modelStatsJSON),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert period statistics: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM period_statistics_dirty WHERE id <= ?", maxDirtyID); err != nil {
+		return fmt.Errorf("failed to clear dirty periods: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// aggregatePeriods computes the statistics of the touched periods from the sessions table
+// セッションは期間内の各日に展開し、同じ期間では1回だけカウントする
+func (db *DB) aggregatePeriods(touched map[periodStatsKey]bool) (map[periodStatsKey]*periodAggregate, error) {
+	// 対象期間全体を含む範囲のセッションだけを読み込む
+	var windowStart, windowEnd time.Time
+	for key := range touched {
+		periodStart, periodEnd := getPeriodRange(key.periodKey, key.periodType)
+		if windowStart.IsZero() || periodStart.Before(windowStart) {
+			windowStart = periodStart
+		}
+		if periodEnd.After(windowEnd) {
+			windowEnd = periodEnd
+		}
+	}
+	// start_timeはタイムゾーン付きで保存されるため前後1日広めに取る
+	windowArgs := []interface{}{
+		windowEnd.AddDate(0, 0, 2).Format("2006-01-02"),
+		windowStart.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	windowCondition := "s.start_time > '0001-01-02' AND s.start_time < ? AND s.end_time >= ?"
+
+	modelTokens, err := db.loadModelTokens(windowCondition, windowArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT
+			s.id, s.project_id, s.start_time, s.end_time,
+			s.total_input_tokens, s.total_output_tokens,
+			s.total_cache_creation_tokens, s.total_cache_read_tokens, s.total_cost_usd,
+			s.active_seconds, s.idle_seconds,
+			s.parent_session_id IS NOT NULL
+		FROM sessions s
+		WHERE `+windowCondition, windowArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	aggregates := make(map[periodStatsKey]*periodAggregate)
+	for rows.Next() {
+		var s SessionRow
+		var startTimeStr, endTimeStr string
+		var activeSeconds, idleSeconds int
+		var isSubagent bool
+		err := rows.Scan(
+			&s.ID, &s.ProjectID, &startTimeStr, &endTimeStr,
+			&s.TotalInputTokens, &s.TotalOutputTokens,
+			&s.TotalCacheCreationTokens, &s.TotalCacheReadTokens, &s.TotalCostUSD,
+			&activeSeconds, &idleSeconds,
+			&isSubagent,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+
+		startTime, endTime, ok := parseSessionRange(startTimeStr, endTimeStr)
+		if !ok {
+			continue
+		}
+
+		counted := make(map[periodStatsKey]bool)
+		forEachPeriod(startTime, endTime, func(periodType, periodKey string) {
+			for _, projectID := range []int64{s.ProjectID, 0} {
+				key := periodStatsKey{projectID: projectID, periodType: periodType, periodKey: periodKey}
+				if !touched[key] || counted[key] {
+					continue
+				}
+				counted[key] = true
+
+				agg, exists := aggregates[key]
+				if !exists {
+					agg = &periodAggregate{models: make(map[string]int)}
+					aggregates[key] = agg
+				}
+				// サブエージェントはトークンのみ加算し、セッション数と作業時間には含めない
+				// （サブエージェントの実行時間は親セッションのツール実行時間に含まれる）
+				if !isSubagent {
+					agg.stats.SessionCount++
+					agg.stats.ActiveSeconds += activeSeconds
+					agg.stats.IdleSeconds += idleSeconds
+				}
+				agg.stats.TotalInputTokens += s.TotalInputTokens
+				agg.stats.TotalOutputTokens += s.TotalOutputTokens
+				agg.stats.TotalCacheCreationTokens += s.TotalCacheCreationTokens
+				agg.stats.TotalCacheReadTokens += s.TotalCacheReadTokens
+				agg.stats.EstimatedCostUSD += s.TotalCostUSD
+				for model, tokens := range modelTokens[s.ID] {
+					agg.models[model] += tokens
+				}
+			}
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+// loadModelTokens loads per-model tokens (input + output) of the sessions matching the condition
+func (db *DB) loadModelTokens(condition string, args []interface{}) (map[string]map[string]int, error) {
+	rows, err := db.conn.Query(`
+		SELECT mu.session_id, mu.model, mu.input_tokens + mu.output_tokens
+		FROM model_usage mu
+		INNER JOIN sessions s ON mu.session_id = s.id
+		WHERE `+condition, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query model usage: %w", err)
+	}
+	defer rows.Close()
+
+	modelTokens := make(map[string]map[string]int)
+	for rows.Next() {
+		var sessionID, model string
+		var tokens int
+		if err := rows.Scan(&sessionID, &model, &tokens); err != nil {
+			return nil, fmt.Errorf("failed to scan model usage: %w", err)
+		}
+		if modelTokens[sessionID] == nil {
+			modelTokens[sessionID] = make(map[string]int)
+		}
+		modelTokens[sessionID][model] += tokens
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating model usage: %w", err)
+	}
+
+	return modelTokens, nil
+}
+
+// parseSessionRange parses the start and end time of a session
+// 開始時刻が不明（ゼロ値）のセッションは集計対象外
+func parseSessionRange(startTimeStr, endTimeStr string) (time.Time, time.Time, bool) {
+	startTime, err := parseDateTime(startTimeStr)
+	if err != nil || startTime.Year() <= 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	endTime, err := parseDateTime(endTimeStr)
+	if err != nil {
+		endTime = startTime
+	}
+	return startTime, endTime, true
+}
+
+// forEachPeriod calls fn for every (period type, period key) that a session covers
+func forEachPeriod(startTime, endTime time.Time, fn func(periodType, periodKey string)) {
+	for _, date := range generateDateRange(startTime, endTime) {
+		for _, periodType := range periodTypes {
+			fn(periodType, getPeriodKey(date, periodType))
+		}
+	}
+}
+
+// sortedModelStats converts model tokens to a slice ordered by tokens (descending)
+func sortedModelStats(models map[string]int) []ModelTokenStats {
+	stats := make([]ModelTokenStats, 0, len(models))
+	for model, tokens := range models {
+		stats = append(stats, ModelTokenStats{Model: model, Tokens: tokens})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tokens != stats[j].Tokens {
+			return stats[i].Tokens > stats[j].Tokens
+		}
+		return stats[i].Model < stats[j].Model
+	})
+	return stats
+}
+
+// getPeriodStatistics reads the latest periods from period_statistics (oldest first)
+// scopeはperiod_statisticsに対する絞り込み条件。複数プロジェクトの行は期間ごとに合算する
+func (db *DB) getPeriodStatistics(period string, limit int, scope string, scopeArgs ...interface{}) ([]TimeSeriesStats, error) {
+	if limit <= 0 {
+		limit = 30
+	}
+
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	// 未反映の変更があれば先に再計算する
+	if err := db.RefreshPeriodStatistics(); err != nil {
+		return nil, fmt.Errorf("failed to refresh period statistics: %w", err)
+	}
+
+	query := `
+		SELECT
+			period_start, period_end, session_count,
+			total_input_tokens, total_output_tokens,
+			total_cache_creation_tokens, total_cache_read_tokens, total_cost_usd,
+			active_seconds, idle_seconds, model_stats_json
+		FROM period_statistics
+		WHERE period_type = ? AND ` + scope + ` AND period_start IN (
+			SELECT DISTINCT period_start FROM period_statistics
+			WHERE period_type = ? AND ` + scope + `
+			ORDER BY period_start DESC
+			LIMIT ?
+		)
+		ORDER BY period_start
+	`
+	args := []interface{}{period}
+	args = append(args, scopeArgs...)
+	args = append(args, period)
+	args = append(args, scopeArgs...)
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query period statistics: %w", err)
+	}
+	defer rows.Close()
+
+	var result []TimeSeriesStats
+	var models map[string]int
+	for rows.Next() {
+		var row TimeSeriesStats
+		var periodStartStr, periodEndStr string
+		var modelStatsJSON sql.NullString
+
+		err := rows.Scan(
+			&periodStartStr, &periodEndStr, &row.SessionCount,
+			&row.TotalInputTokens, &row.TotalOutputTokens,
+			&row.TotalCacheCreationTokens, &row.TotalCacheReadTokens, &row.EstimatedCostUSD,
+			&row.ActiveSeconds, &row.IdleSeconds, &modelStatsJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan period statistics: %w", err)
+		}
+
+		row.PeriodStart, err = parseDateTime(periodStartStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse period start: %w", err)
+		}
+		row.PeriodEnd, err = parseDateTime(periodEndStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse period end: %w", err)
+		}
+
+		var rowModels []ModelTokenStats
+		if modelStatsJSON.Valid && modelStatsJSON.String != "" {
+			if err := json.Unmarshal([]byte(modelStatsJSON.String), &rowModels); err != nil {
+				return nil, fmt.Errorf("failed to parse model stats: %w", err)
+			}
+		}
+
+		// 同じ期間の行（グループ内の複数プロジェクト）を合算
+		if n := len(result); n > 0 && result[n-1].PeriodStart.Equal(row.PeriodStart) {
+			last := &result[n-1]
+			last.SessionCount += row.SessionCount
+			last.TotalInputTokens += row.TotalInputTokens
+			last.TotalOutputTokens += row.TotalOutputTokens
+			last.TotalCacheCreationTokens += row.TotalCacheCreationTokens
+			last.TotalCacheReadTokens += row.TotalCacheReadTokens
+			last.EstimatedCostUSD += row.EstimatedCostUSD
+			last.ActiveSeconds += row.ActiveSeconds
+			last.IdleSeconds += row.IdleSeconds
+		} else {
+			if n > 0 {
+				result[n-1].ModelStats = sortedModelStats(models)
+			}
+			result = append(result, row)
+			models = make(map[string]int)
+		}
+		for _, m := range rowModels {
+			models[m.Model] += m.Tokens
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating period statistics: %w", err)
+	}
+
+	if n := len(result); n > 0 {
+		result[n-1].ModelStats = sortedModelStats(models)
+	}
+
+	return result, nil
+}
+
+// getVersionPeriodStatistics computes the latest periods of the sessions recorded with the given version (oldest first)
+// period_statisticsはバージョン別に集計していないため、sessionsのcli_versionで絞り込んで直接集計する
+// scopeはsessions（別名s）に対する絞り込み条件
+func (db *DB) getVersionPeriodStatistics(period string, limit int, version string, scope string, scopeArgs ...interface{}) ([]TimeSeriesStats, error) {
+	if limit <= 0 {
+		limit = 30
+	}
+
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	condition := "s.cli_version = ? AND " + scope
+	args := append([]interface{}{version}, scopeArgs...)
+
+	modelTokens, err := db.loadModelTokens(condition, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT
+			s.id, s.start_time, s.end_time,
+			s.total_input_tokens, s.total_output_tokens,
+			s.total_cache_creation_tokens, s.total_cache_read_tokens, s.total_cost_usd,
+			s.active_seconds, s.idle_seconds,
+			s.parent_session_id IS NOT NULL
+		FROM sessions s
+		WHERE `+condition, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	aggregates := make(map[string]*periodAggregate)
+	for rows.Next() {
+		var s SessionRow
+		var startTimeStr, endTimeStr string
+		var activeSeconds, idleSeconds int
+		var isSubagent bool
+		err := rows.Scan(
+			&s.ID, &startTimeStr, &endTimeStr,
+			&s.TotalInputTokens, &s.TotalOutputTokens,
+			&s.TotalCacheCreationTokens, &s.TotalCacheReadTokens, &s.TotalCostUSD,
+			&activeSeconds, &idleSeconds,
+			&isSubagent,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+
+		startTime, endTime, ok := parseSessionRange(startTimeStr, endTimeStr)
+		if !ok {
+			continue
+		}
+
+		// period_statisticsと同じく、同じ期間では1回だけカウントする
+		counted := make(map[string]bool)
+		for _, date := range generateDateRange(startTime, endTime) {
+			periodKey := getPeriodKey(date, period)
+			if counted[periodKey] {
+				continue
+			}
+			counted[periodKey] = true
+
+			agg, exists := aggregates[periodKey]
+			if !exists {
+				agg = &periodAggregate{models: make(map[string]int)}
+				aggregates[periodKey] = agg
+			}
+			if !isSubagent {
+				agg.stats.SessionCount++
+				agg.stats.ActiveSeconds += activeSeconds
+				agg.stats.IdleSeconds += idleSeconds
+			}
+			agg.stats.TotalInputTokens += s.TotalInputTokens
+			agg.stats.TotalOutputTokens += s.TotalOutputTokens
+			agg.stats.TotalCacheCreationTokens += s.TotalCacheCreationTokens
+			agg.stats.TotalCacheReadTokens += s.TotalCacheReadTokens
+			agg.stats.EstimatedCostUSD += s.TotalCostUSD
+			for model, tokens := range modelTokens[s.ID] {
+				agg.models[model] += tokens
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	// 期間キーは同じ期間タイプ内で文字列順が時系列順になる
+	periodKeys := make([]string, 0, len(aggregates))
+	for periodKey := range aggregates {
+		periodKeys = append(periodKeys, periodKey)
+	}
+	sort.Strings(periodKeys)
+	if len(periodKeys) > limit {
+		periodKeys = periodKeys[len(periodKeys)-limit:]
+	}
+
+	result := make([]TimeSeriesStats, 0, len(periodKeys))
+	for _, periodKey := range periodKeys {
+		agg := aggregates[periodKey]
+		agg.stats.PeriodStart, agg.stats.PeriodEnd = getPeriodRange(periodKey, period)
+		agg.stats.ModelStats = sortedModelStats(agg.models)
+		result = append(result, agg.stats)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStatistics(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectAID, err := db.CreateProject("period-project-a", "/path/to/period-project-a")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	projectBID, err := db.CreateProject("period-project-b", "/path/to/period-project-b")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	groupID, err := db.CreateProjectGroup("period-group", nil)
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	for _, projectID := range []int64{projectAID, projectBID} {
+		if err := db.AddProjectToGroup(projectID, groupID); err != nil {
+			t.Fatalf("Failed to add project to group: %v", err)
+		}
+	}
+
+	// プロジェクトA: 1/20のセッションと1/20〜1/21の跨日セッション、プロジェクトB: 1/21のセッション
+	sessions := []struct {
+		suffix  string
+		project string
+		start   time.Time
+		end     time.Time
+	}{
+		{"period-1", "period-project-a", time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC), time.Date(2026, 1, 20, 11, 0, 0, 0, time.UTC)},
+		{"period-2", "period-project-a", time.Date(2026, 1, 20, 23, 0, 0, 0, time.UTC), time.Date(2026, 1, 21, 1, 0, 0, 0, time.UTC)},
+		{"period-3", "period-project-b", time.Date(2026, 1, 21, 10, 0, 0, 0, time.UTC), time.Date(2026, 1, 21, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, s := range sessions {
+		session := createTestSession(s.suffix)
+		session.StartTime = s.start
+		session.EndTime = s.end
+		if err := db.CreateSession(session, s.project, time.Now()); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	day20 := time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)
+	day21 := time.Date(2026, 1, 21, 0, 0, 0, 0, time.UTC)
+
+	t.Run("プロジェクトの日別統計を集計できる", func(t *testing.T) {
+		stats, err := db.GetTimeSeriesStats(projectAID, "day", 30, "")
+		if err != nil {
+			t.Fatalf("GetTimeSeriesStats failed: %v", err)
+		}
+		if len(stats) != 2 {
+			t.Fatalf("Expected 2 days, got %d", len(stats))
+		}
+		if !stats[0].PeriodStart.Equal(day20) || stats[0].SessionCount != 2 || stats[0].TotalInputTokens != 200 {
+			t.Errorf("Unexpected stats for 2026-01-20: %+v", stats[0])
+		}
+		if !stats[1].PeriodStart.Equal(day21) || stats[1].SessionCount != 1 {
+			t.Errorf("Unexpected stats for 2026-01-21: %+v", stats[1])
+		}
+		if len(stats[0].ModelStats) != 1 || stats[0].ModelStats[0].Model != "claude-sonnet-4-5" || stats[0].ModelStats[0].Tokens != 300 {
+			t.Errorf("Unexpected model stats: %+v", stats[0].ModelStats)
+		}
+	})
+
+	t.Run("全体とグループの統計を集計できる", func(t *testing.T) {
+		total, err := db.GetTotalTimeSeriesStats("day", 30, "")
+		if err != nil {
+			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
+		}
+		if len(total) != 2 || total[1].SessionCount != 2 {
+			t.Fatalf("Expected 2 sessions on 2026-01-21, got %+v", total)
+		}
+
+		group, err := db.GetGroupTimeSeriesStats(groupID, "week", 30, "")
+		if err != nil {
+			t.Fatalf("GetGroupTimeSeriesStats failed: %v", err)
+		}
+		if len(group) != 1 {
+			t.Fatalf("Expected 1 week, got %d", len(group))
+		}
+		if group[0].SessionCount != 3 || group[0].ModelStats[0].Tokens != 450 {
+			t.Errorf("Unexpected group week stats: %+v", group[0])
+		}
+		if !group[0].PeriodStart.Equal(time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected week starting on Monday 2026-01-19, got %v", group[0].PeriodStart)
+		}
+	})
+
+	t.Run("変更されたセッションの期間だけ再計算される", func(t *testing.T) {
+		// 変更のない期間はキャッシュの値がそのまま使われる
+		_, err := db.conn.Exec(
+			"UPDATE period_statistics SET session_count = 99 WHERE period_type = 'day' AND period_start = '2026-01-20' AND project_id IS NULL",
+		)
+		if err != nil {
+			t.Fatalf("Failed to update period statistics: %v", err)
+		}
+
+		// プロジェクトBのセッションが翌日まで続き、トークンが増えた
+		session := createTestSession("period-3")
+		session.StartTime = time.Date(2026, 1, 21, 10, 0, 0, 0, time.UTC)
+		session.EndTime = time.Date(2026, 1, 22, 1, 0, 0, 0, time.UTC)
+		session.TotalTokens.InputTokens = 500
+		if err := db.UpdateSession(session, "period-project-b", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+
+		total, err := db.GetTotalTimeSeriesStats("day", 30, "")
+		if err != nil {
+			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
+		}
+		if len(total) != 3 {
+			t.Fatalf("Expected 3 days, got %d", len(total))
+		}
+		if total[0].SessionCount != 99 {
+			t.Errorf("Expected untouched period to keep cached value, got %d", total[0].SessionCount)
+		}
+		if total[1].SessionCount != 2 || total[1].TotalInputTokens != 600 {
+			t.Errorf("Expected 2026-01-21 to be recomputed, got %+v", total[1])
+		}
+		if total[2].SessionCount != 1 {
+			t.Errorf("Expected 1 session on 2026-01-22, got %d", total[2].SessionCount)
+		}
+	})
+
+	t.Run("再計算後は未処理の変更が残らない", func(t *testing.T) {
+		var count int
+		if err := db.conn.QueryRow("SELECT COUNT(*) FROM period_statistics_dirty").Scan(&count); err != nil {
+			t.Fatalf("Failed to count dirty periods: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("Expected no dirty periods, got %d", count)
+		}
+	})
+
+	t.Run("集計に使わない列の更新では再計算対象にしない", func(t *testing.T) {
+		if _, err := db.conn.Exec("UPDATE sessions SET needs_reparse = 1, first_user_message = 'updated'"); err != nil {
+			t.Fatalf("Failed to update sessions: %v", err)
+		}
+
+		var count int
+		if err := db.conn.QueryRow("SELECT COUNT(*) FROM period_statistics_dirty").Scan(&count); err != nil {
+			t.Fatalf("Failed to count dirty periods: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("Expected no dirty periods, got %d", count)
+		}
+	})
+
+	t.Run("件数を制限すると新しい期間から取得する", func(t *testing.T) {
+		stats, err := db.GetTotalTimeSeriesStats("day", 1, "")
+		if err != nil {
+			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
+		}
+		if len(stats) != 1 || stats[0].PeriodStart.Day() != 22 {
+			t.Errorf("Expected the latest day only, got %+v", stats)
+		}
+	})
+}
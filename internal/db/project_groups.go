@@ -2,10 +2,14 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrProjectGroupNotFound is returned when a project group does not exist
+var ErrProjectGroupNotFound = errors.New("project group not found")
+
 // ProjectGroupRow represents a row in the project_groups table
 type ProjectGroupRow struct {
 	ID        int64
@@ -115,7 +119,7 @@ func (db *DB) GetProjectGroupByID(id int64) (*ProjectGroupRow, error) {
 		&group.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project group not found: id=%d", id)
+		return nil, fmt.Errorf("%w: id=%d", ErrProjectGroupNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query project group: %w", err)
@@ -38,10 +38,25 @@ type ProjectStats struct {
 	TotalOutputTokens        int       `json:"totalOutputTokens"`
 	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
 	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
+	EstimatedCostUSD         float64   `json:"estimatedCostUsd"`
 	AvgTokens                float64   `json:"avgTokens"`
 	FirstSession             time.Time `json:"firstSession"`
 	LastSession              time.Time `json:"lastSession"`
 	ErrorRate                float64   `json:"errorRate"`
+
+	// 巻き戻しや入力の編集で破棄された会話の分岐
+	AbandonedBranches     int `json:"abandonedBranches"`
+	AbandonedInputTokens  int `json:"abandonedInputTokens"`
+	AbandonedOutputTokens int `json:"abandonedOutputTokens"`
+
+	// トップレベルのセッションの最大コンテキストサイズとコンテキストの圧縮回数
+	AvgPeakContextTokens float64 `json:"avgPeakContextTokens"`
+	MaxPeakContextTokens int     `json:"maxPeakContextTokens"`
+	Compactions          int     `json:"compactions"`
+
+	// トップレベルのセッションの作業時間と待機時間
+	ActiveSeconds int `json:"activeSeconds"`
+	IdleSeconds   int `json:"idleSeconds"`
 }
 
 // BranchStats represents statistics per branch
@@ -57,46 +72,75 @@ type BranchStats struct {
 
 // TimeSeriesStats represents time-series statistics
 type TimeSeriesStats struct {
-	PeriodStart              time.Time `json:"periodStart"`
-	PeriodEnd                time.Time `json:"periodEnd"`
-	SessionCount             int       `json:"sessionCount"`
-	TotalInputTokens         int       `json:"totalInputTokens"`
-	TotalOutputTokens        int       `json:"totalOutputTokens"`
-	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
-	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
+	PeriodStart              time.Time         `json:"periodStart"`
+	PeriodEnd                time.Time         `json:"periodEnd"`
+	SessionCount             int               `json:"sessionCount"`
+	TotalInputTokens         int               `json:"totalInputTokens"`
+	TotalOutputTokens        int               `json:"totalOutputTokens"`
+	TotalCacheCreationTokens int               `json:"totalCacheCreationTokens"`
+	TotalCacheReadTokens     int               `json:"totalCacheReadTokens"`
+	EstimatedCostUSD         float64           `json:"estimatedCostUsd"`
+	ActiveSeconds            int               `json:"activeSeconds"`
+	IdleSeconds              int               `json:"idleSeconds"`
+	ModelStats               []ModelTokenStats `json:"modelStats"`
 }
 
 // GetProjectStats retrieves overall statistics for a project
-func (db *DB) GetProjectStats(projectID int64) (*ProjectStats, error) {
+// version が指定された場合はそのバージョンのセッションに絞り込む
+func (db *DB) GetProjectStats(projectID int64, version string) (*ProjectStats, error) {
 	query := `
 		SELECT
-			COUNT(*) as total_sessions,
+			COUNT(CASE WHEN parent_session_id IS NULL THEN 1 END) as total_sessions,
 			COALESCE(SUM(total_input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(total_output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(total_cache_creation_tokens), 0) as total_cache_creation_tokens,
 			COALESCE(SUM(total_cache_read_tokens), 0) as total_cache_read_tokens,
-			COALESCE(AVG(total_input_tokens + total_output_tokens), 0) as avg_tokens,
+			COALESCE(SUM(total_cost_usd), 0) as total_cost_usd,
+			COALESCE(CAST(SUM(total_input_tokens + total_output_tokens) AS REAL) / NULLIF(COUNT(CASE WHEN parent_session_id IS NULL THEN 1 END), 0), 0) as avg_tokens,
 			MIN(start_time) as first_session,
 			MAX(end_time) as last_session,
-			CAST(SUM(CASE WHEN error_count > 0 THEN 1 ELSE 0 END) AS REAL) / NULLIF(COUNT(*), 0) as error_rate
+			CAST(SUM(CASE WHEN parent_session_id IS NULL AND error_count > 0 THEN 1 ELSE 0 END) AS REAL) / NULLIF(COUNT(CASE WHEN parent_session_id IS NULL THEN 1 END), 0) as error_rate,
+			COALESCE(SUM(abandoned_branches), 0) as abandoned_branches,
+			COALESCE(SUM(abandoned_input_tokens), 0) as abandoned_input_tokens,
+			COALESCE(SUM(abandoned_output_tokens), 0) as abandoned_output_tokens,
+			COALESCE(AVG(CASE WHEN parent_session_id IS NULL THEN peak_context_tokens END), 0) as avg_peak_context_tokens,
+			COALESCE(MAX(CASE WHEN parent_session_id IS NULL THEN peak_context_tokens END), 0) as max_peak_context_tokens,
+			COALESCE(SUM(CASE WHEN parent_session_id IS NULL THEN compaction_count END), 0) as compactions,
+			COALESCE(SUM(CASE WHEN parent_session_id IS NULL THEN active_seconds END), 0) as active_seconds,
+			COALESCE(SUM(CASE WHEN parent_session_id IS NULL THEN idle_seconds END), 0) as idle_seconds
 		FROM sessions
 		WHERE project_id = ?
 	`
 
+	args := []interface{}{projectID}
+	if version != "" {
+		query += " AND cli_version = ?"
+		args = append(args, version)
+	}
+
 	var stats ProjectStats
 	var firstSessionStr, lastSessionStr sql.NullString
 	var errorRate sql.NullFloat64
 
-	err := db.conn.QueryRow(query, projectID).Scan(
+	err := db.conn.QueryRow(query, args...).Scan(
 		&stats.TotalSessions,
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheCreationTokens,
 		&stats.TotalCacheReadTokens,
+		&stats.EstimatedCostUSD,
 		&stats.AvgTokens,
 		&firstSessionStr,
 		&lastSessionStr,
 		&errorRate,
+		&stats.AbandonedBranches,
+		&stats.AbandonedInputTokens,
+		&stats.AbandonedOutputTokens,
+		&stats.AvgPeakContextTokens,
+		&stats.MaxPeakContextTokens,
+		&stats.Compactions,
+		&stats.ActiveSeconds,
+		&stats.IdleSeconds,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query project stats: %w", err)
@@ -130,7 +174,7 @@ func (db *DB) GetBranchStats(projectID int64) ([]BranchStats, error) {
 	query := `
 		SELECT
 			git_branch,
-			COUNT(*) as session_count,
+			COUNT(CASE WHEN parent_session_id IS NULL THEN 1 END) as session_count,
 			COALESCE(SUM(total_input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(total_output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(total_cache_creation_tokens), 0) as total_cache_creation_tokens,
@@ -185,152 +229,13 @@ func (db *DB) GetBranchStats(projectID int64) ([]BranchStats, error) {
 // GetTimeSeriesStats retrieves time-series statistics for a project
 // period can be "day", "week", or "month"
 // limit specifies the maximum number of periods to return (default: 30)
-func (db *DB) GetTimeSeriesStats(projectID int64, period string, limit int) ([]TimeSeriesStats, error) {
-	if limit <= 0 {
-		limit = 30
-	}
-
-	// 期間の検証
-	if period != "day" && period != "week" && period != "month" {
-		return nil, fmt.Errorf("invalid period: %s (must be day, week, or month)", period)
-	}
-
-	// セッションを取得（広めの期間で取得）
-	// limitの期間分のデータを取得するため、start_timeベースで範囲を広めに取る
-	query := `
-		SELECT
-			id, project_id, git_branch, start_time, end_time, duration_seconds,
-			total_input_tokens, total_output_tokens,
-			total_cache_creation_tokens, total_cache_read_tokens,
-			error_count, first_user_message, created_at, updated_at
-		FROM sessions
-		WHERE project_id = ? AND start_time > '0001-01-02'
-		ORDER BY start_time DESC
-	`
-
-	rows, err := db.conn.Query(query, projectID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query sessions: %w", err)
-	}
-	defer rows.Close()
-
-	var sessions []SessionRow
-	for rows.Next() {
-		var s SessionRow
-		var startTimeStr, endTimeStr, createdAtStr, updatedAtStr string
-		var firstUserMsg sql.NullString
-
-		err := rows.Scan(
-			&s.ID,
-			&s.ProjectID,
-			&s.GitBranch,
-			&startTimeStr,
-			&endTimeStr,
-			&s.DurationSeconds,
-			&s.TotalInputTokens,
-			&s.TotalOutputTokens,
-			&s.TotalCacheCreationTokens,
-			&s.TotalCacheReadTokens,
-			&s.ErrorCount,
-			&firstUserMsg,
-			&createdAtStr,
-			&updatedAtStr,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan session: %w", err)
-		}
-
-		s.StartTime, _ = time.Parse(time.RFC3339Nano, startTimeStr)
-		s.EndTime, _ = time.Parse(time.RFC3339Nano, endTimeStr)
-		s.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAtStr)
-		s.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAtStr)
-		if firstUserMsg.Valid {
-			s.FirstUserMessage = firstUserMsg.String
-		}
-
-		sessions = append(sessions, s)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating sessions: %w", err)
-	}
-
-	// セッションを日付ごとに展開して集約
-	return aggregateSessionsByPeriod(sessions, period, limit), nil
-}
-
-// aggregateSessionsByPeriod セッションを期間ごとに集約
-func aggregateSessionsByPeriod(sessions []SessionRow, period string, limit int) []TimeSeriesStats {
-	// 日付ごとにセッションを展開
-	type dailySession struct {
-		date    string
-		session SessionRow
-	}
-	var dailySessions []dailySession
-
-	for _, s := range sessions {
-		dates := generateDateRange(s.StartTime, s.EndTime)
-		for _, date := range dates {
-			dailySessions = append(dailySessions, dailySession{date: date, session: s})
-		}
+// version が指定された場合はそのバージョンのセッションだけを集計する
+func (db *DB) GetTimeSeriesStats(projectID int64, period string, limit int, version string) ([]TimeSeriesStats, error) {
+	if version != "" {
+		return db.getVersionPeriodStatistics(period, limit, version, "s.project_id = ?", projectID)
 	}
-
-	// 期間ごとにグループ化
-	periodMap := make(map[string]*TimeSeriesStats)
-	periodSessions := make(map[string]map[string]bool) // 各期間に含まれるセッションID
-
-	for _, ds := range dailySessions {
-		periodKey := getPeriodKey(ds.date, period)
-
-		if _, exists := periodMap[periodKey]; !exists {
-			periodMap[periodKey] = &TimeSeriesStats{
-				SessionCount:             0,
-				TotalInputTokens:         0,
-				TotalOutputTokens:        0,
-				TotalCacheCreationTokens: 0,
-				TotalCacheReadTokens:     0,
-			}
-			periodSessions[periodKey] = make(map[string]bool)
-		}
-
-		// セッションIDの重複チェック（同じ期間内で同じセッションは1回だけカウント）
-		if !periodSessions[periodKey][ds.session.ID] {
-			periodMap[periodKey].SessionCount++
-			periodMap[periodKey].TotalInputTokens += ds.session.TotalInputTokens
-			periodMap[periodKey].TotalOutputTokens += ds.session.TotalOutputTokens
-			periodMap[periodKey].TotalCacheCreationTokens += ds.session.TotalCacheCreationTokens
-			periodMap[periodKey].TotalCacheReadTokens += ds.session.TotalCacheReadTokens
-			periodSessions[periodKey][ds.session.ID] = true
-		}
-	}
-
-	// 期間キーをソートして時系列データに変換
-	var periodKeys []string
-	for key := range periodMap {
-		periodKeys = append(periodKeys, key)
-	}
-
-	// キーをソート（降順）
-	sortPeriodKeys(periodKeys, period)
-
-	// 結果を構築（limitまで）
-	var result []TimeSeriesStats
-	for i, key := range periodKeys {
-		if i >= limit {
-			break
-		}
-
-		stats := periodMap[key]
-		stats.PeriodStart, stats.PeriodEnd = getPeriodRange(key, period)
-		result = append(result, *stats)
-	}
-
-	// 結果を古い順に並び替え
-	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
-		result[i], result[j] = result[j], result[i]
-	}
-
-	return result
+	// 期間ごとの集計はperiod_statisticsから取得
+	return db.getPeriodStatistics(period, limit, "project_id = ?", projectID)
 }
 
 // getPeriodKey 日付から期間キーを生成
@@ -354,18 +259,6 @@ func getPeriodKey(date string, period string) string {
 	}
 }
 
-// sortPeriodKeys 期間キーをソート（降順）
-func sortPeriodKeys(keys []string, period string) {
-	// 降順ソート
-	for i := 0; i < len(keys)-1; i++ {
-		for j := i + 1; j < len(keys); j++ {
-			if keys[i] < keys[j] {
-				keys[i], keys[j] = keys[j], keys[i]
-			}
-		}
-	}
-}
-
 // getPeriodRange 期間キーから開始日と終了日を取得
 func getPeriodRange(periodKey string, period string) (time.Time, time.Time) {
 	switch period {
@@ -414,6 +307,7 @@ func (db *DB) GetProjectDailySessions(projectID int64, date string) ([]SessionRo
 			error_count, first_user_message, created_at, updated_at
 		FROM sessions
 		WHERE project_id = ?
+		  AND parent_session_id IS NULL
 		  AND DATE(start_time) <= ?
 		  AND DATE(end_time) >= ?
 		ORDER BY start_time DESC
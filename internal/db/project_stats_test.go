@@ -75,7 +75,7 @@ func TestGetProjectStats(t *testing.T) {
 	}
 
 	// プロジェクト統計を取得
-	stats, err := db.GetProjectStats(projectID)
+	stats, err := db.GetProjectStats(projectID, "")
 	if err != nil {
 		t.Fatalf("GetProjectStats failed: %v", err)
 	}
@@ -126,7 +126,7 @@ func TestGetProjectStatsNoSessions(t *testing.T) {
 	}
 
 	// プロジェクト統計を取得
-	stats, err := db.GetProjectStats(projectID)
+	stats, err := db.GetProjectStats(projectID, "")
 	if err != nil {
 		t.Fatalf("GetProjectStats failed: %v", err)
 	}
@@ -266,7 +266,7 @@ func TestGetTimeSeriesStats(t *testing.T) {
 	}
 
 	// 日別の時系列統計を取得（limit=30）
-	timeSeriesStats, err := db.GetTimeSeriesStats(projectID, "day", 30)
+	timeSeriesStats, err := db.GetTimeSeriesStats(projectID, "day", 30, "")
 	if err != nil {
 		t.Fatalf("GetTimeSeriesStats failed: %v", err)
 	}
@@ -390,7 +390,7 @@ func TestGetTimeSeriesStats_CrossMidnight(t *testing.T) {
 
 	t.Run("跨日セッションが各日に含まれる", func(t *testing.T) {
 		// 日別の時系列統計を取得
-		timeSeriesStats, err := db.GetTimeSeriesStats(projectID, "day", 30)
+		timeSeriesStats, err := db.GetTimeSeriesStats(projectID, "day", 30, "")
 		if err != nil {
 			t.Fatalf("GetTimeSeriesStats failed: %v", err)
 		}
@@ -458,7 +458,7 @@ func TestGetTimeSeriesStats_CrossMidnight(t *testing.T) {
 
 	t.Run("週別集計で跨日セッションが正しく処理される", func(t *testing.T) {
 		// 週別の時系列統計を取得
-		weekStats, err := db.GetTimeSeriesStats(projectID, "week", 10)
+		weekStats, err := db.GetTimeSeriesStats(projectID, "week", 10, "")
 		if err != nil {
 			t.Fatalf("GetTimeSeriesStats failed: %v", err)
 		}
@@ -482,7 +482,7 @@ func TestGetTimeSeriesStats_CrossMidnight(t *testing.T) {
 
 	t.Run("月別集計で跨日セッションが正しく処理される", func(t *testing.T) {
 		// 月別の時系列統計を取得
-		monthStats, err := db.GetTimeSeriesStats(projectID, "month", 10)
+		monthStats, err := db.GetTimeSeriesStats(projectID, "month", 10, "")
 		if err != nil {
 			t.Fatalf("GetTimeSeriesStats failed: %v", err)
 		}
@@ -2,10 +2,14 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrProjectNotFound is returned when a project does not exist
+var ErrProjectNotFound = errors.New("project not found")
+
 // ProjectRow represents a row in the projects table
 type ProjectRow struct {
 	ID          int64
@@ -85,7 +89,7 @@ func (db *DB) GetProjectByName(name string) (*ProjectRow, error) {
 		&project.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project not found: %s", name)
+		return nil, fmt.Errorf("%w: %s", ErrProjectNotFound, name)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query project: %w", err)
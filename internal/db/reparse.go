@@ -0,0 +1,115 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// analysisVersion is the version of the analysis stored for each session
+// ログから導出する値（集計列や派生テーブル）の解析方法を変更したら上げる。
+// 上がると既存のセッションは次回の同期でファイル全体を再解析する。
+const analysisVersion = 6
+
+// RequestReparse makes the next sync parse every session file again in full
+// 既存のセッションは再解析対象にし、DBにないファイル（新たに取り込み対象になったファイルなど）も拾えるよう最終スキャン時刻を消す
+func (db *DB) RequestReparse() error {
+	if _, err := db.conn.Exec("UPDATE sessions SET needs_reparse = 1"); err != nil {
+		return fmt.Errorf("failed to mark sessions for reparse: %w", err)
+	}
+	if _, err := db.conn.Exec("UPDATE projects SET last_scan_time = NULL"); err != nil {
+		return fmt.Errorf("failed to reset last scan times: %w", err)
+	}
+	return nil
+}
+
+// ReparseTargets identifies the session files whose sessions must be parsed again in full
+// 解析位置が記録されているセッションはファイルのパスで、記録されていないセッションはファイル名で照合する
+type ReparseTargets struct {
+	files    map[string]bool // プロジェクト名 + ファイルの相対パス
+	sessions map[string]bool // プロジェクト名 + セッションID
+	list     []ReparseFile
+}
+
+// ReparseFile is a session file that must be parsed again in full
+type ReparseFile struct {
+	ProjectName string
+	RelPath     string // プロジェクトディレクトリからの相対パス
+}
+
+// reparseKey joins a project name and a file path or session ID
+func reparseKey(projectName, name string) string {
+	return projectName + "\x00" + name
+}
+
+// Len returns the number of sessions that must be parsed again
+func (t *ReparseTargets) Len() int {
+	return len(t.files) + len(t.sessions)
+}
+
+// Contains reports whether a session file must be parsed again in full
+func (t *ReparseTargets) Contains(projectName string, info parser.SessionFileInfo) bool {
+	relPath := info.RelPath
+	if relPath == "" {
+		relPath = info.SessionID + ".jsonl"
+	}
+	return t.files[reparseKey(projectName, filepath.ToSlash(relPath))] || t.sessions[reparseKey(projectName, info.SessionID)]
+}
+
+// Files returns the session files that must be parsed again
+// 解析位置が記録されていないセッションはセッションIDのファイル名とみなす
+func (t *ReparseTargets) Files() []ReparseFile {
+	return t.list
+}
+
+// ListReparseTargets returns the session files whose sessions must be parsed again in full
+// 集計方法の変更や集計済みのAPI応答の移動で設定される（UpdateSessionで解除される）
+func (db *DB) ListReparseTargets() (*ReparseTargets, error) {
+	rows, err := db.conn.Query(`
+		SELECT s.id, p.name, sf.rel_path
+		FROM sessions s
+		INNER JOIN projects p ON s.project_id = p.id
+		LEFT JOIN session_files sf ON sf.session_id = s.id
+		WHERE s.needs_reparse = 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions needing reparse: %w", err)
+	}
+	defer rows.Close()
+
+	targets := &ReparseTargets{files: make(map[string]bool), sessions: make(map[string]bool)}
+	for rows.Next() {
+		var id, projectName string
+		var relPath sql.NullString
+		if err := rows.Scan(&id, &projectName, &relPath); err != nil {
+			return nil, fmt.Errorf("failed to scan session needing reparse: %w", err)
+		}
+		if relPath.Valid {
+			targets.files[reparseKey(projectName, filepath.ToSlash(relPath.String))] = true
+			targets.list = append(targets.list, ReparseFile{ProjectName: projectName, RelPath: relPath.String})
+		} else {
+			targets.sessions[reparseKey(projectName, id)] = true
+			targets.list = append(targets.list, ReparseFile{ProjectName: projectName, RelPath: id + ".jsonl"})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return targets, nil
+}
+
+// sessionNeedsReparse reports whether a stored session must be parsed again in full
+func (db *DB) sessionNeedsReparse(sessionID string) (bool, error) {
+	var needsReparse bool
+	err := db.conn.QueryRow("SELECT needs_reparse FROM sessions WHERE id = ?", sessionID).Scan(&needsReparse)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get reparse flag: %w", err)
+	}
+	return needsReparse, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestRequestReparse(t *testing.T) {
+	database, dbPath := setupTestDB(t)
+
+	projectID, err := database.CreateProject("reparse-project", "/path/to/reparse")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	for _, suffix := range []string{"reparse-1", "reparse-2"} {
+		if err := database.CreateSession(createTestSession(suffix), "reparse-project", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+	// test-session-reparse-1 は別名のファイルから解析された
+	err = database.SaveSessionFilePosition("reparse-project", "renamed.jsonl", "test-session-reparse-1", parser.FilePosition{Offset: 10})
+	if err != nil {
+		t.Fatalf("SaveSessionFilePosition failed: %v", err)
+	}
+	if err := database.UpdateProjectLastScanTime(projectID, time.Now()); err != nil {
+		t.Fatalf("UpdateProjectLastScanTime failed: %v", err)
+	}
+
+	t.Run("全セッションを再解析対象にする", func(t *testing.T) {
+		if err := database.RequestReparse(); err != nil {
+			t.Fatalf("RequestReparse failed: %v", err)
+		}
+
+		targets, err := database.ListReparseTargets()
+		if err != nil {
+			t.Fatalf("ListReparseTargets failed: %v", err)
+		}
+		if targets.Len() != 2 {
+			t.Errorf("Expected 2 targets, got %d", targets.Len())
+		}
+		// 解析位置が記録されたセッションはファイルのパスで照合する
+		if !targets.Contains("reparse-project", parser.SessionFileInfo{SessionID: "renamed", RelPath: "renamed.jsonl"}) {
+			t.Error("Expected renamed.jsonl to be reparsed")
+		}
+		if !targets.Contains("reparse-project", parser.SessionFileInfo{SessionID: "test-session-reparse-2", RelPath: "test-session-reparse-2.jsonl"}) {
+			t.Error("Expected test-session-reparse-2.jsonl to be reparsed")
+		}
+		if targets.Contains("other-project", parser.SessionFileInfo{SessionID: "test-session-reparse-2", RelPath: "test-session-reparse-2.jsonl"}) {
+			t.Error("Expected files of other projects not to be reparsed")
+		}
+
+		lastScanTime, err := database.GetProjectLastScanTime(projectID)
+		if err != nil {
+			t.Fatalf("GetProjectLastScanTime failed: %v", err)
+		}
+		if lastScanTime != nil {
+			t.Errorf("Expected last scan time to be reset, got %v", lastScanTime)
+		}
+	})
+
+	t.Run("同じ解析バージョンでは開き直しても再解析を要求しない", func(t *testing.T) {
+		for _, suffix := range []string{"reparse-1", "reparse-2"} {
+			if err := database.UpdateSession(createTestSession(suffix), "reparse-project", time.Now()); err != nil {
+				t.Fatalf("UpdateSession failed: %v", err)
+			}
+		}
+		database.Close()
+
+		reopened, err := NewDB(dbPath)
+		if err != nil {
+			t.Fatalf("NewDB failed: %v", err)
+		}
+		defer reopened.Close()
+
+		targets, err := reopened.ListReparseTargets()
+		if err != nil {
+			t.Fatalf("ListReparseTargets failed: %v", err)
+		}
+		if targets.Len() != 0 {
+			t.Errorf("Expected no targets, got %d", targets.Len())
+		}
+	})
+}
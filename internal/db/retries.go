@@ -0,0 +1,206 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// RetryStats represents retry and first-attempt success statistics of tool calls
+type RetryStats struct {
+	ToolName string // ツール別集計の場合のみ
+	Model    string // モデル別集計の場合のみ
+
+	Operations            int // 試行チェーンの数（再試行をまとめた操作数）
+	FirstAttemptSuccesses int
+	FirstAttemptRate      float64
+	Retries               int // 再試行の回数
+	Recovered             int // 最初の試行は失敗したが再試行で成功した操作数
+}
+
+// RetryStatsResult holds retry statistics overall, per tool and per model
+type RetryStatsResult struct {
+	Total   RetryStats
+	ByTool  []RetryStats
+	ByModel []RetryStats
+}
+
+// updateRetryStats detects the attempt chains of the tool calls of a session not yet assigned to a settled chain
+// and stores its retry count
+// 最後の試行が失敗した、または結果を受け取っていないチェーンは追記分の呼び出しや結果で変わるため、
+// 追記分の呼び出しと合わせて検出し直す
+func updateRetryStats(tx *sql.Tx, sessionID string) error {
+	// 削除したチェーンの呼び出しは未割り当てに戻る
+	if _, err := tx.Exec("DELETE FROM tool_attempts WHERE session_id = ? AND NOT settled", sessionID); err != nil {
+		return fmt.Errorf("failed to delete unsettled tool attempts: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, timestamp, tool_name, COALESCE(input_json, ''), COALESCE(model, ''),
+		       is_error, COALESCE(result_text, ''), duration_ms IS NOT NULL
+		FROM tool_calls
+		WHERE attempt_id IS NULL AND session_id = ? AND interruption_type IS NULL
+		ORDER BY timestamp, id
+	`, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to query tool calls: %w", err)
+	}
+
+	var calls []analyzer.AttemptCall
+	var callIDs []int64
+	var hasResult []bool
+	for rows.Next() {
+		var call analyzer.AttemptCall
+		var id int64
+		var result bool
+		if err := rows.Scan(&id, &call.Timestamp, &call.Name, &call.Input, &call.Model, &call.IsError, &call.Result, &result); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan tool call: %w", err)
+		}
+		calls = append(calls, call)
+		callIDs = append(callIDs, id)
+		hasResult = append(hasResult, result)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating tool calls: %w", err)
+	}
+	rows.Close()
+
+	chains := analyzer.DetectRetries(calls)
+	if len(chains) > 0 {
+		stmt, err := tx.Prepare(`
+			INSERT INTO tool_attempts (
+				session_id, tool_name, model, started_at, attempt_count, first_attempt_success, succeeded, settled
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare tool attempt statement: %w", err)
+		}
+		defer stmt.Close()
+
+		callStmt, err := tx.Prepare("UPDATE tool_calls SET attempt_id = ? WHERE id = ?")
+		if err != nil {
+			return fmt.Errorf("failed to prepare tool call attempt statement: %w", err)
+		}
+		defer callStmt.Close()
+
+		for _, chain := range chains {
+			settled := chain.Succeeded && hasResult[chain.Calls[len(chain.Calls)-1]]
+			result, err := stmt.Exec(
+				sessionID, chain.ToolName, chain.Model, chain.StartedAt,
+				chain.Attempts, chain.FirstAttemptSucceeded, chain.Succeeded, settled,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert tool attempt %s: %w", chain.ToolName, err)
+			}
+			attemptID, err := result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get tool attempt ID: %w", err)
+			}
+			for _, i := range chain.Calls {
+				if _, err := callStmt.Exec(attemptID, callIDs[i]); err != nil {
+					return fmt.Errorf("failed to link tool call to attempt: %w", err)
+				}
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE sessions SET retry_count = (
+			SELECT COALESCE(SUM(attempt_count - 1), 0) FROM tool_attempts WHERE session_id = sessions.id
+		)
+		WHERE id = ?
+	`, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update retry count: %w", err)
+	}
+
+	return nil
+}
+
+// GetRetryStats retrieves retry counts and first-attempt success rates overall, per tool and per model
+// projectID / groupID が指定された場合はそのプロジェクト・グループのセッションに、version が指定された場合はそのバージョンのセッションに絞り込む
+func (db *DB) GetRetryStats(projectID, groupID *int64, version string) (*RetryStatsResult, error) {
+	where := " WHERE 1 = 1"
+	var args []interface{}
+	if projectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if groupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *groupID)
+	}
+	if version != "" {
+		where += " AND s.cli_version = ?"
+		args = append(args, version)
+	}
+
+	result := &RetryStatsResult{}
+	total, err := db.queryRetryStats("''", where, args, func(*RetryStats, string) {})
+	if err != nil {
+		return nil, err
+	}
+	if len(total) > 0 {
+		result.Total = total[0]
+	}
+
+	result.ByTool, err = db.queryRetryStats("ta.tool_name", where, args, func(stat *RetryStats, key string) {
+		stat.ToolName = key
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.ByModel, err = db.queryRetryStats("ta.model", where, args, func(stat *RetryStats, key string) {
+		stat.Model = key
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// queryRetryStats aggregates tool_attempts grouped by groupExpr, ordered by the number of operations
+// setKey stores the value of groupExpr in each row.
+func (db *DB) queryRetryStats(groupExpr, where string, args []interface{}, setKey func(*RetryStats, string)) ([]RetryStats, error) {
+	query := `
+		SELECT ` + groupExpr + ` as group_key,
+		       COUNT(*) as operations,
+		       SUM(CASE WHEN ta.first_attempt_success THEN 1 ELSE 0 END) as first_attempt_successes,
+		       SUM(ta.attempt_count - 1) as retries,
+		       SUM(CASE WHEN NOT ta.first_attempt_success AND ta.succeeded THEN 1 ELSE 0 END) as recovered
+		FROM tool_attempts ta
+		INNER JOIN sessions s ON ta.session_id = s.id
+	` + where + `
+		GROUP BY group_key
+		ORDER BY operations DESC, group_key
+	`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool attempts: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []RetryStats{}
+	for rows.Next() {
+		var stat RetryStats
+		var key string
+		if err := rows.Scan(&key, &stat.Operations, &stat.FirstAttemptSuccesses, &stat.Retries, &stat.Recovered); err != nil {
+			return nil, fmt.Errorf("failed to scan tool attempts: %w", err)
+		}
+		setKey(&stat, key)
+		stat.FirstAttemptRate = float64(stat.FirstAttemptSuccesses) / float64(stat.Operations)
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tool attempts: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestRetryStats(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectAID, err := db.CreateProject("retry-project-a", "/path/to/retry-project-a")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	projectBID, err := db.CreateProject("retry-project-b", "/path/to/retry-project-b")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	groupID, err := db.CreateProjectGroup("retry-group", nil)
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := db.AddProjectToGroup(projectBID, groupID); err != nil {
+		t.Fatalf("Failed to add project to group: %v", err)
+	}
+
+	// プロジェクトA（Haiku）: Editが2回「文字列が見つからない」で失敗した後に成功 + Bashが一発成功
+	sessionA := createTestSession("retry-a")
+	base := sessionA.StartTime
+	notFound := "<tool_use_error>String to replace not found in file.</tool_use_error>"
+	sessionA.ToolCalls = []parser.ToolCall{
+		{ID: "t1", Timestamp: base, Name: "Edit", Model: "claude-haiku-4-5", HasResult: true, IsError: true, Result: notFound,
+			Input: map[string]interface{}{"file_path": "/src/a.go", "old_string": "func a()"}},
+		{ID: "t2", Timestamp: base.Add(time.Second), Name: "Edit", Model: "claude-haiku-4-5", HasResult: true, IsError: true, Result: notFound,
+			Input: map[string]interface{}{"file_path": "/src/a.go", "old_string": "func a() error"}},
+		{ID: "t3", Timestamp: base.Add(2 * time.Second), Name: "Edit", Model: "claude-haiku-4-5", HasResult: true,
+			Input: map[string]interface{}{"file_path": "/src/a.go", "old_string": "package a"}},
+		{ID: "t4", Timestamp: base.Add(3 * time.Second), Name: "Bash", Model: "claude-haiku-4-5", HasResult: true,
+			Input: map[string]interface{}{"command": "go test ./..."}},
+	}
+	if err := db.CreateSession(sessionA, "retry-project-a", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// プロジェクトB（Sonnet）: Editが一発成功
+	sessionB := createTestSession("retry-b")
+	sessionB.ToolCalls = []parser.ToolCall{
+		{ID: "t5", Timestamp: base, Name: "Edit", Model: "claude-sonnet-4-5", HasResult: true,
+			Input: map[string]interface{}{"file_path": "/src/b.go", "old_string": "package b"}},
+	}
+	if err := db.CreateSession(sessionB, "retry-project-b", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("セッションの再試行回数を記録する", func(t *testing.T) {
+		page, err := db.ListSessionsPage(SessionListFilter{ProjectID: &projectAID, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListSessionsPage failed: %v", err)
+		}
+		if len(page.Sessions) != 1 || page.Sessions[0].RetryCount != 2 {
+			t.Errorf("Expected 2 retries, got %+v", page.Sessions)
+		}
+	})
+
+	t.Run("ツール別・モデル別の一発成功率を返す", func(t *testing.T) {
+		stats, err := db.GetRetryStats(nil, nil, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+
+		if stats.Total.Operations != 3 || stats.Total.FirstAttemptSuccesses != 2 || stats.Total.Retries != 2 || stats.Total.Recovered != 1 {
+			t.Errorf("Unexpected total: %+v", stats.Total)
+		}
+
+		if len(stats.ByTool) != 2 || stats.ByTool[0].ToolName != "Edit" || stats.ByTool[0].FirstAttemptRate != 0.5 {
+			t.Errorf("Unexpected per-tool stats: %+v", stats.ByTool)
+		}
+
+		rates := make(map[string]float64)
+		for _, stat := range stats.ByModel {
+			rates[stat.Model] = stat.FirstAttemptRate
+		}
+		if len(rates) != 2 || rates["claude-haiku-4-5"] != 0.5 || rates["claude-sonnet-4-5"] != 1 {
+			t.Errorf("Unexpected per-model stats: %+v", stats.ByModel)
+		}
+	})
+
+	t.Run("プロジェクト・グループで絞り込める", func(t *testing.T) {
+		stats, err := db.GetRetryStats(nil, &groupID, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+		if stats.Total.Operations != 1 || len(stats.ByModel) != 1 || stats.ByModel[0].Model != "claude-sonnet-4-5" {
+			t.Errorf("Unexpected group stats: %+v", stats)
+		}
+
+		stats, err = db.GetRetryStats(&projectAID, nil, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+		if stats.Total.Operations != 2 || stats.Total.Retries != 2 {
+			t.Errorf("Unexpected project stats: %+v", stats.Total)
+		}
+	})
+
+	t.Run("再解析しても試行は重複しない", func(t *testing.T) {
+		if err := db.UpdateSession(sessionA, "retry-project-a", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+		stats, err := db.GetRetryStats(&projectAID, nil, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+		if stats.Total.Operations != 2 || stats.Total.Retries != 2 {
+			t.Errorf("Unexpected stats after update: %+v", stats.Total)
+		}
+	})
+
+	t.Run("試行がない場合は空の結果", func(t *testing.T) {
+		emptyID, err := db.CreateProject("retry-empty", "/path/to/retry-empty")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		stats, err := db.GetRetryStats(&emptyID, nil, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+		if stats.Total.Operations != 0 || len(stats.ByTool) != 0 || len(stats.ByModel) != 0 {
+			t.Errorf("Expected empty stats, got %+v", stats)
+		}
+	})
+
+	t.Run("追記分の呼び出しと結果で未完了のチェーンを続ける", func(t *testing.T) {
+		appendID, err := db.CreateProject("retry-append", "/path/to/retry-append")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+
+		// 失敗したBashと、まだ結果のないEdit
+		session := createTestSession("retry-append")
+		session.ToolCalls = []parser.ToolCall{
+			{ID: "t6", Timestamp: base, Name: "Bash", Model: "claude-haiku-4-5", HasResult: true, IsError: true, Result: "exit status 1",
+				Input: map[string]interface{}{"command": "go test ./..."}},
+			{ID: "t7", Timestamp: base.Add(time.Second), Name: "Edit", Model: "claude-haiku-4-5",
+				Input: map[string]interface{}{"file_path": "/src/c.go", "old_string": "func c()"}},
+		}
+		if err := db.CreateSession(session, "retry-append", time.Now()); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		delta := &parser.Session{
+			ModelUsage: map[string]parser.TokenSummary{},
+			ToolCalls: []parser.ToolCall{
+				{ID: "t8", Timestamp: base.Add(3 * time.Second), Name: "Edit", Model: "claude-haiku-4-5", HasResult: true,
+					Input: map[string]interface{}{"file_path": "/src/c.go", "old_string": "package c"}},
+				{ID: "t9", Timestamp: base.Add(4 * time.Second), Name: "Bash", Model: "claude-haiku-4-5", HasResult: true,
+					Input: map[string]interface{}{"command": "go test ./..."}},
+			},
+			UnmatchedToolResults: []parser.ToolResult{
+				{ToolUseID: "t7", Timestamp: base.Add(2 * time.Second), IsError: true, Result: notFound},
+			},
+		}
+		if err := db.AppendSession(session.ID, delta, "retry-append", "retry-append.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		stats, err := db.GetRetryStats(&appendID, nil, "")
+		if err != nil {
+			t.Fatalf("GetRetryStats failed: %v", err)
+		}
+		if stats.Total.Operations != 2 || stats.Total.Retries != 2 || stats.Total.Recovered != 2 || stats.Total.FirstAttemptSuccesses != 0 {
+			t.Errorf("Unexpected stats after append: %+v", stats.Total)
+		}
+
+		page, err := db.ListSessionsPage(SessionListFilter{ProjectID: &appendID, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListSessionsPage failed: %v", err)
+		}
+		if len(page.Sessions) != 1 || page.Sessions[0].RetryCount != 2 {
+			t.Errorf("Expected 2 retries, got %+v", page.Sessions)
+		}
+	})
+}
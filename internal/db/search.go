@@ -0,0 +1,450 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// minTrigramLength is the minimum term length the FTS5 trigram tokenizer can match
+const minTrigramLength = 3
+
+// snippetContextLength is the number of characters shown before and after the first match
+const snippetContextLength = 60
+
+// Search document source types
+const (
+	SearchSourceMessage    = "message"
+	SearchSourceToolInput  = "tool_input"
+	SearchSourceToolResult = "tool_result"
+)
+
+// SearchRoleTool is the role assigned to tool input/result documents
+const SearchRoleTool = "tool"
+
+// SearchFilter holds the query and filters for full-text search
+type SearchFilter struct {
+	Query     string
+	ProjectID *int64
+	GroupID   *int64
+	GitBranch string
+	Role      string    // 'user', 'assistant', 'tool'（空の場合は全て）
+	From      time.Time // ゼロ値の場合は制限なし（含む）
+	To        time.Time // ゼロ値の場合は制限なし（含まない）
+	Limit     int
+	Offset    int
+}
+
+// SearchResult represents a single search hit
+type SearchResult struct {
+	SessionID   string
+	ProjectID   int64
+	ProjectName string
+	GitBranch   string
+	EntryUUID   string
+	SourceType  string
+	Role        string
+	ToolName    string
+	Timestamp   time.Time
+	Snippet     string
+}
+
+// searchDocument is a row to be written to search_documents
+type searchDocument struct {
+	entryUUID  string
+	sourceType string
+	role       string
+	toolName   string
+	timestamp  time.Time
+	content    string
+}
+
+// buildSearchDocuments extracts searchable documents from a parsed session
+func buildSearchDocuments(session *parser.Session) []searchDocument {
+	var docs []searchDocument
+
+	for _, entry := range session.Entries {
+		if entry.Message == nil || (entry.Type != "user" && entry.Type != "assistant") {
+			continue
+		}
+		text := extractTextFromContent(entry.Message.Content)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		docs = append(docs, searchDocument{
+			entryUUID:  entry.UUID,
+			sourceType: SearchSourceMessage,
+			role:       entry.Message.Role,
+			timestamp:  entry.Timestamp,
+			content:    text,
+		})
+	}
+
+	for _, toolCall := range session.ToolCalls {
+		if input := toolInputText(toolCall.Input); input != "" {
+			docs = append(docs, searchDocument{
+				sourceType: SearchSourceToolInput,
+				role:       SearchRoleTool,
+				toolName:   toolCall.Name,
+				timestamp:  toolCall.Timestamp,
+				content:    input,
+			})
+		}
+		if strings.TrimSpace(toolCall.Result) != "" {
+			docs = append(docs, searchDocument{
+				sourceType: SearchSourceToolResult,
+				role:       SearchRoleTool,
+				toolName:   toolCall.Name,
+				timestamp:  toolCall.Timestamp.Add(toolCall.Duration),
+				content:    toolCall.Result,
+			})
+		}
+	}
+
+	return docs
+}
+
+// toolInputText flattens the string values of a tool input into searchable text
+// マップのキー順でソートして出力を安定させる
+func toolInputText(input interface{}) string {
+	var parts []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case string:
+			if val != "" {
+				parts = append(parts, val)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(val[k])
+			}
+		case []interface{}:
+			for _, item := range val {
+				walk(item)
+			}
+		}
+	}
+	walk(input)
+	return strings.Join(parts, "\n")
+}
+
+// indexSessionDocuments replaces the search documents of a session within a transaction
+func indexSessionDocuments(tx *sql.Tx, session *parser.Session) error {
+	_, err := tx.Exec("DELETE FROM search_documents WHERE session_id = ?", session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old search documents: %w", err)
+	}
+
+	return insertSearchDocuments(tx, session.ID, buildSearchDocuments(session))
+}
+
+// insertSearchDocuments adds search documents to a session within a transaction
+func insertSearchDocuments(tx *sql.Tx, sessionID string, docs []searchDocument) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO search_documents (
+			session_id, entry_uuid, source_type, role, tool_name, timestamp, content
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare search document statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, doc := range docs {
+		_, err = stmt.Exec(
+			sessionID, nullIfEmpty(doc.entryUUID), doc.sourceType, doc.role, nullIfEmpty(doc.toolName),
+			formatSortableTime(doc.timestamp), doc.content,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert search document: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// nullIfEmpty returns nil for an empty string so that it is stored as NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// RebuildSearchIndex rebuilds the search documents of every stored session
+func (db *DB) RebuildSearchIndex() error {
+	rows, err := db.conn.Query("SELECT id FROM sessions")
+	if err != nil {
+		return fmt.Errorf("failed to query sessions: %w", err)
+	}
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan session ID: %w", err)
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating sessions: %w", err)
+	}
+	rows.Close()
+
+	for _, sessionID := range sessionIDs {
+		session, err := db.GetSession(sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+		}
+
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if err := indexSessionDocuments(tx, session); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// splitSearchTerms splits a query into whitespace-separated terms
+func splitSearchTerms(query string) []string {
+	return strings.Fields(query)
+}
+
+// buildMatchExpression builds an FTS5 MATCH expression that ANDs each term as a quoted phrase
+func buildMatchExpression(terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, " AND ")
+}
+
+// escapeLike escapes LIKE wildcards using backslash as the escape character
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `%`, `\%`)
+	s = strings.ReplaceAll(s, `_`, `\_`)
+	return s
+}
+
+// Search runs a full-text search over messages and tool inputs/results
+// Returns the matching page of results (newest first) and the total number of hits.
+func (db *DB) Search(filter SearchFilter) ([]SearchResult, int, error) {
+	terms := splitSearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, 0, fmt.Errorf("search query cannot be empty")
+	}
+
+	from := `
+		FROM search_documents d
+		INNER JOIN sessions s ON d.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+	`
+	where := " WHERE 1 = 1"
+	var args []interface{}
+
+	// trigramは3文字未満の語にマッチしないため、短い語を含む場合はLIKEで検索する
+	useFTS := true
+	for _, term := range terms {
+		if len([]rune(term)) < minTrigramLength {
+			useFTS = false
+			break
+		}
+	}
+	if useFTS {
+		where += " AND d.id IN (SELECT rowid FROM search_index WHERE search_index MATCH ?)"
+		args = append(args, buildMatchExpression(terms))
+	} else {
+		for _, term := range terms {
+			where += ` AND d.content LIKE ? ESCAPE '\'`
+			args = append(args, "%"+escapeLike(term)+"%")
+		}
+	}
+
+	if filter.ProjectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.GitBranch != "" {
+		where += " AND s.git_branch = ?"
+		args = append(args, filter.GitBranch)
+	}
+	if filter.Role != "" {
+		where += " AND d.role = ?"
+		args = append(args, filter.Role)
+	}
+	if !filter.From.IsZero() {
+		where += " AND d.timestamp >= ?"
+		args = append(args, formatSortableTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where += " AND d.timestamp < ?"
+		args = append(args, formatSortableTime(filter.To))
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*)"+from+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	query := `
+		SELECT d.session_id, s.project_id, p.name, s.git_branch,
+		       d.entry_uuid, d.source_type, d.role, d.tool_name, d.timestamp, d.content
+	` + from + where + " ORDER BY d.timestamp DESC, d.id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var result SearchResult
+		var entryUUID, toolName sql.NullString
+		var timestampStr, content string
+
+		err := rows.Scan(
+			&result.SessionID, &result.ProjectID, &result.ProjectName, &result.GitBranch,
+			&entryUUID, &result.SourceType, &result.Role, &toolName, &timestampStr, &content,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+
+		result.EntryUUID = entryUUID.String
+		result.ToolName = toolName.String
+		if ts, err := time.Parse(sortableTimestampFormat, timestampStr); err == nil {
+			result.Timestamp = ts
+		}
+		result.Snippet = buildSnippet(content, terms, snippetContextLength)
+
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, total, nil
+}
+
+// SnippetHighlightStart and SnippetHighlightEnd wrap matched terms in snippets
+const (
+	SnippetHighlightStart = "<mark>"
+	SnippetHighlightEnd   = "</mark>"
+)
+
+// buildSnippet returns an HTML-escaped excerpt around the first matched term with all matches highlighted
+// 大文字小文字を区別せずにマッチさせる（ルーン単位で比較するため日本語にも対応）
+func buildSnippet(content string, terms []string, contextLength int) string {
+	runes := []rune(content)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	lowerTerms := make([][]rune, 0, len(terms))
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for i, r := range termRunes {
+			termRunes[i] = unicode.ToLower(r)
+		}
+		lowerTerms = append(lowerTerms, termRunes)
+	}
+
+	// 各位置でマッチする語の長さ（最長一致）
+	matchAt := func(pos int) int {
+		longest := 0
+		for _, term := range lowerTerms {
+			if len(term) <= longest || pos+len(term) > len(lower) {
+				continue
+			}
+			matched := true
+			for j, r := range term {
+				if lower[pos+j] != r {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				longest = len(term)
+			}
+		}
+		return longest
+	}
+
+	first := -1
+	for i := range lower {
+		if matchAt(i) > 0 {
+			first = i
+			break
+		}
+	}
+
+	start, end := 0, len(runes)
+	if first >= 0 {
+		if first > contextLength {
+			start = first - contextLength
+		}
+		if first+contextLength*2 < end {
+			end = first + contextLength*2
+		}
+	} else if end > contextLength*2 {
+		end = contextLength * 2
+	}
+
+	// ハイライト以外の部分はHTMLエスケープする
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	plainStart := start
+	for i := start; i < end; {
+		if n := matchAt(i); n > 0 {
+			if i+n > end {
+				end = i + n
+			}
+			b.WriteString(html.EscapeString(string(runes[plainStart:i])))
+			b.WriteString(SnippetHighlightStart)
+			b.WriteString(html.EscapeString(string(runes[i : i+n])))
+			b.WriteString(SnippetHighlightEnd)
+			i += n
+			plainStart = i
+			continue
+		}
+		i++
+	}
+	b.WriteString(html.EscapeString(string(runes[plainStart:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestSearch(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectID, err := db.CreateProject("search-project", "/path/to/search-project")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	if _, err := db.CreateProject("other-project", "/path/to/other-project"); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	session := createTestSession("search")
+	session.Entries[0].Message.Content = []parser.Content{
+		{Type: "text", Text: "ログインページのリダイレクトバグを修正してください"},
+	}
+	if err := db.CreateSession(session, "search-project", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	other := createTestSession("search-other")
+	other.GitBranch = "feature"
+	other.Entries[0].Message.Content = []parser.Content{
+		{Type: "text", Text: "Add a README section"},
+	}
+	if err := db.CreateSession(other, "other-project", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("メッセージ本文を検索できる", func(t *testing.T) {
+		results, total, err := db.Search(SearchFilter{Query: "リダイレクト", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 1 || len(results) != 1 {
+			t.Fatalf("Expected 1 result, got total=%d len=%d", total, len(results))
+		}
+		result := results[0]
+		if result.SessionID != session.ID || result.ProjectName != "search-project" {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		if result.Role != "user" || result.SourceType != SearchSourceMessage {
+			t.Errorf("Expected user message, got role=%s source=%s", result.Role, result.SourceType)
+		}
+		if !strings.Contains(result.Snippet, "<mark>リダイレクト</mark>") {
+			t.Errorf("Expected highlighted snippet, got %q", result.Snippet)
+		}
+	})
+
+	t.Run("3文字未満の語でも検索できる", func(t *testing.T) {
+		results, total, err := db.Search(SearchFilter{Query: "修正", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 1 || len(results) != 1 {
+			t.Fatalf("Expected 1 result, got total=%d", total)
+		}
+	})
+
+	t.Run("ツールの入力と結果を検索できる", func(t *testing.T) {
+		results, _, err := db.Search(SearchFilter{Query: "permission denied", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results (one per session), got %d", len(results))
+		}
+		if results[0].SourceType != SearchSourceToolResult || results[0].ToolName != "write_file" {
+			t.Errorf("Unexpected tool result: %+v", results[0])
+		}
+
+		results, _, err = db.Search(SearchFilter{Query: "/test/output.txt", Role: SearchRoleTool, ProjectID: &projectID, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].SourceType != SearchSourceToolInput {
+			t.Fatalf("Expected 1 tool input result, got %+v", results)
+		}
+	})
+
+	t.Run("ブランチとロールで絞り込める", func(t *testing.T) {
+		results, _, err := db.Search(SearchFilter{Query: "permission", GitBranch: "feature", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].SessionID != other.ID {
+			t.Fatalf("Expected only the feature branch session, got %+v", results)
+		}
+
+		_, total, err := db.Search(SearchFilter{Query: "リダイレクト", Role: "assistant", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 0 {
+			t.Errorf("Expected no assistant results, got %d", total)
+		}
+	})
+
+	t.Run("日付で絞り込める", func(t *testing.T) {
+		tomorrow := time.Now().AddDate(0, 0, 1)
+		_, total, err := db.Search(SearchFilter{Query: "リダイレクト", From: tomorrow, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 0 {
+			t.Errorf("Expected no results after tomorrow, got %d", total)
+		}
+	})
+
+	t.Run("ページネーションできる", func(t *testing.T) {
+		results, total, err := db.Search(SearchFilter{Query: "permission", Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 2 || len(results) != 1 {
+			t.Errorf("Expected total=2 and 1 result, got total=%d len=%d", total, len(results))
+		}
+	})
+
+	t.Run("セッション更新時にインデックスが置き換えられる", func(t *testing.T) {
+		session.Entries[0].Message.Content = []parser.Content{
+			{Type: "text", Text: "データベースのマイグレーションを追加"},
+		}
+		if err := db.UpdateSession(session, "search-project", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+
+		_, total, err := db.Search(SearchFilter{Query: "リダイレクト", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 0 {
+			t.Errorf("Expected old content to be removed, got %d results", total)
+		}
+
+		_, total, err = db.Search(SearchFilter{Query: "マイグレーション", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if total != 1 {
+			t.Errorf("Expected new content to be indexed, got %d results", total)
+		}
+	})
+}
+
+func TestBuildSnippet(t *testing.T) {
+	t.Run("マッチ箇所の前後を切り出してハイライトする", func(t *testing.T) {
+		content := strings.Repeat("a", 100) + "Target" + strings.Repeat("b", 100)
+		snippet := buildSnippet(content, []string{"target"}, 10)
+		expected := "…" + strings.Repeat("a", 10) + "<mark>Target</mark>" + strings.Repeat("b", 14) + "…"
+		if snippet != expected {
+			t.Errorf("Expected %q, got %q", expected, snippet)
+		}
+	})
+
+	t.Run("HTMLをエスケープする", func(t *testing.T) {
+		snippet := buildSnippet("<div>fix</div>", []string{"fix"}, 10)
+		if snippet != "&lt;div&gt;<mark>fix</mark>&lt;/div&gt;" {
+			t.Errorf("Unexpected snippet: %q", snippet)
+		}
+	})
+}
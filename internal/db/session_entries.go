@@ -0,0 +1,169 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// EntryListFilter holds the filters and cursor for listing the log entries of a session
+type EntryListFilter struct {
+	SessionID string
+	EntryType string // "user", "assistant" など（空の場合は全て）
+	Role      string // メッセージのロール（空の場合は全て）
+	Cursor    string // 前のページのNextCursor
+	Limit     int
+}
+
+// EntryListPage is a page of log entries in timestamp order
+type EntryListPage struct {
+	Entries    []parser.LogEntry
+	Total      int    // カーソルに関係なくフィルタに一致するエントリ数
+	NextCursor string // 次のページがない場合は空
+}
+
+// entryCursor is the position after the last entry of a page
+type entryCursor struct {
+	Timestamp string `json:"t"`
+	ID        int64  `json:"id"`
+}
+
+// encodeEntryCursor encodes a cursor as an opaque URL-safe string
+func encodeEntryCursor(c entryCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeEntryCursor decodes a cursor issued by ListSessionEntries
+func decodeEntryCursor(s string) (*entryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c entryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ListSessionEntries retrieves a page of log entries with their messages in timestamp order
+// GetSessionと異なり、必要な範囲だけを読み込むため長時間のセッションでもメモリを消費しない
+func (db *DB) ListSessionEntries(filter EntryListFilter) (*EntryListPage, error) {
+	from := `
+		FROM log_entries le
+		LEFT JOIN messages m ON le.id = m.log_entry_id
+		WHERE le.session_id = ?
+	`
+	args := []interface{}{filter.SessionID}
+
+	if filter.EntryType != "" {
+		from += " AND le.entry_type = ?"
+		args = append(args, filter.EntryType)
+	}
+	if filter.Role != "" {
+		from += " AND m.role = ?"
+		args = append(args, filter.Role)
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count log entries: %w", err)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeEntryCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		from += " AND (CAST(le.timestamp AS TEXT) > ? OR (CAST(le.timestamp AS TEXT) = ? AND le.id > ?))"
+		args = append(args, cursor.Timestamp, cursor.Timestamp, cursor.ID)
+	}
+
+	query := `
+		SELECT le.id, le.uuid, le.parent_uuid, le.entry_type, le.timestamp, CAST(le.timestamp AS TEXT),
+		       le.cwd, le.version, le.request_id,
+		       m.model, m.role, m.content_json
+	` + from + " ORDER BY CAST(le.timestamp AS TEXT), le.id LIMIT ?"
+	// 次のページの有無を判定するため1件多く取得する
+	args = append(args, filter.Limit+1)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log entries: %w", err)
+	}
+	defer rows.Close()
+
+	page := &EntryListPage{Total: total, Entries: []parser.LogEntry{}}
+	var last entryCursor
+	for rows.Next() {
+		var entry parser.LogEntry
+		var entryID int64
+		var timestampText string
+		var model, role, contentJSON sql.NullString
+
+		err = rows.Scan(
+			&entryID, &entry.UUID, &entry.ParentUUID, &entry.Type, &entry.Timestamp, &timestampText,
+			&entry.Cwd, &entry.Version, &entry.RequestID,
+			&model, &role, &contentJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log entry: %w", err)
+		}
+
+		if len(page.Entries) == filter.Limit {
+			page.NextCursor = encodeEntryCursor(last)
+			break
+		}
+
+		entry.SessionID = filter.SessionID
+
+		// メッセージがあれば復元
+		if contentJSON.Valid {
+			entry.Message = &parser.Message{
+				Model: model.String,
+				Role:  role.String,
+			}
+			if err := json.Unmarshal([]byte(contentJSON.String), &entry.Message.Content); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
+			}
+		}
+
+		page.Entries = append(page.Entries, entry)
+		last = entryCursor{Timestamp: timestampText, ID: entryID}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating log entries: %w", err)
+	}
+
+	return page, nil
+}
+
+// SessionContentCounts holds the number of entries and tool calls stored for a session
+type SessionContentCounts struct {
+	Entries   int
+	Messages  int // ユーザー・アシスタントのエントリ数（セッション詳細のmessagesの件数）
+	ToolCalls int
+}
+
+// GetSessionContentCounts returns the number of log entries, messages and tool calls of a session
+func (db *DB) GetSessionContentCounts(sessionID string) (*SessionContentCounts, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM log_entries WHERE session_id = ?),
+			(SELECT COUNT(*) FROM log_entries WHERE session_id = ? AND entry_type IN ('user', 'assistant')),
+			(SELECT COUNT(*) FROM tool_calls WHERE session_id = ?)
+	`
+	var counts SessionContentCounts
+	err := db.conn.QueryRow(query, sessionID, sessionID, sessionID).Scan(
+		&counts.Entries, &counts.Messages, &counts.ToolCalls,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count session contents: %w", err)
+	}
+	return &counts, nil
+}
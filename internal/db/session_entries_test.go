@@ -0,0 +1,105 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestListSessionEntries(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectName := "entries-test-project"
+	if _, err := db.CreateProject(projectName, "/path/to/entries-test-project"); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	// ユーザーとアシスタントが交互に発言する7エントリ（同時刻のエントリを含む）
+	session := createTestSession("entries")
+	start := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	session.Entries = nil
+	for i := 0; i < 7; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		session.Entries = append(session.Entries, parser.LogEntry{
+			Type:      role,
+			Timestamp: start.Add(time.Duration(i/2) * time.Second),
+			SessionID: session.ID,
+			UUID:      fmt.Sprintf("entries-uuid-%d", i),
+			Message: &parser.Message{
+				Role:    role,
+				Content: []parser.Content{{Type: "text", Text: fmt.Sprintf("message %d", i)}},
+			},
+		})
+	}
+	if err := db.CreateSession(session, projectName, time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("カーソルで全エントリを時刻順に取得できる", func(t *testing.T) {
+		var uuids []string
+		cursor := ""
+		for page := 0; page < 10; page++ {
+			result, err := db.ListSessionEntries(EntryListFilter{SessionID: session.ID, Cursor: cursor, Limit: 3})
+			if err != nil {
+				t.Fatalf("ListSessionEntries failed: %v", err)
+			}
+			if result.Total != 7 {
+				t.Errorf("Expected total 7, got %d", result.Total)
+			}
+			for _, entry := range result.Entries {
+				uuids = append(uuids, entry.UUID)
+			}
+			cursor = result.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+
+		if len(uuids) != 7 {
+			t.Fatalf("Expected 7 entries, got %v", uuids)
+		}
+		for i, uuid := range uuids {
+			if uuid != fmt.Sprintf("entries-uuid-%d", i) {
+				t.Errorf("Unexpected order: %v", uuids)
+				break
+			}
+		}
+	})
+
+	t.Run("ロールで絞り込める", func(t *testing.T) {
+		result, err := db.ListSessionEntries(EntryListFilter{SessionID: session.ID, Role: "assistant", Limit: 10})
+		if err != nil {
+			t.Fatalf("ListSessionEntries failed: %v", err)
+		}
+		if result.Total != 3 || len(result.Entries) != 3 {
+			t.Fatalf("Expected 3 assistant entries, got %d (total %d)", len(result.Entries), result.Total)
+		}
+		if result.Entries[0].Message == nil || result.Entries[0].Message.Content[0].Text != "message 1" {
+			t.Errorf("Unexpected first entry: %+v", result.Entries[0])
+		}
+	})
+
+	t.Run("不正なカーソルはエラーになる", func(t *testing.T) {
+		_, err := db.ListSessionEntries(EntryListFilter{SessionID: session.ID, Cursor: "broken", Limit: 10})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+	})
+
+	t.Run("エントリ数とツール呼び出し数を取得できる", func(t *testing.T) {
+		counts, err := db.GetSessionContentCounts(session.ID)
+		if err != nil {
+			t.Fatalf("GetSessionContentCounts failed: %v", err)
+		}
+		if counts.Entries != 7 || counts.Messages != 7 || counts.ToolCalls != 2 {
+			t.Errorf("Unexpected counts: %+v", counts)
+		}
+	})
+}
@@ -0,0 +1,405 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// SessionFileRow represents the parse position of a session file
+type SessionFileRow struct {
+	SessionID string
+	Position  parser.FilePosition
+}
+
+// upsertSessionFileQuery saves the parse position of a session file
+const upsertSessionFileQuery = `
+	INSERT INTO session_files (
+		project_id, rel_path, session_id, byte_offset, last_uuid, last_model, tool_interrupted, updated_at
+	) VALUES ((SELECT id FROM projects WHERE name = ?), ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT(project_id, rel_path) DO UPDATE SET
+		session_id = excluded.session_id,
+		byte_offset = excluded.byte_offset,
+		last_uuid = excluded.last_uuid,
+		last_model = excluded.last_model,
+		tool_interrupted = excluded.tool_interrupted,
+		updated_at = CURRENT_TIMESTAMP
+`
+
+// sessionFilePositionArgs returns the arguments of upsertSessionFileQuery
+func sessionFilePositionArgs(projectName, relPath, sessionID string, pos parser.FilePosition) []interface{} {
+	return []interface{}{
+		projectName, relPath, sessionID, pos.Offset, nullIfEmpty(pos.LastUUID),
+		nullIfEmpty(pos.LastModel), pos.ToolInterrupted,
+	}
+}
+
+// GetSessionFilePosition retrieves the parse position of a session file
+// 解析位置が記録されていない場合は nil を返す
+func (db *DB) GetSessionFilePosition(projectName, relPath string) (*SessionFileRow, error) {
+	query := `
+		SELECT sf.session_id, sf.byte_offset, sf.last_uuid, sf.last_model, sf.tool_interrupted
+		FROM session_files sf
+		JOIN projects p ON sf.project_id = p.id
+		WHERE p.name = ? AND sf.rel_path = ?
+	`
+	var row SessionFileRow
+	var lastUUID, lastModel sql.NullString
+	err := db.conn.QueryRow(query, projectName, relPath).Scan(
+		&row.SessionID, &row.Position.Offset, &lastUUID, &lastModel, &row.Position.ToolInterrupted,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session file position: %w", err)
+	}
+	row.Position.LastUUID = lastUUID.String
+	row.Position.LastModel = lastModel.String
+
+	return &row, nil
+}
+
+// SaveSessionFilePosition records the parse position of a session file
+func (db *DB) SaveSessionFilePosition(projectName, relPath, sessionID string, pos parser.FilePosition) error {
+	_, err := db.conn.Exec(upsertSessionFileQuery, sessionFilePositionArgs(projectName, relPath, sessionID, pos)...)
+	if err != nil {
+		return fmt.Errorf("failed to save session file position: %w", err)
+	}
+	return nil
+}
+
+// AppendSession adds the lines parsed from the tail of a session file to a stored session
+// delta is the result of parser.ParseFileFrom; its ID is ignored in favour of sessionID.
+// The aggregate columns are incremented and the file position is saved in the same transaction.
+func (db *DB) AppendSession(sessionID string, delta *parser.Session, projectName, relPath string, fileModTime time.Time) error {
+	// トランザクション開始
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // エラー時は自動ロールバック
+
+	// 既存セッションの開始時刻・終了時刻を取得
+	var startTime, endTime time.Time
+	var firstUserMessage sql.NullString
+	err = tx.QueryRow(
+		"SELECT start_time, end_time, first_user_message FROM sessions WHERE id = ?", sessionID,
+	).Scan(&startTime, &endTime, &firstUserMessage)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// 解析済みの行や別のセッションで集計済みのAPI応答のトークンを除外
+	delta, countedKeys, err := withoutCountedUsage(tx, delta, sessionID, startTime)
+	if err != nil {
+		return err
+	}
+	if err = recordCountedMessages(tx, sessionID, countedKeys); err != nil {
+		return err
+	}
+
+	if !delta.EndTime.IsZero() {
+		endTime = delta.EndTime
+	}
+
+	// 先頭のユーザーメッセージはWarmupに続く2つ目のユーザーメッセージで決まる場合があるため、
+	// ユーザーメッセージが2つ揃うまでは保存済みの先頭のメッセージと追記分から計算し直す
+	leading, userCount, err := leadingMessages(tx, sessionID)
+	if err != nil {
+		return err
+	}
+	if userCount < 2 {
+		firstUserMessage.String = calculateFirstUserMessage(&parser.Session{Entries: append(leading, delta.Entries...)})
+	}
+
+	// 推定コストを計算（セッション開始時刻の料金を適用）
+	modelCosts := make(map[string]float64, len(delta.ModelUsage))
+	totalCost := 0.0
+	for model, tokens := range delta.ModelUsage {
+		cost := db.EstimateCost(model, startTime, tokens)
+		modelCosts[model] = cost
+		totalCost += cost
+	}
+
+	// 集計列を加算して更新
+	durationSeconds := int(endTime.Sub(startTime).Seconds())
+	sessionQuery := `
+		UPDATE sessions SET
+			end_time = ?,
+			duration_seconds = ?,
+			total_input_tokens = total_input_tokens + ?,
+			total_output_tokens = total_output_tokens + ?,
+			total_cache_creation_tokens = total_cache_creation_tokens + ?,
+			total_cache_read_tokens = total_cache_read_tokens + ?,
+			total_cost_usd = total_cost_usd + ?,
+			error_count = error_count + ?,
+			first_user_message = ?,
+			file_mod_time = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	_, err = tx.Exec(sessionQuery,
+		endTime.Format(time.RFC3339Nano),
+		durationSeconds,
+		delta.TotalTokens.InputTokens,
+		delta.TotalTokens.OutputTokens,
+		delta.TotalTokens.CacheCreationInputTokens,
+		delta.TotalTokens.CacheReadInputTokens,
+		totalCost,
+		delta.ErrorCount,
+		firstUserMessage.String,
+		fileModTime.Format(time.RFC3339),
+		sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	// モデル使用量を加算
+	modelUsageQuery := `
+		INSERT INTO model_usage (
+			session_id, model, input_tokens, output_tokens,
+			cache_creation_tokens, cache_read_tokens,
+			cache_creation_5m_tokens, cache_creation_1h_tokens, cost_usd
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id, model) DO UPDATE SET
+			input_tokens = input_tokens + excluded.input_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			cache_creation_tokens = cache_creation_tokens + excluded.cache_creation_tokens,
+			cache_read_tokens = cache_read_tokens + excluded.cache_read_tokens,
+			cache_creation_5m_tokens = cache_creation_5m_tokens + excluded.cache_creation_5m_tokens,
+			cache_creation_1h_tokens = cache_creation_1h_tokens + excluded.cache_creation_1h_tokens,
+			cost_usd = cost_usd + excluded.cost_usd
+	`
+	modelStmt, err := tx.Prepare(modelUsageQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare model usage statement: %w", err)
+	}
+	defer modelStmt.Close()
+
+	for model, tokens := range delta.ModelUsage {
+		_, err = modelStmt.Exec(
+			sessionID, model,
+			tokens.InputTokens, tokens.OutputTokens,
+			tokens.CacheCreationInputTokens, tokens.CacheReadInputTokens,
+			tokens.CacheCreation5mInputTokens, tokens.CacheCreation1hInputTokens, modelCosts[model],
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upsert model usage for %s: %w", model, err)
+		}
+	}
+
+	// 追記前の最後のログエントリ（派生データは追記分だけを処理する）
+	lastEntryID, err := lastRowID(tx, "log_entries", sessionID)
+	if err != nil {
+		return err
+	}
+
+	// ログエントリ・メッセージ挿入
+	if err = insertLogEntries(tx, sessionID, delta.Entries, countedKeys); err != nil {
+		return err
+	}
+
+	// コンテキストの圧縮を記録
+	if err = insertCompactions(tx, sessionID, delta.Compactions); err != nil {
+		return err
+	}
+
+	// 追記前の最後のツール呼び出し
+	lastToolCallID, err := lastRowID(tx, "tool_calls", sessionID)
+	if err != nil {
+		return err
+	}
+
+	// ツール呼び出し挿入
+	failedCalls, err := insertToolCalls(tx, sessionID, delta.ToolCalls)
+	if err != nil {
+		return err
+	}
+
+	// 保存済みのツール呼び出しに追記分のtool_resultを反映
+	resultFailedCalls, resultDocs, err := applyToolResults(tx, sessionID, delta.UnmatchedToolResults)
+	if err != nil {
+		return err
+	}
+	failedCalls = append(failedCalls, resultFailedCalls...)
+
+	// エラーパターンを更新
+	if err = recordErrorPatterns(tx, sessionID, failedCalls, nil); err != nil {
+		return err
+	}
+
+	// ユーザーによる中断を記録（ツール名は保存済みのツール呼び出しから補う）
+	if err = insertInterruptions(tx, sessionID, delta.Interruptions); err != nil {
+		return err
+	}
+
+	// 追記分から派生データを集計
+	if err = db.analyzeSession(tx, sessionID, lastEntryID, lastToolCallID, resultFailedCalls); err != nil {
+		return err
+	}
+
+	// 検索インデックスに追記分を登録
+	docs := append(buildSearchDocuments(delta), resultDocs...)
+	if err = insertSearchDocuments(tx, sessionID, docs); err != nil {
+		return err
+	}
+
+	// 解析位置を保存
+	_, err = tx.Exec(upsertSessionFileQuery, sessionFilePositionArgs(projectName, relPath, sessionID, delta.Position)...)
+	if err != nil {
+		return fmt.Errorf("failed to save session file position: %w", err)
+	}
+
+	// コミット
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// leadingMessages returns the first two user messages and the first assistant message stored for a session
+// calculateFirstUserMessage が参照するのはこれらのメッセージだけなので、本文のテキストのみを読み込む
+func leadingMessages(tx *sql.Tx, sessionID string) ([]parser.LogEntry, int, error) {
+	var entries []parser.LogEntry
+	userCount := 0
+	for _, entryType := range []string{"user", "assistant"} {
+		limit := 1
+		if entryType == "user" {
+			limit = 2
+		}
+
+		rows, err := tx.Query(`
+			SELECT COALESCE(m.content_text, '')
+			FROM log_entries le
+			INNER JOIN messages m ON le.id = m.log_entry_id
+			WHERE le.session_id = ? AND le.entry_type = ?
+			ORDER BY le.id
+			LIMIT ?
+		`, sessionID, entryType, limit)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to query leading messages: %w", err)
+		}
+		for rows.Next() {
+			var text string
+			if err := rows.Scan(&text); err != nil {
+				rows.Close()
+				return nil, 0, fmt.Errorf("failed to scan leading message: %w", err)
+			}
+			entries = append(entries, parser.LogEntry{
+				Type:    entryType,
+				Message: &parser.Message{Role: entryType, Content: []parser.Content{{Type: "text", Text: text}}},
+			})
+			if entryType == "user" {
+				userCount++
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("error iterating leading messages: %w", err)
+		}
+		rows.Close()
+	}
+	return entries, userCount, nil
+}
+
+// lastRowID returns the largest ID of the rows of a session in a table, or 0 if it has none
+func lastRowID(tx *sql.Tx, table, sessionID string) (int64, error) {
+	var id int64
+	err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM "+table+" WHERE session_id = ?", sessionID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last row of %s: %w", table, err)
+	}
+	return id, nil
+}
+
+// applyToolResults pairs tool_results with stored tool calls that have not received a result yet
+// Returns the calls that failed (for error patterns) and the search documents of the results.
+func applyToolResults(tx *sql.Tx, sessionID string, results []parser.ToolResult) ([]failedToolCall, []searchDocument, error) {
+	if len(results) == 0 {
+		return nil, nil, nil
+	}
+
+	updateStmt, err := tx.Prepare(`
+		UPDATE tool_calls SET is_error = ?, result_text = ?, result_size = ?, duration_ms = ?, agent_id = ?,
+			stdout = ?, stderr = ?, interrupted = ?, interruption_type = ?
+		WHERE id = ?
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare tool call update statement: %w", err)
+	}
+	defer updateStmt.Close()
+
+	var failedCalls []failedToolCall
+	var docs []searchDocument
+
+	for _, result := range results {
+		if result.ToolUseID == "" {
+			continue
+		}
+
+		var toolCallID int64
+		var toolCall parser.ToolCall
+		err := tx.QueryRow(`
+			SELECT id, timestamp, tool_name
+			FROM tool_calls
+			WHERE session_id = ? AND tool_use_id = ? AND duration_ms IS NULL
+			ORDER BY id
+			LIMIT 1
+		`, sessionID, result.ToolUseID).Scan(&toolCallID, &toolCall.Timestamp, &toolCall.Name)
+		if err == sql.ErrNoRows {
+			// 対応するtool_useがない結果は無視する（ParseFileと同じ扱い）
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find tool call %s: %w", result.ToolUseID, err)
+		}
+
+		toolCall.ID = result.ToolUseID
+		toolCall.HasResult = true
+		toolCall.IsError = result.IsError
+		toolCall.Result = result.Result
+		toolCall.ResultSize = result.ResultSize
+		toolCall.AgentID = result.AgentID
+		toolCall.Stdout = result.Stdout
+		toolCall.Stderr = result.Stderr
+		toolCall.Interrupted = result.Interrupted
+		toolCall.InterruptionType = result.InterruptionType
+		if duration := result.Timestamp.Sub(toolCall.Timestamp); duration > 0 {
+			toolCall.Duration = duration
+		}
+
+		_, err = updateStmt.Exec(
+			toolCall.IsError, toolCall.Result, toolCall.ResultSize, toolCallDurationMs(toolCall), nullIfEmpty(toolCall.AgentID),
+			nullIfEmpty(toolCall.Stdout), nullIfEmpty(toolCall.Stderr), toolCall.Interrupted, nullIfEmpty(toolCall.InterruptionType),
+			toolCallID,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update tool call %s: %w", result.ToolUseID, err)
+		}
+
+		// エラーパターン解析用に失敗したツール呼び出しを記録
+		if toolCall.IsError {
+			failedCalls = append(failedCalls, failedToolCall{id: toolCallID, toolCall: toolCall})
+		}
+		if strings.TrimSpace(toolCall.Result) != "" {
+			docs = append(docs, searchDocument{
+				sourceType: SearchSourceToolResult,
+				role:       SearchRoleTool,
+				toolName:   toolCall.Name,
+				timestamp:  result.Timestamp,
+				content:    toolCall.Result,
+			})
+		}
+	}
+
+	return failedCalls, docs, nil
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestSyncIncremental_AppendsTail(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	claudeDir := filepath.Join(t.TempDir(), ".claude", "projects")
+	projectDir := filepath.Join(claudeDir, "tail-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	sessionPath := filepath.Join(projectDir, "tail-session.jsonl")
+	p := parser.NewParser(claudeDir)
+
+	firstLines := `{"type":"user","timestamp":"2025-01-01T10:00:00Z","sessionId":"tail-session","uuid":"uuid-1","cwd":"/path/to/tail","message":{"role":"user","content":[{"type":"text","text":"List files"}]}}
+{"type":"assistant","timestamp":"2025-01-01T10:00:02Z","sessionId":"tail-session","uuid":"uuid-2","parentUuid":"uuid-1","cwd":"/path/to/tail","message":{"model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"tool_use","id":"toolu_tail","name":"Bash","input":{"command":"ls missing"}}],"usage":{"input_tokens":100,"output_tokens":20}}}
+`
+	appendedLines := `{"type":"user","timestamp":"2025-01-01T10:00:05Z","sessionId":"tail-session","uuid":"uuid-3","parentUuid":"uuid-2","cwd":"/path/to/tail","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_tail","content":"ls: missing: No such file or directory","is_error":true}]}}
+{"type":"assistant","timestamp":"2025-01-01T10:00:07Z","sessionId":"tail-session","uuid":"uuid-4","parentUuid":"uuid-3","cwd":"/path/to/tail","message":{"model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"text","text":"The directory does not exist"}],"usage":{"input_tokens":200,"output_tokens":30}}}
+`
+
+	// writeSession writes the session file with a modification time after the last scan
+	writeSession := func(t *testing.T, content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(sessionPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write session file: %v", err)
+		}
+		if err := os.Chtimes(sessionPath, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	writeSession(t, firstLines, time.Now())
+	initial, err := SyncAll(database, p)
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if len(initial.CreatedProjects) != 1 || len(initial.CreatedSessions) != 1 {
+		t.Fatalf("Expected 1 created project and session, got %+v / %+v", initial.CreatedProjects, initial.CreatedSessions)
+	}
+
+	t.Run("初回同期で解析位置が記録される", func(t *testing.T) {
+		state, err := database.GetSessionFilePosition("tail-project", "tail-session.jsonl")
+		if err != nil {
+			t.Fatalf("GetSessionFilePosition failed: %v", err)
+		}
+		if state == nil {
+			t.Fatal("Expected a recorded position")
+		}
+		if state.SessionID != "tail-session" || state.Position.Offset != int64(len(firstLines)) || state.Position.LastUUID != "uuid-2" {
+			t.Errorf("Unexpected position: %+v", state)
+		}
+		// 追記分の解析に引き継ぐ解析状態も記録される
+		if state.Position.LastModel != "claude-sonnet-4-5" || state.Position.ToolInterrupted {
+			t.Errorf("Unexpected parse state: %+v", state.Position)
+		}
+	})
+
+	t.Run("追記された行だけが追加され集計値が加算される", func(t *testing.T) {
+		writeSession(t, firstLines+appendedLines, time.Now().Add(5*time.Second))
+		result, err := SyncIncremental(database, p)
+		if err != nil {
+			t.Fatalf("SyncIncremental failed: %v", err)
+		}
+		if result.SessionsSynced != 1 {
+			t.Errorf("Expected 1 session synced, got %d", result.SessionsSynced)
+		}
+		if len(result.UpdatedSessions) != 1 || result.UpdatedSessions[0].SessionID != "tail-session" {
+			t.Errorf("Expected tail-session to be reported as updated, got %+v", result.UpdatedSessions)
+		}
+		if len(result.CreatedSessions) != 0 {
+			t.Errorf("Expected no created sessions, got %+v", result.CreatedSessions)
+		}
+
+		session, err := database.GetSession("tail-session")
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if session.TotalTokens.InputTokens != 300 || session.TotalTokens.OutputTokens != 50 {
+			t.Errorf("Expected 300 / 50 tokens, got %d / %d", session.TotalTokens.InputTokens, session.TotalTokens.OutputTokens)
+		}
+		if session.ErrorCount != 1 {
+			t.Errorf("Expected error count 1, got %d", session.ErrorCount)
+		}
+		if !session.EndTime.Equal(time.Date(2025, 1, 1, 10, 0, 7, 0, time.UTC)) {
+			t.Errorf("Unexpected end time: %v", session.EndTime)
+		}
+
+		var entryCount int
+		if err := database.conn.QueryRow("SELECT COUNT(*) FROM log_entries WHERE session_id = ?", "tail-session").Scan(&entryCount); err != nil {
+			t.Fatalf("Failed to count log entries: %v", err)
+		}
+		if entryCount != 4 {
+			t.Errorf("Expected 4 log entries, got %d", entryCount)
+		}
+
+		var modelInputTokens int
+		err = database.conn.QueryRow(
+			"SELECT input_tokens FROM model_usage WHERE session_id = ? AND model = ?", "tail-session", "claude-sonnet-4-5",
+		).Scan(&modelInputTokens)
+		if err != nil {
+			t.Fatalf("Failed to get model usage: %v", err)
+		}
+		if modelInputTokens != 300 {
+			t.Errorf("Expected 300 model input tokens, got %d", modelInputTokens)
+		}
+	})
+
+	t.Run("保存済みのツール呼び出しに追記分の結果が反映される", func(t *testing.T) {
+		session, err := database.GetSession("tail-session")
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if len(session.ToolCalls) != 1 {
+			t.Fatalf("Expected 1 tool call, got %d", len(session.ToolCalls))
+		}
+		toolCall := session.ToolCalls[0]
+		if !toolCall.HasResult || !toolCall.IsError || toolCall.Duration != 3*time.Second {
+			t.Errorf("Tool result was not applied: %+v", toolCall)
+		}
+
+		patterns, err := database.ListErrorPatterns(ErrorPatternFilter{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListErrorPatterns failed: %v", err)
+		}
+		if len(patterns) != 1 || patterns[0].OccurrenceCount != 1 {
+			t.Errorf("Expected 1 error pattern with 1 occurrence, got %+v", patterns)
+		}
+
+		results, _, err := database.Search(SearchFilter{Query: "No such file", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected the appended tool result to be searchable, got %d results", len(results))
+		}
+	})
+
+	t.Run("書き換えられたファイルは全体を再解析する", func(t *testing.T) {
+		writeSession(t, firstLines, time.Now().Add(10*time.Second))
+		if _, err := SyncIncremental(database, p); err != nil {
+			t.Fatalf("SyncIncremental failed: %v", err)
+		}
+
+		session, err := database.GetSession("tail-session")
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if session.TotalTokens.InputTokens != 100 || session.ErrorCount != 0 {
+			t.Errorf("Expected the session to be reparsed, got %d input tokens / %d errors",
+				session.TotalTokens.InputTokens, session.ErrorCount)
+		}
+
+		state, err := database.GetSessionFilePosition("tail-project", "tail-session.jsonl")
+		if err != nil {
+			t.Fatalf("GetSessionFilePosition failed: %v", err)
+		}
+		if state == nil || state.Position.Offset != int64(len(firstLines)) {
+			t.Errorf("Unexpected position after reparse: %+v", state)
+		}
+	})
+}
+
+func TestSyncSessionFiles_WarmupFirstUserMessage(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	claudeDir := filepath.Join(t.TempDir(), ".claude", "projects")
+	projectDir := filepath.Join(claudeDir, "warmup-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	p := parser.NewParser(claudeDir)
+
+	lines := []string{
+		userLine("warmup-session", "w-1", "2025-01-01T10:00:00Z", "Warmup"),
+		`{"type":"assistant","timestamp":"2025-01-01T10:00:02Z","sessionId":"warmup-session","uuid":"w-2","parentUuid":"w-1","cwd":"/path/to/stream","message":{"model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"text","text":"I understand."}],"usage":{"input_tokens":10,"output_tokens":5}}}` + "\n",
+		userLine("warmup-session", "w-3", "2025-01-01T10:01:00Z", "Fix the bug"),
+	}
+
+	// 1行ずつ追記して同期すると、Warmupの後のメッセージで先頭のユーザーメッセージが置き換わる
+	expected := []string{"Warmup", "I understand.", "Fix the bug"}
+	content := ""
+	for i, line := range lines {
+		content += line
+		if err := os.WriteFile(filepath.Join(projectDir, "warmup-session.jsonl"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write session file: %v", err)
+		}
+		if _, err := SyncSessionFiles(database, p, "warmup-project", []string{"warmup-session.jsonl"}); err != nil {
+			t.Fatalf("SyncSessionFiles failed: %v", err)
+		}
+
+		var firstUserMessage string
+		err := database.conn.QueryRow("SELECT first_user_message FROM sessions WHERE id = ?", "warmup-session").Scan(&firstUserMessage)
+		if err != nil {
+			t.Fatalf("Failed to get first user message: %v", err)
+		}
+		if firstUserMessage != expected[i] {
+			t.Errorf("After %d lines: expected %q, got %q", i+1, expected[i], firstUserMessage)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Session list sort keys
+const (
+	SessionSortStartTime = "startTime"
+	SessionSortTokens    = "tokens"
+	SessionSortDuration  = "duration"
+	SessionSortErrors    = "errors"
+	SessionSortContext   = "context"
+)
+
+// ErrInvalidCursor is returned when a session list cursor cannot be decoded or does not match the sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// sessionSortExpressions maps sort keys to SQL expressions
+var sessionSortExpressions = map[string]string{
+	SessionSortStartTime: "s.start_time",
+	SessionSortTokens:    "(s.total_input_tokens + s.total_output_tokens)",
+	SessionSortDuration:  "s.duration_seconds",
+	SessionSortErrors:    "s.error_count",
+	SessionSortContext:   "s.peak_context_tokens",
+}
+
+// IsValidSessionSort reports whether the sort key is supported by ListSessionsPage
+func IsValidSessionSort(sortBy string) bool {
+	_, ok := sessionSortExpressions[sortBy]
+	return ok
+}
+
+// SessionListFilter holds the filters, sort order and cursor for listing sessions
+type SessionListFilter struct {
+	ProjectID *int64
+	GroupID   *int64
+	GitBranch string
+	Model     string // このモデルを使用したセッション
+	ToolName  string // このツールを呼び出したセッション
+	Version   string // Claude Codeのバージョン（sessions.cli_version）
+	From      string // YYYY-MM-DD（含む）
+	To        string // YYYY-MM-DD（含む）
+	MinTokens *int   // 入力+出力トークン数の下限（含む）
+	MaxTokens *int   // 入力+出力トークン数の上限（含む）
+	HasErrors *bool
+	SortBy    string // SessionSort*（空の場合は開始時刻）
+	Ascending bool
+	Cursor    string // 前のページのNextCursor
+	Limit     int
+}
+
+// SessionListPage is a page of top-level sessions
+type SessionListPage struct {
+	Sessions   []*SessionRow
+	Total      int    // カーソルに関係なくフィルタに一致するセッション数
+	NextCursor string // 次のページがない場合は空
+}
+
+// sessionCursor is the position after the last session of a page
+type sessionCursor struct {
+	SortBy    string `json:"s"`
+	Ascending bool   `json:"a"`
+	Value     string `json:"v"`
+	ID        string `json:"id"`
+}
+
+// encodeSessionCursor encodes a cursor as an opaque URL-safe string
+func encodeSessionCursor(c sessionCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSessionCursor decodes a cursor and checks that it was issued for the same sort order
+func decodeSessionCursor(s, sortBy string, ascending bool) (*sessionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c sessionCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != sortBy || c.Ascending != ascending || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ListSessionsPage retrieves a page of top-level sessions matching the filter
+// 並び順の値とセッションIDによるキーセットページネーションのため、同期中に行が増えても重複・欠落しない
+func (db *DB) ListSessionsPage(filter SessionListFilter) (*SessionListPage, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = SessionSortStartTime
+	}
+	sortExpr, ok := sessionSortExpressions[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort key: %s", filter.SortBy)
+	}
+
+	where := " WHERE s.parent_session_id IS NULL"
+	var args []interface{}
+
+	if filter.ProjectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.GitBranch != "" {
+		where += " AND s.git_branch = ?"
+		args = append(args, filter.GitBranch)
+	}
+	if filter.Model != "" {
+		where += " AND EXISTS (SELECT 1 FROM model_usage mu WHERE mu.session_id = s.id AND mu.model = ?)"
+		args = append(args, filter.Model)
+	}
+	if filter.ToolName != "" {
+		where += " AND EXISTS (SELECT 1 FROM tool_calls tc WHERE tc.session_id = s.id AND tc.tool_name = ?)"
+		args = append(args, filter.ToolName)
+	}
+	if filter.Version != "" {
+		where += " AND s.cli_version = ?"
+		args = append(args, filter.Version)
+	}
+	if filter.From != "" {
+		where += " AND DATE(s.start_time) >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where += " AND DATE(s.start_time) <= ?"
+		args = append(args, filter.To)
+	}
+	if filter.MinTokens != nil {
+		where += " AND (s.total_input_tokens + s.total_output_tokens) >= ?"
+		args = append(args, *filter.MinTokens)
+	}
+	if filter.MaxTokens != nil {
+		where += " AND (s.total_input_tokens + s.total_output_tokens) <= ?"
+		args = append(args, *filter.MaxTokens)
+	}
+	if filter.HasErrors != nil {
+		if *filter.HasErrors {
+			where += " AND s.error_count > 0"
+		} else {
+			where += " AND s.error_count = 0"
+		}
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM sessions s"+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	direction, comparison := "DESC", "<"
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeSessionCursor(filter.Cursor, filter.SortBy, filter.Ascending)
+		if err != nil {
+			return nil, err
+		}
+		// 開始時刻は保存された文字列で、それ以外は整数で比較する
+		var value interface{} = cursor.Value
+		if filter.SortBy != SessionSortStartTime {
+			n, err := strconv.ParseInt(cursor.Value, 10, 64)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			value = n
+		}
+		where += fmt.Sprintf(" AND (%s %s ? OR (%s = ? AND s.id %s ?))", sortExpr, comparison, sortExpr, comparison)
+		args = append(args, value, value, cursor.ID)
+	}
+
+	query := `
+		SELECT s.id, s.project_id, s.git_branch, s.start_time, s.end_time, s.duration_seconds,
+		       s.total_input_tokens, s.total_output_tokens,
+		       s.total_cache_creation_tokens, s.total_cache_read_tokens,
+		       s.total_cost_usd,
+		       s.error_count,
+		       s.first_user_message,
+		       s.created_at, s.updated_at,
+		       s.peak_context_tokens, s.compaction_count, s.retry_count,
+		       COUNT(sub.id) as subagent_count,
+		       COALESCE(SUM(sub.total_input_tokens + sub.total_output_tokens), 0) as subagent_tokens,
+		       CAST(` + sortExpr + ` AS TEXT) as sort_value
+		FROM sessions s
+		LEFT JOIN sessions sub ON sub.parent_session_id = s.id
+	` + where + fmt.Sprintf(" GROUP BY s.id ORDER BY %s %s, s.id %s LIMIT ?", sortExpr, direction, direction)
+	// 次のページの有無を判定するため1件多く取得する
+	args = append(args, filter.Limit+1)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	page := &SessionListPage{Total: total}
+	var lastSortValue string
+	for rows.Next() {
+		var session SessionRow
+		var sortValue string
+		err = rows.Scan(
+			&session.ID, &session.ProjectID, &session.GitBranch,
+			&session.StartTime, &session.EndTime, &session.DurationSeconds,
+			&session.TotalInputTokens, &session.TotalOutputTokens,
+			&session.TotalCacheCreationTokens, &session.TotalCacheReadTokens,
+			&session.TotalCostUSD,
+			&session.ErrorCount, &session.FirstUserMessage,
+			&session.CreatedAt, &session.UpdatedAt,
+			&session.PeakContextTokens, &session.CompactionCount, &session.RetryCount,
+			&session.SubagentCount, &session.SubagentTokens,
+			&sortValue,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		if len(page.Sessions) == filter.Limit {
+			last := page.Sessions[len(page.Sessions)-1]
+			page.NextCursor = encodeSessionCursor(sessionCursor{
+				SortBy:    filter.SortBy,
+				Ascending: filter.Ascending,
+				Value:     lastSortValue,
+				ID:        last.ID,
+			})
+			break
+		}
+		page.Sessions = append(page.Sessions, &session)
+		lastSortValue = sortValue
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session rows: %w", err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// createListTestSessions creates sessions with distinct start times, tokens, branches and models
+// list-1 が最も古く、番号が大きいほど開始時刻が新しく入力トークン数が多い
+func createListTestSessions(t *testing.T, db *DB, projectName string) {
+	t.Helper()
+
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		session := createTestSession("list-" + string(rune('0'+i)))
+		session.StartTime = base.AddDate(0, 0, i)
+		session.EndTime = session.StartTime.Add(time.Duration(i) * time.Minute)
+		session.TotalTokens.InputTokens = i * 100
+		session.ModelUsage = map[string]parser.TokenSummary{"claude-sonnet-4-5": session.TotalTokens}
+		for j := range session.Entries {
+			session.Entries[j].UUID += "-" + session.ID
+		}
+		if i%2 == 0 {
+			session.GitBranch = "feature"
+			session.ErrorCount = 0
+			session.ModelUsage = map[string]parser.TokenSummary{"claude-opus-4-5": session.TotalTokens}
+			session.ToolCalls = session.ToolCalls[:1]
+			for j := range session.Entries {
+				session.Entries[j].Version = "2.0.0"
+			}
+		}
+		if err := db.CreateSession(session, projectName, time.Now()); err != nil {
+			t.Fatalf("Failed to create session %d: %v", i, err)
+		}
+	}
+}
+
+// sessionIDs returns the IDs of the session rows
+func sessionIDs(rows []*SessionRow) []string {
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids
+}
+
+func TestListSessionsPage(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectName := "page-test-project"
+	if _, err := db.CreateProject(projectName, "/path/to/page-test-project"); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	createListTestSessions(t, db, projectName)
+
+	t.Run("カーソルで全ページを重複なく取得できる", func(t *testing.T) {
+		var ids []string
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			result, err := db.ListSessionsPage(SessionListFilter{Cursor: cursor, Limit: 2})
+			if err != nil {
+				t.Fatalf("ListSessionsPage failed: %v", err)
+			}
+			if result.Total != 5 {
+				t.Errorf("Expected total 5, got %d", result.Total)
+			}
+			ids = append(ids, sessionIDs(result.Sessions)...)
+			cursor = result.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+
+		expected := []string{"test-session-list-5", "test-session-list-4", "test-session-list-3", "test-session-list-2", "test-session-list-1"}
+		if len(ids) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, ids)
+		}
+		for i := range expected {
+			if ids[i] != expected[i] {
+				t.Errorf("Expected %v, got %v", expected, ids)
+				break
+			}
+		}
+	})
+
+	t.Run("トークン数の昇順で並べ替えられる", func(t *testing.T) {
+		first, err := db.ListSessionsPage(SessionListFilter{SortBy: SessionSortTokens, Ascending: true, Limit: 3})
+		if err != nil {
+			t.Fatalf("ListSessionsPage failed: %v", err)
+		}
+		second, err := db.ListSessionsPage(SessionListFilter{SortBy: SessionSortTokens, Ascending: true, Cursor: first.NextCursor, Limit: 3})
+		if err != nil {
+			t.Fatalf("ListSessionsPage failed: %v", err)
+		}
+
+		ids := append(sessionIDs(first.Sessions), sessionIDs(second.Sessions)...)
+		if len(ids) != 5 || ids[0] != "test-session-list-1" || ids[4] != "test-session-list-5" {
+			t.Errorf("Unexpected order: %v", ids)
+		}
+		if second.NextCursor != "" {
+			t.Errorf("Expected no next cursor on the last page, got %q", second.NextCursor)
+		}
+	})
+
+	t.Run("フィルタを組み合わせて絞り込める", func(t *testing.T) {
+		minTokens := 300
+		hasErrors := false
+		tests := []struct {
+			name     string
+			filter   SessionListFilter
+			expected []string
+		}{
+			{"ブランチ", SessionListFilter{GitBranch: "feature"}, []string{"test-session-list-4", "test-session-list-2"}},
+			{"モデル", SessionListFilter{Model: "claude-opus-4-5"}, []string{"test-session-list-4", "test-session-list-2"}},
+			{"ツール", SessionListFilter{ToolName: "write_file"}, []string{"test-session-list-5", "test-session-list-3", "test-session-list-1"}},
+			{"バージョン", SessionListFilter{Version: "1.0.0"}, []string{"test-session-list-5", "test-session-list-3", "test-session-list-1"}},
+			{"期間", SessionListFilter{From: "2025-03-02", To: "2025-03-03"}, []string{"test-session-list-2", "test-session-list-1"}},
+			{"トークン数とエラーなし", SessionListFilter{MinTokens: &minTokens, HasErrors: &hasErrors}, []string{"test-session-list-4"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.filter.Limit = 10
+				result, err := db.ListSessionsPage(tt.filter)
+				if err != nil {
+					t.Fatalf("ListSessionsPage failed: %v", err)
+				}
+				ids := sessionIDs(result.Sessions)
+				if result.Total != len(tt.expected) || len(ids) != len(tt.expected) {
+					t.Fatalf("Expected %v, got %v (total %d)", tt.expected, ids, result.Total)
+				}
+				for i := range tt.expected {
+					if ids[i] != tt.expected[i] {
+						t.Errorf("Expected %v, got %v", tt.expected, ids)
+						break
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("並び順と一致しないカーソルはエラーになる", func(t *testing.T) {
+		result, err := db.ListSessionsPage(SessionListFilter{Limit: 1})
+		if err != nil {
+			t.Fatalf("ListSessionsPage failed: %v", err)
+		}
+
+		_, err = db.ListSessionsPage(SessionListFilter{SortBy: SessionSortErrors, Cursor: result.NextCursor, Limit: 1})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+
+		_, err = db.ListSessionsPage(SessionListFilter{Cursor: "not-a-cursor", Limit: 1})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
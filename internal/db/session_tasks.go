@@ -0,0 +1,415 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
+)
+
+// Task list sort keys
+const (
+	TaskSortStartTime = "startTime"
+	TaskSortTokens    = "tokens"
+	TaskSortDuration  = "duration"
+	TaskSortToolCalls = "toolCalls"
+	TaskSortErrors    = "errors"
+)
+
+// taskSortExpressions maps sort keys to SQL expressions
+var taskSortExpressions = map[string]string{
+	TaskSortStartTime: "t.start_time",
+	TaskSortTokens:    "(t.input_tokens + t.output_tokens)",
+	TaskSortDuration:  "t.duration_seconds",
+	TaskSortToolCalls: "t.tool_call_count",
+	TaskSortErrors:    "t.error_count",
+}
+
+// IsValidTaskSort reports whether the sort key is supported by ListTasks
+func IsValidTaskSort(sortBy string) bool {
+	_, ok := taskSortExpressions[sortBy]
+	return ok
+}
+
+// TaskRow represents a task of a session as stored in the database
+type TaskRow struct {
+	SessionID   string
+	ProjectName string
+	GitBranch   string
+
+	Index               int
+	StartUUID           string
+	Prompt              string
+	StartTime           time.Time
+	EndTime             time.Time
+	DurationSeconds     int
+	EntryCount          int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+	ToolCallCount       int
+	ErrorCount          int
+}
+
+// TaskListFilter holds the filters, sort order and paging for listing tasks
+type TaskListFilter struct {
+	ProjectID *int64
+	GroupID   *int64
+	GitBranch string
+	From      string // YYYY-MM-DD（含む）
+	To        string // YYYY-MM-DD（含む）
+	SortBy    string // TaskSort*（空の場合は開始時刻）
+	Ascending bool
+	Limit     int
+	Offset    int
+}
+
+// TaskListPage is a page of tasks
+type TaskListPage struct {
+	Tasks []*TaskRow
+	Total int // ページングに関係なくフィルタに一致するタスク数
+}
+
+// loadTaskEntries loads the time-ordered log entries of a session for task and command analysis
+// afterEntryID が 0 より大きい場合はそれより後に保存されたエントリ（追記分）だけを読み込む
+func loadTaskEntries(tx *sql.Tx, sessionID string, afterEntryID int64) ([]analyzer.TaskEntry, error) {
+	// tool_resultを含まず、Claude Codeが自動で挿入したもの（圧縮後の要約を含む）でもないユーザーメッセージをユーザー入力とみなす
+	rows, err := tx.Query(`
+		SELECT le.uuid, le.timestamp,
+		       le.entry_type = 'user' AND NOT le.is_meta AND NOT le.is_compact_summary AND m.content_json IS NOT NULL
+		           AND m.content_json NOT LIKE '%"type":"tool_result"%',
+		       COALESCE(m.content_text, ''),
+		       le.input_tokens, le.output_tokens, le.cache_creation_tokens, le.cache_read_tokens
+		FROM log_entries le
+		LEFT JOIN messages m ON le.id = m.log_entry_id
+		WHERE le.session_id = ? AND le.id > ?
+		ORDER BY le.timestamp, le.id
+	`, sessionID, afterEntryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []analyzer.TaskEntry
+	for rows.Next() {
+		var entry analyzer.TaskEntry
+		err := rows.Scan(
+			&entry.UUID, &entry.Timestamp, &entry.IsPrompt, &entry.Text,
+			&entry.InputTokens, &entry.OutputTokens, &entry.CacheCreationTokens, &entry.CacheReadTokens,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating log entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// loadTaskToolCalls loads the time-ordered tool calls of a session stored after afterToolCallID
+func loadTaskToolCalls(tx *sql.Tx, sessionID string, afterToolCallID int64) ([]analyzer.TaskToolCall, error) {
+	rows, err := tx.Query(`
+		SELECT timestamp, is_error AND interruption_type IS NULL
+		FROM tool_calls
+		WHERE session_id = ? AND id > ?
+		ORDER BY timestamp, id
+	`, sessionID, afterToolCallID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool calls: %w", err)
+	}
+	defer rows.Close()
+
+	var toolCalls []analyzer.TaskToolCall
+	for rows.Next() {
+		var call analyzer.TaskToolCall
+		if err := rows.Scan(&call.Timestamp, &call.IsError); err != nil {
+			return nil, fmt.Errorf("failed to scan tool call: %w", err)
+		}
+		toolCalls = append(toolCalls, call)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tool calls: %w", err)
+	}
+
+	return toolCalls, nil
+}
+
+// updateSessionTasks splits the log entries stored after afterEntryID into tasks
+// 追記分は直前のタスクに続く場合があるため、最後のタスクから分割し直す。afterEntryID に 0 を渡すとセッション全体から分割する。
+// failedResults は保存済みのツール呼び出しのうち、追記分でエラーの結果を受け取ったもの。
+func updateSessionTasks(tx *sql.Tx, sessionID string, afterEntryID, afterToolCallID int64, failedResults []failedToolCall) error {
+	if afterEntryID == 0 {
+		return segmentSessionTasks(tx, sessionID)
+	}
+
+	entries, err := loadTaskEntries(tx, sessionID, afterEntryID)
+	if err != nil {
+		return err
+	}
+	last, err := lastSessionTask(tx, sessionID)
+	if err != nil {
+		return err
+	}
+	// 保存済みのエントリより前の時刻のエントリは前のタスクに入るため、セッション全体から分割し直す
+	if last == nil || (len(entries) > 0 && entries[0].Timestamp.Before(last.EndTime)) {
+		return segmentSessionTasks(tx, sessionID)
+	}
+
+	// 保存済みのタスクに属するツール呼び出しは、その時点で開始済みの最後のタスクに加算する
+	addStmt, err := tx.Prepare(`
+		UPDATE session_tasks SET tool_call_count = tool_call_count + ?, error_count = error_count + ?
+		WHERE id = (
+			SELECT id FROM session_tasks
+			WHERE session_id = ?
+			ORDER BY CASE WHEN start_time <= ? THEN task_index ELSE 0 END DESC, task_index
+			LIMIT 1
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare session task update statement: %w", err)
+	}
+	defer addStmt.Close()
+
+	for _, failed := range failedResults {
+		if failed.toolCall.InterruptionType != "" {
+			continue
+		}
+		if _, err := addStmt.Exec(0, 1, sessionID, formatSortableTime(failed.toolCall.Timestamp)); err != nil {
+			return fmt.Errorf("failed to add tool error to session task: %w", err)
+		}
+	}
+
+	toolCalls, err := loadTaskToolCalls(tx, sessionID, afterToolCallID)
+	if err != nil {
+		return err
+	}
+	var lastTaskCalls []analyzer.TaskToolCall
+	for _, call := range toolCalls {
+		if !call.Timestamp.Before(last.StartTime) {
+			lastTaskCalls = append(lastTaskCalls, call)
+			continue
+		}
+		errorCount := 0
+		if call.IsError {
+			errorCount = 1
+		}
+		if _, err := addStmt.Exec(1, errorCount, sessionID, formatSortableTime(call.Timestamp)); err != nil {
+			return fmt.Errorf("failed to add tool call to session task: %w", err)
+		}
+	}
+
+	// 加算後の最後のタスクを読み直して続きを分割する
+	last, err = lastSessionTask(tx, sessionID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM session_tasks WHERE session_id = ? AND task_index = ?", sessionID, last.Index); err != nil {
+		return fmt.Errorf("failed to delete last session task: %w", err)
+	}
+
+	return insertSessionTasks(tx, sessionID, analyzer.AppendTasks([]analyzer.Task{*last}, entries, lastTaskCalls))
+}
+
+// segmentSessionTasks splits the whole session into tasks and replaces its stored tasks
+func segmentSessionTasks(tx *sql.Tx, sessionID string) error {
+	entries, err := loadTaskEntries(tx, sessionID, 0)
+	if err != nil {
+		return err
+	}
+	toolCalls, err := loadTaskToolCalls(tx, sessionID, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM session_tasks WHERE session_id = ?", sessionID); err != nil {
+		return fmt.Errorf("failed to delete session tasks: %w", err)
+	}
+
+	return insertSessionTasks(tx, sessionID, analyzer.SegmentTasks(entries, toolCalls))
+}
+
+// lastSessionTask retrieves the last stored task of a session, or nil if it has none
+func lastSessionTask(tx *sql.Tx, sessionID string) (*analyzer.Task, error) {
+	var task analyzer.Task
+	var startTimeStr, endTimeStr string
+	err := tx.QueryRow(`
+		SELECT task_index, start_uuid, prompt, start_time, end_time,
+		       entry_count, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens,
+		       tool_call_count, error_count
+		FROM session_tasks
+		WHERE session_id = ?
+		ORDER BY task_index DESC
+		LIMIT 1
+	`, sessionID).Scan(
+		&task.Index, &task.StartUUID, &task.Prompt, &startTimeStr, &endTimeStr,
+		&task.EntryCount, &task.InputTokens, &task.OutputTokens, &task.CacheCreationTokens, &task.CacheReadTokens,
+		&task.ToolCallCount, &task.ErrorCount,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last session task: %w", err)
+	}
+	task.StartTime, _ = parseDateTime(startTimeStr)
+	task.EndTime, _ = parseDateTime(endTimeStr)
+	return &task, nil
+}
+
+// insertSessionTasks inserts the tasks of a session
+func insertSessionTasks(tx *sql.Tx, sessionID string, tasks []analyzer.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO session_tasks (
+			session_id, task_index, start_uuid, prompt, start_time, end_time, duration_seconds,
+			entry_count, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens,
+			tool_call_count, error_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare session task statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, task := range tasks {
+		_, err := stmt.Exec(
+			sessionID, task.Index, task.StartUUID, truncate(task.Prompt, 100),
+			formatSortableTime(task.StartTime), formatSortableTime(task.EndTime),
+			int(task.EndTime.Sub(task.StartTime).Seconds()),
+			task.EntryCount, task.InputTokens, task.OutputTokens, task.CacheCreationTokens, task.CacheReadTokens,
+			task.ToolCallCount, task.ErrorCount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert session task %d: %w", task.Index, err)
+		}
+	}
+
+	return nil
+}
+
+// taskColumns are the columns scanned by scanTaskRows
+const taskColumns = `
+	t.session_id, p.name, s.git_branch,
+	t.task_index, t.start_uuid, t.prompt, t.start_time, t.end_time, t.duration_seconds,
+	t.entry_count, t.input_tokens, t.output_tokens, t.cache_creation_tokens, t.cache_read_tokens,
+	t.tool_call_count, t.error_count
+`
+
+// scanTaskRows scans rows selected with taskColumns
+func scanTaskRows(rows *sql.Rows) ([]*TaskRow, error) {
+	tasks := []*TaskRow{}
+	for rows.Next() {
+		var task TaskRow
+		var startTimeStr, endTimeStr string
+		err := rows.Scan(
+			&task.SessionID, &task.ProjectName, &task.GitBranch,
+			&task.Index, &task.StartUUID, &task.Prompt, &startTimeStr, &endTimeStr, &task.DurationSeconds,
+			&task.EntryCount, &task.InputTokens, &task.OutputTokens, &task.CacheCreationTokens, &task.CacheReadTokens,
+			&task.ToolCallCount, &task.ErrorCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session task: %w", err)
+		}
+		task.StartTime, _ = parseDateTime(startTimeStr)
+		task.EndTime, _ = parseDateTime(endTimeStr)
+		tasks = append(tasks, &task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// GetSessionTasks retrieves the tasks of a session in order
+func (db *DB) GetSessionTasks(sessionID string) ([]*TaskRow, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+taskColumns+`
+		FROM session_tasks t
+		INNER JOIN sessions s ON t.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+		WHERE t.session_id = ?
+		ORDER BY t.task_index
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTaskRows(rows)
+}
+
+// ListTasks retrieves a page of the tasks of top-level sessions matching the filter
+// サブエージェントのタスクは親セッションのTaskツール呼び出しに含まれるため対象外
+func (db *DB) ListTasks(filter TaskListFilter) (*TaskListPage, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = TaskSortStartTime
+	}
+	sortExpr, ok := taskSortExpressions[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort key: %s", filter.SortBy)
+	}
+
+	where := " WHERE s.parent_session_id IS NULL"
+	var args []interface{}
+
+	if filter.ProjectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.GroupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *filter.GroupID)
+	}
+	if filter.GitBranch != "" {
+		where += " AND s.git_branch = ?"
+		args = append(args, filter.GitBranch)
+	}
+	if filter.From != "" {
+		where += " AND DATE(t.start_time) >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where += " AND DATE(t.start_time) <= ?"
+		args = append(args, filter.To)
+	}
+
+	from := `
+		FROM session_tasks t
+		INNER JOIN sessions s ON t.session_id = s.id
+		INNER JOIN projects p ON s.project_id = p.id
+	`
+
+	page := &TaskListPage{}
+	if err := db.conn.QueryRow("SELECT COUNT(*)"+from+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count session tasks: %w", err)
+	}
+
+	direction := "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+	query := "SELECT " + taskColumns + from + where +
+		fmt.Sprintf(" ORDER BY %s %s, t.start_time %s, t.id %s LIMIT ? OFFSET ?", sortExpr, direction, direction, direction)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session tasks: %w", err)
+	}
+	defer rows.Close()
+
+	page.Tasks, err = scanTaskRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
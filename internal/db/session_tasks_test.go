@@ -0,0 +1,149 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestSessionTasks(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectID, err := database.CreateProject("task-project", "/path/to/activity")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	otherID, err := database.CreateProject("task-other", "/path/to/task-other")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	// 2つのユーザー入力（Run the tests / Thanks）で2タスクに分かれるセッション
+	session := createActivitySession()
+	session.ToolCalls = []parser.ToolCall{
+		{ID: "toolu_1", Timestamp: session.StartTime.Add(10 * time.Second), Name: "Bash", HasResult: true, IsError: true, Result: "exit status 1"},
+	}
+	if err := database.CreateSession(session, "task-project", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := database.CreateSession(createTestSession("task-other"), "task-other", time.Now()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	t.Run("ユーザー入力ごとにタスクを記録する", func(t *testing.T) {
+		tasks, err := database.GetSessionTasks("activity-session")
+		if err != nil {
+			t.Fatalf("GetSessionTasks failed: %v", err)
+		}
+		if len(tasks) != 2 {
+			t.Fatalf("Expected 2 tasks, got %+v", tasks)
+		}
+
+		first := tasks[0]
+		if first.Index != 1 || first.StartUUID != "u1" || first.Prompt != "Run the tests" || first.ProjectName != "task-project" {
+			t.Errorf("Unexpected first task: %+v", first)
+		}
+		// tool_resultのユーザーメッセージでは分割しない
+		if first.EntryCount != 4 || first.DurationSeconds != 35 || first.ToolCallCount != 1 || first.ErrorCount != 1 {
+			t.Errorf("Unexpected first task totals: %+v", first)
+		}
+		if tasks[1].StartUUID != "u2" || tasks[1].EntryCount != 2 || tasks[1].ToolCallCount != 0 {
+			t.Errorf("Unexpected second task: %+v", tasks[1])
+		}
+	})
+
+	t.Run("再解析してもタスクは重複しない", func(t *testing.T) {
+		if err := database.UpdateSession(session, "task-project", time.Now()); err != nil {
+			t.Fatalf("UpdateSession failed: %v", err)
+		}
+		tasks, err := database.GetSessionTasks("activity-session")
+		if err != nil {
+			t.Fatalf("GetSessionTasks failed: %v", err)
+		}
+		if len(tasks) != 2 {
+			t.Errorf("Expected 2 tasks after update, got %d", len(tasks))
+		}
+	})
+
+	t.Run("絞り込みと並び替えができる", func(t *testing.T) {
+		page, err := database.ListTasks(TaskListFilter{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListTasks failed: %v", err)
+		}
+		if page.Total != 3 || len(page.Tasks) != 3 {
+			t.Errorf("Expected 3 tasks, got %d (%d)", page.Total, len(page.Tasks))
+		}
+
+		page, err = database.ListTasks(TaskListFilter{ProjectID: &projectID, SortBy: TaskSortDuration, Limit: 1})
+		if err != nil {
+			t.Fatalf("ListTasks failed: %v", err)
+		}
+		if page.Total != 2 || len(page.Tasks) != 1 || page.Tasks[0].StartUUID != "u1" {
+			t.Errorf("Expected the longest task first, got %+v", page.Tasks)
+		}
+
+		page, err = database.ListTasks(TaskListFilter{ProjectID: &otherID, From: "2025-06-02", To: "2025-06-02", Limit: 10})
+		if err != nil {
+			t.Fatalf("ListTasks failed: %v", err)
+		}
+		if page.Total != 0 {
+			t.Errorf("Expected no tasks in the date range, got %d", page.Total)
+		}
+	})
+
+	t.Run("不正な並び順はエラー", func(t *testing.T) {
+		if _, err := database.ListTasks(TaskListFilter{SortBy: "unknown", Limit: 10}); err == nil {
+			t.Error("Expected error for unsupported sort key, got nil")
+		}
+	})
+
+	t.Run("追記分は最後のタスクから続けて分割する", func(t *testing.T) {
+		appendDB, _ := setupTestDB(t)
+		defer appendDB.Close()
+		if _, err := appendDB.CreateProject("task-project", "/path/to/activity"); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+
+		// 結果を受け取る前のツール呼び出しまで
+		full := createActivitySession()
+		head := *full
+		head.Entries = full.Entries[:3]
+		head.EndTime = full.Entries[2].Timestamp
+		head.ToolCalls = []parser.ToolCall{{ID: "toolu_1", Timestamp: full.StartTime.Add(10 * time.Second), Name: "Bash"}}
+		if err := appendDB.CreateSession(&head, "task-project", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		delta := &parser.Session{
+			ModelUsage: map[string]parser.TokenSummary{},
+			EndTime:    full.EndTime,
+			Entries:    full.Entries[3:],
+			ToolCalls: []parser.ToolCall{
+				{ID: "toolu_2", Timestamp: full.StartTime.Add(2*time.Hour + 38*time.Second), Name: "Read", HasResult: true},
+			},
+			UnmatchedToolResults: []parser.ToolResult{
+				{ToolUseID: "toolu_1", Timestamp: full.StartTime.Add(30 * time.Second), IsError: true, Result: "exit status 1"},
+			},
+		}
+		if err := appendDB.AppendSession(full.ID, delta, "task-project", "activity-session.jsonl", time.Now()); err != nil {
+			t.Fatalf("AppendSession failed: %v", err)
+		}
+
+		tasks, err := appendDB.GetSessionTasks(full.ID)
+		if err != nil {
+			t.Fatalf("GetSessionTasks failed: %v", err)
+		}
+		if len(tasks) != 2 {
+			t.Fatalf("Expected 2 tasks, got %+v", tasks)
+		}
+		first := tasks[0]
+		if first.StartUUID != "u1" || first.EntryCount != 4 || first.DurationSeconds != 35 || first.ToolCallCount != 1 || first.ErrorCount != 1 {
+			t.Errorf("Unexpected first task: %+v", first)
+		}
+		if tasks[1].Index != 2 || tasks[1].StartUUID != "u2" || tasks[1].EntryCount != 2 || tasks[1].ToolCallCount != 1 || tasks[1].ErrorCount != 0 {
+			t.Errorf("Unexpected second task: %+v", tasks[1])
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/a-tak/ccloganalysis/internal/analyzer"
 	"github.com/a-tak/ccloganalysis/internal/parser"
 )
 
@@ -21,10 +22,22 @@ type SessionRow struct {
 	TotalOutputTokens       int
 	TotalCacheCreationTokens int
 	TotalCacheReadTokens    int
+	TotalCostUSD            float64
 	ErrorCount              int
 	FirstUserMessage        string
 	CreatedAt               time.Time
 	UpdatedAt               time.Time
+
+	// サブエージェントの実行回数とトークン数（input + output）
+	SubagentCount  int
+	SubagentTokens int
+
+	// 最大のコンテキストサイズとコンテキストの圧縮回数
+	PeakContextTokens int
+	CompactionCount   int
+
+	// 失敗したツール呼び出しの再試行回数
+	RetryCount int
 }
 
 // CreateSession creates a new session and all related data in a transaction
@@ -46,38 +59,55 @@ func (db *DB) CreateSession(session *parser.Session, projectName string, fileMod
 		return fmt.Errorf("failed to get project ID: %w", err)
 	}
 
+	// 集計済みのAPI応答（再開したセッションが再生した履歴など）のトークンを除外
+	session, countedKeys, err := withoutCountedUsage(tx, session, session.ID, session.StartTime)
+	if err != nil {
+		return err
+	}
+
 	// First user messageを計算
 	firstUserMessage := calculateFirstUserMessage(session)
 
+	// 推定コストを計算
+	modelCosts, totalCost := db.estimateSessionCosts(session)
+
 	// セッション挿入
 	durationSeconds := int(session.EndTime.Sub(session.StartTime).Seconds())
 	sessionQuery := `
 		INSERT INTO sessions (
 			id, project_id, git_branch, start_time, end_time, duration_seconds,
 			total_input_tokens, total_output_tokens,
-			total_cache_creation_tokens, total_cache_read_tokens,
-			error_count, first_user_message, file_mod_time
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			total_cache_creation_tokens, total_cache_read_tokens, total_cost_usd,
+			error_count, first_user_message, file_mod_time,
+			parent_session_id, agent_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err = tx.Exec(sessionQuery,
 		session.ID, projectID, session.GitBranch,
 		session.StartTime.Format(time.RFC3339Nano), session.EndTime.Format(time.RFC3339Nano), durationSeconds,
 		session.TotalTokens.InputTokens, session.TotalTokens.OutputTokens,
-		session.TotalTokens.CacheCreationInputTokens, session.TotalTokens.CacheReadInputTokens,
+		session.TotalTokens.CacheCreationInputTokens, session.TotalTokens.CacheReadInputTokens, totalCost,
 		session.ErrorCount,
 		firstUserMessage,
 		fileModTime.Format(time.RFC3339),
+		nullIfEmpty(session.ParentSessionID), nullIfEmpty(session.AgentID),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert session: %w", err)
 	}
 
+	// トークン使用量を集計したAPI応答を記録
+	if err = recordCountedMessages(tx, session.ID, countedKeys); err != nil {
+		return err
+	}
+
 	// モデル使用量挿入
 	modelUsageQuery := `
 		INSERT INTO model_usage (
 			session_id, model, input_tokens, output_tokens,
-			cache_creation_tokens, cache_read_tokens
-		) VALUES (?, ?, ?, ?, ?, ?)
+			cache_creation_tokens, cache_read_tokens,
+			cache_creation_5m_tokens, cache_creation_1h_tokens, cost_usd
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	modelStmt, err := tx.Prepare(modelUsageQuery)
 	if err != nil {
@@ -90,18 +120,118 @@ func (db *DB) CreateSession(session *parser.Session, projectName string, fileMod
 			session.ID, model,
 			tokens.InputTokens, tokens.OutputTokens,
 			tokens.CacheCreationInputTokens, tokens.CacheReadInputTokens,
+			tokens.CacheCreation5mInputTokens, tokens.CacheCreation1hInputTokens, modelCosts[model],
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert model usage for %s: %w", model, err)
 		}
 	}
 
-	// ログエントリ挿入
+	// ログエントリ・メッセージ挿入
+	if err = insertLogEntries(tx, session.ID, session.Entries, countedKeys); err != nil {
+		return err
+	}
+
+	// コンテキストの圧縮を記録
+	if err = insertCompactions(tx, session.ID, session.Compactions); err != nil {
+		return err
+	}
+
+	// ツール呼び出し挿入
+	failedCalls, err := insertToolCalls(tx, session.ID, session.ToolCalls)
+	if err != nil {
+		return err
+	}
+
+	// エラーパターンを更新
+	if err = recordErrorPatterns(tx, session.ID, failedCalls, nil); err != nil {
+		return err
+	}
+
+	// ユーザーによる中断を記録（ツール名は保存済みのツール呼び出しから補う）
+	if err = insertInterruptions(tx, session.ID, session.Interruptions); err != nil {
+		return err
+	}
+
+	// セッション全体から派生データを集計
+	if err = db.analyzeSession(tx, session.ID, 0, 0, nil); err != nil {
+		return err
+	}
+
+	// 検索インデックス登録
+	if err = indexSessionDocuments(tx, session); err != nil {
+		return err
+	}
+
+	// トランザクションコミット
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// analyzeSession updates the data derived from the stored log entries and tool calls of a session within a transaction
+// afterEntryID / afterToolCallID に追記前の最後のIDを渡すと追記分だけを処理し、0 を渡すとセッション全体から計算し直す
+// failedCalls は保存済みのツール呼び出しのうち、追記分のtool_resultでエラーになったもの
+func (db *DB) analyzeSession(tx *sql.Tx, sessionID string, afterEntryID, afterToolCallID int64, failedCalls []failedToolCall) error {
+	// 会話ツリーの破棄された分岐を集計
+	if err := updateConversationTree(tx, sessionID, afterEntryID); err != nil {
+		return err
+	}
+
+	// 作業時間と待機時間を集計
+	if err := db.updateActivityTime(tx, sessionID, afterEntryID); err != nil {
+		return err
+	}
+
+	// 最大コンテキストサイズと圧縮回数を集計
+	if err := updateContextStats(tx, sessionID, afterEntryID); err != nil {
+		return err
+	}
+
+	// ツール呼び出しの再試行を集計
+	if err := updateRetryStats(tx, sessionID); err != nil {
+		return err
+	}
+
+	// スラッシュコマンドの実行を記録（タスクの分割より先に行う）
+	if err := updateSessionCommands(tx, sessionID, afterEntryID); err != nil {
+		return err
+	}
+
+	// ユーザー入力ごとのタスクに分割
+	if err := updateSessionTasks(tx, sessionID, afterEntryID, afterToolCallID, failedCalls); err != nil {
+		return err
+	}
+
+	// 編集ツールによるファイルの変更を記録
+	if err := updateFileEdits(tx, sessionID, afterToolCallID, failedCalls); err != nil {
+		return err
+	}
+
+	// Bashコマンドを分類して記録
+	if err := updateBashCommands(tx, sessionID, afterToolCallID); err != nil {
+		return err
+	}
+
+	// セッションのClaude Codeのバージョンを記録
+	if err := updateSessionVersion(tx, sessionID, afterEntryID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// insertLogEntries inserts log entries and their messages within a transaction
+// countedKeys are the API responses counted for the first time; their usage is recorded on the first entry of each.
+func insertLogEntries(tx *sql.Tx, sessionID string, entries []parser.LogEntry, countedKeys []string) error {
 	logEntryQuery := `
 		INSERT INTO log_entries (
 			session_id, uuid, parent_uuid, entry_type, timestamp,
-			cwd, version, request_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			cwd, version, request_id, input_tokens, output_tokens,
+			cache_creation_tokens, cache_read_tokens, is_meta, is_compact_summary
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	logStmt, err := tx.Prepare(logEntryQuery)
 	if err != nil {
@@ -121,15 +251,31 @@ func (db *DB) CreateSession(session *parser.Session, projectName string, fileMod
 	}
 	defer msgStmt.Close()
 
-	for _, entry := range session.Entries {
+	uncounted := make(map[string]bool, len(countedKeys))
+	for _, key := range countedKeys {
+		uncounted[key] = true
+	}
+
+	for _, entry := range entries {
 		// UUID が空のエントリはスキップ（file-history-snapshot など）
 		if entry.UUID == "" {
 			continue
 		}
 
+		// 同じAPI応答の2行目以降や集計済みの応答にはトークン数を記録しない
+		var usage parser.Usage
+		if entry.Type == "assistant" && entry.Message != nil && entry.Message.Usage != nil {
+			key := parser.MessageUsageKey(entry)
+			if key == "" || uncounted[key] {
+				usage = *entry.Message.Usage
+				delete(uncounted, key)
+			}
+		}
+
 		result, err := logStmt.Exec(
-			session.ID, entry.UUID, entry.ParentUUID, entry.Type, entry.Timestamp,
-			entry.Cwd, entry.Version, entry.RequestID,
+			sessionID, entry.UUID, entry.ParentUUID, entry.Type, entry.Timestamp,
+			entry.Cwd, entry.Version, entry.RequestID, usage.InputTokens, usage.OutputTokens,
+			usage.CacheCreationInputTokens, usage.CacheReadInputTokens, entry.IsMeta, entry.IsCompactSummary,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert log entry %s: %w", entry.UUID, err)
@@ -161,40 +307,66 @@ func (db *DB) CreateSession(session *parser.Session, projectName string, fileMod
 		}
 	}
 
-	// ツール呼び出し挿入
+	return nil
+}
+
+// insertToolCalls inserts tool calls within a transaction and returns the failed ones
+func insertToolCalls(tx *sql.Tx, sessionID string, toolCalls []parser.ToolCall) ([]failedToolCall, error) {
 	toolCallQuery := `
 		INSERT INTO tool_calls (
-			session_id, timestamp, tool_name, input_json, is_error, result_text
-		) VALUES (?, ?, ?, ?, ?, ?)
+			session_id, timestamp, tool_name, input_json, is_error, result_text,
+			tool_use_id, duration_ms, agent_id, model, mcp_server, mcp_tool, result_size,
+			stdout, stderr, interrupted, interruption_type
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	toolStmt, err := tx.Prepare(toolCallQuery)
 	if err != nil {
-		return fmt.Errorf("failed to prepare tool call statement: %w", err)
+		return nil, fmt.Errorf("failed to prepare tool call statement: %w", err)
 	}
 	defer toolStmt.Close()
 
-	for _, toolCall := range session.ToolCalls {
+	var failedCalls []failedToolCall
+
+	for _, toolCall := range toolCalls {
 		// InputをJSONにシリアライズ
 		inputJSON, err := json.Marshal(toolCall.Input)
 		if err != nil {
-			return fmt.Errorf("failed to marshal tool input: %w", err)
+			return nil, fmt.Errorf("failed to marshal tool input: %w", err)
 		}
 
-		_, err = toolStmt.Exec(
-			session.ID, toolCall.Timestamp, toolCall.Name,
+		// MCPツールはサーバー別に集計できるようにサーバー名とツール名を記録
+		mcpServer, mcpTool, _ := analyzer.ParseMCPToolName(toolCall.Name)
+
+		result, err := toolStmt.Exec(
+			sessionID, toolCall.Timestamp, toolCall.Name,
 			string(inputJSON), toolCall.IsError, toolCall.Result,
+			toolCall.ID, toolCallDurationMs(toolCall), nullIfEmpty(toolCall.AgentID), nullIfEmpty(toolCall.Model),
+			nullIfEmpty(mcpServer), nullIfEmpty(mcpTool), toolCall.ResultSize,
+			nullIfEmpty(toolCall.Stdout), nullIfEmpty(toolCall.Stderr), toolCall.Interrupted, nullIfEmpty(toolCall.InterruptionType),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert tool call %s: %w", toolCall.Name, err)
+			return nil, fmt.Errorf("failed to insert tool call %s: %w", toolCall.Name, err)
 		}
-	}
 
-	// トランザクションコミット
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		// エラーパターン解析用に失敗したツール呼び出しを記録
+		if toolCall.IsError {
+			toolCallID, err := result.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tool call ID: %w", err)
+			}
+			failedCalls = append(failedCalls, failedToolCall{id: toolCallID, toolCall: toolCall})
+		}
 	}
 
-	return nil
+	return failedCalls, nil
+}
+
+// toolCallDurationMs returns the tool latency in milliseconds, or nil if no tool_result was received
+func toolCallDurationMs(toolCall parser.ToolCall) interface{} {
+	if !toolCall.HasResult {
+		return nil
+	}
+	return toolCall.Duration.Milliseconds()
 }
 
 // extractTextFromContent extracts text content from Content array for search
@@ -261,26 +433,27 @@ func truncate(s string, maxLen int) string {
 	return string(runes[:maxLen])
 }
 
-// GetSession retrieves a session and all related data
-func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
+// GetSessionHeader retrieves a session with its model usage, without log entries and tool calls
+func (db *DB) GetSessionHeader(sessionID string) (*parser.Session, error) {
 	// セッション基本情報取得
 	sessionQuery := `
 		SELECT s.id, p.decoded_path, s.git_branch, s.start_time, s.end_time,
 		       s.total_input_tokens, s.total_output_tokens,
 		       s.total_cache_creation_tokens, s.total_cache_read_tokens,
-		       s.error_count
+		       s.error_count, s.parent_session_id, s.agent_id
 		FROM sessions s
 		JOIN projects p ON s.project_id = p.id
 		WHERE s.id = ?
 	`
 	var session parser.Session
 	var projectPath string
+	var parentSessionID, agentID sql.NullString
 	err := db.conn.QueryRow(sessionQuery, sessionID).Scan(
 		&session.ID, &projectPath, &session.GitBranch,
 		&session.StartTime, &session.EndTime,
 		&session.TotalTokens.InputTokens, &session.TotalTokens.OutputTokens,
 		&session.TotalTokens.CacheCreationInputTokens, &session.TotalTokens.CacheReadInputTokens,
-		&session.ErrorCount,
+		&session.ErrorCount, &parentSessionID, &agentID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("session not found: %s", sessionID)
@@ -289,11 +462,14 @@ func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
 		return nil, fmt.Errorf("failed to query session: %w", err)
 	}
 	session.ProjectPath = projectPath
+	session.ParentSessionID = parentSessionID.String
+	session.AgentID = agentID.String
 
 	// モデル使用量取得
 	modelUsageQuery := `
 		SELECT model, input_tokens, output_tokens,
-		       cache_creation_tokens, cache_read_tokens
+		       cache_creation_tokens, cache_read_tokens,
+		       cache_creation_5m_tokens, cache_creation_1h_tokens
 		FROM model_usage
 		WHERE session_id = ?
 	`
@@ -311,6 +487,7 @@ func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
 			&model,
 			&tokens.InputTokens, &tokens.OutputTokens,
 			&tokens.CacheCreationInputTokens, &tokens.CacheReadInputTokens,
+			&tokens.CacheCreation5mInputTokens, &tokens.CacheCreation1hInputTokens,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan model usage: %w", err)
@@ -318,6 +495,16 @@ func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
 		session.ModelUsage[model] = tokens
 	}
 
+	return &session, nil
+}
+
+// GetSession retrieves a session and all related data
+func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
+	session, err := db.GetSessionHeader(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
 	// ログエントリとメッセージ取得
 	entryQuery := `
 		SELECT le.id, le.uuid, le.parent_uuid, le.entry_type, le.timestamp,
@@ -373,7 +560,8 @@ func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
 
 	// ツール呼び出し取得
 	toolCallQuery := `
-		SELECT timestamp, tool_name, input_json, is_error, result_text
+		SELECT timestamp, tool_name, input_json, is_error, result_text,
+		       tool_use_id, duration_ms, agent_id
 		FROM tool_calls
 		WHERE session_id = ?
 		ORDER BY timestamp
@@ -388,14 +576,24 @@ func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
 	for toolRows.Next() {
 		var toolCall parser.ToolCall
 		var inputJSON string
+		var resultText, toolUseID, toolAgentID sql.NullString
+		var durationMs sql.NullInt64
 
 		err = toolRows.Scan(
 			&toolCall.Timestamp, &toolCall.Name, &inputJSON,
-			&toolCall.IsError, &toolCall.Result,
+			&toolCall.IsError, &resultText,
+			&toolUseID, &durationMs, &toolAgentID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan tool call: %w", err)
 		}
+		toolCall.Result = resultText.String
+		toolCall.ID = toolUseID.String
+		toolCall.AgentID = toolAgentID.String
+		if durationMs.Valid {
+			toolCall.HasResult = true
+			toolCall.Duration = time.Duration(durationMs.Int64) * time.Millisecond
+		}
 
 		// Inputをデシリアライズ
 		err = json.Unmarshal([]byte(inputJSON), &toolCall.Input)
@@ -406,28 +604,35 @@ func (db *DB) GetSession(sessionID string) (*parser.Session, error) {
 		session.ToolCalls = append(session.ToolCalls, toolCall)
 	}
 
-	return &session, nil
+	return session, nil
 }
 
-// ListSessions retrieves sessions with optional filtering and pagination
+// ListSessions retrieves top-level sessions with optional filtering and pagination
+// サブエージェントのセッションは含まず、親セッションの SubagentCount / SubagentTokens に集計する
 func (db *DB) ListSessions(projectID *int64, limit, offset int) ([]*SessionRow, error) {
 	query := `
 		SELECT s.id, s.project_id, s.git_branch, s.start_time, s.end_time, s.duration_seconds,
 		       s.total_input_tokens, s.total_output_tokens,
 		       s.total_cache_creation_tokens, s.total_cache_read_tokens,
+		       s.total_cost_usd,
 		       s.error_count,
 		       s.first_user_message,
-		       s.created_at, s.updated_at
+		       s.created_at, s.updated_at,
+		       s.peak_context_tokens, s.compaction_count, s.retry_count,
+		       COUNT(sub.id) as subagent_count,
+		       COALESCE(SUM(sub.total_input_tokens + sub.total_output_tokens), 0) as subagent_tokens
 		FROM sessions s
+		LEFT JOIN sessions sub ON sub.parent_session_id = s.id
+		WHERE s.parent_session_id IS NULL
 	`
 
 	var args []interface{}
 	if projectID != nil {
-		query += " WHERE s.project_id = ?"
+		query += " AND s.project_id = ?"
 		args = append(args, *projectID)
 	}
 
-	query += " ORDER BY s.start_time DESC LIMIT ? OFFSET ?"
+	query += " GROUP BY s.id ORDER BY s.start_time DESC LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
 	rows, err := db.conn.Query(query, args...)
@@ -444,8 +649,11 @@ func (db *DB) ListSessions(projectID *int64, limit, offset int) ([]*SessionRow,
 			&session.StartTime, &session.EndTime, &session.DurationSeconds,
 			&session.TotalInputTokens, &session.TotalOutputTokens,
 			&session.TotalCacheCreationTokens, &session.TotalCacheReadTokens,
+			&session.TotalCostUSD,
 			&session.ErrorCount, &session.FirstUserMessage,
 			&session.CreatedAt, &session.UpdatedAt,
+			&session.PeakContextTokens, &session.CompactionCount, &session.RetryCount,
+			&session.SubagentCount, &session.SubagentTokens,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session row: %w", err)
@@ -460,9 +668,9 @@ func (db *DB) ListSessions(projectID *int64, limit, offset int) ([]*SessionRow,
 	return sessions, nil
 }
 
-// CountSessions returns the number of sessions for a project
+// CountSessions returns the number of top-level sessions for a project
 func (db *DB) CountSessions(projectID int64) (int, error) {
-	query := `SELECT COUNT(*) FROM sessions WHERE project_id = ?`
+	query := `SELECT COUNT(*) FROM sessions WHERE project_id = ? AND parent_session_id IS NULL`
 
 	var count int
 	err := db.conn.QueryRow(query, projectID).Scan(&count)
@@ -502,9 +710,22 @@ func (db *DB) UpdateSession(session *parser.Session, projectName string, fileMod
 		return fmt.Errorf("failed to check session existence: %w", err)
 	}
 
+	// 既存の集計済みAPI応答を削除し、別のセッションで集計済みの応答のトークンを除外
+	_, err = tx.Exec("DELETE FROM counted_messages WHERE session_id = ?", session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old counted messages: %w", err)
+	}
+	session, countedKeys, err := withoutCountedUsage(tx, session, session.ID, session.StartTime)
+	if err != nil {
+		return err
+	}
+
 	// First user messageを計算
 	firstUserMessage := calculateFirstUserMessage(session)
 
+	// 推定コストを計算
+	modelCosts, totalCost := db.estimateSessionCosts(session)
+
 	// セッション更新
 	durationSeconds := int(session.EndTime.Sub(session.StartTime).Seconds())
 	sessionQuery := `
@@ -516,9 +737,13 @@ func (db *DB) UpdateSession(session *parser.Session, projectName string, fileMod
 			total_output_tokens = ?,
 			total_cache_creation_tokens = ?,
 			total_cache_read_tokens = ?,
+			total_cost_usd = ?,
 			error_count = ?,
 			first_user_message = ?,
 			file_mod_time = ?,
+			parent_session_id = ?,
+			agent_id = ?,
+			needs_reparse = 0,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
@@ -530,15 +755,23 @@ func (db *DB) UpdateSession(session *parser.Session, projectName string, fileMod
 		session.TotalTokens.OutputTokens,
 		session.TotalTokens.CacheCreationInputTokens,
 		session.TotalTokens.CacheReadInputTokens,
+		totalCost,
 		session.ErrorCount,
 		firstUserMessage,
 		fileModTime.Format(time.RFC3339),
+		nullIfEmpty(session.ParentSessionID),
+		nullIfEmpty(session.AgentID),
 		session.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
 
+	// トークン使用量を集計したAPI応答を記録
+	if err = recordCountedMessages(tx, session.ID, countedKeys); err != nil {
+		return err
+	}
+
 	// 既存のモデル使用量を削除
 	_, err = tx.Exec("DELETE FROM model_usage WHERE session_id = ?", session.ID)
 	if err != nil {
@@ -551,7 +784,31 @@ func (db *DB) UpdateSession(session *parser.Session, projectName string, fileMod
 		return fmt.Errorf("failed to delete old log entries: %w", err)
 	}
 
-	// 既存のツール呼び出しを削除
+	// 既存の圧縮の記録を削除
+	_, err = tx.Exec("DELETE FROM session_compactions WHERE session_id = ?", session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old compactions: %w", err)
+	}
+
+	// 既存の中断の記録を削除
+	_, err = tx.Exec("DELETE FROM session_interruptions WHERE session_id = ?", session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old interruptions: %w", err)
+	}
+
+	// 既存のエラー発生記録が属するパターン（ツール呼び出し削除後に件数を再計算する）
+	stalePatternIDs, err := sessionErrorPatternIDs(tx, session.ID)
+	if err != nil {
+		return err
+	}
+
+	// 既存の試行チェーンを削除
+	_, err = tx.Exec("DELETE FROM tool_attempts WHERE session_id = ?", session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old tool attempts: %w", err)
+	}
+
+	// 既存のツール呼び出しを削除（error_occurrencesはCASCADEで削除される）
 	_, err = tx.Exec("DELETE FROM tool_calls WHERE session_id = ?", session.ID)
 	if err != nil {
 		return fmt.Errorf("failed to delete old tool calls: %w", err)
@@ -561,8 +818,9 @@ func (db *DB) UpdateSession(session *parser.Session, projectName string, fileMod
 	modelUsageQuery := `
 		INSERT INTO model_usage (
 			session_id, model, input_tokens, output_tokens,
-			cache_creation_tokens, cache_read_tokens
-		) VALUES (?, ?, ?, ?, ?, ?)
+			cache_creation_tokens, cache_read_tokens,
+			cache_creation_5m_tokens, cache_creation_1h_tokens, cost_usd
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	modelStmt, err := tx.Prepare(modelUsageQuery)
 	if err != nil {
@@ -575,101 +833,47 @@ func (db *DB) UpdateSession(session *parser.Session, projectName string, fileMod
 			session.ID, model,
 			tokens.InputTokens, tokens.OutputTokens,
 			tokens.CacheCreationInputTokens, tokens.CacheReadInputTokens,
+			tokens.CacheCreation5mInputTokens, tokens.CacheCreation1hInputTokens, modelCosts[model],
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert model usage for %s: %w", model, err)
 		}
 	}
 
-	// ログエントリ挿入
-	logEntryQuery := `
-		INSERT INTO log_entries (
-			session_id, uuid, parent_uuid, entry_type, timestamp,
-			cwd, version, request_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	logStmt, err := tx.Prepare(logEntryQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare log entry statement: %w", err)
+	// ログエントリ・メッセージ挿入
+	if err = insertLogEntries(tx, session.ID, session.Entries, countedKeys); err != nil {
+		return err
 	}
-	defer logStmt.Close()
 
-	messageQuery := `
-		INSERT INTO messages (
-			log_entry_id, model, role, content_text, content_json
-		) VALUES (?, ?, ?, ?, ?)
-	`
-	msgStmt, err := tx.Prepare(messageQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare message statement: %w", err)
+	// コンテキストの圧縮を記録
+	if err = insertCompactions(tx, session.ID, session.Compactions); err != nil {
+		return err
 	}
-	defer msgStmt.Close()
-
-	for _, entry := range session.Entries {
-		// UUID が空のエントリはスキップ
-		if entry.UUID == "" {
-			continue
-		}
-
-		result, err := logStmt.Exec(
-			session.ID, entry.UUID, entry.ParentUUID, entry.Type, entry.Timestamp,
-			entry.Cwd, entry.Version, entry.RequestID,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert log entry %s: %w", entry.UUID, err)
-		}
-
-		// メッセージがあれば挿入
-		if entry.Message != nil {
-			logEntryID, err := result.LastInsertId()
-			if err != nil {
-				return fmt.Errorf("failed to get log entry ID: %w", err)
-			}
 
-			// Content配列をJSONにシリアライズ
-			contentJSON, err := json.Marshal(entry.Message.Content)
-			if err != nil {
-				return fmt.Errorf("failed to marshal message content: %w", err)
-			}
-
-			// テキストコンテンツを抽出
-			contentText := extractTextFromContent(entry.Message.Content)
+	// ツール呼び出し挿入
+	failedCalls, err := insertToolCalls(tx, session.ID, session.ToolCalls)
+	if err != nil {
+		return err
+	}
 
-			_, err = msgStmt.Exec(
-				logEntryID, entry.Message.Model, entry.Message.Role,
-				contentText, string(contentJSON),
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert message for entry %s: %w", entry.UUID, err)
-			}
-		}
+	// エラーパターンを更新
+	if err = recordErrorPatterns(tx, session.ID, failedCalls, stalePatternIDs); err != nil {
+		return err
 	}
 
-	// ツール呼び出し挿入
-	toolCallQuery := `
-		INSERT INTO tool_calls (
-			session_id, timestamp, tool_name, input_json, is_error, result_text
-		) VALUES (?, ?, ?, ?, ?, ?)
-	`
-	toolStmt, err := tx.Prepare(toolCallQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare tool call statement: %w", err)
+	// ユーザーによる中断を記録（ツール名は保存済みのツール呼び出しから補う）
+	if err = insertInterruptions(tx, session.ID, session.Interruptions); err != nil {
+		return err
 	}
-	defer toolStmt.Close()
 
-	for _, toolCall := range session.ToolCalls {
-		inputJSON, err := json.Marshal(toolCall.Input)
-		if err != nil {
-			return fmt.Errorf("failed to marshal tool input: %w", err)
-		}
+	// セッション全体から派生データを集計
+	if err = db.analyzeSession(tx, session.ID, 0, 0, nil); err != nil {
+		return err
+	}
 
-		_, err = toolStmt.Exec(
-			session.ID, toolCall.Timestamp, toolCall.Name,
-			string(inputJSON), toolCall.IsError, toolCall.Result,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert tool call %s: %w", toolCall.Name, err)
-		}
+	// 検索インデックス更新
+	if err = indexSessionDocuments(tx, session); err != nil {
+		return err
 	}
 
 	// コミット
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SubagentRunRow represents a subagent session linked to its parent session
+type SubagentRunRow struct {
+	SessionID                string
+	AgentID                  string
+	ToolUseID                string // サブエージェントを起動したTaskツールのtool_use_id（未対応の場合は空）
+	Description              string // Taskツール入力のdescription
+	SubagentType             string // Taskツール入力のsubagent_type
+	StartTime                time.Time
+	EndTime                  time.Time
+	TotalInputTokens         int
+	TotalOutputTokens        int
+	TotalCacheCreationTokens int
+	TotalCacheReadTokens     int
+	TotalCostUSD             float64
+	ErrorCount               int
+}
+
+// AgentTokens holds token totals of either the main agent or subagents
+type AgentTokens struct {
+	InputTokens         int     `json:"inputTokens"`
+	OutputTokens        int     `json:"outputTokens"`
+	CacheCreationTokens int     `json:"cacheCreationTokens"`
+	CacheReadTokens     int     `json:"cacheReadTokens"`
+	CostUSD             float64 `json:"costUsd"`
+}
+
+// AgentTokenSplit represents token usage split by main agent and subagents
+type AgentTokenSplit struct {
+	MainAgent    AgentTokens `json:"mainAgent"`
+	Subagents    AgentTokens `json:"subagents"`
+	SubagentRuns int         `json:"subagentRuns"`
+}
+
+// ListSubagentRuns retrieves the subagent sessions spawned by a session, ordered by start time
+// Taskツールとの対応付けはtool_calls.agent_idで行う（親セッションの同期順に依存しない）
+func (db *DB) ListSubagentRuns(parentSessionID string) ([]*SubagentRunRow, error) {
+	query := `
+		SELECT s.id, s.agent_id, s.start_time, s.end_time,
+		       s.total_input_tokens, s.total_output_tokens,
+		       s.total_cache_creation_tokens, s.total_cache_read_tokens,
+		       s.total_cost_usd, s.error_count,
+		       tc.tool_use_id, tc.input_json
+		FROM sessions s
+		LEFT JOIN tool_calls tc ON tc.session_id = s.parent_session_id AND tc.agent_id = s.agent_id
+		WHERE s.parent_session_id = ?
+		GROUP BY s.id
+		ORDER BY s.start_time
+	`
+
+	rows, err := db.conn.Query(query, parentSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subagent runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*SubagentRunRow
+	for rows.Next() {
+		var run SubagentRunRow
+		var agentID, toolUseID, inputJSON sql.NullString
+		err := rows.Scan(
+			&run.SessionID, &agentID, &run.StartTime, &run.EndTime,
+			&run.TotalInputTokens, &run.TotalOutputTokens,
+			&run.TotalCacheCreationTokens, &run.TotalCacheReadTokens,
+			&run.TotalCostUSD, &run.ErrorCount,
+			&toolUseID, &inputJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subagent run: %w", err)
+		}
+		run.AgentID = agentID.String
+		run.ToolUseID = toolUseID.String
+
+		// Taskツールの入力から説明とサブエージェント種別を取得
+		if inputJSON.Valid {
+			var input struct {
+				Description  string `json:"description"`
+				SubagentType string `json:"subagent_type"`
+			}
+			if err := json.Unmarshal([]byte(inputJSON.String), &input); err == nil {
+				run.Description = input.Description
+				run.SubagentType = input.SubagentType
+			}
+		}
+
+		runs = append(runs, &run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subagent runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetAgentTokenSplit retrieves token totals split by main agent and subagents
+// projectID / groupID が指定された場合はそのプロジェクト・グループのセッションに絞り込む
+func (db *DB) GetAgentTokenSplit(projectID, groupID *int64, version string) (*AgentTokenSplit, error) {
+	query := `
+		SELECT s.parent_session_id IS NOT NULL as is_subagent,
+		       COUNT(*),
+		       COALESCE(SUM(s.total_input_tokens), 0),
+		       COALESCE(SUM(s.total_output_tokens), 0),
+		       COALESCE(SUM(s.total_cache_creation_tokens), 0),
+		       COALESCE(SUM(s.total_cache_read_tokens), 0),
+		       COALESCE(SUM(s.total_cost_usd), 0)
+		FROM sessions s
+		WHERE 1 = 1
+	`
+
+	var args []interface{}
+	if projectID != nil {
+		query += " AND s.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if groupID != nil {
+		query += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *groupID)
+	}
+	if version != "" {
+		query += " AND s.cli_version = ?"
+		args = append(args, version)
+	}
+	query += " GROUP BY is_subagent"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query agent token split: %w", err)
+	}
+	defer rows.Close()
+
+	var split AgentTokenSplit
+	for rows.Next() {
+		var isSubagent bool
+		var count int
+		var tokens AgentTokens
+		err := rows.Scan(
+			&isSubagent, &count,
+			&tokens.InputTokens, &tokens.OutputTokens,
+			&tokens.CacheCreationTokens, &tokens.CacheReadTokens,
+			&tokens.CostUSD,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan agent token split: %w", err)
+		}
+
+		if isSubagent {
+			split.Subagents = tokens
+			split.SubagentRuns = count
+		} else {
+			split.MainAgent = tokens
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent token split: %w", err)
+	}
+
+	return &split, nil
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestSubagentSessions(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectID, err := db.CreateProject("subagent-project", "/path/to/subagent-project")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	parent := createTestSession("parent")
+	parent.ToolCalls = append(parent.ToolCalls, parser.ToolCall{
+		ID:        "toolu-task",
+		Timestamp: parent.StartTime.Add(20 * time.Second),
+		Name:      "Task",
+		Input:     map[string]interface{}{"description": "Explore code", "subagent_type": "Explore"},
+		HasResult: true,
+		AgentID:   "abc123",
+	})
+
+	subagent := createTestSession("subagent")
+	subagent.ID = "agent-abc123"
+	subagent.AgentID = "abc123"
+	subagent.ParentSessionID = parent.ID
+	subagent.TotalTokens = parser.TokenSummary{InputTokens: 1000, OutputTokens: 300}
+
+	// 親セッションより先にサブエージェントが同期されても紐付けられる
+	if err := db.CreateSession(subagent, "subagent-project", time.Now()); err != nil {
+		t.Fatalf("Failed to create subagent session: %v", err)
+	}
+	if err := db.CreateSession(parent, "subagent-project", time.Now()); err != nil {
+		t.Fatalf("Failed to create parent session: %v", err)
+	}
+
+	t.Run("サブエージェントの実行をTaskツールと紐付けて取得できる", func(t *testing.T) {
+		runs, err := db.ListSubagentRuns(parent.ID)
+		if err != nil {
+			t.Fatalf("ListSubagentRuns failed: %v", err)
+		}
+		if len(runs) != 1 {
+			t.Fatalf("Expected 1 subagent run, got %d", len(runs))
+		}
+		run := runs[0]
+		if run.SessionID != "agent-abc123" || run.AgentID != "abc123" {
+			t.Errorf("Unexpected run identity: %+v", run)
+		}
+		if run.ToolUseID != "toolu-task" || run.Description != "Explore code" || run.SubagentType != "Explore" {
+			t.Errorf("Task tool was not linked: %+v", run)
+		}
+		if run.TotalInputTokens != 1000 || run.TotalOutputTokens != 300 {
+			t.Errorf("Unexpected tokens: %d / %d", run.TotalInputTokens, run.TotalOutputTokens)
+		}
+	})
+
+	t.Run("サブエージェントのセッションは親情報を保持する", func(t *testing.T) {
+		session, err := db.GetSession("agent-abc123")
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if session.ParentSessionID != parent.ID || session.AgentID != "abc123" {
+			t.Errorf("Unexpected parent info: parent=%s agent=%s", session.ParentSessionID, session.AgentID)
+		}
+	})
+
+	t.Run("セッション一覧とセッション数にはトップレベルのみ含まれる", func(t *testing.T) {
+		sessions, err := db.ListSessions(&projectID, 100, 0)
+		if err != nil {
+			t.Fatalf("ListSessions failed: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].ID != parent.ID {
+			t.Fatalf("Expected only the parent session, got %d sessions", len(sessions))
+		}
+		if sessions[0].SubagentCount != 1 || sessions[0].SubagentTokens != 1300 {
+			t.Errorf("Expected 1 subagent / 1300 tokens, got %d / %d", sessions[0].SubagentCount, sessions[0].SubagentTokens)
+		}
+
+		count, err := db.CountSessions(projectID)
+		if err != nil {
+			t.Fatalf("CountSessions failed: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 session, got %d", count)
+		}
+	})
+
+	t.Run("プロジェクト統計のトークンにはサブエージェントも含まれる", func(t *testing.T) {
+		stats, err := db.GetProjectStats(projectID, "")
+		if err != nil {
+			t.Fatalf("GetProjectStats failed: %v", err)
+		}
+		if stats.TotalSessions != 1 {
+			t.Errorf("Expected 1 session, got %d", stats.TotalSessions)
+		}
+		if stats.TotalInputTokens != 1100 {
+			t.Errorf("Expected 1100 input tokens, got %d", stats.TotalInputTokens)
+		}
+	})
+
+	t.Run("メインエージェントとサブエージェントのトークンを分けて集計できる", func(t *testing.T) {
+		split, err := db.GetAgentTokenSplit(&projectID, nil, "")
+		if err != nil {
+			t.Fatalf("GetAgentTokenSplit failed: %v", err)
+		}
+		if split.MainAgent.InputTokens != 100 || split.MainAgent.OutputTokens != 50 {
+			t.Errorf("Unexpected main agent tokens: %+v", split.MainAgent)
+		}
+		if split.Subagents.InputTokens != 1000 || split.Subagents.OutputTokens != 300 {
+			t.Errorf("Unexpected subagent tokens: %+v", split.Subagents)
+		}
+		if split.SubagentRuns != 1 {
+			t.Errorf("Expected 1 subagent run, got %d", split.SubagentRuns)
+		}
+	})
+
+	t.Run("期間別統計のセッション数にサブエージェントは含まれない", func(t *testing.T) {
+		if err := db.RefreshPeriodStatistics(); err != nil {
+			t.Fatalf("RefreshPeriodStatistics failed: %v", err)
+		}
+		stats, err := db.GetTimeSeriesStats(projectID, "month", 12, "")
+		if err != nil {
+			t.Fatalf("GetTimeSeriesStats failed: %v", err)
+		}
+		if len(stats) == 0 {
+			t.Fatal("Expected period statistics")
+		}
+		if stats[0].SessionCount != 1 {
+			t.Errorf("Expected 1 session, got %d", stats[0].SessionCount)
+		}
+		if stats[0].TotalInputTokens != 1100 {
+			t.Errorf("Expected 1100 input tokens, got %d", stats[0].TotalInputTokens)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -30,6 +31,17 @@ type SyncResult struct {
 	SessionsSkipped   int
 	ErrorCount        int
 	Errors            []string // エラー詳細のリスト（"プロジェクト名: エラー内容" または "プロジェクト名/セッションID: エラー内容"）
+
+	// 同期で作成・更新されたプロジェクトとセッション（イベント通知用）
+	CreatedProjects []string
+	CreatedSessions []SyncedSession
+	UpdatedSessions []SyncedSession
+}
+
+// SyncedSession identifies a session saved during a sync
+type SyncedSession struct {
+	ProjectName string
+	SessionID   string
 }
 
 // SyncAll synchronizes all projects from the file system to the database
@@ -107,6 +119,9 @@ func SyncAllWithLoggerAndCallback(db *DB, p *parser.Parser, log *logger.Logger,
 		result.SessionsSkipped += syncResult.SessionsSkipped
 		result.ErrorCount += syncResult.ErrorCount
 		result.Errors = append(result.Errors, syncResult.Errors...)
+		result.CreatedProjects = append(result.CreatedProjects, syncResult.CreatedProjects...)
+		result.CreatedSessions = append(result.CreatedSessions, syncResult.CreatedSessions...)
+		result.UpdatedSessions = append(result.UpdatedSessions, syncResult.UpdatedSessions...)
 
 		// コールバックで進捗を報告
 		if callback != nil {
@@ -150,6 +165,13 @@ func SyncAllWithLoggerAndCallback(db *DB, p *parser.Parser, log *logger.Logger,
 		// エラーが発生しても処理を続行（同期処理全体は失敗させない）
 	}
 
+	// 同期したセッションの期間別統計を再計算
+	if err := db.RefreshPeriodStatistics(); err != nil {
+		log.WarnWithContext("Failed to refresh period statistics", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
 	return result, nil
 }
 
@@ -244,12 +266,19 @@ func SyncIncrementalWithLogger(database *DB, p *parser.Parser, log *logger.Logge
 		"count": len(projectNames),
 	})
 
+	// 再解析が必要になったセッションのファイル（ファイルが変更されていなくても同期する）
+	reparseTargets, err := database.ListReparseTargets()
+	if err != nil {
+		return nil, err
+	}
+
 	// 新規プロジェクト検出フラグ
 	hasNewProjects := false
 
 	for _, projectName := range projectNames {
 		// プロジェクトをDBから取得または作成
 		project, err := database.GetProjectByName(projectName)
+		isNewProject := err != nil
 		if err != nil {
 			// プロジェクトが存在しない場合は作成
 			hasNewProjects = true
@@ -361,6 +390,10 @@ func SyncIncrementalWithLogger(database *DB, p *parser.Parser, log *logger.Logge
 			}
 		}
 
+		if isNewProject {
+			result.CreatedProjects = append(result.CreatedProjects, projectName)
+		}
+
 		// このプロジェクトのスキャン開始時点でのカウントを記録
 		projectSessionsSyncedBefore := result.SessionsSynced
 		projectSessionsSkippedBefore := result.SessionsSkipped
@@ -407,20 +440,16 @@ func SyncIncrementalWithLogger(database *DB, p *parser.Parser, log *logger.Logge
 				continue
 			}
 
-			if !shouldSync {
+			reparse := reparseTargets.Contains(projectName, info)
+			if !shouldSync && !reparse {
 				result.SessionsSkipped++
 				continue
 			}
 
-			// セッションをパース
-			log.DebugWithContext("Parsing session", map[string]interface{}{
-				"project":    projectName,
-				"session_id": info.SessionID,
-			})
-
-			session, err := p.ParseSession(projectName, info.SessionID)
+			// セッションを保存（追記分のみの解析を優先し、できない場合は全体を再解析）
+			sessionID, created, err := syncSessionFile(database, p, projectName, info, reparse, log)
 			if err != nil {
-				log.ErrorWithContext("Failed to parse session", map[string]interface{}{
+				log.ErrorWithContext("Failed to sync session", map[string]interface{}{
 					"project":    projectName,
 					"session_id": info.SessionID,
 					"error":      err.Error(),
@@ -429,49 +458,12 @@ func SyncIncrementalWithLogger(database *DB, p *parser.Parser, log *logger.Logge
 				continue
 			}
 
-			// セッションをDBに保存（file_mod_timeも保存）
-			log.DebugWithContext("Saving session to DB", map[string]interface{}{
-				"project":       projectName,
-				"session_id":    info.SessionID,
-				"file_mod_time": info.ModTime,
-			})
-
-			err = database.CreateSession(session, projectName, info.ModTime)
-			if err != nil {
-				if isUniqueConstraintError(err) {
-					// セッションが既に存在する場合は更新
-					log.DebugWithContext("Session already exists, updating", map[string]interface{}{
-						"project":    projectName,
-						"session_id": info.SessionID,
-					})
-
-					err = database.UpdateSession(session, projectName, info.ModTime)
-					if err != nil {
-						log.ErrorWithContext("Failed to update session", map[string]interface{}{
-							"project":    projectName,
-							"session_id": info.SessionID,
-							"error":      err.Error(),
-						})
-						result.ErrorCount++
-						continue
-					}
-
-					log.DebugWithContext("Session updated", map[string]interface{}{
-						"project":    projectName,
-						"session_id": info.SessionID,
-					})
-					result.SessionsSynced++
-					continue
-				}
-				log.ErrorWithContext("Failed to save session", map[string]interface{}{
-					"project":    projectName,
-					"session_id": info.SessionID,
-					"error":      err.Error(),
-				})
-				result.ErrorCount++
-				continue
+			synced := SyncedSession{ProjectName: projectName, SessionID: sessionID}
+			if created {
+				result.CreatedSessions = append(result.CreatedSessions, synced)
+			} else {
+				result.UpdatedSessions = append(result.UpdatedSessions, synced)
 			}
-
 			result.SessionsSynced++
 		}
 
@@ -517,6 +509,13 @@ func SyncIncrementalWithLogger(database *DB, p *parser.Parser, log *logger.Logge
 		}
 	}
 
+	// 変更されたセッションの期間だけ期間別統計を再計算
+	if err := database.RefreshPeriodStatistics(); err != nil {
+		log.WarnWithContext("Failed to refresh period statistics", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
 	// 変更があった場合のみINFOレベル、なければDEBUGレベルでログ出力
 	if result.SessionsSynced > 0 {
 		log.InfoWithContext("SyncIncremental completed", map[string]interface{}{
@@ -535,72 +534,250 @@ func SyncIncrementalWithLogger(database *DB, p *parser.Parser, log *logger.Logge
 	return result, nil
 }
 
-// syncProjectInternal is an internal helper that synchronizes a single project
-func syncProjectInternal(db *DB, p *parser.Parser, projectName string) (*SyncResult, error) {
-	// デフォルトロガーを使用
-	return syncProjectInternalWithLogger(db, p, projectName, logger.New())
+// SyncSessionFiles synchronizes only the given session files of a project
+// relPaths are paths relative to the project directory, e.g. reported by a filesystem watcher.
+func SyncSessionFiles(database *DB, p *parser.Parser, projectName string, relPaths []string) (*SyncResult, error) {
+	return SyncSessionFilesWithLogger(database, p, projectName, relPaths, logger.New())
 }
 
-// syncProjectInternalWithLogger is an internal helper that synchronizes a single project with custom logger
-func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string, log *logger.Logger) (*SyncResult, error) {
+// SyncSessionFilesWithLogger synchronizes only the given session files of a project with custom logger
+func SyncSessionFilesWithLogger(database *DB, p *parser.Parser, projectName string, relPaths []string, log *logger.Logger) (*SyncResult, error) {
 	result := &SyncResult{}
 
-	log.DebugWithContext("Checking project in database", map[string]interface{}{
-		"project": projectName,
-	})
-
-	// プロジェクトをDBに登録（存在しない場合）
-	project, err := db.GetProjectByName(projectName)
-	if err != nil {
-		// プロジェクトが存在しない場合は作成
-		log.InfoWithContext("Creating new project", map[string]interface{}{
-			"project": projectName,
-		})
-
-		decodedPath := parser.DecodeProjectPath(projectName)
+	// プロジェクトが存在しない場合は作成
+	if _, err := database.GetProjectByName(projectName); err != nil {
+		if _, err := createProjectForSync(database, p, projectName, log); err != nil {
+			return nil, err
+		}
+		result.CreatedProjects = append(result.CreatedProjects, projectName)
+	}
 
-		// 実際の作業ディレクトリを取得
-		workingDir, err := p.GetProjectWorkingDirectory(projectName)
+	for _, relPath := range relPaths {
+		info, err := p.StatSessionFile(projectName, relPath)
 		if err != nil {
-			// セッションが存在しない、またはcwdが取得できない
-			log.WarnWithContext("Could not get working directory", map[string]interface{}{
+			// 削除されたファイルやセッション以外のファイルはスキップ
+			log.DebugWithContext("Skipping non-session file", map[string]interface{}{
 				"project": projectName,
+				"path":    relPath,
 				"error":   err.Error(),
 			})
-			_, err = db.CreateProject(projectName, decodedPath)
+			result.SessionsSkipped++
+			continue
+		}
+		result.SessionsFound++
+
+		sessionID, created, err := syncSessionFile(database, p, projectName, info, false, log)
+		if err != nil {
+			log.ErrorWithContext("Failed to sync session", map[string]interface{}{
+				"project":    projectName,
+				"session_id": info.SessionID,
+				"error":      err.Error(),
+			})
+			result.ErrorCount++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s/%s: %v", projectName, info.SessionID, err))
+			continue
+		}
+
+		synced := SyncedSession{ProjectName: projectName, SessionID: sessionID}
+		if created {
+			result.CreatedSessions = append(result.CreatedSessions, synced)
 		} else {
-			// 実際の作業ディレクトリでGit Root検出
-			gitRoot, gitErr := gitutil.DetectGitRoot(workingDir)
-			if gitErr != nil {
-				log.WarnWithContext("Failed to detect git root", map[string]interface{}{
-					"project": projectName,
-					"error":   gitErr.Error(),
-				})
-				_, err = db.CreateProject(projectName, decodedPath)
-			} else if gitRoot != "" {
-				_, err = db.CreateProjectWithGitRoot(projectName, decodedPath, gitRoot)
-			} else {
-				_, err = db.CreateProject(projectName, decodedPath)
-			}
+			result.UpdatedSessions = append(result.UpdatedSessions, synced)
 		}
+		result.SessionsSynced++
+	}
+	result.ProjectsProcessed++
 
+	// 同期したセッションに応答を奪われたセッションなどを、次の全体スキャンを待たずに集計し直す
+	reparseTargets, err := database.ListReparseTargets()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range reparseTargets.Files() {
+		info, err := p.StatSessionFile(file.ProjectName, file.RelPath)
 		if err != nil {
-			log.ErrorWithContext("Failed to create project", map[string]interface{}{
-				"project": projectName,
+			log.DebugWithContext("Skipping missing session file to reparse", map[string]interface{}{
+				"project": file.ProjectName,
+				"path":    file.RelPath,
 				"error":   err.Error(),
 			})
-			return nil, fmt.Errorf("failed to create project: %w", err)
+			continue
 		}
 
-		// 作成したプロジェクトを取得
-		project, err = db.GetProjectByName(projectName)
+		sessionID, _, err := syncSessionFile(database, p, file.ProjectName, info, true, log)
 		if err != nil {
-			log.ErrorWithContext("Failed to get created project", map[string]interface{}{
-				"project": projectName,
-				"error":   err.Error(),
+			log.ErrorWithContext("Failed to reparse session", map[string]interface{}{
+				"project":    file.ProjectName,
+				"session_id": info.SessionID,
+				"error":      err.Error(),
 			})
-			return nil, fmt.Errorf("failed to get created project: %w", err)
+			result.ErrorCount++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s/%s: %v", file.ProjectName, info.SessionID, err))
+			continue
 		}
+		result.UpdatedSessions = append(result.UpdatedSessions, SyncedSession{ProjectName: file.ProjectName, SessionID: sessionID})
+		result.SessionsSynced++
+	}
+
+	// 新規プロジェクトの場合はグループを同期
+	if len(result.CreatedProjects) > 0 {
+		if err := database.SyncProjectGroups(); err != nil {
+			log.WarnWithContext("Failed to sync project groups", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// 変更されたセッションの期間だけ期間別統計を再計算
+	if err := database.RefreshPeriodStatistics(); err != nil {
+		log.WarnWithContext("Failed to refresh period statistics", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if result.SessionsSynced > 0 {
+		log.InfoWithContext("Session files synced", map[string]interface{}{
+			"project":         projectName,
+			"sessions_synced": result.SessionsSynced,
+			"errors":          result.ErrorCount,
+		})
+	}
+
+	return result, nil
+}
+
+// syncSessionFile saves a changed session file to the DB
+// 前回の解析位置が記録されていれば追記された行だけを解析して追加する。
+// 解析位置がない場合や、ファイルが切り詰め・書き換えられた場合、reparseが指定された場合は全体を再解析する。
+// Returns the saved session ID and whether the session was newly created.
+func syncSessionFile(database *DB, p *parser.Parser, projectName string, info parser.SessionFileInfo, reparse bool, log *logger.Logger) (string, bool, error) {
+	state, err := database.GetSessionFilePosition(projectName, info.RelPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	// 応答を奪われたなどで再解析が必要になったセッションには追記しない
+	if state != nil && !reparse {
+		reparse, err = database.sessionNeedsReparse(state.SessionID)
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	if state != nil && !reparse {
+		delta, err := p.ParseSessionFileFrom(projectName, info, state.Position)
+		switch {
+		case err == nil:
+			err = database.AppendSession(state.SessionID, delta, projectName, info.RelPath, info.ModTime)
+			if err == nil {
+				log.DebugWithContext("Session appended", map[string]interface{}{
+					"project":     projectName,
+					"session_id":  state.SessionID,
+					"new_entries": len(delta.Entries),
+					"byte_offset": delta.Position.Offset,
+				})
+				return state.SessionID, false, nil
+			}
+			// 追記に失敗した場合は全体を再解析する
+			log.WarnWithContext("Failed to append session, falling back to full parse", map[string]interface{}{
+				"project":    projectName,
+				"session_id": state.SessionID,
+				"error":      err.Error(),
+			})
+		case errors.Is(err, parser.ErrFileRewritten):
+			log.DebugWithContext("Session file was rewritten, falling back to full parse", map[string]interface{}{
+				"project":    projectName,
+				"session_id": state.SessionID,
+			})
+		default:
+			return "", false, fmt.Errorf("failed to parse session: %w", err)
+		}
+	}
+
+	// セッション全体をパース
+	log.DebugWithContext("Parsing session", map[string]interface{}{
+		"project":    projectName,
+		"session_id": info.SessionID,
+	})
+
+	session, err := p.ParseSessionFile(projectName, info)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse session: %w", err)
+	}
+
+	// セッションをDBに保存（file_mod_timeも保存）
+	log.DebugWithContext("Saving session to DB", map[string]interface{}{
+		"project":       projectName,
+		"session_id":    info.SessionID,
+		"file_mod_time": info.ModTime,
+	})
+
+	created := true
+	err = database.CreateSession(session, projectName, info.ModTime)
+	if err != nil {
+		if !isUniqueConstraintError(err) {
+			return "", false, fmt.Errorf("failed to save session: %w", err)
+		}
+		created = false
+
+		// セッションが既に存在する場合は更新
+		log.DebugWithContext("Session already exists, updating", map[string]interface{}{
+			"project":    projectName,
+			"session_id": info.SessionID,
+		})
+
+		if err := database.UpdateSession(session, projectName, info.ModTime); err != nil {
+			return "", false, fmt.Errorf("failed to update session: %w", err)
+		}
+
+		log.DebugWithContext("Session updated", map[string]interface{}{
+			"project":    projectName,
+			"session_id": info.SessionID,
+		})
+	}
+
+	saveSessionFilePosition(database, projectName, info, session, log)
+	return session.ID, created, nil
+}
+
+// saveSessionFilePosition records where the next incremental sync should resume parsing a session file
+// 保存に失敗しても次回は全体を再解析するだけなので警告に留める
+func saveSessionFilePosition(database *DB, projectName string, info parser.SessionFileInfo, session *parser.Session, log *logger.Logger) {
+	if session.ID == "" {
+		return
+	}
+	if err := database.SaveSessionFilePosition(projectName, info.RelPath, session.ID, session.Position); err != nil {
+		log.WarnWithContext("Failed to save session file position", map[string]interface{}{
+			"project":    projectName,
+			"session_id": session.ID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// syncProjectInternal is an internal helper that synchronizes a single project
+func syncProjectInternal(db *DB, p *parser.Parser, projectName string) (*SyncResult, error) {
+	// デフォルトロガーを使用
+	return syncProjectInternalWithLogger(db, p, projectName, logger.New())
+}
+
+// syncProjectInternalWithLogger is an internal helper that synchronizes a single project with custom logger
+func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string, log *logger.Logger) (*SyncResult, error) {
+	result := &SyncResult{}
+
+	log.DebugWithContext("Checking project in database", map[string]interface{}{
+		"project": projectName,
+	})
+
+	// プロジェクトをDBに登録（存在しない場合）
+	project, err := db.GetProjectByName(projectName)
+	if err != nil {
+		// プロジェクトが存在しない場合は作成
+		project, err = createProjectForSync(db, p, projectName, log)
+		if err != nil {
+			return nil, err
+		}
+		result.CreatedProjects = append(result.CreatedProjects, projectName)
 	} else if project.GitRoot == nil {
 		// 既存プロジェクトでGit Rootが未設定の場合、検出して更新
 		workingDir, err := p.GetProjectWorkingDirectory(projectName)
@@ -651,6 +828,12 @@ func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string,
 		"count":   result.SessionsFound,
 	})
 
+	// 再解析が必要になったセッションのファイル
+	reparseTargets, err := db.ListReparseTargets()
+	if err != nil {
+		return nil, err
+	}
+
 	// 各セッションを同期
 	for _, info := range sessionInfos {
 		log.DebugWithContext("Processing session", map[string]interface{}{
@@ -658,6 +841,26 @@ func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string,
 			"session_id": info.SessionID,
 		})
 
+		// 再解析が必要な場合はファイル全体を解析し直す
+		// （ファイル名とDBのセッションIDが異なる場合があるため、既存セッションの検索より先に判定する）
+		if reparseTargets.Contains(projectName, info) {
+			sessionID, _, err := syncSessionFile(db, p, projectName, info, true, log)
+			if err != nil {
+				errMsg := fmt.Sprintf("%s/%s: %v", projectName, info.SessionID, err)
+				log.ErrorWithContext("Failed to reparse session", map[string]interface{}{
+					"project":    projectName,
+					"session_id": info.SessionID,
+					"error":      err.Error(),
+				})
+				result.ErrorCount++
+				result.Errors = append(result.Errors, errMsg)
+				continue
+			}
+			result.UpdatedSessions = append(result.UpdatedSessions, SyncedSession{ProjectName: projectName, SessionID: sessionID})
+			result.SessionsSynced++
+			continue
+		}
+
 		// セッションが既にDBに存在するかチェック
 		existingSession, err := db.GetSession(info.SessionID)
 		if err == nil {
@@ -682,7 +885,7 @@ func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string,
 			"project":    projectName,
 			"session_id": info.SessionID,
 		})
-		session, err := p.ParseSession(projectName, info.SessionID)
+		session, err := p.ParseSessionFile(projectName, info)
 		if err != nil {
 			errMsg := fmt.Sprintf("%s/%s: %v", projectName, info.SessionID, err)
 			log.ErrorWithContext("Failed to parse session", map[string]interface{}{
@@ -726,6 +929,9 @@ func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string,
 			continue
 		}
 
+		saveSessionFilePosition(db, projectName, info, session, log)
+		result.CreatedSessions = append(result.CreatedSessions, SyncedSession{ProjectName: projectName, SessionID: session.ID})
+
 		log.DebugWithContext("Session synced successfully", map[string]interface{}{
 			"project":    projectName,
 			"session_id": info.SessionID,
@@ -748,6 +954,60 @@ func syncProjectInternalWithLogger(db *DB, p *parser.Parser, projectName string,
 	return result, nil
 }
 
+// createProjectForSync creates the project of a projects directory, detecting its git root
+func createProjectForSync(db *DB, p *parser.Parser, projectName string, log *logger.Logger) (*ProjectRow, error) {
+	log.InfoWithContext("Creating new project", map[string]interface{}{
+		"project": projectName,
+	})
+
+	decodedPath := parser.DecodeProjectPath(projectName)
+
+	// 実際の作業ディレクトリを取得
+	workingDir, err := p.GetProjectWorkingDirectory(projectName)
+	if err != nil {
+		// セッションが存在しない、またはcwdが取得できない
+		log.WarnWithContext("Could not get working directory", map[string]interface{}{
+			"project": projectName,
+			"error":   err.Error(),
+		})
+		_, err = db.CreateProject(projectName, decodedPath)
+	} else {
+		// 実際の作業ディレクトリでGit Root検出
+		gitRoot, gitErr := gitutil.DetectGitRoot(workingDir)
+		if gitErr != nil {
+			log.WarnWithContext("Failed to detect git root", map[string]interface{}{
+				"project": projectName,
+				"error":   gitErr.Error(),
+			})
+			_, err = db.CreateProject(projectName, decodedPath)
+		} else if gitRoot != "" {
+			_, err = db.CreateProjectWithGitRoot(projectName, decodedPath, gitRoot)
+		} else {
+			_, err = db.CreateProject(projectName, decodedPath)
+		}
+	}
+
+	if err != nil {
+		log.ErrorWithContext("Failed to create project", map[string]interface{}{
+			"project": projectName,
+			"error":   err.Error(),
+		})
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	// 作成したプロジェクトを取得
+	project, err := db.GetProjectByName(projectName)
+	if err != nil {
+		log.ErrorWithContext("Failed to get created project", map[string]interface{}{
+			"project": projectName,
+			"error":   err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get created project: %w", err)
+	}
+
+	return project, nil
+}
+
 // isUniqueConstraintError checks if the error is a UNIQUE constraint violation
 func isUniqueConstraintError(err error) bool {
 	if err == nil {
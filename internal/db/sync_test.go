@@ -214,6 +214,63 @@ func TestSyncProject(t *testing.T) {
 	})
 }
 
+func TestSyncSessionFiles(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	claudeDir := setupTestClaudeDir(t)
+	p := parser.NewParser(claudeDir)
+
+	t.Run("指定したセッションファイルのみ同期できる", func(t *testing.T) {
+		result, err := SyncSessionFiles(database, p, "test-project-1", []string{"session-1.jsonl"})
+		if err != nil {
+			t.Fatalf("SyncSessionFiles failed: %v", err)
+		}
+
+		if result.SessionsSynced != 1 {
+			t.Errorf("Expected 1 session synced, got %d", result.SessionsSynced)
+		}
+		if len(result.CreatedProjects) != 1 || result.CreatedProjects[0] != "test-project-1" {
+			t.Errorf("Expected test-project-1 to be created, got %v", result.CreatedProjects)
+		}
+		if len(result.CreatedSessions) != 1 || result.CreatedSessions[0].SessionID != "session-1" {
+			t.Errorf("Expected session-1 to be created, got %+v", result.CreatedSessions)
+		}
+
+		if _, err := database.GetSession("session-1"); err != nil {
+			t.Errorf("Expected session-1 to be stored: %v", err)
+		}
+		if _, err := database.GetSession("session-2"); err == nil {
+			t.Error("Expected session-2 not to be synced")
+		}
+	})
+
+	t.Run("同期済みのセッションは更新として扱われる", func(t *testing.T) {
+		result, err := SyncSessionFiles(database, p, "test-project-1", []string{"session-1.jsonl"})
+		if err != nil {
+			t.Fatalf("SyncSessionFiles failed: %v", err)
+		}
+
+		if len(result.CreatedProjects) != 0 || len(result.CreatedSessions) != 0 {
+			t.Errorf("Expected nothing to be created, got %v / %+v", result.CreatedProjects, result.CreatedSessions)
+		}
+		if len(result.UpdatedSessions) != 1 || result.UpdatedSessions[0].SessionID != "session-1" {
+			t.Errorf("Expected session-1 to be updated, got %+v", result.UpdatedSessions)
+		}
+	})
+
+	t.Run("存在しないファイルとセッション以外のファイルはスキップされる", func(t *testing.T) {
+		result, err := SyncSessionFiles(database, p, "test-project-1", []string{"deleted.jsonl", "notes.txt"})
+		if err != nil {
+			t.Fatalf("SyncSessionFiles failed: %v", err)
+		}
+
+		if result.SessionsSkipped != 2 || result.SessionsSynced != 0 || result.ErrorCount != 0 {
+			t.Errorf("Expected 2 skipped files, got %+v", result)
+		}
+	})
+}
+
 func TestSyncIncremental(t *testing.T) {
 	database, _ := setupTestDB(t)
 	defer database.Close()
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ToolStats represents per-tool usage statistics
+type ToolStats struct {
+	ToolName     string  `json:"toolName"`
+	CallCount    int     `json:"callCount"`
+	ErrorCount   int     `json:"errorCount"`
+	ErrorRate    float64 `json:"errorRate"`
+	P50LatencyMs int64   `json:"p50LatencyMs"`
+	P95LatencyMs int64   `json:"p95LatencyMs"`
+}
+
+// GetToolStats retrieves per-tool call counts, error rates and latency percentiles
+// projectID / groupID が指定された場合はそのプロジェクト・グループのセッションに、version が指定された場合はそのバージョンのセッションに絞り込む
+func (db *DB) GetToolStats(projectID, groupID *int64, version string) ([]ToolStats, error) {
+	query := `
+		SELECT tc.tool_name, tc.is_error AND tc.interruption_type IS NULL, tc.duration_ms
+		FROM tool_calls tc
+		INNER JOIN sessions s ON tc.session_id = s.id
+		WHERE 1 = 1
+	`
+
+	var args []interface{}
+	if projectID != nil {
+		query += " AND s.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if groupID != nil {
+		query += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *groupID)
+	}
+	if version != "" {
+		query += " AND s.cli_version = ?"
+		args = append(args, version)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool calls: %w", err)
+	}
+	defer rows.Close()
+
+	// ツール名ごとに集計（パーセンタイルはSQLiteで計算できないためGo側で算出）
+	type toolAggregate struct {
+		callCount  int
+		errorCount int
+		durations  []int64
+	}
+	aggregates := make(map[string]*toolAggregate)
+
+	for rows.Next() {
+		var toolName string
+		var isError bool
+		var durationMs sql.NullInt64
+
+		if err := rows.Scan(&toolName, &isError, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan tool call: %w", err)
+		}
+
+		agg, ok := aggregates[toolName]
+		if !ok {
+			agg = &toolAggregate{}
+			aggregates[toolName] = agg
+		}
+		agg.callCount++
+		if isError {
+			agg.errorCount++
+		}
+		if durationMs.Valid {
+			agg.durations = append(agg.durations, durationMs.Int64)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tool calls: %w", err)
+	}
+
+	stats := make([]ToolStats, 0, len(aggregates))
+	for toolName, agg := range aggregates {
+		sort.Slice(agg.durations, func(i, j int) bool { return agg.durations[i] < agg.durations[j] })
+
+		stats = append(stats, ToolStats{
+			ToolName:     toolName,
+			CallCount:    agg.callCount,
+			ErrorCount:   agg.errorCount,
+			ErrorRate:    float64(agg.errorCount) / float64(agg.callCount),
+			P50LatencyMs: percentile(agg.durations, 50),
+			P95LatencyMs: percentile(agg.durations, 95),
+		})
+	}
+
+	// 呼び出し回数の多い順（同数の場合はツール名順）
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].CallCount != stats[j].CallCount {
+			return stats[i].CallCount > stats[j].CallCount
+		}
+		return stats[i].ToolName < stats[j].ToolName
+	})
+
+	return stats, nil
+}
+
+// percentile returns the p-th percentile of sorted values using the nearest-rank method
+// Returns 0 for an empty slice.
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestGetToolStats(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	projectAID, err := db.CreateProject("tool-project-a", "/path/to/tool-project-a")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	projectBID, err := db.CreateProject("tool-project-b", "/path/to/tool-project-b")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	groupID, err := db.CreateProjectGroup("tool-group", nil)
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := db.AddProjectToGroup(projectBID, groupID); err != nil {
+		t.Fatalf("Failed to add project to group: %v", err)
+	}
+
+	// プロジェクトA: Bash x4（1件エラー、レイテンシ 100/200/300/1000ms）+ 結果未受信のRead x1
+	sessionA := createTestSession("tool-a")
+	base := sessionA.StartTime
+	sessionA.ToolCalls = []parser.ToolCall{
+		{ID: "t1", Timestamp: base, Name: "Bash", HasResult: true, Duration: 100 * time.Millisecond},
+		{ID: "t2", Timestamp: base, Name: "Bash", HasResult: true, Duration: 200 * time.Millisecond},
+		{ID: "t3", Timestamp: base, Name: "Bash", HasResult: true, Duration: 300 * time.Millisecond, IsError: true, Result: "exit 1"},
+		{ID: "t4", Timestamp: base, Name: "Bash", HasResult: true, Duration: 1000 * time.Millisecond},
+		{ID: "t5", Timestamp: base, Name: "Read"},
+	}
+	if err := db.CreateSession(sessionA, "tool-project-a", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// プロジェクトB: Edit x1
+	sessionB := createTestSession("tool-b")
+	sessionB.ToolCalls = []parser.ToolCall{
+		{ID: "t6", Timestamp: base, Name: "Edit", HasResult: true, Duration: 50 * time.Millisecond},
+	}
+	if err := db.CreateSession(sessionB, "tool-project-b", time.Now()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	t.Run("全体のツール統計を取得できる", func(t *testing.T) {
+		stats, err := db.GetToolStats(nil, nil, "")
+		if err != nil {
+			t.Fatalf("GetToolStats failed: %v", err)
+		}
+		if len(stats) != 3 {
+			t.Fatalf("Expected 3 tools, got %d", len(stats))
+		}
+
+		bash := stats[0]
+		if bash.ToolName != "Bash" {
+			t.Fatalf("Expected most used tool 'Bash', got '%s'", bash.ToolName)
+		}
+		if bash.CallCount != 4 || bash.ErrorCount != 1 {
+			t.Errorf("Expected 4 calls / 1 error, got %d / %d", bash.CallCount, bash.ErrorCount)
+		}
+		if bash.ErrorRate != 0.25 {
+			t.Errorf("Expected error rate 0.25, got %v", bash.ErrorRate)
+		}
+		if bash.P50LatencyMs != 200 {
+			t.Errorf("Expected p50 200ms, got %d", bash.P50LatencyMs)
+		}
+		if bash.P95LatencyMs != 1000 {
+			t.Errorf("Expected p95 1000ms, got %d", bash.P95LatencyMs)
+		}
+	})
+
+	t.Run("結果未受信のツールはレイテンシ0", func(t *testing.T) {
+		stats, err := db.GetToolStats(&projectAID, nil, "")
+		if err != nil {
+			t.Fatalf("GetToolStats failed: %v", err)
+		}
+		for _, s := range stats {
+			if s.ToolName == "Read" && (s.CallCount != 1 || s.P50LatencyMs != 0) {
+				t.Errorf("Unexpected Read stats: %+v", s)
+			}
+			if s.ToolName == "Edit" {
+				t.Error("Expected Edit to be excluded by project filter")
+			}
+		}
+	})
+
+	t.Run("グループで絞り込める", func(t *testing.T) {
+		stats, err := db.GetToolStats(nil, &groupID, "")
+		if err != nil {
+			t.Fatalf("GetToolStats failed: %v", err)
+		}
+		if len(stats) != 1 || stats[0].ToolName != "Edit" {
+			t.Fatalf("Expected only Edit, got %+v", stats)
+		}
+		if stats[0].P50LatencyMs != 50 {
+			t.Errorf("Expected p50 50ms, got %d", stats[0].P50LatencyMs)
+		}
+	})
+
+	t.Run("tool_use_idとレイテンシがセッションに保存される", func(t *testing.T) {
+		session, err := db.GetSession(sessionA.ID)
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		found := false
+		for _, tc := range session.ToolCalls {
+			if tc.ID == "t4" {
+				found = true
+				if !tc.HasResult || tc.Duration != time.Second {
+					t.Errorf("Expected 1s duration with result, got %+v", tc)
+				}
+			}
+			if tc.ID == "t5" && tc.HasResult {
+				t.Error("Expected t5 to have no result")
+			}
+		}
+		if !found {
+			t.Error("Expected tool call t4")
+		}
+	})
+}
@@ -15,36 +15,52 @@ type TotalStats struct {
 	TotalOutputTokens        int       `json:"totalOutputTokens"`
 	TotalCacheCreationTokens int       `json:"totalCacheCreationTokens"`
 	TotalCacheReadTokens     int       `json:"totalCacheReadTokens"`
+	EstimatedCostUSD         float64   `json:"estimatedCostUsd"`
 	AvgTokens                float64   `json:"avgTokens"`
 	FirstSession             time.Time `json:"firstSession"`
 	LastSession              time.Time `json:"lastSession"`
 	ErrorRate                float64   `json:"errorRate"`
+
+	// トップレベルのセッションの作業時間と待機時間
+	ActiveSeconds int `json:"activeSeconds"`
+	IdleSeconds   int `json:"idleSeconds"`
 }
 
 // GetTotalStats retrieves overall statistics across all projects
-func (db *DB) GetTotalStats() (*TotalStats, error) {
+// version が指定された場合はそのバージョンのセッションに絞り込む
+func (db *DB) GetTotalStats(version string) (*TotalStats, error) {
 	query := `
 		SELECT
 			(SELECT COUNT(DISTINCT id) FROM project_groups) as total_groups,
 			COUNT(DISTINCT p.id) as total_projects,
-			COUNT(s.id) as total_sessions,
+			COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END) as total_sessions,
 			COALESCE(SUM(s.total_input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(s.total_output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(s.total_cache_creation_tokens), 0) as total_cache_creation_tokens,
 			COALESCE(SUM(s.total_cache_read_tokens), 0) as total_cache_read_tokens,
-			COALESCE(AVG(s.total_input_tokens + s.total_output_tokens), 0) as avg_tokens,
+			COALESCE(SUM(s.total_cost_usd), 0) as total_cost_usd,
+			COALESCE(CAST(SUM(s.total_input_tokens + s.total_output_tokens) AS REAL) / NULLIF(COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END), 0), 0) as avg_tokens,
 			MIN(s.start_time) as first_session,
 			MAX(s.end_time) as last_session,
-			CAST(SUM(CASE WHEN s.error_count > 0 THEN 1 ELSE 0 END) AS REAL) / NULLIF(COUNT(s.id), 0) as error_rate
+			CAST(SUM(CASE WHEN s.parent_session_id IS NULL AND s.error_count > 0 THEN 1 ELSE 0 END) AS REAL) / NULLIF(COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END), 0) as error_rate,
+			COALESCE(SUM(CASE WHEN s.parent_session_id IS NULL THEN s.active_seconds END), 0) as active_seconds,
+			COALESCE(SUM(CASE WHEN s.parent_session_id IS NULL THEN s.idle_seconds END), 0) as idle_seconds
 		FROM projects p
 		LEFT JOIN sessions s ON p.id = s.project_id
 	`
 
+	// バージョンの条件はLEFT JOINに付け、該当セッションのないプロジェクトも数える
+	var args []interface{}
+	if version != "" {
+		query += " AND s.cli_version = ?"
+		args = append(args, version)
+	}
+
 	var stats TotalStats
 	var firstSessionStr, lastSessionStr sql.NullString
 	var errorRate sql.NullFloat64
 
-	err := db.conn.QueryRow(query).Scan(
+	err := db.conn.QueryRow(query, args...).Scan(
 		&stats.TotalGroups,
 		&stats.TotalProjects,
 		&stats.TotalSessions,
@@ -52,10 +68,13 @@ func (db *DB) GetTotalStats() (*TotalStats, error) {
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheCreationTokens,
 		&stats.TotalCacheReadTokens,
+		&stats.EstimatedCostUSD,
 		&stats.AvgTokens,
 		&firstSessionStr,
 		&lastSessionStr,
 		&errorRate,
+		&stats.ActiveSeconds,
+		&stats.IdleSeconds,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query total stats: %w", err)
@@ -93,77 +112,13 @@ func (db *DB) GetTotalStats() (*TotalStats, error) {
 // GetTotalTimeSeriesStats retrieves time-series statistics across all projects
 // period can be "day", "week", or "month"
 // limit specifies the maximum number of periods to return (default: 30)
-func (db *DB) GetTotalTimeSeriesStats(period string, limit int) ([]TimeSeriesStats, error) {
-	if limit <= 0 {
-		limit = 30
-	}
-
-	// 期間の検証
-	if period != "day" && period != "week" && period != "month" {
-		return nil, fmt.Errorf("invalid period: %s (must be day, week, or month)", period)
-	}
-
-	// 全プロジェクトのセッションを取得
-	query := `
-		SELECT
-			id, project_id, git_branch, start_time, end_time, duration_seconds,
-			total_input_tokens, total_output_tokens,
-			total_cache_creation_tokens, total_cache_read_tokens,
-			error_count, first_user_message, created_at, updated_at
-		FROM sessions
-		WHERE start_time > '0001-01-02'
-		ORDER BY start_time DESC
-	`
-
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query sessions: %w", err)
-	}
-	defer rows.Close()
-
-	var sessions []SessionRow
-	for rows.Next() {
-		var s SessionRow
-		var startTimeStr, endTimeStr, createdAtStr, updatedAtStr string
-		var firstUserMsg sql.NullString
-
-		err := rows.Scan(
-			&s.ID,
-			&s.ProjectID,
-			&s.GitBranch,
-			&startTimeStr,
-			&endTimeStr,
-			&s.DurationSeconds,
-			&s.TotalInputTokens,
-			&s.TotalOutputTokens,
-			&s.TotalCacheCreationTokens,
-			&s.TotalCacheReadTokens,
-			&s.ErrorCount,
-			&firstUserMsg,
-			&createdAtStr,
-			&updatedAtStr,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan session: %w", err)
-		}
-
-		s.StartTime, _ = time.Parse(time.RFC3339Nano, startTimeStr)
-		s.EndTime, _ = time.Parse(time.RFC3339Nano, endTimeStr)
-		s.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAtStr)
-		s.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAtStr)
-		if firstUserMsg.Valid {
-			s.FirstUserMessage = firstUserMsg.String
-		}
-
-		sessions = append(sessions, s)
+// version が指定された場合はそのバージョンのセッションだけを集計する
+func (db *DB) GetTotalTimeSeriesStats(period string, limit int, version string) ([]TimeSeriesStats, error) {
+	if version != "" {
+		return db.getVersionPeriodStatistics(period, limit, version, "1 = 1")
 	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating sessions: %w", err)
-	}
-
-	// セッションを日付ごとに展開して集約（project_stats.goのヘルパー関数を再利用）
-	return aggregateSessionsByPeriod(sessions, period, limit), nil
+	// 全プロジェクトの集計行（project_id IS NULL）を取得
+	return db.getPeriodStatistics(period, limit, "project_id IS NULL")
 }
 
 // DailyGroupStats represents statistics for a single group on a specific date
@@ -183,7 +138,7 @@ func (db *DB) GetDailyGroupStats(date string) ([]DailyGroupStats, error) {
 		SELECT
 			pg.id as group_id,
 			pg.name as group_name,
-			COUNT(s.id) as session_count,
+			COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END) as session_count,
 			COALESCE(SUM(s.total_input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(s.total_output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(s.total_cache_creation_tokens), 0) as total_cache_creation_tokens,
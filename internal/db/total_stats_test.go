@@ -16,7 +16,7 @@ func TestGetTotalStatsBasic(t *testing.T) {
 		db, _ := setupTestDB(t)
 		defer db.Close()
 
-		stats, err := db.GetTotalStats()
+		stats, err := db.GetTotalStats("")
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -46,7 +46,7 @@ func TestGetTotalStatsBasic(t *testing.T) {
 		defer db.Close()
 
 		// Get stats from empty database - should not error on NULL handling
-		stats, err := db.GetTotalStats()
+		stats, err := db.GetTotalStats("")
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -64,7 +64,7 @@ func TestGetTotalTimeSeriesStatsBasic(t *testing.T) {
 		db, _ := setupTestDB(t)
 		defer db.Close()
 
-		stats, err := db.GetTotalTimeSeriesStats("day", 10)
+		stats, err := db.GetTotalTimeSeriesStats("day", 10, "")
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -82,7 +82,7 @@ func TestGetTotalTimeSeriesStatsBasic(t *testing.T) {
 		testCases := []string{"day", "week", "month"}
 
 		for _, period := range testCases {
-			stats, err := db.GetTotalTimeSeriesStats(period, 10)
+			stats, err := db.GetTotalTimeSeriesStats(period, 10, "")
 
 			if err != nil {
 				t.Errorf("Period %s should not error, got %v", period, err)
@@ -103,7 +103,7 @@ func TestGetTotalTimeSeriesStatsBasic(t *testing.T) {
 		testCases := []int{1, 10, 30, 100}
 
 		for _, limit := range testCases {
-			stats, err := db.GetTotalTimeSeriesStats("day", limit)
+			stats, err := db.GetTotalTimeSeriesStats("day", limit, "")
 
 			if err != nil {
 				t.Errorf("Limit %d should not error, got %v", limit, err)
@@ -401,7 +401,7 @@ func TestGetTotalTimeSeriesStats_CrossMidnight(t *testing.T) {
 
 	t.Run("跨日セッションが各日に含まれる", func(t *testing.T) {
 		// 日別の時系列統計を取得
-		timeSeriesStats, err := db.GetTotalTimeSeriesStats("day", 30)
+		timeSeriesStats, err := db.GetTotalTimeSeriesStats("day", 30, "")
 		if err != nil {
 			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
 		}
@@ -462,7 +462,7 @@ func TestGetTotalTimeSeriesStats_CrossMidnight(t *testing.T) {
 
 	t.Run("週別集計で跨日セッションが正しく処理される", func(t *testing.T) {
 		// 週別の時系列統計を取得
-		weekStats, err := db.GetTotalTimeSeriesStats("week", 10)
+		weekStats, err := db.GetTotalTimeSeriesStats("week", 10, "")
 		if err != nil {
 			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
 		}
@@ -486,7 +486,7 @@ func TestGetTotalTimeSeriesStats_CrossMidnight(t *testing.T) {
 
 	t.Run("月別集計で跨日セッションが正しく処理される", func(t *testing.T) {
 		// 月別の時系列統計を取得
-		monthStats, err := db.GetTotalTimeSeriesStats("month", 10)
+		monthStats, err := db.GetTotalTimeSeriesStats("month", 10, "")
 		if err != nil {
 			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
 		}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// VersionStats represents session, token, error, retry and cost statistics of a Claude Code version
+// (or of a period in a version comparison)
+type VersionStats struct {
+	Version   string
+	FirstSeen time.Time // 最初のセッションの開始時刻
+	LastSeen  time.Time // 最後のセッションの終了時刻
+
+	Sessions            int // トップレベルのセッション数
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+	CostUSD             float64
+	AvgTokens           float64 // セッションあたりのトークン数（入力+出力）
+	ErrorCount          int
+	ErrorRate           float64 // エラーが発生したセッションの割合
+	Retries             int
+	RetriesPerSession   float64
+}
+
+// VersionComparison compares the sessions before and after a version first appeared
+type VersionComparison struct {
+	Version         string
+	PreviousVersion string // 直前に登場したバージョン（最初のバージョンの場合は空）
+	FirstSeen       time.Time
+	Days            int
+	Before          VersionStats // FirstSeenの前のDays日間に開始したセッション
+	After           VersionStats // FirstSeenから後のDays日間に開始したセッション
+}
+
+// updateSessionVersion records the Claude Code version of a session from its first log entry with a version
+// afterEntryID に 0 を渡すと全エントリから求める。それ以外はバージョンが未記録の場合だけ追記されたエントリから求める
+func updateSessionVersion(tx *sql.Tx, sessionID string, afterEntryID int64) error {
+	query := `
+		UPDATE sessions SET cli_version = (
+			SELECT le.version
+			FROM log_entries le
+			WHERE le.session_id = sessions.id AND le.id > ? AND COALESCE(le.version, '') != ''
+			ORDER BY le.timestamp, le.id
+			LIMIT 1
+		)
+		WHERE id = ?
+	`
+	if afterEntryID > 0 {
+		query += " AND cli_version IS NULL"
+	}
+
+	if _, err := tx.Exec(query, afterEntryID, sessionID); err != nil {
+		return fmt.Errorf("failed to update session version: %w", err)
+	}
+	return nil
+}
+
+// versionScopeWhere builds the condition selecting the sessions of a project or group
+func versionScopeWhere(projectID, groupID *int64) (string, []interface{}) {
+	where := " WHERE s.cli_version IS NOT NULL"
+	var args []interface{}
+	if projectID != nil {
+		where += " AND s.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if groupID != nil {
+		where += " AND s.project_id IN (SELECT project_id FROM project_group_mappings WHERE group_id = ?)"
+		args = append(args, *groupID)
+	}
+	return where, args
+}
+
+// queryVersionStats aggregates sessions grouped by groupExpr, ordered by the first session
+func (db *DB) queryVersionStats(groupExpr, where string, args []interface{}) ([]VersionStats, error) {
+	query := `
+		SELECT ` + groupExpr + ` as version,
+		       MIN(s.start_time), MAX(s.end_time),
+		       COUNT(CASE WHEN s.parent_session_id IS NULL THEN s.id END),
+		       COALESCE(SUM(s.total_input_tokens), 0), COALESCE(SUM(s.total_output_tokens), 0),
+		       COALESCE(SUM(s.total_cache_creation_tokens), 0), COALESCE(SUM(s.total_cache_read_tokens), 0),
+		       COALESCE(SUM(s.total_cost_usd), 0),
+		       COALESCE(SUM(s.error_count), 0),
+		       SUM(CASE WHEN s.parent_session_id IS NULL AND s.error_count > 0 THEN 1 ELSE 0 END),
+		       COALESCE(SUM(s.retry_count), 0)
+		FROM sessions s
+	` + where + `
+		GROUP BY version
+		ORDER BY MIN(julianday(s.start_time)), version
+	`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query version stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []VersionStats{}
+	for rows.Next() {
+		var stat VersionStats
+		var firstSeen, lastSeen string
+		var sessionsWithErrors int
+		err := rows.Scan(
+			&stat.Version, &firstSeen, &lastSeen, &stat.Sessions,
+			&stat.InputTokens, &stat.OutputTokens, &stat.CacheCreationTokens, &stat.CacheReadTokens,
+			&stat.CostUSD, &stat.ErrorCount, &sessionsWithErrors, &stat.Retries,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan version stats: %w", err)
+		}
+		stat.FirstSeen, _ = parseDateTime(firstSeen)
+		stat.LastSeen, _ = parseDateTime(lastSeen)
+		if stat.Sessions > 0 {
+			stat.AvgTokens = float64(stat.InputTokens+stat.OutputTokens) / float64(stat.Sessions)
+			stat.ErrorRate = float64(sessionsWithErrors) / float64(stat.Sessions)
+			stat.RetriesPerSession = float64(stat.Retries) / float64(stat.Sessions)
+		}
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating version stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetVersionStats retrieves statistics per Claude Code version, in the order the versions first appeared
+// projectID / groupID が指定された場合はそのプロジェクト・グループのセッションに絞り込む
+func (db *DB) GetVersionStats(projectID, groupID *int64) ([]VersionStats, error) {
+	where, args := versionScopeWhere(projectID, groupID)
+	return db.queryVersionStats("s.cli_version", where, args)
+}
+
+// GetVersionComparison compares the sessions started within days before and after a version first appeared
+// Both periods include the sessions of every version. Returns nil if the version has no sessions.
+func (db *DB) GetVersionComparison(version string, days int, projectID, groupID *int64) (*VersionComparison, error) {
+	versions, err := db.GetVersionStats(projectID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &VersionComparison{Version: version, Days: days}
+	found := false
+	for i, stat := range versions {
+		if stat.Version == version {
+			comparison.FirstSeen = stat.FirstSeen
+			if i > 0 {
+				comparison.PreviousVersion = versions[i-1].Version
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	window := time.Duration(days) * 24 * time.Hour
+	periods := []struct {
+		stats    *VersionStats
+		from, to time.Time
+	}{
+		{&comparison.Before, comparison.FirstSeen.Add(-window), comparison.FirstSeen},
+		{&comparison.After, comparison.FirstSeen, comparison.FirstSeen.Add(window)},
+	}
+	for _, period := range periods {
+		where, args := versionScopeWhere(projectID, groupID)
+		where += " AND julianday(s.start_time) >= julianday(?) AND julianday(s.start_time) < julianday(?)"
+		args = append(args, period.from.UTC().Format(time.RFC3339Nano), period.to.UTC().Format(time.RFC3339Nano))
+
+		stats, err := db.queryVersionStats("''", where, args)
+		if err != nil {
+			return nil, err
+		}
+		if len(stats) > 0 {
+			*period.stats = stats[0]
+		}
+		period.stats.Version = ""
+	}
+
+	return comparison, nil
+}
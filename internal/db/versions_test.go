@@ -0,0 +1,251 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestVersionStats(t *testing.T) {
+	database, _ := setupTestDB(t)
+	defer database.Close()
+
+	projectAID, err := database.CreateProject("version-project-a", "/path/to/version-project-a")
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if _, err := database.CreateProject("version-project-b", "/path/to/version-project-b"); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	create := func(suffix, projectName, version string, startTime time.Time, errorCount int) {
+		session := createTestSession(suffix)
+		session.StartTime = startTime
+		session.EndTime = startTime.Add(time.Hour)
+		for i := range session.Entries {
+			session.Entries[i].Version = version
+		}
+		session.ErrorCount = errorCount
+		if err := database.CreateSession(session, projectName, time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+
+	firstSeen := time.Date(2025, 6, 8, 10, 0, 0, 0, time.UTC)
+	create("v1-a", "version-project-a", "1.0.0", time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC), 1)
+	create("v1-b", "version-project-a", "1.0.0", time.Date(2025, 6, 5, 10, 0, 0, 0, time.UTC), 0)
+	create("v2-a", "version-project-a", "1.1.0", firstSeen, 1)
+	create("v2-b", "version-project-b", "1.1.0", firstSeen.Add(24*time.Hour), 1)
+
+	t.Run("バージョンごとに登場順で集計する", func(t *testing.T) {
+		stats, err := database.GetVersionStats(nil, nil)
+		if err != nil {
+			t.Fatalf("GetVersionStats failed: %v", err)
+		}
+		if len(stats) != 2 {
+			t.Fatalf("Expected 2 versions, got %+v", stats)
+		}
+
+		v1 := stats[0]
+		if v1.Version != "1.0.0" || v1.Sessions != 2 || v1.ErrorRate != 0.5 || v1.InputTokens != 200 || v1.AvgTokens != 150 {
+			t.Errorf("Unexpected 1.0.0 stats: %+v", v1)
+		}
+		if !v1.FirstSeen.Equal(time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected first seen: %v", v1.FirstSeen)
+		}
+		if stats[1].Version != "1.1.0" || stats[1].Sessions != 2 || stats[1].ErrorRate != 1 {
+			t.Errorf("Unexpected 1.1.0 stats: %+v", stats[1])
+		}
+	})
+
+	t.Run("プロジェクトで絞り込む", func(t *testing.T) {
+		stats, err := database.GetVersionStats(&projectAID, nil)
+		if err != nil {
+			t.Fatalf("GetVersionStats failed: %v", err)
+		}
+		if len(stats) != 2 || stats[1].Sessions != 1 {
+			t.Errorf("Unexpected project stats: %+v", stats)
+		}
+	})
+
+	t.Run("バージョン登場前後の期間を比較する", func(t *testing.T) {
+		comparison, err := database.GetVersionComparison("1.1.0", 7, nil, nil)
+		if err != nil {
+			t.Fatalf("GetVersionComparison failed: %v", err)
+		}
+		if comparison == nil {
+			t.Fatal("Expected comparison, got nil")
+		}
+		if comparison.PreviousVersion != "1.0.0" || !comparison.FirstSeen.Equal(firstSeen) {
+			t.Errorf("Unexpected comparison: %+v", comparison)
+		}
+		// 7日より前のセッションは含めない
+		if comparison.Before.Sessions != 1 || comparison.Before.ErrorRate != 0 {
+			t.Errorf("Unexpected before stats: %+v", comparison.Before)
+		}
+		if comparison.After.Sessions != 2 || comparison.After.ErrorRate != 1 {
+			t.Errorf("Unexpected after stats: %+v", comparison.After)
+		}
+	})
+
+	t.Run("存在しないバージョンはnil", func(t *testing.T) {
+		comparison, err := database.GetVersionComparison("9.9.9", 7, nil, nil)
+		if err != nil {
+			t.Fatalf("GetVersionComparison failed: %v", err)
+		}
+		if comparison != nil {
+			t.Errorf("Expected nil, got %+v", comparison)
+		}
+	})
+
+	t.Run("既存の統計をバージョンで絞り込める", func(t *testing.T) {
+		stats, err := database.GetToolStats(nil, nil, "1.1.0")
+		if err != nil {
+			t.Fatalf("GetToolStats failed: %v", err)
+		}
+		if len(stats) != 2 {
+			t.Fatalf("Expected 2 tools, got %+v", stats)
+		}
+		for _, stat := range stats {
+			if stat.CallCount != 2 {
+				t.Errorf("Expected 2 calls of %s, got %d", stat.ToolName, stat.CallCount)
+			}
+		}
+	})
+
+	groupID, err := database.CreateProjectGroup("version-group", nil)
+	if err != nil {
+		t.Fatalf("CreateProjectGroup failed: %v", err)
+	}
+	if err := database.AddProjectToGroup(projectAID, groupID); err != nil {
+		t.Fatalf("AddProjectToGroup failed: %v", err)
+	}
+
+	t.Run("全体・プロジェクト・グループの統計をバージョンで絞り込める", func(t *testing.T) {
+		total, err := database.GetTotalStats("1.1.0")
+		if err != nil {
+			t.Fatalf("GetTotalStats failed: %v", err)
+		}
+		if total.TotalSessions != 2 || total.ErrorRate != 1 || !total.FirstSession.Equal(firstSeen) {
+			t.Errorf("Unexpected total stats: %+v", total)
+		}
+
+		project, err := database.GetProjectStats(projectAID, "1.1.0")
+		if err != nil {
+			t.Fatalf("GetProjectStats failed: %v", err)
+		}
+		if project.TotalSessions != 1 || project.TotalInputTokens != 100 {
+			t.Errorf("Unexpected project stats: %+v", project)
+		}
+
+		group, err := database.GetGroupStats(groupID, "1.0.0")
+		if err != nil {
+			t.Fatalf("GetGroupStats failed: %v", err)
+		}
+		if group.TotalProjects != 1 || group.TotalSessions != 2 || group.ErrorRate != 0.5 {
+			t.Errorf("Unexpected group stats: %+v", group)
+		}
+
+		split, err := database.GetAgentTokenSplit(nil, nil, "1.0.0")
+		if err != nil {
+			t.Fatalf("GetAgentTokenSplit failed: %v", err)
+		}
+		if split.MainAgent.InputTokens != 200 || split.SubagentRuns != 0 {
+			t.Errorf("Unexpected agent token split: %+v", split)
+		}
+	})
+
+	t.Run("タイムラインをバージョンで絞り込める", func(t *testing.T) {
+		days, err := database.GetTotalTimeSeriesStats("day", 30, "1.0.0")
+		if err != nil {
+			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
+		}
+		if len(days) != 2 || days[0].SessionCount != 1 || !days[0].PeriodStart.Equal(time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected daily timeline: %+v", days)
+		}
+
+		// limitは新しい期間から数える
+		latest, err := database.GetTotalTimeSeriesStats("day", 1, "1.0.0")
+		if err != nil {
+			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
+		}
+		if len(latest) != 1 || !latest[0].PeriodStart.Equal(time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected limited timeline: %+v", latest)
+		}
+
+		months, err := database.GetTotalTimeSeriesStats("month", 30, "1.1.0")
+		if err != nil {
+			t.Fatalf("GetTotalTimeSeriesStats failed: %v", err)
+		}
+		if len(months) != 1 || months[0].SessionCount != 2 || months[0].TotalInputTokens != 200 || len(months[0].ModelStats) == 0 {
+			t.Errorf("Unexpected monthly timeline: %+v", months)
+		}
+
+		project, err := database.GetTimeSeriesStats(projectAID, "month", 30, "1.1.0")
+		if err != nil {
+			t.Fatalf("GetTimeSeriesStats failed: %v", err)
+		}
+		if len(project) != 1 || project[0].SessionCount != 1 {
+			t.Errorf("Unexpected project timeline: %+v", project)
+		}
+
+		group, err := database.GetGroupTimeSeriesStats(groupID, "month", 30, "1.0.0")
+		if err != nil {
+			t.Fatalf("GetGroupTimeSeriesStats failed: %v", err)
+		}
+		if len(group) != 2 || group[0].SessionCount != 1 || group[1].SessionCount != 1 {
+			t.Errorf("Unexpected group timeline: %+v", group)
+		}
+
+		if _, err := database.GetTotalTimeSeriesStats("year", 30, "1.0.0"); err == nil {
+			t.Error("Expected error for invalid period")
+		}
+	})
+	t.Run("追記ではバージョンが未記録の場合だけ記録する", func(t *testing.T) {
+		appendDB, _ := setupTestDB(t)
+		defer appendDB.Close()
+		if _, err := appendDB.CreateProject("version-append", "/path/to/version-append"); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+
+		session := createTestSession("version-append")
+		for i := range session.Entries {
+			session.Entries[i].Version = ""
+		}
+		if err := appendDB.CreateSession(session, "version-append", time.Now()); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+
+		appendVersion := func(uuid, version string, offset time.Duration) string {
+			t.Helper()
+			entry := session.Entries[len(session.Entries)-1]
+			entry.UUID = uuid
+			entry.Timestamp = session.EndTime.Add(offset)
+			entry.Version = version
+			entry.Message = nil
+			delta := &parser.Session{
+				ModelUsage: map[string]parser.TokenSummary{},
+				EndTime:    entry.Timestamp,
+				Entries:    []parser.LogEntry{entry},
+			}
+			if err := appendDB.AppendSession(session.ID, delta, "version-append", "version-append.jsonl", time.Now()); err != nil {
+				t.Fatalf("AppendSession failed: %v", err)
+			}
+
+			var cliVersion sql.NullString
+			if err := appendDB.conn.QueryRow("SELECT cli_version FROM sessions WHERE id = ?", session.ID).Scan(&cliVersion); err != nil {
+				t.Fatalf("QueryRow failed: %v", err)
+			}
+			return cliVersion.String
+		}
+
+		if got := appendVersion("version-append-1", "1.2.0", time.Minute); got != "1.2.0" {
+			t.Errorf("Expected version 1.2.0, got %q", got)
+		}
+		if got := appendVersion("version-append-2", "1.3.0", 2*time.Minute); got != "1.2.0" {
+			t.Errorf("Expected first version 1.2.0 to be kept, got %q", got)
+		}
+	})
+}
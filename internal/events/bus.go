@@ -0,0 +1,150 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+)
+
+// Event types
+const (
+	TypeSessionCreated = "session.created"
+	TypeSessionUpdated = "session.updated"
+	TypeScanProgress   = "scan.progress"
+	TypeProjectCreated = "project.created"
+)
+
+// subscriberBufferSize is the number of events buffered per subscriber
+// バッファが溢れた購読者へのイベントは破棄する（同期処理をブロックしないため）
+const subscriberBufferSize = 64
+
+// Event represents a single notification published on the bus
+type Event struct {
+	ID   uint64
+	Type string
+	Time time.Time
+	Data interface{} // JSONにシリアライズされるペイロード
+}
+
+// SessionEventData is the payload of session.created / session.updated events
+type SessionEventData struct {
+	ProjectName string `json:"projectName"`
+	SessionID   string `json:"sessionId"`
+}
+
+// ProjectEventData is the payload of project.created events
+type ProjectEventData struct {
+	ProjectName string `json:"projectName"`
+}
+
+// Bus is an in-process publish/subscribe hub for live update events
+// A nil *Bus is valid and discards all events.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	nextID      uint64
+	closed      bool
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber and returns its event channel and an unsubscribe function
+// The channel is closed by the unsubscribe function or by Close.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	b.mu.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Close closes all subscriber channels so that streaming connections end
+// サーバーのシャットダウン時に呼び出す
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// SubscriberCount returns the number of active subscribers
+func (b *Bus) SubscriberCount() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Publish sends an event to all subscribers without blocking
+func (b *Bus) Publish(eventType string, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := Event{
+		ID:   b.nextID,
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// 受信が追いつかない購読者へのイベントは破棄する
+		}
+	}
+}
+
+// PublishSyncResult publishes project.created / session.created / session.updated events for a sync result
+func (b *Bus) PublishSyncResult(result *db.SyncResult) {
+	if b == nil || result == nil {
+		return
+	}
+
+	for _, projectName := range result.CreatedProjects {
+		b.Publish(TypeProjectCreated, ProjectEventData{ProjectName: projectName})
+	}
+	for _, session := range result.CreatedSessions {
+		b.Publish(TypeSessionCreated, SessionEventData{ProjectName: session.ProjectName, SessionID: session.SessionID})
+	}
+	for _, session := range result.UpdatedSessions {
+		b.Publish(TypeSessionUpdated, SessionEventData{ProjectName: session.ProjectName, SessionID: session.SessionID})
+	}
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/a-tak/ccloganalysis/internal/db"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("購読者にイベントが配信される", func(t *testing.T) {
+		bus := NewBus()
+		ch, unsubscribe := bus.Subscribe()
+		defer unsubscribe()
+
+		bus.Publish(TypeSessionCreated, SessionEventData{ProjectName: "project", SessionID: "session-1"})
+
+		event := <-ch
+		if event.Type != TypeSessionCreated || event.ID != 1 {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+		data, ok := event.Data.(SessionEventData)
+		if !ok || data.SessionID != "session-1" {
+			t.Errorf("Unexpected event data: %+v", event.Data)
+		}
+	})
+
+	t.Run("受信が追いつかない購読者へのイベントは破棄される", func(t *testing.T) {
+		bus := NewBus()
+		ch, unsubscribe := bus.Subscribe()
+		defer unsubscribe()
+
+		for i := 0; i < subscriberBufferSize+10; i++ {
+			bus.Publish(TypeScanProgress, nil)
+		}
+		if len(ch) != subscriberBufferSize {
+			t.Errorf("Expected %d buffered events, got %d", subscriberBufferSize, len(ch))
+		}
+	})
+
+	t.Run("購読解除とCloseでチャネルが閉じられる", func(t *testing.T) {
+		bus := NewBus()
+		ch1, unsubscribe1 := bus.Subscribe()
+		ch2, unsubscribe2 := bus.Subscribe()
+		defer unsubscribe2()
+
+		unsubscribe1()
+		if _, ok := <-ch1; ok {
+			t.Error("Expected the unsubscribed channel to be closed")
+		}
+
+		bus.Close()
+		if _, ok := <-ch2; ok {
+			t.Error("Expected the channel to be closed by Close")
+		}
+		if bus.SubscriberCount() != 0 {
+			t.Errorf("Expected no subscribers, got %d", bus.SubscriberCount())
+		}
+
+		// Close後の購読は閉じたチャネルを返す
+		ch3, unsubscribe3 := bus.Subscribe()
+		defer unsubscribe3()
+		if _, ok := <-ch3; ok {
+			t.Error("Expected a closed channel after Close")
+		}
+	})
+
+	t.Run("nilのバスへの発行は無視される", func(t *testing.T) {
+		var bus *Bus
+		bus.Publish(TypeScanProgress, nil)
+		bus.PublishSyncResult(&db.SyncResult{CreatedProjects: []string{"project"}})
+		bus.Close()
+	})
+
+	t.Run("同期結果からプロジェクトとセッションのイベントを発行する", func(t *testing.T) {
+		bus := NewBus()
+		ch, unsubscribe := bus.Subscribe()
+		defer unsubscribe()
+
+		bus.PublishSyncResult(&db.SyncResult{
+			CreatedProjects: []string{"new-project"},
+			CreatedSessions: []db.SyncedSession{{ProjectName: "new-project", SessionID: "session-1"}},
+			UpdatedSessions: []db.SyncedSession{{ProjectName: "old-project", SessionID: "session-2"}},
+		})
+
+		expected := []string{TypeProjectCreated, TypeSessionCreated, TypeSessionUpdated}
+		for _, eventType := range expected {
+			event := <-ch
+			if event.Type != eventType {
+				t.Errorf("Expected %s, got %s", eventType, event.Type)
+			}
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package gitutil
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Commit represents a commit read from the history of a local repository
+type Commit struct {
+	Hash        string
+	Branch      string // コミットに到達したローカルブランチ（git log --source）
+	AuthorName  string
+	AuthorEmail string
+	Time        time.Time // 作成日時（リベースで変わらないauthor date）
+	Subject     string
+
+	// コミットメッセージに "Co-Authored-By: Claude ..." のトレーラーがあるか
+	CoAuthoredByClaude bool
+}
+
+// レコード・フィールドの区切り（コミットメッセージに含まれない制御文字）
+const (
+	commitRecordSeparator = "\x1e"
+	commitFieldSeparator  = "\x1f"
+)
+
+// IsGitAvailable reports whether the git command can be executed
+func IsGitAvailable() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// ReadCommits reads the non-merge commits of the local branches of a repository
+// authored within [since, until] (a zero time leaves that side unbounded), newest first.
+// Returns an empty list for a repository without commits.
+func ReadCommits(gitRoot string, since, until time.Time) ([]Commit, error) {
+	args := []string{
+		"-C", gitRoot, "log", "--branches", "--source", "--no-merges",
+		"--format=" + commitRecordSeparator + strings.Join([]string{"%H", "%S", "%an", "%ae", "%aI", "%s", "%b"}, commitFieldSeparator),
+	}
+	// --since/--untilはcommitter dateで絞り込むため、author dateでの絞り込みは読み込んだ後に行う
+	// リベースなどでcommitter dateはauthor dateより後になるので、--sinceだけで読み込む範囲を狭められる
+	if !since.IsZero() {
+		args = append(args, "--since="+since.Format(time.RFC3339))
+	}
+
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git log failed in %s: %s", gitRoot, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to run git log in %s: %w", gitRoot, err)
+	}
+
+	commits, err := parseCommitLog(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := commits[:0]
+	for _, commit := range commits {
+		if (!since.IsZero() && commit.Time.Before(since)) || (!until.IsZero() && commit.Time.After(until)) {
+			continue
+		}
+		filtered = append(filtered, commit)
+	}
+	return filtered, nil
+}
+
+// parseCommitLog parses the output of git log in the format used by ReadCommits
+func parseCommitLog(output string) ([]Commit, error) {
+	commits := []Commit{}
+	for _, record := range strings.Split(output, commitRecordSeparator) {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, commitFieldSeparator, 7)
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("invalid git log record: %q", record)
+		}
+
+		committedAt, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit date %q: %w", fields[4], err)
+		}
+
+		commits = append(commits, Commit{
+			Hash:               fields[0],
+			Branch:             strings.TrimPrefix(fields[1], "refs/heads/"),
+			AuthorName:         fields[2],
+			AuthorEmail:        fields[3],
+			Time:               committedAt,
+			Subject:            fields[5],
+			CoAuthoredByClaude: hasClaudeCoAuthor(fields[6]),
+		})
+	}
+	return commits, nil
+}
+
+// hasClaudeCoAuthor reports whether a commit body has a Co-Authored-By trailer naming Claude
+func hasClaudeCoAuthor(body string) bool {
+	const trailer = "co-authored-by:"
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if strings.HasPrefix(line, trailer) && strings.Contains(line[len(trailer):], "claude") {
+			return true
+		}
+	}
+	return false
+}
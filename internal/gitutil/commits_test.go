@@ -0,0 +1,153 @@
+package gitutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCommitLog(t *testing.T) {
+	t.Run("コミットとCo-Authored-Byトレーラーを解析する", func(t *testing.T) {
+		output := "\x1eabc123\x1frefs/heads/feature\x1fAlice\x1falice@example.com\x1f2025-06-01T10:00:00+09:00\x1fAdd parser\x1fDetails\n\nCo-Authored-By: Claude <noreply@anthropic.com>\n" +
+			"\x1edef456\x1frefs/heads/main\x1fBob\x1fbob@example.com\x1f2025-06-01T09:00:00Z\x1fFix typo\x1f\n"
+
+		commits, err := parseCommitLog(output)
+		if err != nil {
+			t.Fatalf("parseCommitLog failed: %v", err)
+		}
+		if len(commits) != 2 {
+			t.Fatalf("Expected 2 commits, got %+v", commits)
+		}
+
+		first := commits[0]
+		if first.Hash != "abc123" || first.Branch != "feature" || first.AuthorEmail != "alice@example.com" || first.Subject != "Add parser" {
+			t.Errorf("Unexpected commit: %+v", first)
+		}
+		if !first.Time.Equal(time.Date(2025, 6, 1, 1, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected commit time: %v", first.Time)
+		}
+		if !first.CoAuthoredByClaude {
+			t.Error("Expected Claude co-author")
+		}
+		if commits[1].CoAuthoredByClaude {
+			t.Error("Expected no Claude co-author")
+		}
+	})
+
+	t.Run("空の出力は空のリスト", func(t *testing.T) {
+		commits, err := parseCommitLog("")
+		if err != nil {
+			t.Fatalf("parseCommitLog failed: %v", err)
+		}
+		if len(commits) != 0 {
+			t.Errorf("Expected no commits, got %+v", commits)
+		}
+	})
+
+	t.Run("不正なレコードはエラー", func(t *testing.T) {
+		if _, err := parseCommitLog("\x1eabc123\x1fmain"); err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+}
+
+func TestReadCommits(t *testing.T) {
+	if !IsGitAvailable() {
+		t.Skip("git command not available")
+	}
+
+	repo := t.TempDir()
+	git := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	commit := func(date, file, message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, file), []byte(message), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		git(date, "add", file)
+		git(date, "commit", "-q", "-m", message)
+	}
+
+	git("2025-06-01T00:00:00Z", "init", "-q", "-b", "main")
+
+	t.Run("コミットがないリポジトリは空のリスト", func(t *testing.T) {
+		commits, err := ReadCommits(repo, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("ReadCommits failed: %v", err)
+		}
+		if len(commits) != 0 {
+			t.Errorf("Expected no commits, got %+v", commits)
+		}
+	})
+
+	commit("2025-06-01T10:00:00Z", "a.txt", "Initial commit")
+	git("2025-06-02T10:00:00Z", "checkout", "-q", "-b", "feature")
+	commit("2025-06-02T10:00:00Z", "b.txt", "Add feature\n\nCo-Authored-By: Claude <noreply@anthropic.com>")
+
+	t.Run("ローカルブランチのコミットを新しい順に読み込む", func(t *testing.T) {
+		commits, err := ReadCommits(repo, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("ReadCommits failed: %v", err)
+		}
+		if len(commits) != 2 {
+			t.Fatalf("Expected 2 commits, got %+v", commits)
+		}
+		if commits[0].Subject != "Add feature" || commits[0].Branch != "feature" || !commits[0].CoAuthoredByClaude {
+			t.Errorf("Unexpected commit: %+v", commits[0])
+		}
+		if commits[1].Subject != "Initial commit" || commits[1].CoAuthoredByClaude {
+			t.Errorf("Unexpected commit: %+v", commits[1])
+		}
+	})
+
+	t.Run("期間で絞り込む", func(t *testing.T) {
+		commits, err := ReadCommits(repo, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), time.Time{})
+		if err != nil {
+			t.Fatalf("ReadCommits failed: %v", err)
+		}
+		if len(commits) != 1 || commits[0].Subject != "Add feature" {
+			t.Errorf("Unexpected commits: %+v", commits)
+		}
+	})
+
+	t.Run("作成日時（author date）で絞り込む", func(t *testing.T) {
+		// リベースなどでcommitter dateだけが期間外になったコミット
+		cmd := exec.Command("git", "-C", repo, "commit", "-q", "--allow-empty", "-m", "Rebased commit")
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE=2025-06-03T10:00:00Z", "GIT_COMMITTER_DATE=2025-06-05T10:00:00Z",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git commit failed: %v\n%s", err, output)
+		}
+
+		commits, err := ReadCommits(repo, time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC), time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("ReadCommits failed: %v", err)
+		}
+		if len(commits) != 1 || commits[0].Subject != "Rebased commit" {
+			t.Errorf("Unexpected commits: %+v", commits)
+		}
+	})
+
+	t.Run("リポジトリでないディレクトリはエラー", func(t *testing.T) {
+		if _, err := ReadCommits(t.TempDir(), time.Time{}, time.Time{}); err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+}
@@ -2,23 +2,58 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+// MaxToolResultLength is the maximum number of characters kept from a tool_result
+const MaxToolResultLength = 2000
+
+// maxLineSize is the maximum size of a single JSONL line
+const maxLineSize = 10 * 1024 * 1024
+
+// ErrFileRewritten is returned by ParseFileFrom when the file no longer matches the parsed position
+// ファイルが切り詰められた・書き換えられた場合は全体を再解析する必要がある
+var ErrFileRewritten = errors.New("session file was truncated or rewritten")
+
 // Parser handles JSONL log file parsing
 type Parser struct {
 	claudeDir string
 }
 
+// subagentFilePrefix is the file name prefix of subagent transcripts
+const subagentFilePrefix = "agent-"
+
+// syntheticModel is the model name of assistant messages written by Claude Code itself (e.g. after an interruption)
+const syntheticModel = "<synthetic>"
+
+// Interruption notices written by Claude Code as user messages
+const (
+	interruptNotice     = "[Request interrupted by user]"
+	toolInterruptNotice = "[Request interrupted by user for tool use]"
+)
+
+// rejectionPrefixes are the tool_result texts written when the user rejects a tool use
+var rejectionPrefixes = []string{
+	"The user doesn't want to proceed with this tool use.",
+	"The user doesn't want to take this action right now.",
+}
+
 // SessionFileInfo holds session ID and file modification time
 type SessionFileInfo struct {
 	SessionID string
 	ModTime   time.Time
+
+	// プロジェクトディレクトリからの相対パス
+	// サブエージェントは "<sessionId>/subagents/agent-xxx.jsonl" に置かれる場合がある
+	RelPath string
 }
 
 // NewParser creates a new Parser instance
@@ -37,6 +72,11 @@ func GetDefaultClaudeDir() (string, error) {
 	return filepath.Join(homeDir, ".claude", "projects"), nil
 }
 
+// ClaudeDir returns the Claude projects directory
+func (p *Parser) ClaudeDir() string {
+	return p.claudeDir
+}
+
 // ListProjects returns all project directories
 func (p *Parser) ListProjects() ([]string, error) {
 	entries, err := os.ReadDir(p.claudeDir)
@@ -77,9 +117,10 @@ func (p *Parser) ListSessions(projectName string) ([]string, error) {
 		return nil, fmt.Errorf("failed to read project directory: %w", err)
 	}
 
+	// サブエージェントのトランスクリプトはトップレベルのセッションではないため除外
 	var sessions []string
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") && !IsSubagentFile(entry.Name()) {
 			sessions = append(sessions, strings.TrimSuffix(entry.Name(), ".jsonl"))
 		}
 	}
@@ -87,6 +128,7 @@ func (p *Parser) ListSessions(projectName string) ([]string, error) {
 }
 
 // ListSessionsWithModTime returns session IDs with file modification times
+// Subagent transcripts (agent-*.jsonl, including <sessionId>/subagents/) are included
 func (p *Parser) ListSessionsWithModTime(projectName string) ([]SessionFileInfo, error) {
 	projectDir := filepath.Join(p.claudeDir, projectName)
 	entries, err := os.ReadDir(projectDir)
@@ -96,7 +138,17 @@ func (p *Parser) ListSessionsWithModTime(projectName string) ([]SessionFileInfo,
 
 	var sessions []SessionFileInfo
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+		if entry.IsDir() {
+			// <sessionId>/subagents/ 配下のサブエージェントのトランスクリプト
+			subagents, err := listSubagentFiles(projectDir, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			sessions = append(sessions, subagents...)
+			continue
+		}
+
+		if strings.HasSuffix(entry.Name(), ".jsonl") {
 			info, err := entry.Info()
 			if err != nil {
 				return nil, fmt.Errorf("failed to get file info for %s: %w", entry.Name(), err)
@@ -105,106 +157,528 @@ func (p *Parser) ListSessionsWithModTime(projectName string) ([]SessionFileInfo,
 			sessions = append(sessions, SessionFileInfo{
 				SessionID: strings.TrimSuffix(entry.Name(), ".jsonl"),
 				ModTime:   info.ModTime(),
+				RelPath:   entry.Name(),
 			})
 		}
 	}
 	return sessions, nil
 }
 
+// listSubagentFiles returns the subagent transcripts stored under <projectDir>/<sessionDir>/subagents
+func listSubagentFiles(projectDir, sessionDir string) ([]SessionFileInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(projectDir, sessionDir, "subagents"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read subagents directory: %w", err)
+	}
+
+	var sessions []SessionFileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") || !IsSubagentFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file info for %s: %w", entry.Name(), err)
+		}
+
+		sessions = append(sessions, SessionFileInfo{
+			SessionID: strings.TrimSuffix(entry.Name(), ".jsonl"),
+			ModTime:   info.ModTime(),
+			RelPath:   filepath.Join(sessionDir, "subagents", entry.Name()),
+		})
+	}
+	return sessions, nil
+}
+
+// StatSessionFile returns the SessionFileInfo of a session file given by its path relative to the project directory
+// Only top-level session files and <sessionId>/subagents/agent-*.jsonl are accepted.
+func (p *Parser) StatSessionFile(projectName, relPath string) (SessionFileInfo, error) {
+	if !IsSessionFilePath(relPath) {
+		return SessionFileInfo{}, fmt.Errorf("not a session file: %s", relPath)
+	}
+
+	info, err := os.Stat(filepath.Join(p.claudeDir, projectName, relPath))
+	if err != nil {
+		return SessionFileInfo{}, fmt.Errorf("failed to get file info for %s: %w", relPath, err)
+	}
+
+	return SessionFileInfo{
+		SessionID: strings.TrimSuffix(filepath.Base(relPath), ".jsonl"),
+		ModTime:   info.ModTime(),
+		RelPath:   relPath,
+	}, nil
+}
+
+// IsSessionFilePath reports whether a path relative to the project directory is a session file
+// ListSessionsWithModTime と同じ配置（直下の .jsonl と <sessionId>/subagents/agent-*.jsonl）のみ対象
+func IsSessionFilePath(relPath string) bool {
+	if !strings.HasSuffix(relPath, ".jsonl") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	switch len(parts) {
+	case 1:
+		return true
+	case 3:
+		return parts[1] == "subagents" && IsSubagentFile(parts[2])
+	default:
+		return false
+	}
+}
+
+// IsSubagentFile reports whether a session file name belongs to a subagent transcript
+func IsSubagentFile(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), subagentFilePrefix)
+}
+
 // ParseSession parses a single session file
 func (p *Parser) ParseSession(projectName, sessionID string) (*Session, error) {
 	filePath := filepath.Join(p.claudeDir, projectName, sessionID+".jsonl")
 	return p.ParseFile(filePath)
 }
 
+// ParseSessionFile parses a session file listed by ListSessionsWithModTime
+func (p *Parser) ParseSessionFile(projectName string, info SessionFileInfo) (*Session, error) {
+	if info.RelPath == "" {
+		return p.ParseSession(projectName, info.SessionID)
+	}
+	return p.ParseFile(filepath.Join(p.claudeDir, projectName, info.RelPath))
+}
+
 // ParseFile parses a JSONL file and returns a Session
 func (p *Parser) ParseFile(filePath string) (*Session, error) {
+	return p.ParseFileFrom(filePath, FilePosition{})
+}
+
+// ParseSessionFileFrom parses the lines appended to a session file after pos
+func (p *Parser) ParseSessionFileFrom(projectName string, info SessionFileInfo, pos FilePosition) (*Session, error) {
+	relPath := info.RelPath
+	if relPath == "" {
+		relPath = info.SessionID + ".jsonl"
+	}
+	return p.ParseFileFrom(filepath.Join(p.claudeDir, projectName, relPath), pos)
+}
+
+// ParseFileFrom parses only the lines after pos and returns them as a Session
+// The returned Session holds the appended entries only, and its Position is where the next call should resume.
+// Returns ErrFileRewritten if the file was truncated or rewritten after pos was recorded.
+func (p *Parser) ParseFileFrom(filePath string, pos FilePosition) (*Session, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
+	if pos.Offset > 0 {
+		if err := verifyPosition(file, pos); err != nil {
+			return nil, err
+		}
+		if _, err := file.Seek(pos.Offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek file: %w", err)
+		}
+	}
+
 	session := &Session{
 		ModelUsage: make(map[string]TokenSummary),
+		Position:   pos,
 	}
 
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size for large lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 10*1024*1024) // 10MB max line size
+	// 前回の解析の続きから状態を引き継ぐ
+	state := newParseState()
+	state.lastModel = pos.LastModel
+	state.toolInterrupted = pos.ToolInterrupted
 
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		if line == "" {
-			continue
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		raw, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf("error reading file: %w", readErr)
 		}
-
-		var entry LogEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			// Parse error is returned by scanner.Err()
-			continue
+		if len(raw) > maxLineSize {
+			return nil, fmt.Errorf("error reading file: line exceeds %d bytes", maxLineSize)
 		}
+		// 改行で終わっていない行は書き込み途中の可能性がある
+		complete := readErr == nil
+
+		line := bytes.TrimRight(raw, "\r\n")
+		if len(line) > 0 {
+			var entry LogEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				if !complete {
+					// 書き込み途中の行は次回の解析に回す
+					break
+				}
+				// 不正な行はスキップ
+				session.Position.LastUUID = ""
+			} else {
+				session.addEntry(entry, state)
+				session.Position.LastUUID = entry.UUID
+			}
+		}
+		session.Position.Offset += int64(len(raw))
 
-		// Set session info from first entry
-		if session.ID == "" {
-			session.ID = entry.SessionID
-			session.ProjectPath = entry.Cwd
-			session.GitBranch = entry.GitBranch
-			session.StartTime = entry.Timestamp
+		if !complete {
+			break
 		}
-		session.EndTime = entry.Timestamp
+	}
+	session.Position.LastModel = state.lastModel
+	session.Position.ToolInterrupted = state.toolInterrupted
+
+	return session, nil
+}
+
+// parseState holds the lookup tables used while parsing a file
+type parseState struct {
+	// tool_use_id -> session.ToolCalls のインデックス（tool_resultとの対応付け用）
+	toolCallIndex map[string]int
+
+	// トークン使用量を集計済みのAPI応答のキー
+	countedUsage map[string]bool
+
+	// 直前に応答したモデル（中断された応答のモデルとして記録する）
+	lastModel string
+
+	// 直前のユーザーエントリでツールの拒否・キャンセルを記録したか
+	// 続けて書き込まれる中断通知（[Request interrupted by user for tool use]）を二重に数えないために使う
+	toolInterrupted bool
+}
+
+func newParseState() *parseState {
+	return &parseState{
+		toolCallIndex: make(map[string]int),
+		countedUsage:  make(map[string]bool),
+	}
+}
+
+// MessageUsageKey returns the key identifying the API response of an assistant entry
+// Returns an empty string if the entry has no message ID.
+func MessageUsageKey(entry LogEntry) string {
+	if entry.Message == nil || entry.Message.ID == "" {
+		return ""
+	}
+	return entry.Message.ID + ":" + entry.RequestID
+}
 
-		// Aggregate token usage for assistant messages
-		if entry.Type == "assistant" && entry.Message != nil && entry.Message.Usage != nil {
-			usage := entry.Message.Usage
-			model := entry.Message.Model
+// addEntry aggregates a log entry into the session
+func (session *Session) addEntry(entry LogEntry, state *parseState) {
+	// summaryエントリはタイムスタンプやセッションIDを持たないため、セッションの情報には使わない
+	if entry.Type == "summary" {
+		session.Entries = append(session.Entries, entry)
+		return
+	}
 
+	// Set session info from first entry
+	if session.ID == "" {
+		session.ID = entry.SessionID
+		session.ProjectPath = entry.Cwd
+		session.GitBranch = entry.GitBranch
+		session.StartTime = entry.Timestamp
+
+		// サブエージェントのトランスクリプトはsessionIdに親セッションのIDが入っている
+		if entry.IsSidechain && entry.AgentID != "" {
+			session.AgentID = entry.AgentID
+			session.ParentSessionID = entry.SessionID
+			session.ID = subagentFilePrefix + entry.AgentID
+		}
+	}
+	session.EndTime = entry.Timestamp
+
+	// Aggregate token usage for assistant messages
+	if entry.Type == "assistant" && entry.Message != nil && entry.Message.Usage != nil {
+		usage := entry.Message.Usage
+		model := entry.Message.Model
+		if model != syntheticModel {
+			state.lastModel = model
+		}
+		state.toolInterrupted = false
+
+		// 1つのAPI応答はコンテンツブロックごとに同じusageを持つ行として書き込まれるため、1回だけ集計する
+		key := MessageUsageKey(entry)
+		if key == "" || !state.countedUsage[key] {
 			// Update total tokens
-			session.TotalTokens.InputTokens += usage.InputTokens
-			session.TotalTokens.OutputTokens += usage.OutputTokens
-			session.TotalTokens.CacheCreationInputTokens += usage.CacheCreationInputTokens
-			session.TotalTokens.CacheReadInputTokens += usage.CacheReadInputTokens
+			session.TotalTokens.Add(usage)
 
 			// Update model-specific tokens
 			modelSummary := session.ModelUsage[model]
-			modelSummary.InputTokens += usage.InputTokens
-			modelSummary.OutputTokens += usage.OutputTokens
-			modelSummary.CacheCreationInputTokens += usage.CacheCreationInputTokens
-			modelSummary.CacheReadInputTokens += usage.CacheReadInputTokens
+			modelSummary.Add(usage)
 			session.ModelUsage[model] = modelSummary
 
-			// Extract tool calls
-			for _, content := range entry.Message.Content {
-				if content.Type == "tool_use" {
-					session.ToolCalls = append(session.ToolCalls, ToolCall{
-						Timestamp: entry.Timestamp,
-						Name:      content.Name,
-						Input:     content.Input,
-					})
+			if key != "" {
+				state.countedUsage[key] = true
+				var tokens TokenSummary
+				tokens.Add(usage)
+				session.MessageUsages = append(session.MessageUsages, MessageUsage{Key: key, Model: model, Tokens: tokens})
+			}
+		}
+
+		// Extract tool calls
+		for _, content := range entry.Message.Content {
+			if content.Type == "tool_use" {
+				if content.ID != "" {
+					state.toolCallIndex[content.ID] = len(session.ToolCalls)
 				}
+				session.ToolCalls = append(session.ToolCalls, ToolCall{
+					ID:        content.ID,
+					Timestamp: entry.Timestamp,
+					Name:      content.Name,
+					Input:     content.Input,
+					Model:     entry.Message.Model,
+				})
 			}
 		}
+	}
 
-		// Track tool results and errors
-		if entry.Type == "user" && entry.Message != nil {
-			for _, content := range entry.Message.Content {
-				if content.Type == "tool_result" && content.IsError {
-					session.ErrorCount++
+	// コンテキストの圧縮を記録
+	if entry.Type == "system" && entry.Subtype == "compact_boundary" {
+		compaction := Compaction{UUID: entry.UUID, Timestamp: entry.Timestamp}
+		if entry.CompactMetadata != nil {
+			compaction.Trigger = entry.CompactMetadata.Trigger
+			compaction.PreTokens = entry.CompactMetadata.PreTokens
+		}
+		session.Compactions = append(session.Compactions, compaction)
+	}
+
+	// Track tool results and errors
+	if entry.Type == "user" && entry.Message != nil {
+		for _, content := range entry.Message.Content {
+			if content.Type == "text" && !entry.IsMeta {
+				session.addInterruptionNotice(entry, content.Text, state)
+				continue
+			}
+			if content.Type != "tool_result" {
+				continue
+			}
+
+			fullResult := toolResultText(content.ToolResultContent)
+			result := truncateRunes(fullResult, MaxToolResultLength)
+			useResult := parseToolUseResult(entry.ToolUseResult)
+			stdout := truncateRunes(useResult.Stdout, MaxToolResultLength)
+			stderr := truncateRunes(useResult.Stderr, MaxToolResultLength)
+
+			// ユーザーによる拒否・キャンセルはツールのエラーとは区別して記録する
+			interruptionType := toolInterruptionType(fullResult, useResult)
+			if content.IsError && interruptionType == "" {
+				session.ErrorCount++
+			}
+
+			// 対応するtool_useに結果を紐付ける
+			idx, ok := state.toolCallIndex[content.ToolUseID]
+			if interruptionType != "" {
+				interruption := Interruption{
+					UUID:      entry.UUID,
+					Timestamp: entry.Timestamp,
+					Type:      interruptionType,
+					ToolUseID: content.ToolUseID,
 				}
+				if ok {
+					interruption.ToolName = session.ToolCalls[idx].Name
+					interruption.Model = session.ToolCalls[idx].Model
+				}
+				session.Interruptions = append(session.Interruptions, interruption)
+				state.toolInterrupted = true
+			}
+			if !ok {
+				session.UnmatchedToolResults = append(session.UnmatchedToolResults, ToolResult{
+					ToolUseID:   content.ToolUseID,
+					Timestamp:   entry.Timestamp,
+					IsError:     content.IsError,
+					Result:      result,
+					ResultSize:  len(fullResult),
+					AgentID:     useResult.AgentID,
+					Stdout:      stdout,
+					Stderr:      stderr,
+					Interrupted: useResult.Interrupted,
+
+					InterruptionType: interruptionType,
+				})
+				continue
+			}
+			toolCall := &session.ToolCalls[idx]
+			toolCall.HasResult = true
+			toolCall.IsError = content.IsError
+			toolCall.Result = result
+			toolCall.ResultSize = len(fullResult)
+			toolCall.AgentID = useResult.AgentID
+			toolCall.Stdout = stdout
+			toolCall.Stderr = stderr
+			toolCall.Interrupted = useResult.Interrupted
+			toolCall.InterruptionType = interruptionType
+			if duration := entry.Timestamp.Sub(toolCall.Timestamp); duration > 0 {
+				toolCall.Duration = duration
 			}
 		}
+	}
 
-		session.Entries = append(session.Entries, entry)
+	session.Entries = append(session.Entries, entry)
+}
+
+// addInterruptionNotice records an interruption if the user text is an interruption notice written by Claude Code
+func (session *Session) addInterruptionNotice(entry LogEntry, text string, state *parseState) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == interruptNotice:
+		session.Interruptions = append(session.Interruptions, Interruption{
+			UUID:      entry.UUID,
+			Timestamp: entry.Timestamp,
+			Type:      InterruptionTypeInterrupt,
+			Model:     state.lastModel,
+		})
+	case text == toolInterruptNotice:
+		// 拒否・キャンセルしたtool_resultの後に書き込まれる通知は、tool_result側で記録済み
+		if state.toolInterrupted {
+			state.toolInterrupted = false
+			return
+		}
+		session.Interruptions = append(session.Interruptions, Interruption{
+			UUID:      entry.UUID,
+			Timestamp: entry.Timestamp,
+			Type:      InterruptionTypeCancel,
+			Model:     state.lastModel,
+		})
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+// toolInterruptionType returns the interruption type of a tool result, or an empty string for ordinary results
+func toolInterruptionType(result string, useResult ToolUseResult) string {
+	result = strings.TrimSpace(result)
+	for _, prefix := range rejectionPrefixes {
+		if strings.HasPrefix(result, prefix) {
+			return InterruptionTypeRejection
+		}
+	}
+	if strings.HasPrefix(result, toolInterruptNotice) || useResult.Interrupted {
+		return InterruptionTypeCancel
 	}
+	return ""
+}
 
-	return session, nil
+// verifyPosition checks that the file still contains the content parsed up to pos
+func verifyPosition(file *os.File, pos FilePosition) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() < pos.Offset {
+		return ErrFileRewritten
+	}
+	if pos.LastUUID == "" {
+		return nil
+	}
+
+	// 解析済み範囲の最後の行が同じエントリか確認する
+	line, err := lastLineBefore(file, pos.Offset)
+	if err != nil {
+		return fmt.Errorf("failed to read parsed lines: %w", err)
+	}
+	var entry struct {
+		UUID string `json:"uuid"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil || entry.UUID != pos.LastUUID {
+		return ErrFileRewritten
+	}
+	return nil
+}
+
+// lastLineBefore returns the last non-empty line that ends at or before offset
+func lastLineBefore(file *os.File, offset int64) ([]byte, error) {
+	const chunkSize = 64 * 1024
+
+	var buf []byte
+	for end := offset; end > 0; {
+		start := end - chunkSize
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]byte, end-start)
+		if _, err := file.ReadAt(chunk, start); err != nil {
+			return nil, err
+		}
+		buf = append(chunk, buf...)
+		end = start
+
+		trimmed := bytes.TrimRight(buf, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if len(buf) > maxLineSize {
+			break
+		}
+	}
+	return bytes.TrimRight(buf, "\r\n"), nil
+}
+
+// Subtract removes the token counts of another summary
+func (t *TokenSummary) Subtract(other TokenSummary) {
+	t.InputTokens -= other.InputTokens
+	t.OutputTokens -= other.OutputTokens
+	t.CacheCreationInputTokens -= other.CacheCreationInputTokens
+	t.CacheReadInputTokens -= other.CacheReadInputTokens
+	t.CacheCreation5mInputTokens -= other.CacheCreation5mInputTokens
+	t.CacheCreation1hInputTokens -= other.CacheCreation1hInputTokens
+}
+
+// Add accumulates the token counts of a usage block
+// Cache creation tokens without a TTL breakdown are counted as 5m cache writes
+func (t *TokenSummary) Add(usage *Usage) {
+	t.InputTokens += usage.InputTokens
+	t.OutputTokens += usage.OutputTokens
+	t.CacheCreationInputTokens += usage.CacheCreationInputTokens
+	t.CacheReadInputTokens += usage.CacheReadInputTokens
+
+	if usage.CacheCreation != nil {
+		t.CacheCreation5mInputTokens += usage.CacheCreation.Ephemeral5mInputTokens
+		t.CacheCreation1hInputTokens += usage.CacheCreation.Ephemeral1hInputTokens
+	} else {
+		t.CacheCreation5mInputTokens += usage.CacheCreationInputTokens
+	}
+}
+
+// toolResultText extracts the text of a tool_result content (string or array of blocks)
+func toolResultText(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var texts []string
+		for _, item := range v {
+			block, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if text, ok := block["text"].(string); ok && text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	default:
+		return ""
+	}
+}
+
+// parseToolUseResult parses the toolUseResult of a tool_result entry
+// Only Task results carry an agentId, and only Bash results carry stdout, stderr and interrupted.
+func parseToolUseResult(raw json.RawMessage) ToolUseResult {
+	var result ToolUseResult
+	// toolUseResultはエラー時などに文字列になるため、オブジェクトの場合のみ解析する
+	if len(raw) == 0 || raw[0] != '{' {
+		return result
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return ToolUseResult{}
+	}
+	return result
+}
+
+// truncateRunes truncates a string to maxLen characters (rune-based for Japanese support)
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen])
 }
 
 // GetProjectWorkingDirectory returns the actual working directory for a project
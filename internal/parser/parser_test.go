@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -171,9 +174,143 @@ func TestParseFile_ToolCalls(t *testing.T) {
 		if toolCall.Name != "Bash" {
 			t.Errorf("Expected tool name 'Bash', got '%s'", toolCall.Name)
 		}
+		if toolCall.Model == "" {
+			t.Error("Expected tool call to record the model")
+		}
+	}
+}
+
+func TestParseFile_ToolResultPairing(t *testing.T) {
+	parser := NewParser(".")
+
+	t.Run("tool_resultの結果とレイテンシが紐付けられる", func(t *testing.T) {
+		session, err := parser.ParseFile(filepath.Join("testdata", "sample_session.jsonl"))
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		if len(session.ToolCalls) != 1 {
+			t.Fatalf("Expected 1 tool call, got %d", len(session.ToolCalls))
+		}
+
+		toolCall := session.ToolCalls[0]
+		if toolCall.ID != "toolu_001" {
+			t.Errorf("Expected tool use ID 'toolu_001', got '%s'", toolCall.ID)
+		}
+		if !toolCall.HasResult {
+			t.Fatal("Expected tool call to have a result")
+		}
+		if toolCall.Result != "file1.txt\nfile2.txt" {
+			t.Errorf("Unexpected result: %q", toolCall.Result)
+		}
+		if toolCall.ResultSize != len("file1.txt\nfile2.txt") {
+			t.Errorf("Unexpected result size: %d", toolCall.ResultSize)
+		}
+		if toolCall.IsError {
+			t.Error("Expected IsError to be false")
+		}
+		if toolCall.Duration != 5*time.Second {
+			t.Errorf("Expected duration 5s, got %v", toolCall.Duration)
+		}
+	})
+
+	t.Run("エラー結果はIsErrorが設定される", func(t *testing.T) {
+		session, err := parser.ParseFile(filepath.Join("testdata", "session_with_error.jsonl"))
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		if len(session.ToolCalls) != 1 {
+			t.Fatalf("Expected 1 tool call, got %d", len(session.ToolCalls))
+		}
+
+		toolCall := session.ToolCalls[0]
+		if !toolCall.IsError {
+			t.Error("Expected IsError to be true")
+		}
+		if !strings.Contains(toolCall.Result, "No such file or directory") {
+			t.Errorf("Unexpected result: %q", toolCall.Result)
+		}
+	})
+}
+
+func TestParseFile_Subagents(t *testing.T) {
+	parser := NewParser(".")
+
+	t.Run("Taskのtool_resultからサブエージェントIDを取得できる", func(t *testing.T) {
+		session, err := parser.ParseFile(filepath.Join("testdata", "subagent_parent_session.jsonl"))
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		if session.ID != "parent-session-001" || session.AgentID != "" || session.ParentSessionID != "" {
+			t.Errorf("Unexpected top-level session metadata: id=%s agent=%s parent=%s", session.ID, session.AgentID, session.ParentSessionID)
+		}
+		if len(session.ToolCalls) != 1 {
+			t.Fatalf("Expected 1 tool call, got %d", len(session.ToolCalls))
+		}
+		if session.ToolCalls[0].AgentID != "a1b2c3d" {
+			t.Errorf("Expected agent ID 'a1b2c3d', got '%s'", session.ToolCalls[0].AgentID)
+		}
+	})
+
+	t.Run("サブエージェントのトランスクリプトは親セッションに紐付けられる", func(t *testing.T) {
+		session, err := parser.ParseFile(filepath.Join("testdata", "agent-a1b2c3d.jsonl"))
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		if session.ID != "agent-a1b2c3d" {
+			t.Errorf("Expected session ID 'agent-a1b2c3d', got '%s'", session.ID)
+		}
+		if session.AgentID != "a1b2c3d" {
+			t.Errorf("Expected agent ID 'a1b2c3d', got '%s'", session.AgentID)
+		}
+		if session.ParentSessionID != "parent-session-001" {
+			t.Errorf("Expected parent session 'parent-session-001', got '%s'", session.ParentSessionID)
+		}
+		if session.TotalTokens.InputTokens != 1000 || session.TotalTokens.OutputTokens != 200 {
+			t.Errorf("Unexpected tokens: %+v", session.TotalTokens)
+		}
+	})
+}
+
+func TestParseToolUseResult(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want ToolUseResult
+	}{
+		{"Taskの結果", `{"status":"completed","agentId":"abc"}`, ToolUseResult{AgentID: "abc"}},
+		{"Bashの結果", `{"stdout":"ok","stderr":"warn","interrupted":true,"isImage":false}`, ToolUseResult{Stdout: "ok", Stderr: "warn", Interrupted: true}},
+		{"文字列", `"Error: permission denied"`, ToolUseResult{}},
+		{"空", ``, ToolUseResult{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseToolUseResult([]byte(tt.raw)); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
 	}
 }
 
+func TestToolResultText(t *testing.T) {
+	t.Run("配列形式のcontentからテキストを抽出する", func(t *testing.T) {
+		content := []interface{}{
+			map[string]interface{}{"type": "text", "text": "line1"},
+			map[string]interface{}{"type": "image"},
+			map[string]interface{}{"type": "text", "text": "line2"},
+		}
+		if got := toolResultText(content); got != "line1\nline2" {
+			t.Errorf("Expected 'line1\\nline2', got %q", got)
+		}
+	})
+
+	t.Run("長い結果は切り詰められる", func(t *testing.T) {
+		long := strings.Repeat("あ", MaxToolResultLength+10)
+		if got := truncateRunes(long, MaxToolResultLength); len([]rune(got)) != MaxToolResultLength {
+			t.Errorf("Expected %d runes, got %d", MaxToolResultLength, len([]rune(got)))
+		}
+	})
+}
+
 func TestParseFile_ErrorCount(t *testing.T) {
 	testFile := filepath.Join("testdata", "session_with_error.jsonl")
 	parser := NewParser(".")
@@ -556,6 +693,76 @@ func TestListSessionsWithModTime_NonJsonlFilesIgnored(t *testing.T) {
 	}
 }
 
+func TestListSessionsWithModTime_Subagents(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude", "projects")
+	projectDir := filepath.Join(claudeDir, "test-project")
+	nestedDir := filepath.Join(projectDir, "session-1", "subagents")
+	if err := os.MkdirAll(nestedDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	// トップレベルのセッション、旧形式（同じディレクトリ）と新形式（<sessionId>/subagents/）のサブエージェント
+	for _, path := range []string{
+		filepath.Join(projectDir, "session-1.jsonl"),
+		filepath.Join(projectDir, "agent-aaa.jsonl"),
+		filepath.Join(nestedDir, "agent-bbb.jsonl"),
+	} {
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	parser := NewParser(claudeDir)
+
+	sessionInfos, err := parser.ListSessionsWithModTime("test-project")
+	if err != nil {
+		t.Fatalf("ListSessionsWithModTime failed: %v", err)
+	}
+
+	relPaths := make(map[string]string)
+	for _, info := range sessionInfos {
+		relPaths[info.SessionID] = info.RelPath
+	}
+	if len(relPaths) != 3 {
+		t.Fatalf("Expected 3 session files, got %v", relPaths)
+	}
+	if relPaths["agent-bbb"] != filepath.Join("session-1", "subagents", "agent-bbb.jsonl") {
+		t.Errorf("Unexpected relative path for nested subagent: %s", relPaths["agent-bbb"])
+	}
+
+	// ListSessionsはトップレベルのセッションのみ返す
+	sessions, err := parser.ListSessions("test-project")
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0] != "session-1" {
+		t.Errorf("Expected only session-1, got %v", sessions)
+	}
+}
+
+func TestIsSessionFilePath(t *testing.T) {
+	tests := []struct {
+		relPath  string
+		expected bool
+	}{
+		{"session-1.jsonl", true},
+		{"agent-aaa.jsonl", true},
+		{filepath.Join("session-1", "subagents", "agent-bbb.jsonl"), true},
+		{filepath.Join("session-1", "subagents", "other.jsonl"), false},
+		{filepath.Join("session-1", "agent-bbb.jsonl"), false},
+		{"notes.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			if got := IsSessionFilePath(tt.relPath); got != tt.expected {
+				t.Errorf("IsSessionFilePath(%q) = %v, want %v", tt.relPath, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestListSessionsWithModTime_EmptyDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	claudeDir := filepath.Join(tmpDir, ".claude", "projects")
@@ -589,3 +796,301 @@ func TestListSessionsWithModTime_NonExistentProject(t *testing.T) {
 		t.Error("Expected error for non-existent project, got nil")
 	}
 }
+
+func TestParseFile_CacheCreationBreakdown(t *testing.T) {
+	testFile := filepath.Join("testdata", "cache_ttl_session.jsonl")
+	parser := NewParser(".")
+
+	session, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// 内訳あり（5m:1000, 1h:2000）+ 内訳なし（400は5mとして扱う）
+	if session.TotalTokens.CacheCreationInputTokens != 3400 {
+		t.Errorf("Expected cache creation tokens 3400, got %d", session.TotalTokens.CacheCreationInputTokens)
+	}
+	if session.TotalTokens.CacheCreation5mInputTokens != 1400 {
+		t.Errorf("Expected 5m cache creation tokens 1400, got %d", session.TotalTokens.CacheCreation5mInputTokens)
+	}
+	if session.TotalTokens.CacheCreation1hInputTokens != 2000 {
+		t.Errorf("Expected 1h cache creation tokens 2000, got %d", session.TotalTokens.CacheCreation1hInputTokens)
+	}
+
+	modelUsage := session.ModelUsage["claude-sonnet-4-5-20250929"]
+	if modelUsage.CacheCreation1hInputTokens != 2000 {
+		t.Errorf("Expected model 1h cache creation tokens 2000, got %d", modelUsage.CacheCreation1hInputTokens)
+	}
+}
+
+func TestParseFile_DeduplicatesStreamedUsage(t *testing.T) {
+	testFile := filepath.Join("testdata", "streamed_session.jsonl")
+	parser := NewParser(".")
+
+	session, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	t.Run("同じAPI応答のusageは1回だけ集計される", func(t *testing.T) {
+		// msg_01（3行）は1回、msg_02は1回
+		if session.TotalTokens.InputTokens != 110 || session.TotalTokens.OutputTokens != 55 {
+			t.Errorf("Expected 110 / 55 tokens, got %d / %d", session.TotalTokens.InputTokens, session.TotalTokens.OutputTokens)
+		}
+		if session.TotalTokens.CacheCreationInputTokens != 200 || session.TotalTokens.CacheReadInputTokens != 800 {
+			t.Errorf("Expected 200 / 800 cache tokens, got %d / %d",
+				session.TotalTokens.CacheCreationInputTokens, session.TotalTokens.CacheReadInputTokens)
+		}
+		modelUsage := session.ModelUsage["claude-sonnet-4-5-20250929"]
+		if modelUsage.InputTokens != 110 {
+			t.Errorf("Expected model input tokens 110, got %d", modelUsage.InputTokens)
+		}
+	})
+
+	t.Run("集計したAPI応答が記録される", func(t *testing.T) {
+		if len(session.MessageUsages) != 2 {
+			t.Fatalf("Expected 2 message usages, got %+v", session.MessageUsages)
+		}
+		if session.MessageUsages[0].Key != "msg_01:req_01" || session.MessageUsages[0].Tokens.InputTokens != 100 {
+			t.Errorf("Unexpected message usage: %+v", session.MessageUsages[0])
+		}
+	})
+
+	t.Run("重複したusageの行のツール呼び出しも抽出される", func(t *testing.T) {
+		if len(session.ToolCalls) != 1 || session.ToolCalls[0].ID != "toolu_streamed" || !session.ToolCalls[0].HasResult {
+			t.Errorf("Unexpected tool calls: %+v", session.ToolCalls)
+		}
+		if len(session.Entries) != 6 {
+			t.Errorf("Expected 6 entries, got %d", len(session.Entries))
+		}
+	})
+}
+
+func TestParseFile_Compaction(t *testing.T) {
+	testFile := filepath.Join("testdata", "compacted_session.jsonl")
+	parser := NewParser(".")
+
+	session, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	t.Run("compact_boundaryから圧縮を記録する", func(t *testing.T) {
+		if len(session.Compactions) != 1 {
+			t.Fatalf("Expected 1 compaction, got %+v", session.Compactions)
+		}
+		compaction := session.Compactions[0]
+		if compaction.UUID != "c-uuid-3" || compaction.Trigger != "auto" || compaction.PreTokens != 155060 {
+			t.Errorf("Unexpected compaction: %+v", compaction)
+		}
+		if !session.Entries[4].IsCompactSummary {
+			t.Error("Expected the continued message to be marked as a compact summary")
+		}
+	})
+
+	t.Run("summaryエントリはセッションの情報に影響しない", func(t *testing.T) {
+		if session.ID != "test-session-compacted" {
+			t.Errorf("Expected session ID test-session-compacted, got %s", session.ID)
+		}
+		expectedStart := time.Date(2026, 1, 14, 9, 0, 0, 0, time.UTC)
+		if !session.StartTime.Equal(expectedStart) {
+			t.Errorf("Expected start time %v, got %v", expectedStart, session.StartTime)
+		}
+		if session.Entries[0].Summary != "Build fix and refactoring" || session.Entries[0].LeafUUID != "c-uuid-2" {
+			t.Errorf("Unexpected summary entry: %+v", session.Entries[0])
+		}
+	})
+}
+
+func TestParseFile_Interruptions(t *testing.T) {
+	testFile := filepath.Join("testdata", "interrupted_session.jsonl")
+	parser := NewParser(".")
+
+	session, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	t.Run("中断・拒否・キャンセルを区別して記録する", func(t *testing.T) {
+		want := []Interruption{
+			{UUID: "i-uuid-3", Type: InterruptionTypeInterrupt, Model: "claude-sonnet-4-5"},
+			{UUID: "i-uuid-6", Type: InterruptionTypeRejection, ToolUseID: "toolu_i1", ToolName: "Edit", Model: "claude-opus-4-1"},
+			{UUID: "i-uuid-9", Type: InterruptionTypeCancel, ToolUseID: "toolu_i2", ToolName: "Bash", Model: "claude-opus-4-1"},
+			// tool_resultを伴わない中断通知は、直前に応答したモデルのキャンセルとして記録する（<synthetic>は除く）
+			{UUID: "i-uuid-11", Type: InterruptionTypeCancel, Model: "claude-opus-4-1"},
+		}
+		if len(session.Interruptions) != len(want) {
+			t.Fatalf("Expected %d interruptions, got %+v", len(want), session.Interruptions)
+		}
+		for i, w := range want {
+			got := session.Interruptions[i]
+			got.Timestamp = time.Time{}
+			if got != w {
+				t.Errorf("Interruption %d: got %+v, want %+v", i, got, w)
+			}
+		}
+	})
+
+	t.Run("拒否・キャンセルはエラーに数えない", func(t *testing.T) {
+		if session.ErrorCount != 1 {
+			t.Errorf("Expected 1 error, got %d", session.ErrorCount)
+		}
+		// ツール呼び出しの結果はそのまま記録する
+		if !session.ToolCalls[0].IsError || !session.ToolCalls[1].Interrupted {
+			t.Errorf("Unexpected tool calls: %+v", session.ToolCalls)
+		}
+		if session.ToolCalls[0].InterruptionType != InterruptionTypeRejection || session.ToolCalls[1].InterruptionType != InterruptionTypeCancel {
+			t.Errorf("Unexpected interruption types: %q / %q", session.ToolCalls[0].InterruptionType, session.ToolCalls[1].InterruptionType)
+		}
+	})
+}
+
+func TestParseFileFrom(t *testing.T) {
+	parser := NewParser(".")
+
+	firstLines := `{"type":"user","timestamp":"2025-01-01T10:00:00Z","sessionId":"tail-session","uuid":"uuid-1","message":{"role":"user","content":[{"type":"text","text":"List files"}]}}
+{"type":"assistant","timestamp":"2025-01-01T10:00:02Z","sessionId":"tail-session","uuid":"uuid-2","message":{"model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"tool_use","id":"toolu_tail","name":"Bash","input":{"command":"ls"}}],"usage":{"input_tokens":100,"output_tokens":20}}}
+`
+	appendedLines := `{"type":"user","timestamp":"2025-01-01T10:00:05Z","sessionId":"tail-session","uuid":"uuid-3","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_tail","content":"a.txt","is_error":true}]}}
+{"type":"assistant","timestamp":"2025-01-01T10:00:07Z","sessionId":"tail-session","uuid":"uuid-4","message":{"model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":200,"output_tokens":30}}}
+`
+
+	writeFile := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "tail-session.jsonl")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		return path
+	}
+
+	t.Run("全体の解析で解析位置が記録される", func(t *testing.T) {
+		path := writeFile(t, firstLines)
+		session, err := parser.ParseFile(path)
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		if session.Position.Offset != int64(len(firstLines)) {
+			t.Errorf("Expected offset %d, got %d", len(firstLines), session.Position.Offset)
+		}
+		if session.Position.LastUUID != "uuid-2" {
+			t.Errorf("Expected last UUID 'uuid-2', got '%s'", session.Position.LastUUID)
+		}
+	})
+
+	t.Run("追記された行だけを解析する", func(t *testing.T) {
+		path := writeFile(t, firstLines+appendedLines)
+		pos := FilePosition{Offset: int64(len(firstLines)), LastUUID: "uuid-2"}
+
+		delta, err := parser.ParseFileFrom(path, pos)
+		if err != nil {
+			t.Fatalf("ParseFileFrom failed: %v", err)
+		}
+		if len(delta.Entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(delta.Entries))
+		}
+		if delta.TotalTokens.InputTokens != 200 || delta.TotalTokens.OutputTokens != 30 {
+			t.Errorf("Unexpected tokens: %+v", delta.TotalTokens)
+		}
+		if delta.ErrorCount != 1 {
+			t.Errorf("Expected error count 1, got %d", delta.ErrorCount)
+		}
+		if delta.Position.Offset != int64(len(firstLines+appendedLines)) || delta.Position.LastUUID != "uuid-4" {
+			t.Errorf("Unexpected position: %+v", delta.Position)
+		}
+
+		// 解析済み範囲のtool_useへの結果は未対応として返される
+		if len(delta.UnmatchedToolResults) != 1 {
+			t.Fatalf("Expected 1 unmatched tool result, got %d", len(delta.UnmatchedToolResults))
+		}
+		result := delta.UnmatchedToolResults[0]
+		if result.ToolUseID != "toolu_tail" || !result.IsError || result.Result != "a.txt" || result.ResultSize != 5 {
+			t.Errorf("Unexpected tool result: %+v", result)
+		}
+	})
+
+	t.Run("書き込み途中の行は次回に回す", func(t *testing.T) {
+		partial := `{"type":"user","timestamp":"2025-01-01T10:00:09Z","sessionId":"tail-se`
+		path := writeFile(t, firstLines+partial)
+
+		session, err := parser.ParseFile(path)
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		if len(session.Entries) != 2 {
+			t.Errorf("Expected 2 entries, got %d", len(session.Entries))
+		}
+		if session.Position.Offset != int64(len(firstLines)) {
+			t.Errorf("Expected offset %d, got %d", len(firstLines), session.Position.Offset)
+		}
+	})
+
+	t.Run("切り詰められたファイルはErrFileRewrittenを返す", func(t *testing.T) {
+		path := writeFile(t, firstLines)
+		pos := FilePosition{Offset: int64(len(firstLines + appendedLines)), LastUUID: "uuid-4"}
+
+		if _, err := parser.ParseFileFrom(path, pos); !errors.Is(err, ErrFileRewritten) {
+			t.Errorf("Expected ErrFileRewritten, got %v", err)
+		}
+	})
+
+	t.Run("書き換えられたファイルはErrFileRewrittenを返す", func(t *testing.T) {
+		rewritten := strings.ReplaceAll(firstLines+appendedLines, "uuid-2", "uuid-x")
+		path := writeFile(t, rewritten)
+		pos := FilePosition{Offset: int64(len(firstLines)), LastUUID: "uuid-2"}
+
+		if _, err := parser.ParseFileFrom(path, pos); !errors.Is(err, ErrFileRewritten) {
+			t.Errorf("Expected ErrFileRewritten, got %v", err)
+		}
+	})
+}
+
+func TestParseFileFrom_CarriesParseState(t *testing.T) {
+	parser := NewParser(".")
+	fullPath := filepath.Join("testdata", "interrupted_session.jsonl")
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	full, err := parser.ParseFile(fullPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// どの行で追記が区切られても、全体を解析した場合と同じ中断が記録される
+	for end := bytes.IndexByte(content, '\n'); end >= 0 && end+1 < len(content); {
+		prefixPath := filepath.Join(t.TempDir(), "prefix.jsonl")
+		if err := os.WriteFile(prefixPath, content[:end+1], 0644); err != nil {
+			t.Fatalf("Failed to write prefix: %v", err)
+		}
+		head, err := parser.ParseFile(prefixPath)
+		if err != nil {
+			t.Fatalf("ParseFile failed: %v", err)
+		}
+		tail, err := parser.ParseFileFrom(fullPath, head.Position)
+		if err != nil {
+			t.Fatalf("ParseFileFrom failed: %v", err)
+		}
+
+		got := append(append([]Interruption{}, head.Interruptions...), tail.Interruptions...)
+		if len(got) != len(full.Interruptions) {
+			t.Fatalf("Split at %d: expected %d interruptions, got %+v", end, len(full.Interruptions), got)
+		}
+		for i, want := range full.Interruptions {
+			// 解析済み範囲のtool_useのツール名・モデルは、DBに保存済みのツール呼び出しから補う
+			if want.ToolUseID != "" {
+				got[i].ToolName, got[i].Model = want.ToolName, want.Model
+			}
+			if got[i] != want {
+				t.Errorf("Split at %d: interruption %d = %+v, want %+v", end, i, got[i], want)
+			}
+		}
+
+		next := bytes.IndexByte(content[end+1:], '\n')
+		if next < 0 {
+			break
+		}
+		end += next + 1
+	}
+}
@@ -17,6 +17,33 @@ type LogEntry struct {
 	GitBranch  string    `json:"gitBranch"`
 	Message    *Message  `json:"message,omitempty"`
 	RequestID  string    `json:"requestId,omitempty"`
+
+	// サブエージェント（サイドチェーン）のエントリの場合に設定される
+	IsSidechain bool   `json:"isSidechain,omitempty"`
+	AgentID     string `json:"agentId,omitempty"`
+
+	// ツール実行結果のメタデータ（文字列またはオブジェクト）
+	ToolUseResult json.RawMessage `json:"toolUseResult,omitempty"`
+
+	// systemエントリの種類（コンテキスト圧縮の境界は "compact_boundary"）と圧縮時のメタデータ
+	Subtype         string           `json:"subtype,omitempty"`
+	CompactMetadata *CompactMetadata `json:"compactMetadata,omitempty"`
+
+	// 圧縮後の会話の先頭に挿入された要約のユーザーメッセージの場合に true
+	IsCompactSummary bool `json:"isCompactSummary,omitempty"`
+
+	// Claude Codeが自動で挿入したユーザーメッセージ（カスタムコマンドの展開内容など）の場合に true
+	IsMeta bool `json:"isMeta,omitempty"`
+
+	// summaryエントリの要約文と、要約した会話の最後のエントリのUUID
+	Summary  string `json:"summary,omitempty"`
+	LeafUUID string `json:"leafUuid,omitempty"`
+}
+
+// CompactMetadata holds the details of a context compaction recorded on a compact_boundary entry
+type CompactMetadata struct {
+	Trigger   string `json:"trigger"`   // "auto" または "manual"（/compact）
+	PreTokens int    `json:"preTokens"` // 圧縮前のコンテキストのトークン数
 }
 
 // Message represents the message content in assistant/user entries
@@ -78,9 +105,9 @@ type Content struct {
 	Caller *Caller     `json:"caller,omitempty"`
 
 	// For tool_result
-	ToolUseID      string      `json:"tool_use_id,omitempty"`
+	ToolUseID         string      `json:"tool_use_id,omitempty"`
 	ToolResultContent interface{} `json:"content,omitempty"` // Can be string or array
-	IsError        bool        `json:"is_error,omitempty"`
+	IsError           bool        `json:"is_error,omitempty"`
 }
 
 // Caller represents the caller info for tool_use
@@ -110,20 +137,110 @@ type ToolUseResult struct {
 	Stderr      string `json:"stderr,omitempty"`
 	Interrupted bool   `json:"interrupted"`
 	IsImage     bool   `json:"isImage"`
+	AgentID     string `json:"agentId,omitempty"` // Taskツールが起動したサブエージェントのID
 }
 
 // Session represents a parsed session with aggregated data
 type Session struct {
-	ID           string
-	ProjectPath  string
-	GitBranch    string
-	StartTime    time.Time
-	EndTime      time.Time
-	Entries      []LogEntry
-	TotalTokens  TokenSummary
-	ModelUsage   map[string]TokenSummary
-	ToolCalls    []ToolCall
-	ErrorCount   int
+	ID          string
+	ProjectPath string
+	GitBranch   string
+	StartTime   time.Time
+	EndTime     time.Time
+	Entries     []LogEntry
+	TotalTokens TokenSummary
+	ModelUsage  map[string]TokenSummary
+	ToolCalls   []ToolCall
+	ErrorCount  int
+
+	// サブエージェントのトランスクリプト（agent-*.jsonl）の場合に設定される
+	// IDは "agent-<AgentID>"、ParentSessionIDはサブエージェントを起動したセッションのID
+	AgentID         string
+	ParentSessionID string
+
+	// 解析済みの位置（ParseFileFromで追記分の解析を再開するために使う）
+	Position FilePosition
+
+	// 解析した範囲に対応するtool_useがなかったtool_result
+	// 追記分の解析では、保存済みのツール呼び出しに結果を反映するために使う
+	UnmatchedToolResults []ToolResult
+
+	// コンテキストの圧縮（compact_boundaryエントリ）
+	Compactions []Compaction
+
+	// ユーザーによる中断・ツールの拒否・ツール実行のキャンセル
+	Interruptions []Interruption
+
+	// TotalTokens / ModelUsage に加算したAPI応答（message.idがあるもののみ）
+	// 別ファイルや以前の解析で集計済みの応答を除外するために使う
+	MessageUsages []MessageUsage
+}
+
+// MessageUsage is the token usage of a single API response
+// Claude Code writes one line per content block of a response, each repeating the same usage,
+// so usage is counted once per Key.
+type MessageUsage struct {
+	Key    string // message.id と requestId を連結したキー
+	Model  string
+	Tokens TokenSummary
+}
+
+// Compaction is a compaction of the conversation context, either automatic or by /compact
+type Compaction struct {
+	UUID      string
+	Timestamp time.Time
+	Trigger   string
+	PreTokens int
+}
+
+// Interruption types
+const (
+	// 応答中にユーザーが中断した（[Request interrupted by user]）
+	InterruptionTypeInterrupt = "interrupt"
+	// ツールの実行許可をユーザーが拒否した（The user doesn't want to proceed with this tool use...）
+	InterruptionTypeRejection = "rejection"
+	// ツールの実行中にユーザーがESCでキャンセルした（[Request interrupted by user for tool use]）
+	InterruptionTypeCancel = "cancel"
+)
+
+// Interruption is a user interruption, tool rejection or tool cancellation
+type Interruption struct {
+	UUID      string // 中断を記録したエントリのUUID
+	Timestamp time.Time
+	Type      string // InterruptionType*
+
+	// 拒否・キャンセルされたツール呼び出し（応答の中断の場合は空）
+	// 追記分の解析で対応するtool_useが解析範囲にない場合、ToolNameとModelは空
+	ToolUseID string
+	ToolName  string
+
+	// 中断された応答を出力していたモデル
+	Model string
+}
+
+// FilePosition records how far a session file has been parsed
+type FilePosition struct {
+	Offset   int64  // 最後に解析した行の末尾のバイト位置
+	LastUUID string // 最後に解析したエントリのUUID（UUIDのないエントリの場合は空）
+
+	// 追記分の解析に引き継ぐ解析状態
+	LastModel       string // 直前に応答したモデル
+	ToolInterrupted bool   // 直前のユーザーエントリでツールの拒否・キャンセルを記録したか
+}
+
+// ToolResult represents a tool_result that could not be paired within the parsed lines
+type ToolResult struct {
+	ToolUseID   string
+	Timestamp   time.Time
+	IsError     bool
+	Result      string
+	ResultSize  int
+	AgentID     string
+	Stdout      string
+	Stderr      string
+	Interrupted bool
+
+	InterruptionType string // InterruptionTypeRejection / InterruptionTypeCancel（ユーザーによる拒否・キャンセルの場合）
 }
 
 // TokenSummary holds aggregated token counts
@@ -132,13 +249,38 @@ type TokenSummary struct {
 	OutputTokens             int
 	CacheCreationInputTokens int
 	CacheReadInputTokens     int
+
+	// キャッシュ作成トークンのTTL別内訳（料金計算用）
+	CacheCreation5mInputTokens int
+	CacheCreation1hInputTokens int
 }
 
 // ToolCall represents a single tool invocation
 type ToolCall struct {
+	ID        string // tool_use_id
 	Timestamp time.Time
 	Name      string
 	Input     interface{}
+	Model     string // tool_useを出力したモデル
 	IsError   bool
 	Result    string
+
+	// 切り詰める前の結果テキストのバイト数（コンテキストに追加された大きさの目安）
+	ResultSize int
+
+	// 対応するtool_resultを受信済みか（HasResult=falseの場合Durationは無効）
+	HasResult bool
+	Duration  time.Duration
+
+	// Taskツールが起動したサブエージェントのID（tool_resultのtoolUseResultから取得）
+	AgentID string
+
+	// Bashツールの標準出力・標準エラー出力と中断されたか（toolUseResultから取得、出力は切り詰める）
+	Stdout      string
+	Stderr      string
+	Interrupted bool
+
+	// ユーザーが実行を拒否・キャンセルした場合の種類（InterruptionTypeRejection / InterruptionTypeCancel）
+	// IsError はtool_resultのis_errorのままだが、ツールのエラーとしては数えない
+	InterruptionType string
 }
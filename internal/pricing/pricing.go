@@ -0,0 +1,162 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+// Rates holds USD prices per million tokens for a single model
+type Rates struct {
+	Input        float64 `json:"input"`
+	Output       float64 `json:"output"`
+	CacheWrite5m float64 `json:"cacheWrite5m"`
+	CacheWrite1h float64 `json:"cacheWrite1h"`
+	CacheRead    float64 `json:"cacheRead"`
+}
+
+// Entry is a pricing rule for models whose name starts with ModelPrefix,
+// valid from EffectiveFrom until the next entry with the same prefix
+type Entry struct {
+	ModelPrefix   string    `json:"modelPrefix"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+	Rates         Rates     `json:"rates"`
+}
+
+// Table resolves model names and timestamps to rates
+type Table struct {
+	entries []Entry
+}
+
+// perMillion converts per-million-token prices to per-token prices
+const perMillion = 1_000_000.0
+
+// defaultEntries is the built-in pricing table (USD per million tokens)
+// 価格改定があった場合は新しいEffectiveFromでエントリを追加する（既存エントリは消さない）
+var defaultEntries = []Entry{
+	// Opus
+	{ModelPrefix: "claude-opus-4-5", EffectiveFrom: date(2025, 11, 24), Rates: Rates{Input: 5, Output: 25, CacheWrite5m: 6.25, CacheWrite1h: 10, CacheRead: 0.50}},
+	{ModelPrefix: "claude-opus-4", EffectiveFrom: date(2025, 5, 22), Rates: Rates{Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.50}},
+	{ModelPrefix: "claude-3-opus", EffectiveFrom: date(2024, 3, 4), Rates: Rates{Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.50}},
+
+	// Sonnet
+	{ModelPrefix: "claude-sonnet-4", EffectiveFrom: date(2025, 5, 22), Rates: Rates{Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.30}},
+	{ModelPrefix: "claude-3-7-sonnet", EffectiveFrom: date(2025, 2, 24), Rates: Rates{Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.30}},
+	{ModelPrefix: "claude-3-5-sonnet", EffectiveFrom: date(2024, 6, 20), Rates: Rates{Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.30}},
+
+	// Haiku
+	{ModelPrefix: "claude-haiku-4-5", EffectiveFrom: date(2025, 10, 15), Rates: Rates{Input: 1, Output: 5, CacheWrite5m: 1.25, CacheWrite1h: 2, CacheRead: 0.10}},
+	{ModelPrefix: "claude-3-5-haiku", EffectiveFrom: date(2024, 11, 4), Rates: Rates{Input: 1, Output: 5, CacheWrite5m: 1.25, CacheWrite1h: 2, CacheRead: 0.10}},
+	{ModelPrefix: "claude-3-5-haiku", EffectiveFrom: date(2024, 12, 1), Rates: Rates{Input: 0.80, Output: 4, CacheWrite5m: 1, CacheWrite1h: 1.6, CacheRead: 0.08}},
+	{ModelPrefix: "claude-3-haiku", EffectiveFrom: date(2024, 3, 13), Rates: Rates{Input: 0.25, Output: 1.25, CacheWrite5m: 0.30, CacheWrite1h: 0.50, CacheRead: 0.03}},
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// NewTable creates a pricing table from the given entries
+func NewTable(entries []Entry) *Table {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+
+	// 長いプレフィックスを優先し、同じプレフィックスでは新しい価格を優先
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if len(sorted[i].ModelPrefix) != len(sorted[j].ModelPrefix) {
+			return len(sorted[i].ModelPrefix) > len(sorted[j].ModelPrefix)
+		}
+		if sorted[i].ModelPrefix != sorted[j].ModelPrefix {
+			return sorted[i].ModelPrefix < sorted[j].ModelPrefix
+		}
+		return sorted[i].EffectiveFrom.After(sorted[j].EffectiveFrom)
+	})
+
+	return &Table{entries: sorted}
+}
+
+// DefaultTable returns the built-in pricing table
+func DefaultTable() *Table {
+	return NewTable(defaultEntries)
+}
+
+// LoadTable loads a pricing table from a JSON file containing an array of entries
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing file: %w", err)
+	}
+
+	for i, entry := range entries {
+		if entry.ModelPrefix == "" {
+			return nil, fmt.Errorf("pricing entry %d: modelPrefix cannot be empty", i)
+		}
+	}
+
+	return NewTable(entries), nil
+}
+
+// Lookup returns the rates for a model at the given time
+// The longest matching prefix wins; within that prefix the latest entry
+// effective at the given time is used. If the time precedes every entry,
+// the oldest entry is used so that early logs are still priced.
+func (t *Table) Lookup(model string, at time.Time) (Rates, bool) {
+	var matchedPrefix string
+	var fallback *Entry
+
+	for i := range t.entries {
+		entry := &t.entries[i]
+		if !strings.HasPrefix(model, entry.ModelPrefix) {
+			continue
+		}
+		if matchedPrefix != "" && entry.ModelPrefix != matchedPrefix {
+			// より短いプレフィックスには進まない
+			break
+		}
+		matchedPrefix = entry.ModelPrefix
+
+		if !entry.EffectiveFrom.After(at) {
+			return entry.Rates, true
+		}
+		fallback = entry
+	}
+
+	if fallback != nil {
+		return fallback.Rates, true
+	}
+	return Rates{}, false
+}
+
+// Cost returns the estimated USD cost of the token usage for a model at the given time
+// Cache creation tokens without a 5m/1h breakdown are priced at the 5m rate.
+// Unknown models cost 0.
+func (t *Table) Cost(model string, at time.Time, tokens parser.TokenSummary) float64 {
+	rates, ok := t.Lookup(model, at)
+	if !ok {
+		return 0
+	}
+
+	cache5m := tokens.CacheCreation5mInputTokens
+	cache1h := tokens.CacheCreation1hInputTokens
+	// 内訳が不明な分は5分キャッシュとして扱う
+	if remainder := tokens.CacheCreationInputTokens - cache5m - cache1h; remainder > 0 {
+		cache5m += remainder
+	}
+
+	cost := float64(tokens.InputTokens)*rates.Input +
+		float64(tokens.OutputTokens)*rates.Output +
+		float64(cache5m)*rates.CacheWrite5m +
+		float64(cache1h)*rates.CacheWrite1h +
+		float64(tokens.CacheReadInputTokens)*rates.CacheRead
+
+	return cost / perMillion
+}
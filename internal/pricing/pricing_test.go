@@ -0,0 +1,150 @@
+package pricing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTable_Lookup(t *testing.T) {
+	table := DefaultTable()
+	at := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("最長プレフィックスが優先される", func(t *testing.T) {
+		rates, ok := table.Lookup("claude-opus-4-5-20251101", at)
+		if !ok {
+			t.Fatal("Expected rates for claude-opus-4-5")
+		}
+		if rates.Input != 5 {
+			t.Errorf("Expected opus-4-5 input rate 5, got %v", rates.Input)
+		}
+
+		rates, ok = table.Lookup("claude-opus-4-1-20250805", at)
+		if !ok {
+			t.Fatal("Expected rates for claude-opus-4-1")
+		}
+		if rates.Input != 15 {
+			t.Errorf("Expected opus-4 input rate 15, got %v", rates.Input)
+		}
+	})
+
+	t.Run("未知のモデルはfalseを返す", func(t *testing.T) {
+		_, ok := table.Lookup("<synthetic>", at)
+		if ok {
+			t.Error("Expected no rates for unknown model")
+		}
+	})
+
+	t.Run("適用開始日で料金が切り替わる", func(t *testing.T) {
+		before, _ := table.Lookup("claude-3-5-haiku-20241022", time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC))
+		after, _ := table.Lookup("claude-3-5-haiku-20241022", time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC))
+		if before.Input != 1 {
+			t.Errorf("Expected input rate 1 before price change, got %v", before.Input)
+		}
+		if after.Input != 0.80 {
+			t.Errorf("Expected input rate 0.80 after price change, got %v", after.Input)
+		}
+	})
+
+	t.Run("最初の適用開始日より前は最古の料金を使う", func(t *testing.T) {
+		rates, ok := table.Lookup("claude-sonnet-4-5-20250929", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		if !ok {
+			t.Fatal("Expected fallback rates")
+		}
+		if rates.Input != 3 {
+			t.Errorf("Expected input rate 3, got %v", rates.Input)
+		}
+	})
+}
+
+func TestTable_Cost(t *testing.T) {
+	table := NewTable([]Entry{
+		{
+			ModelPrefix:   "test-model",
+			EffectiveFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Rates:         Rates{Input: 1, Output: 2, CacheWrite5m: 3, CacheWrite1h: 4, CacheRead: 5},
+		},
+	})
+	at := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("TTL別のキャッシュ作成料金を適用する", func(t *testing.T) {
+		tokens := parser.TokenSummary{
+			InputTokens:                1_000_000,
+			OutputTokens:               1_000_000,
+			CacheCreationInputTokens:   2_000_000,
+			CacheReadInputTokens:       1_000_000,
+			CacheCreation5mInputTokens: 1_000_000,
+			CacheCreation1hInputTokens: 1_000_000,
+		}
+		cost := table.Cost("test-model", at, tokens)
+		// 1 + 2 + 3 + 4 + 5
+		if !almostEqual(cost, 15) {
+			t.Errorf("Expected cost 15, got %v", cost)
+		}
+	})
+
+	t.Run("内訳のないキャッシュ作成は5分料金で計算する", func(t *testing.T) {
+		tokens := parser.TokenSummary{
+			CacheCreationInputTokens: 1_000_000,
+		}
+		cost := table.Cost("test-model", at, tokens)
+		if !almostEqual(cost, 3) {
+			t.Errorf("Expected cost 3, got %v", cost)
+		}
+	})
+
+	t.Run("未知のモデルは0", func(t *testing.T) {
+		cost := table.Cost("unknown", at, parser.TokenSummary{InputTokens: 1000})
+		if cost != 0 {
+			t.Errorf("Expected cost 0, got %v", cost)
+		}
+	})
+}
+
+func TestLoadTable(t *testing.T) {
+	t.Run("JSONファイルから料金表を読み込む", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pricing.json")
+		content := `[{"modelPrefix":"custom","effectiveFrom":"2025-01-01T00:00:00Z","rates":{"input":10,"output":20}}]`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write pricing file: %v", err)
+		}
+
+		table, err := LoadTable(path)
+		if err != nil {
+			t.Fatalf("LoadTable failed: %v", err)
+		}
+
+		rates, ok := table.Lookup("custom-model", time.Now())
+		if !ok {
+			t.Fatal("Expected rates for custom-model")
+		}
+		if rates.Input != 10 || rates.Output != 20 {
+			t.Errorf("Unexpected rates: %+v", rates)
+		}
+	})
+
+	t.Run("modelPrefixが空の場合はエラー", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pricing.json")
+		if err := os.WriteFile(path, []byte(`[{"rates":{"input":1}}]`), 0644); err != nil {
+			t.Fatalf("Failed to write pricing file: %v", err)
+		}
+
+		if _, err := LoadTable(path); err == nil {
+			t.Error("Expected error for empty modelPrefix")
+		}
+	})
+
+	t.Run("存在しないファイルはエラー", func(t *testing.T) {
+		if _, err := LoadTable(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+			t.Error("Expected error for missing file")
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/events"
 	"github.com/a-tak/ccloganalysis/internal/parser"
 )
 
@@ -41,6 +42,7 @@ type ScanManager struct {
 	mu       sync.RWMutex
 	cancelFn context.CancelFunc
 	wg       sync.WaitGroup
+	events   *events.Bus
 }
 
 // NewScanManager creates a new ScanManager instance
@@ -54,6 +56,14 @@ func NewScanManager(database *db.DB, p *parser.Parser) *ScanManager {
 	}
 }
 
+// SetEventBus sets the bus that receives scan.progress events
+// Call before StartInitialScan.
+func (m *ScanManager) SetEventBus(bus *events.Bus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = bus
+}
+
 // StartInitialScan starts the initial scan operation asynchronously
 func (m *ScanManager) StartInitialScan(ctx context.Context) error {
 	m.mu.Lock()
@@ -135,6 +145,7 @@ func (m *ScanManager) runScan(ctx context.Context) {
 		m.progress.SessionsSynced = update.SessionsSynced
 		m.progress.SessionsSkipped = update.SessionsSkipped
 		m.progress.ErrorCount = update.ErrorCount
+		m.events.Publish(events.TypeScanProgress, *m.progress)
 		m.mu.Unlock()
 	}
 
@@ -151,6 +162,7 @@ func (m *ScanManager) runScan(ctx context.Context) {
 		m.progress.Status = ScanStatusFailed
 		m.progress.LastError = err.Error()
 		m.progress.CompletedAt = &now
+		m.events.Publish(events.TypeScanProgress, *m.progress)
 		return
 	}
 
@@ -166,4 +178,7 @@ func (m *ScanManager) runScan(ctx context.Context) {
 	if result.ErrorCount > 0 {
 		m.progress.LastError = fmt.Sprintf("%d errors occurred during scan", result.ErrorCount)
 	}
+
+	// 完了を通知（初回スキャンのセッション単位のイベントは件数が多いため送らない）
+	m.events.Publish(events.TypeScanProgress, *m.progress)
 }
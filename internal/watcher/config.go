@@ -11,8 +11,18 @@ type WatcherConfig struct {
 	Enabled  bool
 	Interval time.Duration
 	Debounce time.Duration
+
+	// 変更検出の方式（BackendAuto / BackendNotify / BackendPoll、空の場合はポーリング）
+	Backend string
 }
 
+// Watcher backends
+const (
+	BackendAuto   = "auto"   // ファイルシステム通知が使えれば通知、使えなければポーリング
+	BackendNotify = "notify" // ファイルシステム通知（Linuxのinotify、使えない場合はポーリング）
+	BackendPoll   = "poll"   // 一定間隔のポーリング
+)
+
 const (
 	defaultInterval = 15 // seconds
 	minInterval     = 5  // seconds
@@ -25,6 +35,7 @@ const (
 
 // LoadWatcherConfig loads watcher configuration from environment variables
 // File watching is enabled by default unless ENABLE_FILE_WATCH=false is explicitly set
+// FILE_WATCH_BACKEND selects the change detection backend (auto, notify or poll; default: auto)
 func LoadWatcherConfig() WatcherConfig {
 	enabled := os.Getenv("ENABLE_FILE_WATCH") != "false"
 
@@ -35,6 +46,18 @@ func LoadWatcherConfig() WatcherConfig {
 		Enabled:  enabled,
 		Interval: time.Duration(interval) * time.Second,
 		Debounce: time.Duration(debounce) * time.Second,
+		Backend:  parseEnvBackend("FILE_WATCH_BACKEND"),
+	}
+}
+
+// parseEnvBackend parses an environment variable as watcher backend
+// Returns BackendAuto if env var is not set or invalid
+func parseEnvBackend(envVar string) string {
+	switch value := os.Getenv(envVar); value {
+	case BackendNotify, BackendPoll:
+		return value
+	default:
+		return BackendAuto
 	}
 }
 
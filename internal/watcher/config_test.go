@@ -11,6 +11,7 @@ func TestLoadWatcherConfig_Defaults(t *testing.T) {
 	os.Unsetenv("ENABLE_FILE_WATCH")
 	os.Unsetenv("FILE_WATCH_INTERVAL")
 	os.Unsetenv("FILE_WATCH_DEBOUNCE")
+	os.Unsetenv("FILE_WATCH_BACKEND")
 
 	config := LoadWatcherConfig()
 
@@ -23,6 +24,9 @@ func TestLoadWatcherConfig_Defaults(t *testing.T) {
 	if config.Debounce != 5*time.Second {
 		t.Errorf("Expected Debounce to be 5s, got %v", config.Debounce)
 	}
+	if config.Backend != BackendAuto {
+		t.Errorf("Expected Backend to be %q by default, got %q", BackendAuto, config.Backend)
+	}
 }
 
 func TestLoadWatcherConfig_Enabled(t *testing.T) {
@@ -69,6 +73,32 @@ func TestLoadWatcherConfig_CustomDebounce(t *testing.T) {
 	}
 }
 
+func TestLoadWatcherConfig_Backend(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected string
+	}{
+		{"通知", "notify", BackendNotify},
+		{"ポーリング", "poll", BackendPoll},
+		{"自動", "auto", BackendAuto},
+		{"不正な値は自動", "inotify", BackendAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("FILE_WATCH_BACKEND", tt.envValue)
+			defer os.Unsetenv("FILE_WATCH_BACKEND")
+
+			config := LoadWatcherConfig()
+
+			if config.Backend != tt.expected {
+				t.Errorf("Expected Backend to be %q, got %q", tt.expected, config.Backend)
+			}
+		})
+	}
+}
+
 func TestLoadWatcherConfig_BoundaryValues(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,23 @@
+package watcher
+
+import "errors"
+
+// errNotifyUnsupported is returned when native filesystem notifications are not available on the platform
+var errNotifyUnsupported = errors.New("native filesystem notifications are not supported on this platform")
+
+// maxWatchDepth is the deepest directory level watched below the Claude projects directory
+// <projects>/<project>/<sessionId>/subagents/agent-*.jsonl まで監視する
+const maxWatchDepth = 3
+
+// fileEvent is a change notification from a native filesystem watcher
+type fileEvent struct {
+	path     string // 変更されたファイルのパス
+	overflow bool   // 通知が失われたため全体の同期が必要
+}
+
+// notifier delivers change notifications of files under the Claude projects directory
+type notifier interface {
+	// Events returns the notification channel (closed when the notifier stops)
+	Events() <-chan fileEvent
+	Close() error
+}
@@ -0,0 +1,188 @@
+//go:build linux
+
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyWatchMask is the set of inotify events watched on each directory
+const inotifyWatchMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+
+// inotifyEventBufferSize is the buffer size of the notification channel
+const inotifyEventBufferSize = 256
+
+// watchedDir is a directory registered with inotify
+type watchedDir struct {
+	path  string
+	depth int // Claude projectsディレクトリからの深さ（0 = projectsディレクトリ）
+}
+
+// inotifyNotifier watches the Claude projects directory tree with inotify
+type inotifyNotifier struct {
+	fd     int
+	file   *os.File
+	events chan fileEvent
+	done   chan struct{}
+
+	mu      sync.Mutex
+	watches map[int32]watchedDir
+
+	closeOnce sync.Once
+}
+
+// newNotifier creates an inotify-based notifier watching root and its project subdirectories
+func newNotifier(root string) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	n := &inotifyNotifier{
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"), // 非ブロッキングfdのためランタイムのポーラーで読み込める
+		events:  make(chan fileEvent, inotifyEventBufferSize),
+		done:    make(chan struct{}),
+		watches: make(map[int32]watchedDir),
+	}
+
+	if err := n.addTree(root, 0, false); err != nil {
+		n.file.Close()
+		return nil, err
+	}
+
+	go n.readLoop()
+
+	return n, nil
+}
+
+// Events returns the notification channel
+func (n *inotifyNotifier) Events() <-chan fileEvent {
+	return n.events
+}
+
+// Close stops watching and closes the notification channel
+func (n *inotifyNotifier) Close() error {
+	var err error
+	n.closeOnce.Do(func() {
+		close(n.done)
+		err = n.file.Close()
+	})
+	return err
+}
+
+// addTree registers dir and its subdirectories up to maxWatchDepth
+// emitExisting is set for directories created after watching started, whose files may have been written
+// before the watch was registered
+func (n *inotifyNotifier) addTree(dir string, depth int, emitExisting bool) error {
+	wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyWatchMask)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	n.mu.Lock()
+	n.watches[int32(wd)] = watchedDir{path: dir, depth: depth}
+	n.mu.Unlock()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if depth < maxWatchDepth {
+				if err := n.addTree(path, depth+1, emitExisting); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if emitExisting && strings.HasSuffix(entry.Name(), ".jsonl") {
+			n.send(fileEvent{path: path})
+		}
+	}
+
+	return nil
+}
+
+// readLoop reads inotify events until the notifier is closed (runs in goroutine)
+func (n *inotifyNotifier) readLoop() {
+	defer close(n.events)
+
+	buf := make([]byte, 64*1024)
+	for {
+		size, err := n.file.Read(buf)
+		if err != nil {
+			// Close()によるファイルクローズ、または読み込みエラー
+			return
+		}
+
+		offset := 0
+		for offset+syscall.SizeofInotifyEvent <= size {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(raw.Len)
+			if nameEnd > size {
+				break
+			}
+			name := strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00")
+			offset = nameEnd
+
+			n.handleEvent(raw.Wd, raw.Mask, name)
+		}
+	}
+}
+
+// handleEvent converts a raw inotify event into a file notification
+func (n *inotifyNotifier) handleEvent(wd int32, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		// カーネルのイベントキューが溢れた
+		n.send(fileEvent{overflow: true})
+		return
+	}
+
+	n.mu.Lock()
+	dir, ok := n.watches[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		// 監視対象のディレクトリが削除された
+		delete(n.watches, wd)
+		ok = false
+	}
+	n.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+
+	path := filepath.Join(dir.path, name)
+
+	if mask&syscall.IN_ISDIR != 0 {
+		if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 && dir.depth < maxWatchDepth {
+			if err := n.addTree(path, dir.depth+1, true); err != nil {
+				// 監視を追加できなかったディレクトリの変更は全体の同期で取り込む
+				n.send(fileEvent{overflow: true})
+			}
+		}
+		return
+	}
+
+	if strings.HasSuffix(name, ".jsonl") {
+		n.send(fileEvent{path: path})
+	}
+}
+
+// send delivers a notification unless the notifier is closed
+func (n *inotifyNotifier) send(event fileEvent) {
+	select {
+	case n.events <- event:
+	case <-n.done:
+	}
+}
@@ -0,0 +1,136 @@
+//go:build linux
+
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a-tak/ccloganalysis/internal/events"
+	"github.com/a-tak/ccloganalysis/internal/parser"
+)
+
+func TestFileWatcher_NotifyBackend(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	projectsDir := setupTestProjectsDir(t)
+	p := parser.NewParser(projectsDir)
+
+	config := WatcherConfig{
+		Enabled:  true,
+		Interval: time.Hour, // ポーリングでは同期されないようにする
+		Debounce: 50 * time.Millisecond,
+		Backend:  BackendNotify,
+	}
+
+	watcher := NewFileWatcher(database, p, config)
+	bus := events.NewBus()
+	watcher.SetEventBus(bus)
+	ch, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	watcher.Start()
+	defer watcher.Stop()
+
+	if backend := watcher.Backend(); backend != BackendNotify {
+		t.Fatalf("Expected backend %q, got %q", BackendNotify, backend)
+	}
+
+	t.Run("新規プロジェクトのセッションファイルが同期される", func(t *testing.T) {
+		createTestSession(t, projectsDir, "notify-project", "notify-session")
+
+		waitForSession(t, watcher, "notify-session")
+
+		if !waitForEvent(ch, events.TypeSessionCreated, "notify-session") {
+			t.Error("Expected a session.created event for notify-session")
+		}
+	})
+
+	t.Run("追記されたセッションだけが更新される", func(t *testing.T) {
+		createTestSession(t, projectsDir, "test-project", "other-session")
+		waitForSession(t, watcher, "other-session")
+
+		sessionFile := filepath.Join(projectsDir, "notify-project", "notify-session.jsonl")
+		f, err := os.OpenFile(sessionFile, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open session file: %v", err)
+		}
+		_, err = f.WriteString(`{"sessionId":"notify-session","type":"user","message":{"role":"user","content":"more"},"uuid":"notify-session-uuid-3","timestamp":"2026-01-24T10:00:05Z"}` + "\n")
+		f.Close()
+		if err != nil {
+			t.Fatalf("Failed to append to session file: %v", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			session, err := database.GetSession("notify-session")
+			if err == nil && !session.EndTime.Before(time.Date(2026, 1, 24, 10, 0, 5, 0, time.UTC)) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out waiting for the appended entry to be synced")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		if !waitForEvent(ch, events.TypeSessionUpdated, "notify-session") {
+			t.Error("Expected a session.updated event for notify-session")
+		}
+	})
+}
+
+func TestFileWatcher_PollBackend(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	p := parser.NewParser(setupTestProjectsDir(t))
+	config := WatcherConfig{
+		Enabled:  true,
+		Interval: time.Hour,
+		Debounce: time.Second,
+		Backend:  BackendPoll,
+	}
+
+	watcher := NewFileWatcher(database, p, config)
+	watcher.Start()
+	defer watcher.Stop()
+
+	if backend := watcher.Backend(); backend != BackendPoll {
+		t.Errorf("Expected backend %q, got %q", BackendPoll, backend)
+	}
+}
+
+// waitForSession waits until the session is stored in the database
+func waitForSession(t *testing.T, watcher *FileWatcher, sessionID string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := watcher.db.GetSession(sessionID); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for session %s to be synced", sessionID)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// waitForEvent waits until an event matching the type and session is received
+func waitForEvent(ch <-chan events.Event, eventType, sessionID string) bool {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-ch:
+			data, ok := event.Data.(events.SessionEventData)
+			if event.Type == eventType && ok && data.SessionID == sessionID {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
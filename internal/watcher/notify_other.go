@@ -0,0 +1,8 @@
+//go:build !linux
+
+package watcher
+
+// newNotifier is not available on this platform; the poller is used instead
+func newNotifier(root string) (notifier, error) {
+	return nil, errNotifyUnsupported
+}
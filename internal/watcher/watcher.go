@@ -2,25 +2,43 @@ package watcher
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/a-tak/ccloganalysis/internal/db"
+	"github.com/a-tak/ccloganalysis/internal/events"
+	"github.com/a-tak/ccloganalysis/internal/logger"
 	"github.com/a-tak/ccloganalysis/internal/parser"
 )
 
+// notifyFlushInterval is how often pending file changes are checked against the debounce period
+const notifyFlushInterval = 200 * time.Millisecond
+
+// pendingChange tracks notifications of a file that has not been synced yet
+type pendingChange struct {
+	first time.Time // 最初の通知時刻
+	last  time.Time // 最後の通知時刻
+}
+
 // FileWatcher watches filesystem for new JSONL files
 type FileWatcher struct {
 	db       *db.DB
 	parser   *parser.Parser
 	interval time.Duration
 	debounce time.Duration
+	backend  string
 
 	stopCh   chan struct{}
 	doneCh   chan struct{}
 	lastSync time.Time
 	mu       sync.Mutex
 	running  bool
+	events   *events.Bus
+
+	// 実際に使用している変更検出の方式（BackendNotify または BackendPoll）
+	activeBackend string
 }
 
 // NewFileWatcher creates a new file watcher
@@ -34,9 +52,17 @@ func NewFileWatcher(database *db.DB, p *parser.Parser, config WatcherConfig) *Fi
 		parser:   p,
 		interval: config.Interval,
 		debounce: config.Debounce,
+		backend:  config.Backend,
 	}
 }
 
+// SetEventBus sets the bus that receives the changes found by each sync
+func (w *FileWatcher) SetEventBus(bus *events.Bus) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = bus
+}
+
 // Start starts the file watcher
 func (w *FileWatcher) Start() {
 	w.mu.Lock()
@@ -52,7 +78,39 @@ func (w *FileWatcher) Start() {
 	w.running = true
 	w.lastSync = time.Time{} // Zero time to allow initial sync
 
-	go w.watchLoop()
+	n := w.startNotifier()
+	if n != nil {
+		w.activeBackend = BackendNotify
+	} else {
+		w.activeBackend = BackendPoll
+	}
+
+	go w.watchLoop(n)
+}
+
+// Backend returns the change detection backend in use (empty before Start)
+func (w *FileWatcher) Backend() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.activeBackend
+}
+
+// startNotifier creates a native filesystem notifier if the configured backend allows it
+// 通知が使えない場合は nil を返し、ポーリングで監視する
+func (w *FileWatcher) startNotifier() notifier {
+	if w.backend != BackendAuto && w.backend != BackendNotify {
+		return nil
+	}
+
+	n, err := newNotifier(w.parser.ClaudeDir())
+	if err != nil {
+		logger.New().WarnWithContext("Filesystem notifications unavailable, falling back to polling", map[string]interface{}{
+			"backend": w.backend,
+			"error":   err.Error(),
+		})
+		return nil
+	}
+	return n
 }
 
 // Stop stops the file watcher
@@ -79,10 +137,26 @@ func (w *FileWatcher) Stop() {
 	w.mu.Unlock()
 }
 
-// watchLoop is the main polling loop (runs in goroutine)
-func (w *FileWatcher) watchLoop() {
+// watchLoop is the main watch loop (runs in goroutine)
+// ファイルシステム通知が使える場合は通知で監視し、通知が止まった場合はポーリングに切り替える
+func (w *FileWatcher) watchLoop(n notifier) {
 	defer close(w.doneCh)
 
+	if n != nil {
+		if stopped := w.notifyLoop(n); stopped {
+			return
+		}
+
+		w.mu.Lock()
+		w.activeBackend = BackendPoll
+		w.mu.Unlock()
+	}
+
+	w.pollLoop()
+}
+
+// pollLoop checks for changes on every tick
+func (w *FileWatcher) pollLoop() {
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
@@ -99,6 +173,101 @@ func (w *FileWatcher) watchLoop() {
 	}
 }
 
+// notifyLoop syncs the files reported by the notifier after their debounce period
+// Returns true if the watcher was stopped, false if the notifier stopped unexpectedly.
+func (w *FileWatcher) notifyLoop(n notifier) bool {
+	defer n.Close()
+
+	// 通知の登録前に変更されたファイルを取り込む
+	_ = w.triggerSync()
+
+	flushTicker := time.NewTicker(notifyFlushInterval)
+	defer flushTicker.Stop()
+
+	pending := make(map[string]*pendingChange)
+
+	for {
+		select {
+		case <-w.stopCh:
+			return true
+		case event, ok := <-n.Events():
+			if !ok {
+				return false
+			}
+			if event.overflow {
+				// 通知が失われたため全体を同期する
+				_ = w.triggerSync()
+				continue
+			}
+
+			now := time.Now()
+			change, exists := pending[event.path]
+			if !exists {
+				change = &pendingChange{first: now}
+				pending[event.path] = change
+			}
+			change.last = now
+		case now := <-flushTicker.C:
+			w.flushPending(pending, now)
+		}
+	}
+}
+
+// flushPending syncs the pending files that are ready
+// ファイルごとに最後の通知からdebounce経過したら同期する（書き込みが続く場合もinterval経過で同期する）
+func (w *FileWatcher) flushPending(pending map[string]*pendingChange, now time.Time) {
+	// プロジェクトごとに同期対象のファイルをまとめる
+	byProject := make(map[string][]string)
+	for path, change := range pending {
+		if now.Sub(change.last) < w.debounce && now.Sub(change.first) < w.interval {
+			continue
+		}
+		delete(pending, path)
+
+		projectName, relPath, ok := w.splitSessionPath(path)
+		if !ok {
+			continue
+		}
+		byProject[projectName] = append(byProject[projectName], relPath)
+	}
+
+	for projectName, relPaths := range byProject {
+		_ = w.syncFiles(projectName, relPaths) // Error is handled at upper layer
+	}
+}
+
+// splitSessionPath splits a notified path into the project name and the path relative to the project directory
+func (w *FileWatcher) splitSessionPath(path string) (string, string, bool) {
+	rel, err := filepath.Rel(w.parser.ClaudeDir(), path)
+	if err != nil {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+	if len(parts) != 2 || !parser.IsSessionFilePath(parts[1]) {
+		return "", "", false
+	}
+	return parts[0], filepath.FromSlash(parts[1]), true
+}
+
+// syncFiles syncs only the changed session files of a project
+func (w *FileWatcher) syncFiles(projectName string, relPaths []string) error {
+	result, err := db.SyncSessionFiles(w.db, w.parser, projectName, relPaths)
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
+	}
+
+	w.mu.Lock()
+	w.lastSync = time.Now()
+	bus := w.events
+	w.mu.Unlock()
+
+	// 作成・更新されたプロジェクトとセッションを通知
+	bus.PublishSyncResult(result)
+
+	return nil
+}
+
 // shouldSync checks if enough time has passed since last sync (debounce)
 func (w *FileWatcher) shouldSync() bool {
 	w.mu.Lock()
@@ -116,7 +285,7 @@ func (w *FileWatcher) shouldSync() bool {
 func (w *FileWatcher) triggerSync() error {
 	// DEBUGレベルでのみ表示（ログスパム削減のため）
 
-	_, err := db.SyncIncremental(w.db, w.parser)
+	result, err := db.SyncIncremental(w.db, w.parser)
 	if err != nil {
 		return fmt.Errorf("sync failed: %w", err)
 	}
@@ -124,7 +293,11 @@ func (w *FileWatcher) triggerSync() error {
 	// Update last sync time
 	w.mu.Lock()
 	w.lastSync = time.Now()
+	bus := w.events
 	w.mu.Unlock()
 
+	// 作成・更新されたプロジェクトとセッションを通知
+	bus.PublishSyncResult(result)
+
 	return nil
 }